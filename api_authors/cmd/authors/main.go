@@ -16,10 +16,13 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/client/db"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/config"
 	author "github.com/reversersed/go-web-services/tree/main/api_authors/internal/handlers/author"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/cache/freecache"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/mongo"
+	RabbitClient "github.com/reversersed/go-web-services/tree/main/api_authors/pkg/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/shutdown"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/signature"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/validator"
 )
 
@@ -42,18 +45,25 @@ func main() {
 		logger.Fatal(err)
 	}
 
+	logger.Info("rabbitmq initializing...")
+	rabbit, err := RabbitClient.New(config.Rabbit, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	rabbitSender := rabbitmq.NewSender(rabbit.Connection, logger)
+
 	logger.Info("services initializing...")
-	bookStorage := db.NewStorage(db_client, "authors", logger)
-	bookService := client.NewService(bookStorage, logger, cache, validator.New())
+	authorStorage := db.NewStorage(db_client, "authors", logger)
+	authorService := client.NewService(authorStorage, logger, cache, validator.New(), rabbitSender)
 
 	logger.Info("handlers registration...")
-	handler := author.Handler{Logger: logger, BookService: bookService}
+	handler := author.Handler{Logger: logger, AuthorService: authorService}
 	handler.Register(router)
 
 	logger.Info("starting application...")
-	start(router, logger, config.Server)
+	start(signature.Middleware(router, config.Signature.Secret, config.Signature.MaxBodySize, logger), logger, config.Server, rabbit, rabbitSender)
 }
-func start(router *httprouter.Router, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
+func start(router http.Handler, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
 	var server *http.Server
 	var listener net.Listener
 
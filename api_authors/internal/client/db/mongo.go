@@ -1,11 +1,19 @@
 package db
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
 	"sync"
 
 	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type db struct {
@@ -21,3 +29,93 @@ func NewStorage(storage *mongo.Database, collection string, logger *logging.Logg
 	}
 	return db
 }
+func (d *db) AddAuthor(ctx context.Context, author *client.Author) (*client.Author, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.InsertOne(ctx, author)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("cannot get inserted document id")
+	}
+	author.Id = id
+
+	return author, nil
+}
+func (d *db) GetAuthors(ctx context.Context, id []primitive.ObjectID) ([]*client.Author, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	filter := bson.M{"_id": bson.M{"$in": id}}
+	result, err := d.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var authors []*client.Author
+	err = result.All(ctx, &authors)
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, errormiddleware.NotFoundError([]string{"no author with provided id"}, "marshalled array contained 0 elements")
+	}
+	return authors, nil
+}
+func (d *db) GetAllAuthors(ctx context.Context, offset, limit int) ([]*client.Author, error) {
+	return d.findByFilter(ctx, bson.D{}, offset, limit)
+}
+func (d *db) FindAuthors(ctx context.Context, name string, offset, limit int) ([]*client.Author, error) {
+	filter := bson.M{"name": primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}}
+	return d.findByFilter(ctx, filter, offset, limit)
+}
+func (d *db) findByFilter(ctx context.Context, filter any, offset, limit int) ([]*client.Author, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	options := options.Find().SetSkip(int64(offset)).SetLimit(int64(limit)).SetSort(bson.M{"name": 1})
+	result, err := d.collection.Find(ctx, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var authors []*client.Author
+	err = result.All(ctx, &authors)
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, errormiddleware.NotFoundError([]string{"no authors found"}, "marshalled array contained 0 elements")
+	}
+	return authors, nil
+}
+func (d *db) UpdateAuthor(ctx context.Context, author *client.Author) error {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.ReplaceOne(ctx, bson.M{"_id": author.Id}, author)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"author with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) DeleteAuthor(ctx context.Context, id primitive.ObjectID) error {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"author with provided id not found"}, fmt.Sprintf("deleted count was == %d", result.DeletedCount))
+	}
+	return nil
+}
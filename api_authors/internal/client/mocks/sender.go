@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_client is a generated GoMock package.
+package mock_client
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSender is a mock of Sender interface.
+type MockSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockSenderMockRecorder
+}
+
+// MockSenderMockRecorder is the mock recorder for MockSender.
+type MockSenderMockRecorder struct {
+	mock *MockSender
+}
+
+// NewMockSender creates a new mock instance.
+func NewMockSender(ctrl *gomock.Controller) *MockSender {
+	mock := &MockSender{ctrl: ctrl}
+	mock.recorder = &MockSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSender) EXPECT() *MockSenderMockRecorder {
+	return m.recorder
+}
+
+// SendAuthorChangedMessage mocks base method.
+func (m *MockSender) SendAuthorChangedMessage(ctx context.Context, authorId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendAuthorChangedMessage", ctx, authorId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendAuthorChangedMessage indicates an expected call of SendAuthorChangedMessage.
+func (mr *MockSenderMockRecorder) SendAuthorChangedMessage(ctx, authorId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAuthorChangedMessage", reflect.TypeOf((*MockSender)(nil).SendAuthorChangedMessage), ctx, authorId)
+}
+
+// SendAuthorDeletedMessage mocks base method.
+func (m *MockSender) SendAuthorDeletedMessage(ctx context.Context, authorId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendAuthorDeletedMessage", ctx, authorId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendAuthorDeletedMessage indicates an expected call of SendAuthorDeletedMessage.
+func (mr *MockSenderMockRecorder) SendAuthorDeletedMessage(ctx, authorId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAuthorDeletedMessage", reflect.TypeOf((*MockSender)(nil).SendAuthorDeletedMessage), ctx, authorId)
+}
@@ -5,7 +5,12 @@
 package mock_client
 
 import (
+	context "context"
+	reflect "reflect"
+
 	gomock "github.com/golang/mock/gomock"
+	client "github.com/reversersed/go-web-services/tree/main/api_authors/internal/client"
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockStorage is a mock of Storage interface.
@@ -30,3 +35,91 @@ func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
 func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
+
+// AddAuthor mocks base method.
+func (m *MockStorage) AddAuthor(ctx context.Context, author *client.Author) (*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuthor", ctx, author)
+	ret0, _ := ret[0].(*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAuthor indicates an expected call of AddAuthor.
+func (mr *MockStorageMockRecorder) AddAuthor(ctx, author interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthor", reflect.TypeOf((*MockStorage)(nil).AddAuthor), ctx, author)
+}
+
+// DeleteAuthor mocks base method.
+func (m *MockStorage) DeleteAuthor(ctx context.Context, id primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAuthor", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAuthor indicates an expected call of DeleteAuthor.
+func (mr *MockStorageMockRecorder) DeleteAuthor(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAuthor", reflect.TypeOf((*MockStorage)(nil).DeleteAuthor), ctx, id)
+}
+
+// FindAuthors mocks base method.
+func (m *MockStorage) FindAuthors(ctx context.Context, name string, offset, limit int) ([]*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAuthors", ctx, name, offset, limit)
+	ret0, _ := ret[0].([]*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthors indicates an expected call of FindAuthors.
+func (mr *MockStorageMockRecorder) FindAuthors(ctx, name, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthors", reflect.TypeOf((*MockStorage)(nil).FindAuthors), ctx, name, offset, limit)
+}
+
+// GetAllAuthors mocks base method.
+func (m *MockStorage) GetAllAuthors(ctx context.Context, offset, limit int) ([]*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAuthors", ctx, offset, limit)
+	ret0, _ := ret[0].([]*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAuthors indicates an expected call of GetAllAuthors.
+func (mr *MockStorageMockRecorder) GetAllAuthors(ctx, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAuthors", reflect.TypeOf((*MockStorage)(nil).GetAllAuthors), ctx, offset, limit)
+}
+
+// GetAuthors mocks base method.
+func (m *MockStorage) GetAuthors(ctx context.Context, id []primitive.ObjectID) ([]*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", ctx, id)
+	ret0, _ := ret[0].([]*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthors indicates an expected call of GetAuthors.
+func (mr *MockStorageMockRecorder) GetAuthors(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockStorage)(nil).GetAuthors), ctx, id)
+}
+
+// UpdateAuthor mocks base method.
+func (m *MockStorage) UpdateAuthor(ctx context.Context, author *client.Author) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAuthor", ctx, author)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAuthor indicates an expected call of UpdateAuthor.
+func (mr *MockStorageMockRecorder) UpdateAuthor(ctx, author interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAuthor", reflect.TypeOf((*MockStorage)(nil).UpdateAuthor), ctx, author)
+}
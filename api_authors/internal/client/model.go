@@ -3,6 +3,27 @@ package client
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Author struct {
-	Id   primitive.ObjectID `json:"id" bson:"_id" validate:"primitiveid"`
-	Name string             `json:"name" bson:"name" validate:"min=4,max=32"`
+	Id        primitive.ObjectID `json:"id" bson:"_id,omitempty" validate:"primitiveid"`
+	Name      string             `json:"name" bson:"name" validate:"min=4,max=32"`
+	Bio       string             `json:"bio,omitempty" bson:"bio" validate:"max=4096"`
+	BirthYear int                `json:"birthyear,omitempty" bson:"birthyear" validate:"omitempty,gte=0,lte=2100"`
+	DeathYear int                `json:"deathyear,omitempty" bson:"deathyear" validate:"omitempty,gte=0,lte=2100"`
+	Photo     string             `json:"photo,omitempty" bson:"photo" validate:"omitempty,url"`
+}
+
+type AddAuthorQuery struct {
+	Name      string `json:"name" validate:"required,min=4,max=32"`
+	Bio       string `json:"bio" validate:"max=4096"`
+	BirthYear int    `json:"birthyear" validate:"omitempty,gte=0,lte=2100"`
+	DeathYear int    `json:"deathyear" validate:"omitempty,gte=0,lte=2100"`
+	Photo     string `json:"photo" validate:"omitempty,url"`
+}
+
+// Only non-nil fields are applied to the author
+type UpdateAuthorQuery struct {
+	Name      *string `json:"name" validate:"omitempty,min=4,max=32"`
+	Bio       *string `json:"bio" validate:"omitempty,max=4096"`
+	BirthYear *int    `json:"birthyear" validate:"omitempty,gte=0,lte=2100"`
+	DeathYear *int    `json:"deathyear" validate:"omitempty,gte=0,lte=2100"`
+	Photo     *string `json:"photo" validate:"omitempty,url"`
 }
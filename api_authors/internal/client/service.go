@@ -1,18 +1,181 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/cache"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
 	valid "github.com/reversersed/go-web-services/tree/main/api_authors/pkg/validator"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+//go:generate mockgen -source=service.go -destination=mocks/sender.go
+
+type Sender interface {
+	SendAuthorChangedMessage(ctx context.Context, authorId string) error
+	SendAuthorDeletedMessage(ctx context.Context, authorId string) error
+}
 type service struct {
 	storage   Storage
 	logger    *logging.Logger
 	cache     cache.Cache
 	validator *valid.Validator
+	sender    Sender
+}
+
+func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator, sender Sender) *service {
+	return &service{storage: storage, logger: logger, cache: cache, validator: validator, sender: sender}
 }
+func (s *service) GetAuthors(ctx context.Context, id string) ([]*Author, error) {
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
 
-func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator) *service {
-	return &service{storage: storage, logger: logger, cache: cache, validator: validator}
+	ids := strings.Split(id, ",")
+	primitives := make([]primitive.ObjectID, 0, len(ids))
+	authors := make([]*Author, 0, len(ids))
+	for _, cnvrt := range ids {
+		hex, err := primitive.ObjectIDFromHex(cnvrt)
+		if err != nil {
+			return nil, errormiddleware.BadRequestError([]string{"wrong request params"}, fmt.Sprintf("can't convert value %s to object hex. Must be primitive id. %v", cnvrt, err))
+		}
+		if bytes, err := s.cache.Get([]byte(hex.Hex())); err == nil {
+			var a Author
+			json.Unmarshal(bytes, &a)
+			authors = append(authors, &a)
+			continue
+		}
+		primitives = append(primitives, hex)
+	}
+	if len(primitives) == 0 {
+		s.logger.Infof("got %d items from cache", len(authors))
+		return authors, nil
+	}
+
+	fetched, err := s.storage.GetAuthors(cntx, primitives)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range fetched {
+		data, _ := json.Marshal(a)
+		s.cache.Set([]byte(a.Id.Hex()), data, int((time.Hour*6)/time.Second))
+	}
+	s.logger.Infof("added %d items in cache", len(fetched))
+
+	return append(authors, fetched...), nil
+}
+func (s *service) GetAllAuthors(ctx context.Context, offset, limit int) ([]*Author, error) {
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.storage.GetAllAuthors(cntx, offset, limit)
+}
+func (s *service) FindAuthors(ctx context.Context, name string, offset, limit int) ([]*Author, error) {
+	if len(strings.TrimSpace(name)) == 0 {
+		return nil, errormiddleware.BadRequestError([]string{"name: search query can't be empty"}, "received empty search query")
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return s.storage.FindAuthors(cntx, strings.TrimSpace(name), offset, limit)
+}
+func (s *service) AddAuthor(ctx context.Context, query *AddAuthorQuery) (*Author, error) {
+	if err := s.validator.Struct(query); err != nil {
+		return nil, errormiddleware.ValidationError(err, "wrong query")
+	}
+	author := &Author{
+		Name:      query.Name,
+		Bio:       query.Bio,
+		BirthYear: query.BirthYear,
+		DeathYear: query.DeathYear,
+		Photo:     query.Photo,
+	}
+	if err := validateLifeYears(author); err != nil {
+		return nil, err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	response, err := s.storage.AddAuthor(cntx, author)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(response)
+	s.cache.Set([]byte(response.Id.Hex()), data, int((time.Hour*6)/time.Second))
+	s.logger.Infof("created new author: %v", response)
+	return response, nil
+}
+func (s *service) UpdateAuthor(ctx context.Context, id string, query *UpdateAuthorQuery) (*Author, error) {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong author id"}, err.Error())
+	}
+	if err := s.validator.Struct(query); err != nil {
+		return nil, errormiddleware.ValidationError(err, "wrong query")
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	authors, err := s.storage.GetAuthors(cntx, []primitive.ObjectID{pId})
+	if err != nil {
+		return nil, err
+	}
+	author := authors[0]
+	if query.Name != nil {
+		author.Name = *query.Name
+	}
+	if query.Bio != nil {
+		author.Bio = *query.Bio
+	}
+	if query.BirthYear != nil {
+		author.BirthYear = *query.BirthYear
+	}
+	if query.DeathYear != nil {
+		author.DeathYear = *query.DeathYear
+	}
+	if query.Photo != nil {
+		author.Photo = *query.Photo
+	}
+	if err := validateLifeYears(author); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.UpdateAuthor(cntx, author); err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(author)
+	s.cache.Set([]byte(author.Id.Hex()), data, int((time.Hour*6)/time.Second))
+	if err := s.sender.SendAuthorChangedMessage(ctx, author.Id.Hex()); err != nil {
+		s.logger.Errorf("can't send author changed message: %v", err)
+	}
+	s.logger.Infof("updated author: %v", author)
+	return author, nil
+}
+func (s *service) DeleteAuthor(ctx context.Context, id string) error {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong author id"}, err.Error())
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := s.storage.DeleteAuthor(cntx, pId); err != nil {
+		return err
+	}
+	s.cache.Delete([]byte(pId.Hex()))
+	if err := s.sender.SendAuthorDeletedMessage(ctx, pId.Hex()); err != nil {
+		s.logger.Errorf("can't send author deleted message: %v", err)
+	}
+	s.logger.Warnf("author %s has been deleted", id)
+	return nil
+}
+func validateLifeYears(author *Author) error {
+	if author.BirthYear > 0 && author.DeathYear > 0 && author.DeathYear < author.BirthYear {
+		return errormiddleware.BadRequestError([]string{"deathyear can't be less than birthyear"}, fmt.Sprintf("received birth year %d and death year %d", author.BirthYear, author.DeathYear))
+	}
+	return nil
 }
@@ -1 +1,106 @@
-package client
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/client"
+	mock "github.com/reversersed/go-web-services/tree/main/api_authors/internal/client/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/cache/freecache"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGetAuthors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	cache := freecache.NewCache(1024 * 1024)
+	service := client.NewService(storage, logger, cache, validator.New(), mock.NewMockSender(ctrl))
+
+	cached := &client.Author{Id: primitive.NewObjectID(), Name: "Cached author"}
+	stored := &client.Author{Id: primitive.NewObjectID(), Name: "Stored author"}
+	data, _ := json.Marshal(cached)
+	cache.Set([]byte(cached.Id.Hex()), data, 60)
+
+	storage.EXPECT().GetAuthors(gomock.Any(), []primitive.ObjectID{stored.Id}).Return([]*client.Author{stored}, nil)
+
+	authors, err := service.GetAuthors(context.Background(), cached.Id.Hex()+","+stored.Id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []*client.Author{cached, stored}, authors)
+
+	// second call is served from cache only
+	authors, err = service.GetAuthors(context.Background(), stored.Id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, []*client.Author{stored}, authors)
+
+	_, err = service.GetAuthors(context.Background(), "wrongid")
+	assert.Error(t, err)
+}
+func TestUpdateAuthor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	sender := mock.NewMockSender(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), sender)
+
+	id := primitive.NewObjectID()
+	name := "New author name"
+	deathYear := 1800
+
+	t.Run("success", func(t *testing.T) {
+		storage.EXPECT().GetAuthors(gomock.Any(), []primitive.ObjectID{id}).Return([]*client.Author{{Id: id, Name: "Author", BirthYear: 1700}}, nil)
+		storage.EXPECT().UpdateAuthor(gomock.Any(), &client.Author{Id: id, Name: name, BirthYear: 1700, DeathYear: deathYear}).Return(nil)
+		sender.EXPECT().SendAuthorChangedMessage(gomock.Any(), id.Hex()).Return(nil)
+
+		author, err := service.UpdateAuthor(context.Background(), id.Hex(), &client.UpdateAuthorQuery{Name: &name, DeathYear: &deathYear})
+		assert.NoError(t, err)
+		assert.Equal(t, name, author.Name)
+		assert.Equal(t, deathYear, author.DeathYear)
+	})
+	t.Run("death year before birth", func(t *testing.T) {
+		storage.EXPECT().GetAuthors(gomock.Any(), []primitive.ObjectID{id}).Return([]*client.Author{{Id: id, Name: "Author", BirthYear: 1900}}, nil)
+
+		_, err := service.UpdateAuthor(context.Background(), id.Hex(), &client.UpdateAuthorQuery{DeathYear: &deathYear})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("wrong id", func(t *testing.T) {
+		_, err := service.UpdateAuthor(context.Background(), "wrongid", &client.UpdateAuthorQuery{})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+}
+func TestDeleteAuthor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	sender := mock.NewMockSender(ctrl)
+	cache := freecache.NewCache(1024 * 1024)
+	service := client.NewService(storage, logger, cache, validator.New(), sender)
+
+	id := primitive.NewObjectID()
+	cache.Set([]byte(id.Hex()), []byte("{}"), 60)
+
+	storage.EXPECT().DeleteAuthor(gomock.Any(), id).Return(nil)
+	sender.EXPECT().SendAuthorDeletedMessage(gomock.Any(), id.Hex()).Return(errors.New("rabbit is down"))
+
+	assert.NoError(t, service.DeleteAuthor(context.Background(), id.Hex()))
+	_, err := cache.Get([]byte(id.Hex()))
+	assert.Error(t, err)
+}
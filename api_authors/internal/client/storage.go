@@ -1,6 +1,18 @@
 package client
 
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 //go:generate mockgen -source=storage.go -destination=mocks/storage.go
 
 type Storage interface {
+	AddAuthor(ctx context.Context, author *Author) (*Author, error)
+	GetAuthors(ctx context.Context, id []primitive.ObjectID) ([]*Author, error)
+	GetAllAuthors(ctx context.Context, offset, limit int) ([]*Author, error)
+	FindAuthors(ctx context.Context, name string, offset, limit int) ([]*Author, error)
+	UpdateAuthor(ctx context.Context, author *Author) error
+	DeleteAuthor(ctx context.Context, id primitive.ObjectID) error
 }
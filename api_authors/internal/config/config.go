@@ -26,10 +26,19 @@ type RabbitConfig struct {
 	Rabbit_User string `env:"RABBITMQ_USER" env-required:"true"`
 	Rabbit_Pass string `env:"RABBITMQ_PASS" env-required:"true"`
 }
+
+// Secret is shared by all services, internal requests are signed with it.
+// Signed requests with body larger than MaxBodySize bytes are rejected
+type SignatureConfig struct {
+	Service     string `env:"SERVICE_NAME" env-default:"api_authors"`
+	Secret      string `env:"SERVICE_SECRET" env-required:"true"`
+	MaxBodySize int64  `env:"SERVICE_MAX_BODY_SIZE" env-default:"1048576"`
+}
 type Config struct {
-	Server   *ServerConfig
-	Database *DatabaseConfig
-	Rabbit   *RabbitConfig
+	Server    *ServerConfig
+	Database  *DatabaseConfig
+	Rabbit    *RabbitConfig
+	Signature *SignatureConfig
 }
 
 var cfg *Config
@@ -40,6 +49,7 @@ func GetConfig() *Config {
 		logger := logging.GetLogger()
 		logger.Info("reading api config...")
 		srvCfg := &ServerConfig{}
+		signatureCfg := &SignatureConfig{}
 		dbCfg := &DatabaseConfig{}
 		rabbitCfg := &RabbitConfig{}
 
@@ -61,10 +71,16 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		if err := cleanenv.ReadConfig("config/.env", signatureCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
 		cfg = &Config{
-			Server:   srvCfg,
-			Database: dbCfg,
-			Rabbit:   rabbitCfg,
+			Server:    srvCfg,
+			Database:  dbCfg,
+			Rabbit:    rabbitCfg,
+			Signature: signatureCfg,
 		}
 	})
 	return cfg
@@ -1,23 +1,177 @@
 package book
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
 )
 
 //go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
 
 const (
-// url_auth              = "/users/auth"
+	url_authors        = "/authors"
+	url_all_authors    = "/authors/all"
+	url_search_authors = "/authors/search"
+	url_author_by_id   = "/authors/:id"
 )
 
 type Service interface {
+	GetAuthors(ctx context.Context, id string) ([]*client.Author, error)
+	GetAllAuthors(ctx context.Context, offset, limit int) ([]*client.Author, error)
+	FindAuthors(ctx context.Context, name string, offset, limit int) ([]*client.Author, error)
+	AddAuthor(ctx context.Context, query *client.AddAuthorQuery) (*client.Author, error)
+	UpdateAuthor(ctx context.Context, id string, query *client.UpdateAuthorQuery) (*client.Author, error)
+	DeleteAuthor(ctx context.Context, id string) error
 }
 type Handler struct {
-	Logger      *logging.Logger
-	BookService Service
+	Logger        *logging.Logger
+	AuthorService Service
 }
 
 func (h *Handler) Register(route *httprouter.Router) {
-	//route.HandlerFunc(http.MethodPost, url_auth, h.Logger.Middleware(errormiddleware.Middleware(h.AuthUser)))
+	route.HandlerFunc(http.MethodPost, url_authors, h.Logger.Middleware(errormiddleware.Middleware(h.AddAuthor)))
+	route.HandlerFunc(http.MethodGet, url_authors, h.Logger.Middleware(errormiddleware.Middleware(h.GetAuthors)))
+	route.HandlerFunc(http.MethodGet, url_all_authors, h.Logger.Middleware(errormiddleware.Middleware(h.GetAllAuthors)))
+	route.HandlerFunc(http.MethodGet, url_search_authors, h.Logger.Middleware(errormiddleware.Middleware(h.FindAuthors)))
+	route.HandlerFunc(http.MethodPatch, url_author_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.UpdateAuthor)))
+	route.HandlerFunc(http.MethodDelete, url_author_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.DeleteAuthor)))
+}
+func (h *Handler) AddAuthor(w http.ResponseWriter, r *http.Request) error {
+	var query client.AddAuthorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	author, err := h.AuthorService.AddAuthor(ctx, &query)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	body, _ := json.Marshal(author)
+	w.Write(body)
+	return nil
+}
+func (h *Handler) GetAuthors(w http.ResponseWriter, r *http.Request) error {
+	id := r.URL.Query().Get("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id param must contain at least 1 id"}, "wrong query (try use ?id=id1,id2,id3...)")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	authors, err := h.AuthorService.GetAuthors(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(&authors)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return nil
+}
+func (h *Handler) GetAllAuthors(w http.ResponseWriter, r *http.Request) error {
+	offset, limit, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	authors, err := h.AuthorService.GetAllAuthors(ctx, offset, limit)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(&authors)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return nil
+}
+func (h *Handler) FindAuthors(w http.ResponseWriter, r *http.Request) error {
+	name := r.URL.Query().Get("name")
+	if len(name) == 0 {
+		return errormiddleware.BadRequestError([]string{"name: parameter is required"}, "name query is not present")
+	}
+	offset, limit, err := pagination(r)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	authors, err := h.AuthorService.FindAuthors(ctx, name, offset, limit)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(&authors)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return nil
+}
+func (h *Handler) UpdateAuthor(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
+	}
+	var query client.UpdateAuthorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	author, err := h.AuthorService.UpdateAuthor(ctx, id, &query)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(author)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return nil
+}
+func (h *Handler) DeleteAuthor(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.AuthorService.DeleteAuthor(ctx, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func pagination(r *http.Request) (int, int, error) {
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		return 0, 0, errormiddleware.BadRequestError([]string{"bad query request", "offset must be present"}, err.Error())
+	}
+	if offset < 0 {
+		return 0, 0, errormiddleware.BadRequestError([]string{"bad query request"}, "offset must be greater than -1")
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		return 0, 0, errormiddleware.BadRequestError([]string{"bad query request", "limit must be present"}, err.Error())
+	}
+	if limit <= 0 {
+		return 0, 0, errormiddleware.BadRequestError([]string{"bad query request"}, "limit must be greater than 0")
+	}
+	return offset, limit, nil
 }
@@ -2,6 +2,7 @@ package book
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,12 +11,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_authors/internal/client"
 	mock "github.com/reversersed/go-web-services/tree/main/api_authors/internal/handlers/author/mocks"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var h *Handler
@@ -32,7 +35,14 @@ func TestRegister(t *testing.T) {
 		Name   string
 		Path   string
 		Method string
-	}{}
+	}{
+		{"Add author", url_authors, http.MethodPost},
+		{"Get authors by id", url_authors, http.MethodGet},
+		{"Get all authors", url_all_authors, http.MethodGet},
+		{"Search authors", url_search_authors, http.MethodGet},
+		{"Update author", url_author_by_id, http.MethodPatch},
+		{"Delete author", url_author_by_id, http.MethodDelete},
+	}
 	router := httprouter.New()
 	h.Register(router)
 	for _, registerCase := range registerCases {
@@ -57,7 +67,85 @@ func TestHandlers(t *testing.T) {
 		Handler     func(w http.ResponseWriter, r *http.Request) error
 		Method      string
 		Options     []handlerOptions
-	}{}
+	}{
+		{
+			HandlerName: "AddAuthor",
+			Handler:     h.AddAuthor,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().AddAuthor(gomock.Any(), &client.AddAuthorQuery{Name: "Author name"}).Return(&client.Author{Id: primitive.NilObjectID, Name: "Author name"}, nil)
+					},
+					InputJson: func() *[]byte {
+						b, _ := json.Marshal(&client.AddAuthorQuery{Name: "Author name"})
+						return &b
+					},
+					ExceptedStatus: http.StatusCreated,
+					ExceptedBody:   "{\"id\":\"000000000000000000000000\",\"name\":\"Author name\"}",
+				},
+				{
+					Name:           "nil body",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"invalid json scheme"}, "EOF"),
+					ExceptedBody:   "{\"messages\":[\"invalid json scheme\"],\"dev_message\":\"EOF\",\"code\":\"IE-0003\"}",
+				},
+				{
+					Name: "service error",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().AddAuthor(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.NotFoundError([]string{"not found"}, "not found"))
+					},
+					InputJson: func() *[]byte {
+						b, _ := json.Marshal(&client.AddAuthorQuery{Name: "Author name"})
+						return &b
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"not found"}, "not found"),
+					ExceptedBody:   "{\"messages\":[\"not found\"],\"dev_message\":\"not found\",\"code\":\"IE-0002\"}",
+				},
+			},
+		},
+		{
+			HandlerName: "GetAuthors",
+			Handler:     h.GetAuthors,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"id param must contain at least 1 id"}, "wrong query (try use ?id=id1,id2,id3...)"),
+					ExceptedBody:   "{\"messages\":[\"id param must contain at least 1 id\"],\"dev_message\":\"wrong query (try use ?id=id1,id2,id3...)\",\"code\":\"IE-0003\"}",
+				},
+			},
+		},
+		{
+			HandlerName: "GetAllAuthors",
+			Handler:     h.GetAllAuthors,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name:           "no offset",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad query request", "offset must be present"}, "strconv.Atoi: parsing \"\": invalid syntax"),
+					ExceptedBody:   "{\"messages\":[\"bad query request\",\"offset must be present\"],\"dev_message\":\"strconv.Atoi: parsing \\\"\\\": invalid syntax\",\"code\":\"IE-0003\"}",
+				},
+			},
+		},
+		{
+			HandlerName: "DeleteAuthor",
+			Handler:     h.DeleteAuthor,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present"),
+					ExceptedBody:   "{\"messages\":[\"id: parameter is required\"],\"dev_message\":\"id path is not present\",\"code\":\"IE-0003\"}",
+				},
+			},
+		},
+	}
 	for _, tt := range testTable {
 		for _, testCase := range tt.Options {
 			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
@@ -67,7 +155,7 @@ func TestHandlers(t *testing.T) {
 				if testCase.MockBehaviour != nil {
 					testCase.MockBehaviour(service)
 				}
-				h.BookService = service
+				h.AuthorService = service
 
 				w := httptest.NewRecorder()
 				var r *http.Request
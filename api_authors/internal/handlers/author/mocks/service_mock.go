@@ -5,7 +5,11 @@
 package mock_book
 
 import (
+	context "context"
+	reflect "reflect"
+
 	gomock "github.com/golang/mock/gomock"
+	client "github.com/reversersed/go-web-services/tree/main/api_authors/internal/client"
 )
 
 // MockService is a mock of Service interface.
@@ -30,3 +34,92 @@ func NewMockService(ctrl *gomock.Controller) *MockService {
 func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
+
+// AddAuthor mocks base method.
+func (m *MockService) AddAuthor(ctx context.Context, query *client.AddAuthorQuery) (*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuthor", ctx, query)
+	ret0, _ := ret[0].(*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAuthor indicates an expected call of AddAuthor.
+func (mr *MockServiceMockRecorder) AddAuthor(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthor", reflect.TypeOf((*MockService)(nil).AddAuthor), ctx, query)
+}
+
+// DeleteAuthor mocks base method.
+func (m *MockService) DeleteAuthor(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAuthor", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAuthor indicates an expected call of DeleteAuthor.
+func (mr *MockServiceMockRecorder) DeleteAuthor(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAuthor", reflect.TypeOf((*MockService)(nil).DeleteAuthor), ctx, id)
+}
+
+// FindAuthors mocks base method.
+func (m *MockService) FindAuthors(ctx context.Context, name string, offset, limit int) ([]*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAuthors", ctx, name, offset, limit)
+	ret0, _ := ret[0].([]*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthors indicates an expected call of FindAuthors.
+func (mr *MockServiceMockRecorder) FindAuthors(ctx, name, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthors", reflect.TypeOf((*MockService)(nil).FindAuthors), ctx, name, offset, limit)
+}
+
+// GetAllAuthors mocks base method.
+func (m *MockService) GetAllAuthors(ctx context.Context, offset, limit int) ([]*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAuthors", ctx, offset, limit)
+	ret0, _ := ret[0].([]*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAuthors indicates an expected call of GetAllAuthors.
+func (mr *MockServiceMockRecorder) GetAllAuthors(ctx, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAuthors", reflect.TypeOf((*MockService)(nil).GetAllAuthors), ctx, offset, limit)
+}
+
+// GetAuthors mocks base method.
+func (m *MockService) GetAuthors(ctx context.Context, id string) ([]*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", ctx, id)
+	ret0, _ := ret[0].([]*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthors indicates an expected call of GetAuthors.
+func (mr *MockServiceMockRecorder) GetAuthors(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockService)(nil).GetAuthors), ctx, id)
+}
+
+// UpdateAuthor mocks base method.
+func (m *MockService) UpdateAuthor(ctx context.Context, id string, query *client.UpdateAuthorQuery) (*client.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAuthor", ctx, id, query)
+	ret0, _ := ret[0].(*client.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAuthor indicates an expected call of UpdateAuthor.
+func (mr *MockServiceMockRecorder) UpdateAuthor(ctx, id, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAuthor", reflect.TypeOf((*MockService)(nil).UpdateAuthor), ctx, id, query)
+}
@@ -1,6 +1,9 @@
 package rabbitmq
 
 import (
+	"context"
+	"time"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/reversersed/go-web-services/tree/main/api_authors/pkg/logging"
 )
@@ -16,3 +19,36 @@ func NewSender(connection *amqp.Connection, logger *logging.Logger) *Sender {
 func (s *Sender) Close() error {
 	return nil
 }
+func (s *Sender) SendAuthorChangedMessage(ctx context.Context, authorId string) error {
+	return s.publish(ctx, "AuthorChangedExchange", authorId)
+}
+func (s *Sender) SendAuthorDeletedMessage(ctx context.Context, authorId string) error {
+	return s.publish(ctx, "AuthorDeletedExchange", authorId)
+}
+
+// consumers bind their own queues to the exchange, so nothing is kept when nobody listens
+func (s *Sender) publish(ctx context.Context, exchange, authorId string) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare(exchange, "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err = ch.PublishWithContext(cntx, exchange, "#", false, false, amqp.Publishing{
+		ContentType: "text/plain",
+		Body:        []byte(authorId),
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending %s message: %v", exchange, err)
+		return err
+	}
+	s.logger.Infof("Sended author (%s) message to %s", authorId, exchange)
+	return nil
+}
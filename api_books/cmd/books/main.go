@@ -14,6 +14,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
 	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client/db"
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client/files"
 	"github.com/reversersed/go-web-services/tree/main/api_books/internal/config"
 	"github.com/reversersed/go-web-services/tree/main/api_books/internal/handlers/book"
 	"github.com/reversersed/go-web-services/tree/main/api_books/internal/rabbitmq"
@@ -22,6 +23,7 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/mongo"
 	RabbitClient "github.com/reversersed/go-web-services/tree/main/api_books/pkg/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/shutdown"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/signature"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/validator"
 )
 
@@ -51,18 +53,46 @@ func main() {
 	}
 	rabbitSender := rabbitmq.NewSender(rabbit.Connection, logger)
 
+	logger.Infof("file store initializing with %s backend...", config.Files.Backend)
+	fileStore, err := newFileStore(config.Files)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	logger.Info("services initializing...")
 	bookStorage := db.NewStorage(db_client, "books", logger)
-	bookService := client.NewService(bookStorage, logger, cache, validator.New(), config.Urls)
+	bookService := client.NewService(bookStorage, logger, cache, validator.New(), fileStore, config.Urls, &signature.Signer{Service: config.Signature.Service, Secret: config.Signature.Secret})
+	reconciler := client.NewReconciler(bookStorage, fileStore, logger, config.Files)
+	reconciler.Start()
+	genreReceiver := rabbitmq.NewGenreReceiver(rabbit.Connection, logger, bookService)
+	genreReceiver.Start()
+	authorReceiver := rabbitmq.NewAuthorReceiver(rabbit.Connection, logger, bookService)
+	authorReceiver.Start()
 
 	logger.Info("handlers registration...")
-	handler := book.Handler{Logger: logger, BookService: bookService}
+	handler := book.Handler{Logger: logger, BookService: bookService, MaxFileSize: config.Files.MaxFileSize, MaxCoverSize: config.Files.MaxCoverSize}
 	handler.Register(router)
 
 	logger.Info("starting application...")
-	start(router, logger, config.Server, rabbit, rabbitSender)
+	start(signature.Middleware(router, config.Signature.Secret, max(config.Signature.MaxBodySize, handler.MaxRequestSize()), logger), logger, config.Server, rabbit, rabbitSender, genreReceiver, authorReceiver, reconciler)
+}
+func newFileStore(cfg *config.FilesConfig) (client.FileStore, error) {
+	switch cfg.Backend {
+	case "local":
+		return files.NewLocalStore(cfg.LocalPath)
+	case "s3":
+		return files.NewS3Store(files.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown file store backend %s", cfg.Backend)
+	}
 }
-func start(router *httprouter.Router, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
+func start(router http.Handler, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
 	var server *http.Server
 	var listener net.Listener
 
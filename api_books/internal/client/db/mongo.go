@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/cursor"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,8 +32,33 @@ func NewStorage(storage *mongo.Database, collection string, logger *logging.Logg
 		logger:     logger,
 	}
 	defer db.seedBooks()
+	db.createIndexes()
 	return db
 }
+func (d *db) createIndexes() {
+	models := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: "text"}},
+			Options: options.Index().SetName("name_text").SetDefaultLanguage("none"),
+		},
+		{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetName("name")},
+		{Keys: bson.D{{Key: "author", Value: 1}}, Options: options.Index().SetName("author")},
+		{Keys: bson.D{{Key: "genres", Value: 1}}, Options: options.Index().SetName("genres")},
+		{Keys: bson.D{{Key: "year", Value: 1}}, Options: options.Index().SetName("year")},
+		{Keys: bson.D{{Key: "pages", Value: 1}}, Options: options.Index().SetName("pages")},
+		{Keys: bson.D{{Key: "filepath", Value: 1}}, Options: options.Index().SetName("filepath")},
+		{Keys: bson.D{{Key: "coverpath", Value: 1}}, Options: options.Index().SetName("coverpath")},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	names, err := d.collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		d.logger.Errorf("can't create book indexes: %v", err)
+		return
+	}
+	d.logger.Infof("book indexes created: %v", names)
+}
 func (d *db) seedBooks() {
 	docCount, _ := d.collection.CountDocuments(context.Background(), bson.D{})
 	if docCount > 0 {
@@ -108,39 +138,290 @@ func (d *db) GetBookById(ctx context.Context, id primitive.ObjectID) (*client.Bo
 
 	return &book, nil
 }
-func (d *db) GetByFilter(ctx context.Context, filter map[string]string, offset, limit int) ([]*client.Book, error) {
+func (d *db) GetByFilter(ctx context.Context, filter map[string]string, pageCursor string, offset, limit int) (*client.BookPage, error) {
 	d.RLock()
 	defer d.RUnlock()
 
-	options := options.Find().SetSkip(int64(offset)).SetLimit(int64(limit)).SetSort(bson.M{"name": -1})
+	filters, err := buildFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	field, ascending, err := buildSort(filter)
+	if err != nil {
+		return nil, err
+	}
+	var current *cursor.Cursor
+	if len(pageCursor) > 0 {
+		current, err = cursor.Decode(pageCursor)
+		if err != nil {
+			return nil, errormiddleware.BadRequestError([]string{"cursor: invalid cursor"}, err.Error())
+		}
+		if current.Field != field {
+			return nil, errormiddleware.BadRequestError([]string{"cursor: invalid cursor"}, fmt.Sprintf("cursor was made for sort by %s, but received sort by %s", current.Field, field))
+		}
+	}
+
+	total, err := d.collection.CountDocuments(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	query := filters
+	direction := current.Direction(ascending)
+	options := options.Find().SetLimit(int64(limit + 1)).SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+	if current != nil {
+		query = append(query, bson.E{Key: "$and", Value: bson.A{current.Filter(ascending)}})
+	} else {
+		options.SetSkip(int64(offset))
+	}
+
+	result, err := d.collection.Find(ctx, query, options)
+	if err != nil {
+		return nil, err
+	}
+	books := make([]*client.Book, 0)
+	if err = result.All(ctx, &books); err != nil {
+		return nil, err
+	}
+
+	items, next, prev := cursor.Page(books, limit, field, current, offset > 0, func(b *client.Book) (interface{}, primitive.ObjectID) {
+		switch field {
+		case "year":
+			return b.Year, b.Id
+		case "pages":
+			return b.Pages, b.Id
+		default:
+			return b.Name, b.Id
+		}
+	})
+	return &client.BookPage{Items: items, Total: total, NextCursor: next, PrevCursor: prev}, nil
+}
+func (d *db) UpdateBook(ctx context.Context, book *client.Book) error {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.ReplaceOne(ctx, bson.M{"_id": book.Id}, book)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"book not exists"}, fmt.Sprintf("book with id %s was not found", book.Id.Hex()))
+	}
+	return nil
+}
+func (d *db) DeleteBook(ctx context.Context, id primitive.ObjectID) error {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"book not exists"}, fmt.Sprintf("book with id %s was not found", id.Hex()))
+	}
+	return nil
+}
+
+// Sort and search keys are handled separately, every other key must be a known filter
+func buildFilter(filter map[string]string) (bson.D, error) {
 	filters := make(bson.D, 0)
+	years := bson.M{}
+	pages := bson.M{}
 
 	for i, v := range filter {
 		switch i {
-		case "year":
-			filters = append(filters, bson.E{Key: "year", Value: bson.M{"$gte": v}})
+		case "author":
+			id, err := primitive.ObjectIDFromHex(v)
+			if err != nil {
+				return nil, errormiddleware.BadRequestError([]string{"author: invalid author id"}, err.Error())
+			}
+			filters = append(filters, bson.E{Key: "author", Value: id})
+		case "genres":
+			ids := make([]primitive.ObjectID, 0)
+			for _, hex := range strings.Split(v, ",") {
+				id, err := primitive.ObjectIDFromHex(hex)
+				if err != nil {
+					return nil, errormiddleware.BadRequestError([]string{"genres: invalid genre id"}, err.Error())
+				}
+				ids = append(ids, id)
+			}
+			switch filter["genresmode"] {
+			case "", "any":
+				filters = append(filters, bson.E{Key: "genres", Value: bson.M{"$in": ids}})
+			case "all":
+				filters = append(filters, bson.E{Key: "genres", Value: bson.M{"$all": ids}})
+			default:
+				return nil, errormiddleware.BadRequestError([]string{"genresmode: must be any or all"}, fmt.Sprintf("received genres mode %s", filter["genresmode"]))
+			}
+		case "yearfrom", "yearto", "pagesfrom", "pagesto":
+			value, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, errormiddleware.BadRequestError([]string{fmt.Sprintf("%s: must be a number", i)}, err.Error())
+			}
+			operator := "$gte"
+			if strings.HasSuffix(i, "to") {
+				operator = "$lte"
+			}
+			if strings.HasPrefix(i, "year") {
+				years[operator] = value
+			} else {
+				pages[operator] = value
+			}
+		case "name":
+			filters = append(filters, bson.E{Key: "name", Value: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(v), Options: "i"}})
+		case "genresmode", "sort", "order", "search", "searchauthors":
+			continue
 		default:
 			return nil, errormiddleware.BadRequestError([]string{"invalid filter received"}, fmt.Sprintf("filter %s: %s is unsupported", i, v))
 		}
 	}
+	if len(years) > 0 {
+		filters = append(filters, bson.E{Key: "year", Value: years})
+	}
+	if len(pages) > 0 {
+		filters = append(filters, bson.E{Key: "pages", Value: pages})
+	}
 
-	result, err := d.collection.Find(ctx, filters, options)
+	if search, ok := filter["search"]; ok {
+		authors := make([]primitive.ObjectID, 0)
+		if len(filter["searchauthors"]) > 0 {
+			for _, hex := range strings.Split(filter["searchauthors"], ",") {
+				if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+					authors = append(authors, id)
+				}
+			}
+		}
+		if len(authors) == 0 {
+			filters = append(filters, bson.E{Key: "$text", Value: bson.M{"$search": search}})
+		} else {
+			// every $or clause is indexed, so $text is allowed inside it
+			filters = append(filters, bson.E{Key: "$or", Value: bson.A{
+				bson.M{"$text": bson.M{"$search": search}},
+				bson.M{"author": bson.M{"$in": authors}},
+			}})
+		}
+	}
+	return filters, nil
+}
+
+// Returns sort field and whether listing is sorted in ascending order
+func buildSort(filter map[string]string) (string, bool, error) {
+	field := "name"
+	if sort, ok := filter["sort"]; ok {
+		switch sort {
+		case "name", "year", "pages":
+			field = sort
+		default:
+			return "", false, errormiddleware.BadRequestError([]string{"sort: must be one of name, year, pages"}, fmt.Sprintf("received sort field %s", sort))
+		}
+	}
+	switch filter["order"] {
+	case "asc":
+		return field, true, nil
+	case "", "desc":
+		return field, false, nil
+	default:
+		return "", false, errormiddleware.BadRequestError([]string{"order: must be asc or desc"}, fmt.Sprintf("received order %s", filter["order"]))
+	}
+}
+
+// Objects are content addressed, so the same file could be shared by several books
+func (d *db) IsFileUsed(ctx context.Context, key string) (bool, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	count, err := d.collection.CountDocuments(ctx, bson.M{"$or": bson.A{bson.M{"filepath": key}, bson.M{"coverpath": key}}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Returns all books with only id and file keys filled
+func (d *db) GetFileReferences(ctx context.Context) ([]*client.Book, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	result, err := d.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "filepath": 1, "coverpath": 1}))
 	if err != nil {
 		return nil, err
 	}
-	if err := result.Err(); errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, errormiddleware.NotFoundError([]string{"no books found"}, result.Err().Error())
-	} else if err != nil {
+	books := make([]*client.Book, 0)
+	if err := result.All(ctx, &books); err != nil {
 		return nil, err
 	}
+	return books, nil
+}
+func (d *db) GetBookIdsByGenre(ctx context.Context, genre primitive.ObjectID) ([]primitive.ObjectID, error) {
+	d.RLock()
+	defer d.RUnlock()
 
-	var books []*client.Book
-	err = result.All(ctx, &books)
+	result, err := d.collection.Find(ctx, bson.M{"genres": genre}, options.Find().SetProjection(bson.M{"_id": 1}))
 	if err != nil {
 		return nil, err
 	}
-	if len(books) == 0 {
-		return nil, errormiddleware.NotFoundError([]string{"no books found"}, "document slice contains 0 items")
+	books := make([]*client.Book, 0)
+	if err := result.All(ctx, &books); err != nil {
+		return nil, err
 	}
-	return books, nil
+	ids := make([]primitive.ObjectID, 0, len(books))
+	for _, book := range books {
+		ids = append(ids, book.Id)
+	}
+	return ids, nil
+}
+func (d *db) GetBookIdsByAuthor(ctx context.Context, author primitive.ObjectID) ([]primitive.ObjectID, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	result, err := d.collection.Find(ctx, bson.M{"author": author}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	books := make([]*client.Book, 0)
+	if err := result.All(ctx, &books); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(books))
+	for _, book := range books {
+		ids = append(ids, book.Id)
+	}
+	return ids, nil
+}
+func (d *db) RemoveGenre(ctx context.Context, genre primitive.ObjectID) error {
+	d.Lock()
+	defer d.Unlock()
+
+	_, err := d.collection.UpdateMany(ctx, bson.M{"genres": genre}, bson.M{"$pull": bson.M{"genres": genre}})
+	return err
+}
+
+// Genres without books are not present in result
+func (d *db) CountByGenres(ctx context.Context, genres []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"genres": bson.M{"$in": genres}}}},
+		{{Key: "$unwind", Value: "$genres"}},
+		{{Key: "$match", Value: bson.M{"genres": bson.M{"$in": genres}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$genres", "count": bson.M{"$sum": 1}}}},
+	}
+	result, err := d.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var groups []struct {
+		Genre primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err := result.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	counts := make(map[primitive.ObjectID]int64, len(groups))
+	for _, group := range groups {
+		counts[group.Genre] = group.Count
+	}
+	return counts, nil
 }
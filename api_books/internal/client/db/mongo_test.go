@@ -0,0 +1,75 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	var table = []struct {
+		Name          string
+		Filter        map[string]string
+		Excepted      bson.D
+		ExceptedError bool
+	}{
+		{"empty filter", map[string]string{}, bson.D{}, false},
+		{"author", map[string]string{"author": id.Hex()}, bson.D{{Key: "author", Value: id}}, false},
+		{"wrong author", map[string]string{"author": "wrongid"}, nil, true},
+		{"any genres", map[string]string{"genres": id.Hex()}, bson.D{{Key: "genres", Value: bson.M{"$in": []primitive.ObjectID{id}}}}, false},
+		{"all genres", map[string]string{"genres": id.Hex(), "genresmode": "all"}, bson.D{{Key: "genres", Value: bson.M{"$all": []primitive.ObjectID{id}}}}, false},
+		{"wrong genres mode", map[string]string{"genres": id.Hex(), "genresmode": "none"}, nil, true},
+		{"year range", map[string]string{"yearfrom": "1900", "yearto": "2000"}, bson.D{{Key: "year", Value: bson.M{"$gte": 1900, "$lte": 2000}}}, false},
+		{"pages from", map[string]string{"pagesfrom": "100"}, bson.D{{Key: "pages", Value: bson.M{"$gte": 100}}}, false},
+		{"wrong year", map[string]string{"yearfrom": "year"}, nil, true},
+		{"name prefix", map[string]string{"name": "a.b"}, bson.D{{Key: "name", Value: primitive.Regex{Pattern: `^a\.b`, Options: "i"}}}, false},
+		{"search", map[string]string{"search": "text"}, bson.D{{Key: "$text", Value: bson.M{"$search": "text"}}}, false},
+		{"search with authors", map[string]string{"search": "text", "searchauthors": id.Hex()}, bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"$text": bson.M{"$search": "text"}},
+			bson.M{"author": bson.M{"$in": []primitive.ObjectID{id}}},
+		}}}, false},
+		{"unsupported filter", map[string]string{"unknown": "value"}, nil, true},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			filter, err := buildFilter(tt.Filter)
+			if tt.ExceptedError {
+				assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Excepted, filter)
+		})
+	}
+}
+func TestBuildSort(t *testing.T) {
+	var table = []struct {
+		Name              string
+		Filter            map[string]string
+		ExceptedField     string
+		ExceptedAscending bool
+		ExceptedError     bool
+	}{
+		{"default", map[string]string{}, "name", false, false},
+		{"year ascending", map[string]string{"sort": "year", "order": "asc"}, "year", true, false},
+		{"pages descending", map[string]string{"sort": "pages", "order": "desc"}, "pages", false, false},
+		{"wrong field", map[string]string{"sort": "author"}, "", false, true},
+		{"wrong order", map[string]string{"order": "random"}, "", false, true},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			field, ascending, err := buildSort(tt.Filter)
+			if tt.ExceptedError {
+				assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExceptedField, field)
+			assert.Equal(t, tt.ExceptedAscending, ascending)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
+)
+
+type local struct {
+	root string
+}
+
+// Stores objects as plain files in the root directory
+func NewLocalStore(root string) (client.FileStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &local{root: root}, nil
+}
+func (l *local) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	// objects are content addressed, so existing object already has the same content
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	temp, err := os.CreateTemp(l.root, ".upload_*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, body)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("object %s: written %d bytes, expected %d", key, written, size)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+func (l *local) Get(ctx context.Context, key string) (*client.File, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errormiddleware.NotFoundError([]string{"file not exists"}, fmt.Sprintf("object %s not found", key))
+	} else if err != nil {
+		return nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &client.File{Body: file, Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+func (l *local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+func (l *local) Move(ctx context.Context, from, to string) error {
+	source, err := l.path(from)
+	if err != nil {
+		return err
+	}
+	destination, err := l.path(to)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(source); errors.Is(err, os.ErrNotExist) {
+		return errormiddleware.NotFoundError([]string{"file not exists"}, fmt.Sprintf("object %s not found", from))
+	}
+	if _, err := os.Stat(destination); err == nil {
+		return os.Remove(source)
+	}
+	return os.Rename(source, destination)
+}
+func (l *local) List(ctx context.Context) ([]*client.ObjectInfo, error) {
+	entries, err := os.ReadDir(l.root)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]*client.ObjectInfo, 0, len(entries))
+	for _, entry := range entries {
+		// temporary files of uploads in progress are not objects yet
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".upload_") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		objects = append(objects, &client.ObjectInfo{Key: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return objects, nil
+}
+
+// Keys can't point outside of the root directory
+func (l *local) path(key string) (string, error) {
+	if len(key) == 0 || key == "." || key == ".." || filepath.Base(key) != key {
+		return "", errormiddleware.BadRequestError([]string{"wrong file key"}, fmt.Sprintf("key %s is not a plain file name", key))
+	}
+	return filepath.Join(l.root, key), nil
+}
@@ -0,0 +1,87 @@
+package files
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	testStore(t, store)
+
+	t.Run("wrong keys", func(t *testing.T) {
+		for _, key := range []string{"", "..", "../book.pdf", "dir/book.pdf"} {
+			err := store.Put(context.Background(), key, strings.NewReader("content"), 7)
+			assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code, key)
+		}
+	})
+	t.Run("size mismatch", func(t *testing.T) {
+		assert.Error(t, store.Put(context.Background(), "short.pdf", strings.NewReader("content"), 100))
+		_, err := store.Get(context.Background(), "short.pdf")
+		assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+	})
+}
+
+// Common behaviour of all file stores
+func testStore(t *testing.T, store client.FileStore) {
+	ctx := context.Background()
+	content := "%PDF-1.4 book content"
+
+	t.Run("put and get", func(t *testing.T) {
+		assert.NoError(t, store.Put(ctx, "book.pdf", strings.NewReader(content), int64(len(content))))
+		// content addressed object is not rewritten
+		assert.NoError(t, store.Put(ctx, "book.pdf", strings.NewReader(content), int64(len(content))))
+
+		file, err := store.Get(ctx, "book.pdf")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer file.Body.Close()
+		assert.Equal(t, int64(len(content)), file.Size)
+		assert.False(t, file.ModTime.IsZero())
+
+		data, err := io.ReadAll(file.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		_, err = file.Body.Seek(9, io.SeekStart)
+		assert.NoError(t, err)
+		part := make([]byte, 4)
+		_, err = io.ReadFull(file.Body, part)
+		assert.NoError(t, err)
+		assert.Equal(t, "book", string(part))
+	})
+	t.Run("move and list", func(t *testing.T) {
+		assert.NoError(t, store.Put(ctx, "staging_book.pdf", strings.NewReader(content), int64(len(content))))
+		assert.NoError(t, store.Move(ctx, "staging_book.pdf", "moved.pdf"))
+		// destination already exists, so only source is removed
+		assert.NoError(t, store.Put(ctx, "staging_book.pdf", strings.NewReader(content), int64(len(content))))
+		assert.NoError(t, store.Move(ctx, "staging_book.pdf", "book.pdf"))
+
+		objects, err := store.List(ctx)
+		assert.NoError(t, err)
+		keys := make([]string, 0)
+		for _, v := range objects {
+			keys = append(keys, v.Key)
+			assert.Equal(t, int64(len(content)), v.Size)
+		}
+		assert.ElementsMatch(t, []string{"book.pdf", "moved.pdf"}, keys)
+		assert.NoError(t, store.Delete(ctx, "moved.pdf"))
+	})
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, store.Delete(ctx, "book.pdf"))
+		assert.NoError(t, store.Delete(ctx, "book.pdf"))
+
+		_, err := store.Get(ctx, "book.pdf")
+		assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+	})
+}
@@ -0,0 +1,369 @@
+package files
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
+)
+
+const (
+	s3_algorithm        = "AWS4-HMAC-SHA256"
+	s3_time_format      = "20060102T150405Z"
+	s3_unsigned_payload = "UNSIGNED-PAYLOAD"
+	s3_empty_payload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// Client of S3 compatible storages (AWS, MinIO, etc).
+// Requests use path style addressing and signature version 4
+type s3 struct {
+	cfg        S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (client.FileStore, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoint.Scheme) == 0 || len(endpoint.Host) == 0 {
+		return nil, fmt.Errorf("s3 endpoint %s must contain scheme and host", cfg.Endpoint)
+	}
+	if len(cfg.Bucket) == 0 {
+		return nil, errors.New("s3 bucket is not set")
+	}
+	if len(cfg.Region) == 0 {
+		cfg.Region = "us-east-1"
+	}
+	// no overall timeout, since objects could be large. Requests are limited by their contexts
+	return &s3{cfg: cfg, endpoint: endpoint, httpClient: &http.Client{}, now: time.Now}, nil
+}
+func (s *s3) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	req, err := s.request(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := s.send(req, s3_unsigned_payload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+func (s *s3) Get(ctx context.Context, key string) (*client.File, error) {
+	req, err := s.request(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.send(req, s3_empty_payload)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &client.File{
+		Body:    &objectReader{store: s, ctx: ctx, key: key, size: resp.ContentLength},
+		Size:    resp.ContentLength,
+		ModTime: modTime,
+	}, nil
+}
+func (s *s3) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.send(req, s3_empty_payload)
+	if err != nil {
+		var notFound *errormiddleware.Error
+		if errors.As(err, &notFound) && notFound.Code == errormiddleware.NotFoundErrorCode {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// S3 has no rename, so object is copied and source is deleted
+func (s *s3) Move(ctx context.Context, from, to string) error {
+	if err := validKey(from); err != nil {
+		return err
+	}
+	head, err := s.request(ctx, http.MethodHead, to, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.send(head, s3_empty_payload)
+	if err == nil {
+		resp.Body.Close()
+		return s.Delete(ctx, from)
+	}
+	var notFound *errormiddleware.Error
+	if !errors.As(err, &notFound) || notFound.Code != errormiddleware.NotFoundErrorCode {
+		return err
+	}
+
+	req, _ := s.request(ctx, http.MethodPut, to, nil)
+	req.Header.Set("X-Amz-Copy-Source", fmt.Sprintf("/%s/%s", s.cfg.Bucket, from))
+	resp, err = s.send(req, s3_empty_payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// copy could fail after response status was sent, then error is in the body
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err == nil && result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3 copy %s to %s failed: %s %s", from, to, result.Code, result.Message)
+	}
+	return s.Delete(ctx, from)
+}
+func (s *s3) List(ctx context.Context) ([]*client.ObjectInfo, error) {
+	objects := make([]*client.ObjectInfo, 0)
+	token := ""
+	for {
+		uri := s.bucketURL()
+		query := url.Values{"list-type": {"2"}}
+		if len(token) > 0 {
+			query.Set("continuation-token", token)
+		}
+		uri.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.send(req, s3_empty_payload)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range result.Contents {
+			objects = append(objects, &client.ObjectInfo{Key: v.Key, Size: v.Size, ModTime: v.LastModified})
+		}
+		if !result.IsTruncated || len(result.NextContinuationToken) == 0 {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+func (s *s3) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	uri := s.bucketURL()
+	uri.Path += "/" + key
+	return http.NewRequestWithContext(ctx, method, uri.String(), body)
+}
+func (s *s3) bucketURL() *url.URL {
+	uri := *s.endpoint
+	uri.Path = fmt.Sprintf("%s/%s", s.endpoint.Path, s.cfg.Bucket)
+	return &uri
+}
+func validKey(key string) error {
+	if len(key) == 0 || strings.Contains(key, "/") {
+		return errormiddleware.BadRequestError([]string{"wrong file key"}, fmt.Sprintf("key %s is not a plain file name", key))
+	}
+	return nil
+}
+
+// Signs and sends request. Response body must be closed by caller when error is nil
+func (s *s3) send(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now().UTC())
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errormiddleware.NotFoundError([]string{"file not exists"}, fmt.Sprintf("object %s not found", req.URL.Path))
+	}
+	return nil, fmt.Errorf("s3 %s %s returned %s: %s", req.Method, req.URL.Path, resp.Status, message)
+}
+
+// Adds signature version 4 authorization header.
+// Only host, range and x-amz-* headers are signed, so proxies can't break the signature
+func (s *s3) sign(req *http.Request, payloadHash string, now time.Time) {
+	date := now.Format(s3_time_format)
+	req.Header.Set("X-Amz-Date", date)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date[:8], s.cfg.Region)
+	stringToSign := strings.Join([]string{s3_algorithm, date, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSum([]byte("AWS4"+s.cfg.SecretKey), date[:8])
+	key = hmacSum(key, s.cfg.Region)
+	key = hmacSum(key, "s3")
+	key = hmacSum(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s", s3_algorithm, s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// Signature requires RFC 3986 encoding, url.QueryEscape encodes spaces as '+'
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Object body that is downloaded lazily with ranged requests.
+// Seek only moves the offset, so http.ServeContent fetches only requested part of the object
+type objectReader struct {
+	store  *s3
+	ctx    context.Context
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (o *objectReader) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		req, err := o.store.request(o.ctx, http.MethodGet, o.key, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+		resp, err := o.store.send(req, s3_empty_payload)
+		if err != nil {
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	if err == io.EOF && o.offset < o.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+func (o *objectReader) Seek(offset int64, whence int) (int64, error) {
+	var position int64
+	switch whence {
+	case io.SeekStart:
+		position = offset
+	case io.SeekCurrent:
+		position = o.offset + offset
+	case io.SeekEnd:
+		position = o.size + offset
+	default:
+		return 0, errors.New("seek: invalid whence")
+	}
+	if position < 0 {
+		return 0, errors.New("seek: negative position")
+	}
+	if position != o.offset && o.body != nil {
+		o.body.Close()
+		o.body = nil
+	}
+	o.offset = position
+	return position, nil
+}
+func (o *objectReader) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"time"
+)
+
+//go:generate mockgen -source=filestore.go -destination=mocks/filestore.go
+
+// Stored object. Body can seek, so the file can be served partially
+type File struct {
+	Body    io.ReadSeekCloser
+	Size    int64
+	ModTime time.Time
+}
+
+// Stored object description
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage of book files and covers.
+// Objects are addressed by content keys made by FileKey, so equal files are stored once
+type FileStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64) error
+	// Returns not found error if there's no object with provided key
+	Get(ctx context.Context, key string) (*File, error)
+	// Deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// Moves object to another key. Existing destination object is kept, since it has the same content
+	Move(ctx context.Context, from, to string) error
+	List(ctx context.Context) ([]*ObjectInfo, error)
+}
+
+// Uploaded file that is not stored yet. Extension must contain leading dot
+type UploadFile struct {
+	Body      io.ReadSeeker
+	Extension string
+}
+
+// Prefix of objects that are uploaded, but not committed yet
+const StagingPrefix = "staging_"
+
+// Returns content key and sha256 checksum of the file.
+// Key is a hex checksum followed by file extension. Body is rewound to the start after reading
+func FileKey(file *UploadFile) (key string, checksum string, size int64, err error) {
+	hash := sha256.New()
+	size, err = io.Copy(hash, file.Body)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if _, err = file.Body.Seek(0, io.SeekStart); err != nil {
+		return "", "", 0, err
+	}
+	checksum = hex.EncodeToString(hash.Sum(nil))
+	return checksum + strings.ToLower(file.Extension), checksum, size, nil
+}
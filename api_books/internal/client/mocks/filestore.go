@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: filestore.go
+
+// Package mock_client is a generated GoMock package.
+package mock_client
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	client "github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+)
+
+// MockFileStore is a mock of FileStore interface.
+type MockFileStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileStoreMockRecorder
+}
+
+// MockFileStoreMockRecorder is the mock recorder for MockFileStore.
+type MockFileStoreMockRecorder struct {
+	mock *MockFileStore
+}
+
+// NewMockFileStore creates a new mock instance.
+func NewMockFileStore(ctrl *gomock.Controller) *MockFileStore {
+	mock := &MockFileStore{ctrl: ctrl}
+	mock.recorder = &MockFileStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileStore) EXPECT() *MockFileStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockFileStore) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockFileStoreMockRecorder) Delete(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFileStore)(nil).Delete), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockFileStore) Get(ctx context.Context, key string) (*client.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(*client.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockFileStoreMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockFileStore)(nil).Get), ctx, key)
+}
+
+// List mocks base method.
+func (m *MockFileStore) List(ctx context.Context) ([]*client.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]*client.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockFileStoreMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockFileStore)(nil).List), ctx)
+}
+
+// Move mocks base method.
+func (m *MockFileStore) Move(ctx context.Context, from, to string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Move", ctx, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Move indicates an expected call of Move.
+func (mr *MockFileStoreMockRecorder) Move(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Move", reflect.TypeOf((*MockFileStore)(nil).Move), ctx, from, to)
+}
+
+// Put mocks base method.
+func (m *MockFileStore) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Put", ctx, key, body, size)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Put indicates an expected call of Put.
+func (mr *MockFileStoreMockRecorder) Put(ctx, key, body, size interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockFileStore)(nil).Put), ctx, key, body, size)
+}
@@ -51,6 +51,35 @@ func (mr *MockStorageMockRecorder) AddBook(ctx, book interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBook", reflect.TypeOf((*MockStorage)(nil).AddBook), ctx, book)
 }
 
+// CountByGenres mocks base method.
+func (m *MockStorage) CountByGenres(ctx context.Context, genres []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByGenres", ctx, genres)
+	ret0, _ := ret[0].(map[primitive.ObjectID]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByGenres indicates an expected call of CountByGenres.
+func (mr *MockStorageMockRecorder) CountByGenres(ctx, genres interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByGenres", reflect.TypeOf((*MockStorage)(nil).CountByGenres), ctx, genres)
+}
+
+// DeleteBook mocks base method.
+func (m *MockStorage) DeleteBook(ctx context.Context, id primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockStorageMockRecorder) DeleteBook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockStorage)(nil).DeleteBook), ctx, id)
+}
+
 // GetBookById mocks base method.
 func (m *MockStorage) GetBookById(ctx context.Context, id primitive.ObjectID) (*client.Book, error) {
 	m.ctrl.T.Helper()
@@ -81,17 +110,105 @@ func (mr *MockStorageMockRecorder) GetBookByName(ctx, name interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookByName", reflect.TypeOf((*MockStorage)(nil).GetBookByName), ctx, name)
 }
 
+// GetBookIdsByAuthor mocks base method.
+func (m *MockStorage) GetBookIdsByAuthor(ctx context.Context, author primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookIdsByAuthor", ctx, author)
+	ret0, _ := ret[0].([]primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookIdsByAuthor indicates an expected call of GetBookIdsByAuthor.
+func (mr *MockStorageMockRecorder) GetBookIdsByAuthor(ctx, author interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookIdsByAuthor", reflect.TypeOf((*MockStorage)(nil).GetBookIdsByAuthor), ctx, author)
+}
+
+// GetBookIdsByGenre mocks base method.
+func (m *MockStorage) GetBookIdsByGenre(ctx context.Context, genre primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookIdsByGenre", ctx, genre)
+	ret0, _ := ret[0].([]primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookIdsByGenre indicates an expected call of GetBookIdsByGenre.
+func (mr *MockStorageMockRecorder) GetBookIdsByGenre(ctx, genre interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookIdsByGenre", reflect.TypeOf((*MockStorage)(nil).GetBookIdsByGenre), ctx, genre)
+}
+
 // GetByFilter mocks base method.
-func (m *MockStorage) GetByFilter(ctx context.Context, filter map[string]string, offset, limit int) ([]*client.Book, error) {
+func (m *MockStorage) GetByFilter(ctx context.Context, filter map[string]string, cursor string, offset, limit int) (*client.BookPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByFilter", ctx, filter, offset, limit)
-	ret0, _ := ret[0].([]*client.Book)
+	ret := m.ctrl.Call(m, "GetByFilter", ctx, filter, cursor, offset, limit)
+	ret0, _ := ret[0].(*client.BookPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByFilter indicates an expected call of GetByFilter.
-func (mr *MockStorageMockRecorder) GetByFilter(ctx, filter, offset, limit interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetByFilter(ctx, filter, cursor, offset, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByFilter", reflect.TypeOf((*MockStorage)(nil).GetByFilter), ctx, filter, cursor, offset, limit)
+}
+
+// GetFileReferences mocks base method.
+func (m *MockStorage) GetFileReferences(ctx context.Context) ([]*client.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileReferences", ctx)
+	ret0, _ := ret[0].([]*client.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileReferences indicates an expected call of GetFileReferences.
+func (mr *MockStorageMockRecorder) GetFileReferences(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileReferences", reflect.TypeOf((*MockStorage)(nil).GetFileReferences), ctx)
+}
+
+// IsFileUsed mocks base method.
+func (m *MockStorage) IsFileUsed(ctx context.Context, key string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFileUsed", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsFileUsed indicates an expected call of IsFileUsed.
+func (mr *MockStorageMockRecorder) IsFileUsed(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFileUsed", reflect.TypeOf((*MockStorage)(nil).IsFileUsed), ctx, key)
+}
+
+// RemoveGenre mocks base method.
+func (m *MockStorage) RemoveGenre(ctx context.Context, genre primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGenre", ctx, genre)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGenre indicates an expected call of RemoveGenre.
+func (mr *MockStorageMockRecorder) RemoveGenre(ctx, genre interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGenre", reflect.TypeOf((*MockStorage)(nil).RemoveGenre), ctx, genre)
+}
+
+// UpdateBook mocks base method.
+func (m *MockStorage) UpdateBook(ctx context.Context, book *client.Book) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, book)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockStorageMockRecorder) UpdateBook(ctx, book interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByFilter", reflect.TypeOf((*MockStorage)(nil).GetByFilter), ctx, filter, offset, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockStorage)(nil).UpdateBook), ctx, book)
 }
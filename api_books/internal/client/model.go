@@ -13,24 +13,46 @@ type Genre struct {
 	Name string
 }
 type Book struct {
-	Id        primitive.ObjectID   `json:"id" bson:"_id,omitempty" validate:"primitiveid"`
-	Name      string               `json:"name" bson:"name" validate:"min=4,max=32"`
-	AuthorId  primitive.ObjectID   `json:"-" bson:"author"`
-	Author    *Author              `json:"author,omitempty" bson:"-"`
-	GenresId  []primitive.ObjectID `json:"-" bson:"genres"`
-	Genres    []*Genre              `json:"genres,omitempty" bson:"-"`
-	Pages     int                  `json:"pages" bson:"-"`
-	Year      int                  `json:"year" bson:"year"`
-	FilePath  string               `json:"file" bson:"filepath"`
-	CoverPath string               `json:"cover" bson:"coverpath"`
+	Id          primitive.ObjectID   `json:"id" bson:"_id,omitempty" validate:"primitiveid"`
+	Name        string               `json:"name" bson:"name" validate:"min=4,max=32"`
+	AuthorId    primitive.ObjectID   `json:"-" bson:"author"`
+	Author      *Author              `json:"author,omitempty" bson:"-"`
+	GenresId    []primitive.ObjectID `json:"-" bson:"genres"`
+	Genres      []*Genre             `json:"genres,omitempty" bson:"-"`
+	Pages       int                  `json:"pages" bson:"pages"`
+	Year        int                  `json:"year" bson:"year"`
+	FilePath    string               `json:"file" bson:"filepath"`
+	CoverPath   string               `json:"cover" bson:"coverpath"`
+	FileSha256  string               `json:"filesha256" bson:"filesha256"`
+	CoverSha256 string               `json:"coversha256" bson:"coversha256"`
+	Covers      *CoverUrls           `json:"covers,omitempty" bson:"-"`
+}
+
+// Cursors are empty when there is no next or previous page
+type BookPage struct {
+	Items      []*Book `json:"items"`
+	Total      int64   `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
 }
 
 type InsertBookQuery struct {
-	Name      string               `validate:"required,min=4,max=32"`
-	AuthorId  primitive.ObjectID   `validate:"required,primitiveid"`
-	GenresId  []primitive.ObjectID `validate:"required"`
-	Year      int                  `validate:"gte=1400,lte=2100"`
-	Pages     int                  `validate:"required,lte=5000"`
-	FilePath  string
-	CoverPath string
+	Name     string               `validate:"required,min=4,max=32"`
+	AuthorId primitive.ObjectID   `validate:"required,primitiveid"`
+	GenresId []primitive.ObjectID `validate:"required"`
+	Year     int                  `validate:"gte=1400,lte=2100"`
+	Pages    int                  `validate:"required,lte=5000"`
+	File     *UploadFile          `validate:"required"`
+	Cover    *UploadFile          `validate:"required"`
+}
+
+// Only non-nil fields are applied to the book
+type UpdateBookQuery struct {
+	Name     *string               `validate:"omitempty,min=4,max=32"`
+	AuthorId *primitive.ObjectID   `validate:"omitempty,primitiveid"`
+	GenresId *[]primitive.ObjectID `validate:"omitempty,min=1"`
+	Year     *int                  `validate:"omitempty,gte=1400,lte=2100"`
+	Pages    *int                  `validate:"omitempty,lte=5000"`
+	File     *UploadFile
+	Cover    *UploadFile
 }
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
+)
+
+// Mismatches between books collection and file store
+type ReconcileReport struct {
+	// Book id to keys of it's files that are missing in the store
+	MissingFiles map[string][]string
+	// Objects that are not used by any book
+	OrphanedFiles []string
+	// Staged objects that were never committed
+	StaleStaging []string
+	Removed      []string
+}
+
+// Periodically checks that every book has it's files and every stored file has a book.
+// Missing files are only reported, orphaned and stale staged objects are removed if cleanup is enabled
+type Reconciler struct {
+	storage  Storage
+	files    FileStore
+	logger   *logging.Logger
+	interval time.Duration
+	grace    time.Duration
+	cleanup  bool
+	now      func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewReconciler(storage Storage, files FileStore, logger *logging.Logger, cfg *config.FilesConfig) *Reconciler {
+	return &Reconciler{
+		storage:  storage,
+		files:    files,
+		logger:   logger,
+		interval: cfg.ReconcileInterval,
+		grace:    cfg.ReconcileGrace,
+		cleanup:  cfg.ReconcileCleanup,
+		now:      time.Now,
+	}
+}
+
+// Runs reconciliation in background until reconciler is closed. Zero interval disables it
+func (r *Reconciler) Start() {
+	if r.interval <= 0 {
+		r.logger.Warn("file reconciler is disabled")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			if _, err := r.Reconcile(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Errorf("file reconciliation failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+func (r *Reconciler) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	return nil
+}
+func (r *Reconciler) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	// objects are listed before books, so object that was committed in between has it's book in the list
+	objects, err := r.files.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	books, err := r.storage.GetFileReferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReconcileReport{MissingFiles: make(map[string][]string)}
+
+	stored := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		stored[object.Key] = true
+	}
+	used := make(map[string]bool, len(books)*(2+len(CoverSizes)))
+	for _, book := range books {
+		for _, key := range []string{book.FilePath, book.CoverPath} {
+			if len(key) == 0 {
+				continue
+			}
+			used[key] = true
+			if !stored[key] {
+				report.MissingFiles[book.Id.Hex()] = append(report.MissingFiles[book.Id.Hex()], key)
+			}
+		}
+		// covers uploaded before thumbnails were introduced have none, so missing thumbnails are not reported
+		for _, key := range thumbnailKeys(book.CoverPath) {
+			used[key] = true
+		}
+	}
+
+	deadline := r.now().Add(-r.grace)
+	for _, object := range objects {
+		if used[object.Key] || object.ModTime.After(deadline) {
+			continue
+		}
+		if strings.HasPrefix(object.Key, StagingPrefix) {
+			report.StaleStaging = append(report.StaleStaging, object.Key)
+		} else {
+			report.OrphanedFiles = append(report.OrphanedFiles, object.Key)
+		}
+		if r.cleanup && r.remove(ctx, object.Key) {
+			report.Removed = append(report.Removed, object.Key)
+		}
+	}
+
+	for id, keys := range report.MissingFiles {
+		r.logger.Warnf("book %s has missing files: %v", id, keys)
+	}
+	r.logger.Infof("file reconciliation done: %d books with missing files, %d orphaned files, %d stale staged files, %d removed",
+		len(report.MissingFiles), len(report.OrphanedFiles), len(report.StaleStaging), len(report.Removed))
+	return report, nil
+}
+
+// Object usage is checked again, since book could be saved after references were read.
+// Thumbnail is used while it's cover is used
+func (r *Reconciler) remove(ctx context.Context, key string) bool {
+	source := key
+	if cover, ok := thumbnailSource(key); ok && !strings.HasPrefix(key, StagingPrefix) {
+		source = cover
+	}
+	if used, err := r.storage.IsFileUsed(ctx, source); err != nil || used {
+		return false
+	}
+	if err := r.files.Delete(ctx, key); err != nil {
+		r.logger.Errorf("can't remove file %s: %v", key, err)
+		return false
+	}
+	return true
+}
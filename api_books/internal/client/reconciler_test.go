@@ -0,0 +1,79 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+	mock "github.com/reversersed/go-web-services/tree/main/api_books/internal/client/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	files := mock.NewMockFileStore(ctrl)
+	reconciler := client.NewReconciler(storage, files, logger, &config.FilesConfig{ReconcileGrace: time.Hour, ReconcileCleanup: true})
+
+	old := time.Now().Add(-2 * time.Hour)
+	book := &client.Book{Id: primitive.NewObjectID(), FilePath: "book.pdf", CoverPath: "missing.png"}
+	objects := []*client.ObjectInfo{
+		{Key: "book.pdf", ModTime: old},
+		{Key: "orphan.pdf", ModTime: old},
+		{Key: "used.pdf", ModTime: old},
+		// thumbnails of used cover are kept, even though cover itself is missing
+		{Key: "missing_small.png", ModTime: old},
+		{Key: "other_medium.png", ModTime: old},
+		{Key: "fresh.pdf", ModTime: time.Now()},
+		{Key: client.StagingPrefix + "1_stale.pdf", ModTime: old},
+		{Key: client.StagingPrefix + "2_fresh.pdf", ModTime: time.Now()},
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		files.EXPECT().List(gomock.Any()).Return(objects, nil)
+		storage.EXPECT().GetFileReferences(gomock.Any()).Return([]*client.Book{book}, nil)
+		storage.EXPECT().IsFileUsed(gomock.Any(), "orphan.pdf").Return(false, nil)
+		files.EXPECT().Delete(gomock.Any(), "orphan.pdf").Return(nil)
+		// book was saved after references were read
+		storage.EXPECT().IsFileUsed(gomock.Any(), "used.pdf").Return(true, nil)
+		storage.EXPECT().IsFileUsed(gomock.Any(), "other.png").Return(false, nil)
+		files.EXPECT().Delete(gomock.Any(), "other_medium.png").Return(nil)
+		storage.EXPECT().IsFileUsed(gomock.Any(), client.StagingPrefix+"1_stale.pdf").Return(false, nil)
+		files.EXPECT().Delete(gomock.Any(), client.StagingPrefix+"1_stale.pdf").Return(errors.New("store is down"))
+
+		report, err := reconciler.Reconcile(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]string{book.Id.Hex(): {"missing.png"}}, report.MissingFiles)
+		assert.Equal(t, []string{"orphan.pdf", "used.pdf", "other_medium.png"}, report.OrphanedFiles)
+		assert.Equal(t, []string{client.StagingPrefix + "1_stale.pdf"}, report.StaleStaging)
+		assert.Equal(t, []string{"orphan.pdf", "other_medium.png"}, report.Removed)
+	})
+	t.Run("list failed", func(t *testing.T) {
+		files.EXPECT().List(gomock.Any()).Return(nil, errors.New("store is down"))
+
+		_, err := reconciler.Reconcile(context.Background())
+		assert.Error(t, err)
+	})
+	t.Run("report only", func(t *testing.T) {
+		reconciler := client.NewReconciler(storage, files, logger, &config.FilesConfig{ReconcileGrace: time.Hour})
+		files.EXPECT().List(gomock.Any()).Return(objects, nil)
+		storage.EXPECT().GetFileReferences(gomock.Any()).Return([]*client.Book{book}, nil)
+
+		report, err := reconciler.Reconcile(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, report.OrphanedFiles, 3)
+		assert.Empty(t, report.Removed)
+	})
+}
@@ -1,9 +1,12 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,7 +16,9 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/cache"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/pdf"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/signature"
 	valid "github.com/reversersed/go-web-services/tree/main/api_books/pkg/validator"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -25,16 +30,17 @@ type service struct {
 	validator *valid.Validator
 	genreApi  *BaseClient
 	authorApi *BaseClient
+	files     FileStore
 }
 
-func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator, cfg *config.UrlConfig) *service {
-	return &service{storage: storage, logger: logger, cache: cache, validator: validator,
+func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator, files FileStore, cfg *config.UrlConfig, signer *signature.Signer) *service {
+	return &service{storage: storage, logger: logger, cache: cache, validator: validator, files: files,
 		genreApi: &BaseClient{
-			Base: &rest.RestClient{BaseURL: cfg.GenreApiAdress, HttpClient: &http.Client{Timeout: 5 * time.Second}, Logger: logger},
+			Base: &rest.RestClient{BaseURL: cfg.GenreApiAdress, HttpClient: &http.Client{Timeout: 5 * time.Second}, Logger: logger, Signer: signer},
 			Path: "/genres",
 		},
 		authorApi: &BaseClient{
-			Base: &rest.RestClient{BaseURL: cfg.AuthorApiAdress, HttpClient: &http.Client{Timeout: 5 * time.Second}, Logger: logger},
+			Base: &rest.RestClient{BaseURL: cfg.AuthorApiAdress, HttpClient: &http.Client{Timeout: 5 * time.Second}, Logger: logger, Signer: signer},
 			Path: "/authors",
 		}}
 }
@@ -45,6 +51,7 @@ func (s *service) GetBook(ctx context.Context, id string) (*Book, error) {
 	if byteBook, err := s.cache.Get([]byte(fmt.Sprintf("book_%s", id))); err == nil {
 		var unBook Book
 		json.Unmarshal(byteBook, &unBook)
+		unBook.Covers = coverUrls(&unBook)
 		return &unBook, nil
 	}
 
@@ -65,9 +72,10 @@ func (s *service) GetBook(ctx context.Context, id string) (*Book, error) {
 	go s.findBookAuthor(cntx, book, &wg)
 
 	wg.Wait()
+	book.Covers = coverUrls(book)
 	if _, err := s.cache.Get([]byte(fmt.Sprintf("book_%s", book.Id.Hex()))); err != nil {
 		bytes, _ := json.Marshal(book)
-		s.cache.Set([]byte(fmt.Sprintf("book_%s", book.Id.Hex())), bytes, int(24*time.Hour))
+		s.cache.Set([]byte(fmt.Sprintf("book_%s", book.Id.Hex())), bytes, int((24*time.Hour)/time.Second))
 	}
 	return book, nil
 }
@@ -79,32 +87,74 @@ func (s *service) IsBookExists(ctx context.Context, name string) bool {
 	s.logger.Infof("find book: %v with err %v", book, err)
 	return (err == nil) && (book != nil)
 }
-func (s *service) AddBook(ctx context.Context, query *InsertBookQuery) (*Book, error) {
-	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
 
+// Uploads are staged first and committed to their content keys only after the record is saved.
+// Any failure is compensated, so there are no records without files and no files without records
+func (s *service) AddBook(ctx context.Context, query *InsertBookQuery) (*Book, error) {
+	if query.File != nil {
+		meta, err := readDocument(query.File, query.Pages)
+		if err != nil {
+			return nil, err
+		}
+		query.Pages = meta.Pages
+		// name is optional when document has a title
+		if len(query.Name) == 0 && len(meta.Title) > 0 {
+			query.Name = meta.Title
+			if s.IsBookExists(ctx, query.Name) {
+				return nil, errormiddleware.NotUniqueError([]string{fmt.Sprintf("name %s already taken", query.Name)}, "book with document's title already in database")
+			}
+		}
+	}
 	if err := s.validator.Struct(query); err != nil {
 		return nil, errormiddleware.ValidationError(err, "wrong query")
 	}
+	file, err := s.stageFile(ctx, query.File)
+	if err != nil {
+		return nil, err
+	}
+	covers, err := s.stageCover(ctx, query.Cover)
+	if err != nil {
+		s.discardFiles(ctx, file)
+		return nil, err
+	}
+	cover := covers[0]
+	staged := append([]*stagedFile{file}, covers...)
 	book := &Book{
-		Name:      query.Name,
-		AuthorId:  query.AuthorId,
-		GenresId:  query.GenresId,
-		Pages:     query.Pages,
-		Year:      query.Year,
-		FilePath:  query.FilePath,
-		CoverPath: query.CoverPath,
+		Name:        query.Name,
+		AuthorId:    query.AuthorId,
+		GenresId:    query.GenresId,
+		Pages:       query.Pages,
+		Year:        query.Year,
+		FilePath:    file.key,
+		FileSha256:  file.checksum,
+		CoverPath:   cover.key,
+		CoverSha256: cover.checksum,
 	}
+
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
 	id, err := s.storage.AddBook(cntx, book)
 	if err != nil {
+		s.discardFiles(ctx, staged...)
 		return nil, err
 	}
-
 	book.Id, err = primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.commitFiles(ctx, staged...); err != nil {
+		s.logger.Errorf("can't commit files of book %s, creation is rolled back: %v", id, err)
+		if err := s.storage.DeleteBook(cntx, book.Id); err != nil {
+			s.logger.Errorf("can't remove record of book %s: %v", id, err)
+		}
+		s.discardFiles(ctx, staged...)
+		s.removeUnusedFile(ctx, book.FilePath)
+		s.removeUnusedFile(ctx, book.CoverPath)
+		return nil, err
+	}
+
 	wg := sync.WaitGroup{}
 	wg.Add(2)
 
@@ -112,34 +162,409 @@ func (s *service) AddBook(ctx context.Context, query *InsertBookQuery) (*Book, e
 	go s.findBookAuthor(cntx, book, &wg)
 
 	wg.Wait()
+	book.Covers = coverUrls(book)
 
 	data, _ := json.Marshal(book)
 	s.cache.Set([]byte(fmt.Sprintf("book_%s", book.Id.Hex())), data, int((time.Hour*6)/time.Second))
 	s.logger.Infof("created new book: %v", book)
 	return book, nil
 }
-func (s *service) FindBooks(ctx context.Context, filters map[string]string, offset, limit int) ([]*Book, error) {
+func (s *service) FindBooks(ctx context.Context, filters map[string]string, cursor string, offset, limit int) (*BookPage, error) {
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	books, err := s.storage.GetByFilter(cntx, filters, offset, limit)
+	query := make(map[string]string, len(filters)+1)
+	for k, v := range filters {
+		query[k] = v
+	}
+	delete(query, "searchauthors")
+	if search, ok := query["search"]; ok {
+		if len(strings.TrimSpace(search)) == 0 {
+			return nil, errormiddleware.BadRequestError([]string{"search: query can't be empty"}, "received empty search query")
+		}
+		query["search"] = strings.TrimSpace(search)
+		query["searchauthors"] = s.findAuthorsByName(cntx, query["search"])
+	}
+
+	page, err := s.storage.GetByFilter(cntx, query, cursor, offset, limit)
 	if err != nil {
 		return nil, err
 	}
 	wg := sync.WaitGroup{}
-	wg.Add(len(books) * 2)
-	for _, v := range books {
+	wg.Add(len(page.Items) * 2)
+	for _, v := range page.Items {
 		go s.findBookGenres(cntx, v, &wg)
 		go s.findBookAuthor(cntx, v, &wg)
 	}
 	wg.Wait()
-	for _, v := range books {
+	for _, v := range page.Items {
+		v.Covers = coverUrls(v)
 		if _, err := s.cache.Get([]byte(fmt.Sprintf("book_%s", v.Id.Hex()))); err != nil {
 			bytes, _ := json.Marshal(v)
-			s.cache.Set([]byte(fmt.Sprintf("book_%s", v.Id.Hex())), bytes, int(24*time.Hour))
+			s.cache.Set([]byte(fmt.Sprintf("book_%s", v.Id.Hex())), bytes, int((24*time.Hour)/time.Second))
+		}
+	}
+	return page, nil
+}
+func (s *service) UpdateBook(ctx context.Context, id string, query *UpdateBookQuery) (*Book, error) {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errormiddleware.BadRequestError([]string{"bad request"}, err.Error())
+	}
+	if query.File != nil {
+		pages := 0
+		if query.Pages != nil {
+			pages = *query.Pages
+		}
+		meta, err := readDocument(query.File, pages)
+		if err != nil {
+			return nil, err
+		}
+		query.Pages = &meta.Pages
+	}
+	if err := s.validator.Struct(query); err != nil {
+		return nil, errormiddleware.ValidationError(err, "wrong query")
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	book, err := s.storage.GetBookById(cntx, pId)
+	if err != nil {
+		return nil, err
+	}
+	old := *book
+	if query.Name != nil && *query.Name != book.Name {
+		if existing, err := s.storage.GetBookByName(cntx, *query.Name); err == nil && existing != nil {
+			return nil, errormiddleware.NotUniqueError([]string{fmt.Sprintf("name %s already taken", *query.Name)}, "book with provided name already in database")
 		}
+		book.Name = *query.Name
+	}
+	if query.AuthorId != nil {
+		book.AuthorId = *query.AuthorId
+	}
+	if query.GenresId != nil {
+		book.GenresId = *query.GenresId
+	}
+	if query.Year != nil {
+		book.Year = *query.Year
+	}
+	if query.Pages != nil {
+		book.Pages = *query.Pages
+	}
+	var file *stagedFile
+	if query.File != nil {
+		if file, err = s.stageFile(ctx, query.File); err != nil {
+			return nil, err
+		}
+		book.FilePath, book.FileSha256 = file.key, file.checksum
+	}
+	staged := []*stagedFile{file}
+	if query.Cover != nil {
+		covers, err := s.stageCover(ctx, query.Cover)
+		if err != nil {
+			s.discardFiles(ctx, file)
+			return nil, err
+		}
+		book.CoverPath, book.CoverSha256 = covers[0].key, covers[0].checksum
+		staged = append(staged, covers...)
+	}
+
+	// uploads could take a while, so update gets it's own timeout
+	updateCtx, updateCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer updateCancel()
+
+	if err := s.storage.UpdateBook(updateCtx, book); err != nil {
+		s.discardFiles(ctx, staged...)
+		return nil, err
+	}
+	s.cache.Delete([]byte(fmt.Sprintf("book_%s", book.Id.Hex())))
+
+	if err := s.commitFiles(ctx, staged...); err != nil {
+		s.logger.Errorf("can't commit files of book %s, update is rolled back: %v", id, err)
+		if err := s.storage.UpdateBook(updateCtx, &old); err != nil {
+			s.logger.Errorf("can't restore record of book %s: %v", id, err)
+		}
+		s.discardFiles(ctx, staged...)
+		s.removeReplacedFiles(ctx, book, &old)
+		return nil, err
+	}
+	s.removeReplacedFiles(ctx, &old, book)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go s.findBookGenres(updateCtx, book, &wg)
+	go s.findBookAuthor(updateCtx, book, &wg)
+
+	wg.Wait()
+	book.Covers = coverUrls(book)
+
+	s.logger.Infof("updated book: %v", book)
+	return book, nil
+}
+
+// Book files are removed from the store if they are not used by other books
+func (s *service) DeleteBook(ctx context.Context, id string) (*Book, error) {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errormiddleware.BadRequestError([]string{"bad request"}, err.Error())
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	book, err := s.storage.GetBookById(cntx, pId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.DeleteBook(cntx, pId); err != nil {
+		return nil, err
+	}
+	s.cache.Delete([]byte(fmt.Sprintf("book_%s", book.Id.Hex())))
+	s.removeUnusedFile(ctx, book.FilePath)
+	s.removeUnusedFile(ctx, book.CoverPath)
+	s.logger.Warnf("book %s has been deleted", id)
+	return book, nil
+}
+
+// Cached books contain genre objects, so they are evicted together with the genre
+func (s *service) OnGenreChanged(ctx context.Context, id string) {
+	if _, err := s.evictGenre(ctx, id); err != nil {
+		s.logger.Errorf("can't evict changed genre %s: %v", id, err)
+	}
+}
+func (s *service) OnGenreDeleted(ctx context.Context, id string) {
+	pId, err := s.evictGenre(ctx, id)
+	if err != nil {
+		s.logger.Errorf("can't evict deleted genre %s: %v", id, err)
+		return
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := s.storage.RemoveGenre(cntx, pId); err != nil {
+		s.logger.Errorf("can't remove deleted genre %s from books: %v", id, err)
+		return
+	}
+	s.logger.Infof("genre %s has been removed from books", id)
+}
+
+// Cached books contain author object, so they are evicted together with the author
+func (s *service) OnAuthorChanged(ctx context.Context, id string) {
+	if err := s.evictAuthor(ctx, id); err != nil {
+		s.logger.Errorf("can't evict changed author %s: %v", id, err)
 	}
-	return books, nil
+}
+func (s *service) OnAuthorDeleted(ctx context.Context, id string) {
+	if err := s.evictAuthor(ctx, id); err != nil {
+		s.logger.Errorf("can't evict deleted author %s: %v", id, err)
+	}
+}
+func (s *service) CountGenreBooks(ctx context.Context, ids string) (map[string]int64, error) {
+	genres := make([]primitive.ObjectID, 0)
+	for _, id := range strings.Split(ids, ",") {
+		pId, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errormiddleware.BadRequestError([]string{"id: invalid genre id"}, fmt.Sprintf("received genre id %s: %v", id, err))
+		}
+		genres = append(genres, pId)
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	counts, err := s.storage.CountByGenres(cntx, genres)
+	if err != nil {
+		return nil, err
+	}
+	response := make(map[string]int64, len(genres))
+	for _, genre := range genres {
+		response[genre.Hex()] = counts[genre]
+	}
+	return response, nil
+}
+func (s *service) evictGenre(ctx context.Context, id string) (primitive.ObjectID, error) {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	s.cache.Delete([]byte(fmt.Sprintf("genre_%s", id)))
+
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	books, err := s.storage.GetBookIdsByGenre(cntx, pId)
+	if err != nil {
+		return pId, err
+	}
+	for _, book := range books {
+		s.cache.Delete([]byte(fmt.Sprintf("book_%s", book.Hex())))
+	}
+	return pId, nil
+}
+func (s *service) evictAuthor(ctx context.Context, id string) error {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	s.cache.Delete([]byte(fmt.Sprintf("author_%s", id)))
+
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	books, err := s.storage.GetBookIdsByAuthor(cntx, pId)
+	if err != nil {
+		return err
+	}
+	for _, book := range books {
+		s.cache.Delete([]byte(fmt.Sprintf("book_%s", book.Hex())))
+	}
+	return nil
+}
+func (s *service) GetFile(ctx context.Context, key string) (*File, error) {
+	if len(key) == 0 {
+		return nil, errormiddleware.NotFoundError([]string{"file not exists"}, "file key is empty")
+	}
+	return s.files.Get(ctx, key)
+}
+
+// Reads page count and title of uploaded document and rewinds it. Zero pages means that count was not provided,
+// otherwise it must match the document
+func readDocument(file *UploadFile, pages int) (*pdf.Metadata, error) {
+	meta, err := pdf.Parse(file.Body)
+	if errors.Is(err, pdf.ErrNotPDF) || errors.Is(err, pdf.ErrNoPages) {
+		return nil, errormiddleware.BadRequestError([]string{"file: uploaded file is not a valid pdf document"}, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if pages != 0 && pages != meta.Pages {
+		return nil, errormiddleware.BadRequestError([]string{fmt.Sprintf("pages: document has %d pages", meta.Pages)}, fmt.Sprintf("provided %d pages don't match the document", pages))
+	}
+	return meta, nil
+}
+
+// Uploaded file that is kept under staging key until it's book record is saved
+type stagedFile struct {
+	staging  string
+	key      string
+	checksum string
+}
+
+// Staging key is unique, so concurrent uploads of the same file don't interfere
+func (s *service) stageFile(ctx context.Context, file *UploadFile) (*stagedFile, error) {
+	key, checksum, size, err := FileKey(file)
+	if err != nil {
+		return nil, err
+	}
+	return s.stage(ctx, key, checksum, file.Body, size)
+}
+
+// Cover is staged together with it's thumbnails. Cover is always the first staged file
+func (s *service) stageCover(ctx context.Context, file *UploadFile) ([]*stagedFile, error) {
+	thumbnails, err := makeThumbnails(file)
+	if err != nil {
+		return nil, err
+	}
+	cover, err := s.stageFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	staged := []*stagedFile{cover}
+	for _, size := range CoverSizes {
+		thumbnail, err := s.stage(ctx, ThumbnailKey(cover.key, size), "", bytes.NewReader(thumbnails[size]), int64(len(thumbnails[size])))
+		if err != nil {
+			s.discardFiles(ctx, staged...)
+			return nil, err
+		}
+		staged = append(staged, thumbnail)
+	}
+	return staged, nil
+}
+func (s *service) stage(ctx context.Context, key, checksum string, body io.Reader, size int64) (*stagedFile, error) {
+	staged := &stagedFile{staging: fmt.Sprintf("%s%s_%s", StagingPrefix, primitive.NewObjectID().Hex(), key), key: key, checksum: checksum}
+	if err := s.files.Put(ctx, staged.staging, body, size); err != nil {
+		s.logger.Errorf("can't stage file %s: %v", key, err)
+		return nil, err
+	}
+	return staged, nil
+}
+
+// Moves staged files to their content keys. Nil files are skipped
+func (s *service) commitFiles(ctx context.Context, files ...*stagedFile) error {
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		if err := s.files.Move(ctx, file.staging, file.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Removes staged files. Files that could not be removed are left to the reconciler
+func (s *service) discardFiles(ctx context.Context, files ...*stagedFile) {
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		if err := s.files.Delete(ctx, file.staging); err != nil {
+			s.logger.Errorf("can't remove staged file %s: %v", file.staging, err)
+		}
+	}
+}
+
+// Removes files of the previous book state that were replaced in the current one
+func (s *service) removeReplacedFiles(ctx context.Context, previous, current *Book) {
+	if previous.FilePath != current.FilePath {
+		s.removeUnusedFile(ctx, previous.FilePath)
+	}
+	if previous.CoverPath != current.CoverPath {
+		s.removeUnusedFile(ctx, previous.CoverPath)
+	}
+}
+
+// Store errors are only logged, orphaned object doesn't break anything. Cover is removed with it's thumbnails
+func (s *service) removeUnusedFile(ctx context.Context, key string) {
+	if len(key) == 0 {
+		return
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	used, err := s.storage.IsFileUsed(cntx, key)
+	if err != nil {
+		s.logger.Errorf("can't check usage of file %s: %v", key, err)
+		return
+	}
+	if used {
+		return
+	}
+	for _, file := range append([]string{key}, thumbnailKeys(key)...) {
+		if err := s.files.Delete(cntx, file); err != nil {
+			s.logger.Errorf("can't remove file %s: %v", file, err)
+		}
+	}
+}
+
+// Returns comma separated ids of authors whose name matches search query.
+// Search is still done by book names if author service is not responding
+func (s *service) findAuthorsByName(ctx context.Context, name string) string {
+	authorBytes, err := s.authorApi.SendGetGeneric(ctx, "/search", map[string][]string{"name": {name}, "offset": {"0"}, "limit": {"50"}})
+	if err != nil {
+		s.logger.Warnf("can't find authors by name %s: %v", name, err)
+		return ""
+	}
+	var authors []*Author
+	json.Unmarshal(authorBytes, &authors)
+
+	ids := make([]string, 0, len(authors))
+	for _, v := range authors {
+		ids = append(ids, v.Id.Hex())
+	}
+	return strings.Join(ids, ",")
 }
 func (s *service) findBookGenres(ctx context.Context, book *Book, wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -169,7 +594,7 @@ func (s *service) findBookGenres(ctx context.Context, book *Book, wg *sync.WaitG
 		for _, v := range genres {
 			if _, ok := s.cache.Get([]byte(fmt.Sprintf("genre_%s", v.Id.Hex()))); ok != nil {
 				bytes, _ := json.Marshal(v)
-				s.cache.Set([]byte(fmt.Sprintf("genre_%s", v.Id.Hex())), bytes, int(12*time.Hour))
+				s.cache.Set([]byte(fmt.Sprintf("genre_%s", v.Id.Hex())), bytes, int((12*time.Hour)/time.Second))
 			}
 		}
 	}
@@ -192,10 +617,17 @@ func (s *service) findBookAuthor(ctx context.Context, book *Book, wg *sync.WaitG
 		book.Author = nil
 		return
 	}
-	json.Unmarshal(authorBytes, &author)
-	if _, ok := s.cache.Get([]byte(fmt.Sprintf("author_%s", author.Id.Hex()))); ok != nil {
-		s.cache.Set([]byte(fmt.Sprintf("author_%s", author.Id.Hex())), authorBytes, int(12*time.Hour))
+	var authors []*Author
+	json.Unmarshal(authorBytes, &authors)
+	if len(authors) == 0 {
+		s.logger.Errorf("author service returned no author for book %v", book)
+		book.Author = nil
+		return
+	}
+	if _, ok := s.cache.Get([]byte(fmt.Sprintf("author_%s", authors[0].Id.Hex()))); ok != nil {
+		bytes, _ := json.Marshal(authors[0])
+		s.cache.Set([]byte(fmt.Sprintf("author_%s", authors[0].Id.Hex())), bytes, int((12*time.Hour)/time.Second))
 	}
 
-	book.Author = &author
+	book.Author = authors[0]
 }
@@ -1 +1,483 @@
-package client
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
+	mock "github.com/reversersed/go-web-services/tree/main/api_books/internal/client/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/cache/freecache"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Minimal document with 100 pages and a title
+const bookDocument = `%PDF-1.4
+1 0 obj
+<< /Type /Catalog /Pages 2 0 R >>
+endobj
+2 0 obj
+<< /Type /Pages /Kids [] /Count 100 >>
+endobj
+3 0 obj
+<< /Title (Document title) >>
+endobj
+trailer
+<< /Root 1 0 R /Info 3 0 R >>
+%%EOF
+`
+
+// Cover image that is larger than every thumbnail
+func coverImage() string {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 800, 400)))
+	return buf.String()
+}
+
+// Matches staging key of the file with provided content key, or any staging key if it's empty
+type stagedKey string
+
+func (k stagedKey) Matches(x interface{}) bool {
+	key, ok := x.(string)
+	return ok && strings.HasPrefix(key, client.StagingPrefix) && (len(k) == 0 || strings.HasSuffix(key, "_"+string(k)))
+}
+func (k stagedKey) String() string {
+	return fmt.Sprintf("is staging key of %s", string(k))
+}
+
+func TestUpdateBook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	files := mock.NewMockFileStore(ctrl)
+	cache := freecache.NewCache(1024 * 1024)
+	service := client.NewService(storage, logger, cache, validator.New(), files, &config.UrlConfig{}, nil)
+
+	author := &client.Author{Id: primitive.NewObjectID(), Name: "Author"}
+	genre := &client.Genre{Id: primitive.NewObjectID(), Name: "Genre"}
+	data, _ := json.Marshal(author)
+	cache.Set([]byte(fmt.Sprintf("author_%s", author.Id.Hex())), data, 60)
+	data, _ = json.Marshal(genre)
+	cache.Set([]byte(fmt.Sprintf("genre_%s", genre.Id.Hex())), data, 60)
+
+	id := primitive.NewObjectID()
+	name := "New book name"
+	year := 2001
+
+	t.Run("success", func(t *testing.T) {
+		cache.Set([]byte(fmt.Sprintf("book_%s", id.Hex())), []byte("{}"), 60)
+		storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name", AuthorId: author.Id, GenresId: []primitive.ObjectID{genre.Id}}, nil)
+		storage.EXPECT().GetBookByName(gomock.Any(), name).Return(nil, errormiddleware.NotFoundError([]string{"book not exists"}, ""))
+		storage.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(nil)
+
+		book, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Name: &name, Year: &year})
+		assert.NoError(t, err)
+		assert.Equal(t, name, book.Name)
+		assert.Equal(t, year, book.Year)
+		assert.Equal(t, author, book.Author)
+		assert.Equal(t, []*client.Genre{genre}, book.Genres)
+
+		_, err = cache.Get([]byte(fmt.Sprintf("book_%s", id.Hex())))
+		assert.Error(t, err)
+	})
+	t.Run("name taken", func(t *testing.T) {
+		storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name"}, nil)
+		storage.EXPECT().GetBookByName(gomock.Any(), name).Return(&client.Book{Id: primitive.NewObjectID(), Name: name}, nil)
+
+		_, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Name: &name})
+		assert.Equal(t, errormiddleware.NotUniqueErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("file replaced", func(t *testing.T) {
+		content := bookDocument + "% new content\n"
+		sum := sha256.Sum256([]byte(content))
+		checksum := hex.EncodeToString(sum[:])
+		gomock.InOrder(
+			storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name", FilePath: "old.pdf", FileSha256: "old"}, nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(checksum+".pdf"), gomock.Any(), int64(len(content))).Return(nil),
+			storage.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(nil),
+			files.EXPECT().Move(gomock.Any(), stagedKey(checksum+".pdf"), checksum+".pdf").Return(nil),
+			storage.EXPECT().IsFileUsed(gomock.Any(), "old.pdf").Return(false, nil),
+			files.EXPECT().Delete(gomock.Any(), "old.pdf").Return(nil),
+		)
+
+		book, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{File: &client.UploadFile{Body: strings.NewReader(content), Extension: ".PDF"}})
+		assert.NoError(t, err)
+		assert.Equal(t, checksum+".pdf", book.FilePath)
+		assert.Equal(t, checksum, book.FileSha256)
+		assert.Equal(t, 100, book.Pages)
+	})
+	t.Run("pages don't match the file", func(t *testing.T) {
+		pages := 99
+		_, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Pages: &pages, File: &client.UploadFile{Body: strings.NewReader(bookDocument), Extension: ".pdf"}})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("cover replaced", func(t *testing.T) {
+		cover := coverImage()
+		sum := sha256.Sum256([]byte(cover))
+		key := hex.EncodeToString(sum[:]) + ".png"
+		gomock.InOrder(
+			storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name", CoverPath: "old.png"}, nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(key), gomock.Any(), int64(len(cover))).Return(nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(client.ThumbnailKey(key, client.CoverSmall)), gomock.Any(), gomock.Any()).Return(nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(client.ThumbnailKey(key, client.CoverMedium)), gomock.Any(), gomock.Any()).Return(nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(client.ThumbnailKey(key, client.CoverLarge)), gomock.Any(), gomock.Any()).Return(nil),
+			storage.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(nil),
+			files.EXPECT().Move(gomock.Any(), stagedKey(key), key).Return(nil),
+			files.EXPECT().Move(gomock.Any(), stagedKey(""), gomock.Any()).Return(nil).Times(3),
+			storage.EXPECT().IsFileUsed(gomock.Any(), "old.png").Return(false, nil),
+			files.EXPECT().Delete(gomock.Any(), "old.png").Return(nil),
+			files.EXPECT().Delete(gomock.Any(), "old_small.png").Return(nil),
+			files.EXPECT().Delete(gomock.Any(), "old_medium.png").Return(nil),
+			files.EXPECT().Delete(gomock.Any(), "old_large.png").Return(nil),
+		)
+
+		book, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Cover: &client.UploadFile{Body: strings.NewReader(cover), Extension: ".png"}})
+		assert.NoError(t, err)
+		assert.Equal(t, key, book.CoverPath)
+		assert.Equal(t, &client.CoverUrls{
+			Original: fmt.Sprintf("/books/%s/cover", id.Hex()),
+			Small:    fmt.Sprintf("/books/%s/cover?size=small", id.Hex()),
+			Medium:   fmt.Sprintf("/books/%s/cover?size=medium", id.Hex()),
+			Large:    fmt.Sprintf("/books/%s/cover?size=large", id.Hex()),
+		}, book.Covers)
+	})
+	t.Run("cover can't be decoded", func(t *testing.T) {
+		storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name"}, nil)
+
+		_, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Cover: &client.UploadFile{Body: strings.NewReader("\x89PNG\r\n\x1a\nbroken"), Extension: ".png"}})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("update failed", func(t *testing.T) {
+		gomock.InOrder(
+			storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name", CoverPath: "old.png"}, nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(nil).Times(4),
+			storage.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(errors.New("database is down")),
+			files.EXPECT().Delete(gomock.Any(), stagedKey("")).Return(nil).Times(4),
+		)
+
+		_, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Cover: &client.UploadFile{Body: strings.NewReader(coverImage()), Extension: ".png"}})
+		assert.Error(t, err)
+	})
+	t.Run("commit failed", func(t *testing.T) {
+		old := &client.Book{Id: id, Name: "Book name", CoverPath: "old.png"}
+		gomock.InOrder(
+			storage.EXPECT().GetBookById(gomock.Any(), id).Return(old, nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(nil).Times(4),
+			storage.EXPECT().UpdateBook(gomock.Any(), gomock.Any()).Return(nil),
+			files.EXPECT().Move(gomock.Any(), stagedKey(""), gomock.Any()).Return(errors.New("store is down")),
+			storage.EXPECT().UpdateBook(gomock.Any(), &client.Book{Id: id, Name: "Book name", CoverPath: "old.png"}).Return(nil),
+			files.EXPECT().Delete(gomock.Any(), stagedKey("")).Return(nil).Times(4),
+			storage.EXPECT().IsFileUsed(gomock.Any(), gomock.Not("old.png")).Return(false, nil),
+			// new cover is removed with it's thumbnails
+			files.EXPECT().Delete(gomock.Any(), gomock.Not("old.png")).Return(nil).Times(4),
+		)
+
+		_, err := service.UpdateBook(context.Background(), id.Hex(), &client.UpdateBookQuery{Cover: &client.UploadFile{Body: strings.NewReader(coverImage()), Extension: ".png"}})
+		assert.Error(t, err)
+	})
+	t.Run("wrong id", func(t *testing.T) {
+		_, err := service.UpdateBook(context.Background(), "wrongid", &client.UpdateBookQuery{})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+}
+func TestDeleteBook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	files := mock.NewMockFileStore(ctrl)
+	cache := freecache.NewCache(1024 * 1024)
+	service := client.NewService(storage, logger, cache, validator.New(), files, &config.UrlConfig{}, nil)
+
+	id := primitive.NewObjectID()
+	cache.Set([]byte(fmt.Sprintf("book_%s", id.Hex())), []byte("{}"), 60)
+
+	gomock.InOrder(
+		storage.EXPECT().GetBookById(gomock.Any(), id).Return(&client.Book{Id: id, Name: "Book name", FilePath: "book.pdf", CoverPath: "cover.png"}, nil),
+		storage.EXPECT().DeleteBook(gomock.Any(), id).Return(nil),
+		storage.EXPECT().IsFileUsed(gomock.Any(), "book.pdf").Return(false, nil),
+		files.EXPECT().Delete(gomock.Any(), "book.pdf").Return(nil),
+		// cover is shared with another book
+		storage.EXPECT().IsFileUsed(gomock.Any(), "cover.png").Return(true, nil),
+	)
+
+	book, err := service.DeleteBook(context.Background(), id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, "Book name", book.Name)
+	_, err = cache.Get([]byte(fmt.Sprintf("book_%s", id.Hex())))
+	assert.Error(t, err)
+}
+func TestGenreEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	cache := freecache.NewCache(1024 * 1024)
+	service := client.NewService(storage, logger, cache, validator.New(), mock.NewMockFileStore(ctrl), &config.UrlConfig{}, nil)
+
+	genre, book, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	fill := func() {
+		cache.Set([]byte(fmt.Sprintf("genre_%s", genre.Hex())), []byte("{}"), 60)
+		cache.Set([]byte(fmt.Sprintf("book_%s", book.Hex())), []byte("{}"), 60)
+		cache.Set([]byte(fmt.Sprintf("book_%s", other.Hex())), []byte("{}"), 60)
+	}
+	evicted := func(key string) bool {
+		_, err := cache.Get([]byte(key))
+		return err != nil
+	}
+
+	t.Run("changed genre", func(t *testing.T) {
+		fill()
+		storage.EXPECT().GetBookIdsByGenre(gomock.Any(), genre).Return([]primitive.ObjectID{book}, nil)
+
+		service.OnGenreChanged(context.Background(), genre.Hex())
+		assert.True(t, evicted(fmt.Sprintf("genre_%s", genre.Hex())))
+		assert.True(t, evicted(fmt.Sprintf("book_%s", book.Hex())))
+		assert.False(t, evicted(fmt.Sprintf("book_%s", other.Hex())))
+	})
+	t.Run("deleted genre", func(t *testing.T) {
+		fill()
+		gomock.InOrder(
+			storage.EXPECT().GetBookIdsByGenre(gomock.Any(), genre).Return([]primitive.ObjectID{book}, nil),
+			storage.EXPECT().RemoveGenre(gomock.Any(), genre).Return(nil),
+		)
+
+		service.OnGenreDeleted(context.Background(), genre.Hex())
+		assert.True(t, evicted(fmt.Sprintf("genre_%s", genre.Hex())))
+		assert.True(t, evicted(fmt.Sprintf("book_%s", book.Hex())))
+		assert.False(t, evicted(fmt.Sprintf("book_%s", other.Hex())))
+	})
+	t.Run("wrong id", func(t *testing.T) {
+		service.OnGenreDeleted(context.Background(), "wrongid")
+	})
+}
+func TestAuthorEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	cache := freecache.NewCache(1024 * 1024)
+	service := client.NewService(storage, logger, cache, validator.New(), mock.NewMockFileStore(ctrl), &config.UrlConfig{}, nil)
+
+	author, book, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	fill := func() {
+		cache.Set([]byte(fmt.Sprintf("author_%s", author.Hex())), []byte("{}"), 60)
+		cache.Set([]byte(fmt.Sprintf("book_%s", book.Hex())), []byte("{}"), 60)
+		cache.Set([]byte(fmt.Sprintf("book_%s", other.Hex())), []byte("{}"), 60)
+	}
+	evicted := func(key string) bool {
+		_, err := cache.Get([]byte(key))
+		return err != nil
+	}
+
+	t.Run("changed author", func(t *testing.T) {
+		fill()
+		storage.EXPECT().GetBookIdsByAuthor(gomock.Any(), author).Return([]primitive.ObjectID{book}, nil)
+
+		service.OnAuthorChanged(context.Background(), author.Hex())
+		assert.True(t, evicted(fmt.Sprintf("author_%s", author.Hex())))
+		assert.True(t, evicted(fmt.Sprintf("book_%s", book.Hex())))
+		assert.False(t, evicted(fmt.Sprintf("book_%s", other.Hex())))
+	})
+	t.Run("deleted author", func(t *testing.T) {
+		fill()
+		storage.EXPECT().GetBookIdsByAuthor(gomock.Any(), author).Return([]primitive.ObjectID{book}, nil)
+
+		service.OnAuthorDeleted(context.Background(), author.Hex())
+		assert.True(t, evicted(fmt.Sprintf("author_%s", author.Hex())))
+		assert.True(t, evicted(fmt.Sprintf("book_%s", book.Hex())))
+		assert.False(t, evicted(fmt.Sprintf("book_%s", other.Hex())))
+	})
+	t.Run("wrong id", func(t *testing.T) {
+		service.OnAuthorChanged(context.Background(), "wrongid")
+	})
+}
+func TestCountGenreBooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), mock.NewMockFileStore(ctrl), &config.UrlConfig{}, nil)
+
+	used, empty := primitive.NewObjectID(), primitive.NewObjectID()
+	storage.EXPECT().CountByGenres(gomock.Any(), []primitive.ObjectID{used, empty}).Return(map[primitive.ObjectID]int64{used: 2}, nil)
+
+	counts, err := service.CountGenreBooks(context.Background(), used.Hex()+","+empty.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{used.Hex(): 2, empty.Hex(): 0}, counts)
+
+	_, err = service.CountGenreBooks(context.Background(), "wrongid")
+	assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+}
+func TestAddBook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	files := mock.NewMockFileStore(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), files, &config.UrlConfig{}, nil)
+
+	query := func() *client.InsertBookQuery {
+		return &client.InsertBookQuery{
+			Name:     "Book name",
+			AuthorId: primitive.NewObjectID(),
+			GenresId: []primitive.ObjectID{primitive.NewObjectID()},
+			Year:     2000,
+			Pages:    100,
+			File:     &client.UploadFile{Body: strings.NewReader(bookDocument), Extension: ".pdf"},
+			Cover:    &client.UploadFile{Body: strings.NewReader(coverImage()), Extension: ".png"},
+		}
+	}
+
+	t.Run("cover upload failed", func(t *testing.T) {
+		gomock.InOrder(
+			files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), int64(len(bookDocument))).Return(nil),
+			files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(errors.New("store is down")),
+			files.EXPECT().Delete(gomock.Any(), stagedKey("")).Return(nil),
+		)
+
+		_, err := service.AddBook(context.Background(), query())
+		assert.Error(t, err)
+	})
+	t.Run("record insert failed", func(t *testing.T) {
+		gomock.InOrder(
+			files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(nil).Times(5),
+			storage.EXPECT().AddBook(gomock.Any(), gomock.Any()).Return("", errors.New("database is down")),
+			files.EXPECT().Delete(gomock.Any(), stagedKey("")).Return(nil).Times(5),
+		)
+
+		_, err := service.AddBook(context.Background(), query())
+		assert.Error(t, err)
+	})
+	t.Run("commit failed", func(t *testing.T) {
+		id := primitive.NewObjectID()
+		gomock.InOrder(
+			files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(nil).Times(5),
+			storage.EXPECT().AddBook(gomock.Any(), gomock.Any()).Return(id.Hex(), nil),
+			files.EXPECT().Move(gomock.Any(), stagedKey(""), gomock.Any()).Return(nil),
+			files.EXPECT().Move(gomock.Any(), stagedKey(""), gomock.Any()).Return(errors.New("store is down")),
+			storage.EXPECT().DeleteBook(gomock.Any(), id).Return(nil),
+			files.EXPECT().Delete(gomock.Any(), stagedKey("")).Return(nil).Times(5),
+			storage.EXPECT().IsFileUsed(gomock.Any(), gomock.Any()).Return(false, nil),
+			files.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil),
+			storage.EXPECT().IsFileUsed(gomock.Any(), gomock.Any()).Return(false, nil),
+			files.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).Times(4),
+		)
+
+		_, err := service.AddBook(context.Background(), query())
+		assert.Error(t, err)
+	})
+	t.Run("same files are stored once", func(t *testing.T) {
+		first, second := query(), query()
+		files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(nil).Times(10)
+		files.EXPECT().Move(gomock.Any(), stagedKey(""), gomock.Any()).Return(nil).Times(10)
+		storage.EXPECT().AddBook(gomock.Any(), gomock.Any()).Return(primitive.NewObjectID().Hex(), nil).Times(2)
+
+		firstBook, err := service.AddBook(context.Background(), first)
+		assert.NoError(t, err)
+		secondBook, err := service.AddBook(context.Background(), second)
+		assert.NoError(t, err)
+		assert.Equal(t, firstBook.FilePath, secondBook.FilePath)
+		assert.Equal(t, firstBook.CoverSha256, secondBook.CoverSha256)
+	})
+	t.Run("metadata from document", func(t *testing.T) {
+		q := query()
+		q.Name, q.Pages = "", 0
+		storage.EXPECT().GetBookByName(gomock.Any(), "Document title").Return(nil, errormiddleware.NotFoundError([]string{"book not exists"}, ""))
+		files.EXPECT().Put(gomock.Any(), stagedKey(""), gomock.Any(), gomock.Any()).Return(nil).Times(5)
+		files.EXPECT().Move(gomock.Any(), stagedKey(""), gomock.Any()).Return(nil).Times(5)
+		storage.EXPECT().AddBook(gomock.Any(), gomock.Any()).Return(primitive.NewObjectID().Hex(), nil)
+
+		book, err := service.AddBook(context.Background(), q)
+		assert.NoError(t, err)
+		assert.Equal(t, "Document title", book.Name)
+		assert.Equal(t, 100, book.Pages)
+	})
+	t.Run("title already taken", func(t *testing.T) {
+		q := query()
+		q.Name = ""
+		storage.EXPECT().GetBookByName(gomock.Any(), "Document title").Return(&client.Book{Name: "Document title"}, nil)
+
+		_, err := service.AddBook(context.Background(), q)
+		assert.Equal(t, errormiddleware.NotUniqueErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("pages don't match the file", func(t *testing.T) {
+		q := query()
+		q.Pages = 120
+		_, err := service.AddBook(context.Background(), q)
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("not a pdf", func(t *testing.T) {
+		q := query()
+		q.File.Body = strings.NewReader("plain text")
+		_, err := service.AddBook(context.Background(), q)
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("missing file", func(t *testing.T) {
+		q := query()
+		q.File = nil
+		_, err := service.AddBook(context.Background(), q)
+		assert.Equal(t, errormiddleware.ValidationErrorCode, err.(*errormiddleware.Error).Code)
+	})
+}
+func TestFindBooksSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	author := &client.Author{Id: primitive.NewObjectID(), Name: "Author"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/authors/search", r.URL.Path)
+		assert.Equal(t, "author", r.URL.Query().Get("name"))
+		data, _ := json.Marshal([]*client.Author{author})
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), mock.NewMockFileStore(ctrl), &config.UrlConfig{AuthorApiAdress: server.URL, GenreApiAdress: server.URL}, nil)
+
+	t.Run("authors resolved", func(t *testing.T) {
+		storage.EXPECT().GetByFilter(gomock.Any(), map[string]string{"search": "author", "searchauthors": author.Id.Hex()}, "", 0, 10).Return(&client.BookPage{Items: []*client.Book{}}, nil)
+
+		page, err := service.FindBooks(context.Background(), map[string]string{"search": " author "}, "", 0, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+	})
+	t.Run("empty search", func(t *testing.T) {
+		_, err := service.FindBooks(context.Background(), map[string]string{"search": " "}, "", 0, 10)
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+}
@@ -12,5 +12,13 @@ type Storage interface {
 	AddBook(ctx context.Context, book *Book) (string, error)
 	GetBookByName(ctx context.Context, name string) (*Book, error)
 	GetBookById(ctx context.Context, id primitive.ObjectID) (*Book, error)
-	GetByFilter(ctx context.Context, filter map[string]string, offset, limit int) ([]*Book, error)
+	GetByFilter(ctx context.Context, filter map[string]string, cursor string, offset, limit int) (*BookPage, error)
+	UpdateBook(ctx context.Context, book *Book) error
+	DeleteBook(ctx context.Context, id primitive.ObjectID) error
+	IsFileUsed(ctx context.Context, key string) (bool, error)
+	GetFileReferences(ctx context.Context) ([]*Book, error)
+	GetBookIdsByGenre(ctx context.Context, genre primitive.ObjectID) ([]primitive.ObjectID, error)
+	GetBookIdsByAuthor(ctx context.Context, author primitive.ObjectID) ([]primitive.ObjectID, error)
+	RemoveGenre(ctx context.Context, genre primitive.ObjectID) error
+	CountByGenres(ctx context.Context, genres []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
 }
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/thumbnail"
+)
+
+const (
+	CoverSmall  = "small"
+	CoverMedium = "medium"
+	CoverLarge  = "large"
+)
+
+var (
+	CoverSizes = []string{CoverSmall, CoverMedium, CoverLarge}
+	// Longest side of thumbnail in pixels
+	coverSides = map[string]int{CoverSmall: 160, CoverMedium: 320, CoverLarge: 640}
+)
+
+// Links to the cover and it's thumbnails
+type CoverUrls struct {
+	Original string `json:"original"`
+	Small    string `json:"small"`
+	Medium   string `json:"medium"`
+	Large    string `json:"large"`
+}
+
+func IsCoverSize(size string) bool {
+	_, ok := coverSides[size]
+	return ok
+}
+
+// Thumbnails are stored next to the cover, under it's key with size suffix: <sha256>_small.png
+func ThumbnailKey(cover, size string) string {
+	ext := filepath.Ext(cover)
+	return fmt.Sprintf("%s_%s%s", strings.TrimSuffix(cover, ext), size, ext)
+}
+
+// Returns keys of all thumbnails of the cover. Only images have thumbnails
+func thumbnailKeys(key string) []string {
+	switch filepath.Ext(key) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		return nil
+	}
+	keys := make([]string, 0, len(CoverSizes))
+	for _, size := range CoverSizes {
+		keys = append(keys, ThumbnailKey(key, size))
+	}
+	return keys
+}
+
+// Returns key of the cover if provided key belongs to it's thumbnail
+func thumbnailSource(key string) (string, bool) {
+	ext := filepath.Ext(key)
+	for _, size := range CoverSizes {
+		if base, ok := strings.CutSuffix(strings.TrimSuffix(key, ext), "_"+size); ok && len(base) > 0 {
+			return base + ext, true
+		}
+	}
+	return "", false
+}
+func coverUrls(book *Book) *CoverUrls {
+	if len(book.CoverPath) == 0 {
+		return nil
+	}
+	base := fmt.Sprintf("/books/%s/cover", book.Id.Hex())
+	return &CoverUrls{
+		Original: base,
+		Small:    base + "?size=" + CoverSmall,
+		Medium:   base + "?size=" + CoverMedium,
+		Large:    base + "?size=" + CoverLarge,
+	}
+}
+
+// Decodes uploaded cover and encodes thumbnail of every size in cover's format. Cover is rewound
+func makeThumbnails(cover *UploadFile) (map[string][]byte, error) {
+	img, format, err := thumbnail.Decode(cover.Body)
+	if err != nil {
+		return nil, errormiddleware.BadRequestError([]string{"cover: image can't be decoded"}, err.Error())
+	}
+	if _, err := cover.Body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	thumbnails := make(map[string][]byte, len(CoverSizes))
+	for _, size := range CoverSizes {
+		var buf bytes.Buffer
+		scaled := thumbnail.Fit(img, coverSides[size])
+		if format == "png" {
+			err = png.Encode(&buf, scaled)
+		} else {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return nil, err
+		}
+		thumbnails[size] = buf.Bytes()
+	}
+	return thumbnails, nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
@@ -30,11 +31,37 @@ type UrlConfig struct {
 	GenreApiAdress  string `env:"GENRE_API_URL" env-required:"true"`
 	AuthorApiAdress string `env:"AUTHOR_API_URL" env-required:"true"`
 }
+type FilesConfig struct {
+	Backend     string `env:"FILES_BACKEND" env-default:"local"`
+	LocalPath   string `env:"FILES_LOCAL_PATH" env-default:"./files/books"`
+	S3Endpoint  string `env:"FILES_S3_ENDPOINT"`
+	S3Region    string `env:"FILES_S3_REGION" env-default:"us-east-1"`
+	S3Bucket    string `env:"FILES_S3_BUCKET"`
+	S3AccessKey string `env:"FILES_S3_ACCESS_KEY"`
+	S3SecretKey string `env:"FILES_S3_SECRET_KEY"`
+	// Upload limits in bytes
+	MaxFileSize  int64 `env:"FILES_MAX_FILE_SIZE" env-default:"52428800"`
+	MaxCoverSize int64 `env:"FILES_MAX_COVER_SIZE" env-default:"5242880"`
+	// Reconciler doesn't touch objects younger than grace period, since their books could be saved right now
+	ReconcileInterval time.Duration `env:"FILES_RECONCILE_INTERVAL" env-default:"1h"`
+	ReconcileGrace    time.Duration `env:"FILES_RECONCILE_GRACE" env-default:"1h"`
+	ReconcileCleanup  bool          `env:"FILES_RECONCILE_CLEANUP" env-default:"true"`
+}
+
+// Secret is shared by all services, internal requests are signed with it.
+// Signed requests with body larger than MaxBodySize bytes are rejected
+type SignatureConfig struct {
+	Service     string `env:"SERVICE_NAME" env-default:"api_books"`
+	Secret      string `env:"SERVICE_SECRET" env-required:"true"`
+	MaxBodySize int64  `env:"SERVICE_MAX_BODY_SIZE" env-default:"1048576"`
+}
 type Config struct {
-	Server   *ServerConfig
-	Database *DatabaseConfig
-	Rabbit   *RabbitConfig
-	Urls     *UrlConfig
+	Server    *ServerConfig
+	Database  *DatabaseConfig
+	Rabbit    *RabbitConfig
+	Urls      *UrlConfig
+	Files     *FilesConfig
+	Signature *SignatureConfig
 }
 
 var cfg *Config
@@ -45,9 +72,11 @@ func GetConfig() *Config {
 		logger := logging.GetLogger()
 		logger.Info("reading api config...")
 		srvCfg := &ServerConfig{}
+		signatureCfg := &SignatureConfig{}
 		dbCfg := &DatabaseConfig{}
 		rabbitCfg := &RabbitConfig{}
 		urlCfg := &UrlConfig{}
+		filesCfg := &FilesConfig{}
 
 		if err := cleanenv.ReadConfig("config/.env", srvCfg); err != nil {
 			desc, _ := cleanenv.GetDescription(cfg, nil)
@@ -72,11 +101,23 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		if err := cleanenv.ReadConfig("config/.env", filesCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", signatureCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
 		cfg = &Config{
-			Server:   srvCfg,
-			Database: dbCfg,
-			Rabbit:   rabbitCfg,
-			Urls:     urlCfg,
+			Server:    srvCfg,
+			Database:  dbCfg,
+			Rabbit:    rabbitCfg,
+			Urls:      urlCfg,
+			Files:     filesCfg,
+			Signature: signatureCfg,
 		}
 	})
 	return cfg
@@ -3,10 +3,12 @@ package book
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -26,23 +28,46 @@ const (
 	url_add_book        = "/books"
 	url_get_books       = "/books"
 	url_find_book_by_id = "/books/:id"
+	url_book_by_id      = "/books/:id"
+	url_book_file       = "/books/:id/file"
+	url_book_cover      = "/books/:id/cover"
+	url_genre_counts    = "/stats/genres"
+
+	default_max_file_size  = 50 << 20
+	default_max_cover_size = 5 << 20
+	// files above this size are kept on disk while request is handled
+	form_memory_size = 10 << 20
+	form_fields_size = 1 << 20
 )
 
 type Service interface {
 	IsBookExists(ctx context.Context, name string) bool
 	AddBook(ctx context.Context, query *client.InsertBookQuery) (*client.Book, error)
-	FindBooks(ctx context.Context, filters map[string]string, offset, limit int) ([]*client.Book, error)
+	FindBooks(ctx context.Context, filters map[string]string, cursor string, offset, limit int) (*client.BookPage, error)
 	GetBook(ctx context.Context, id string) (*client.Book, error)
+	UpdateBook(ctx context.Context, id string, query *client.UpdateBookQuery) (*client.Book, error)
+	DeleteBook(ctx context.Context, id string) (*client.Book, error)
+	GetFile(ctx context.Context, key string) (*client.File, error)
+	CountGenreBooks(ctx context.Context, ids string) (map[string]int64, error)
 }
+
+// Upload limits are in bytes. Zero limits are replaced with defaults
 type Handler struct {
-	Logger      *logging.Logger
-	BookService Service
+	Logger       *logging.Logger
+	BookService  Service
+	MaxFileSize  int64
+	MaxCoverSize int64
 }
 
 func (h *Handler) Register(route *httprouter.Router) {
 	route.HandlerFunc(http.MethodPost, url_add_book, h.Logger.Middleware(errormiddleware.Middleware(h.AddBookHandler)))
 	route.HandlerFunc(http.MethodGet, url_get_books, h.Logger.Middleware(errormiddleware.Middleware(h.GetBooks)))
 	route.HandlerFunc(http.MethodGet, url_find_book_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.FindBook)))
+	route.HandlerFunc(http.MethodPatch, url_book_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.UpdateBook)))
+	route.HandlerFunc(http.MethodDelete, url_book_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.DeleteBook)))
+	route.HandlerFunc(http.MethodGet, url_book_file, h.Logger.Middleware(errormiddleware.Middleware(h.GetBookFile)))
+	route.HandlerFunc(http.MethodGet, url_book_cover, h.Logger.Middleware(errormiddleware.Middleware(h.GetBookCover)))
+	route.HandlerFunc(http.MethodGet, url_genre_counts, h.Logger.Middleware(errormiddleware.Middleware(h.GetGenreCounts)))
 }
 func (h *Handler) FindBook(w http.ResponseWriter, r *http.Request) error {
 	params := httprouter.ParamsFromContext(r.Context())
@@ -60,41 +85,66 @@ func (h *Handler) FindBook(w http.ResponseWriter, r *http.Request) error {
 	w.Write(bytes)
 	return nil
 }
+
+// Returns amount of books in every requested genre, genres without books have zero count
+func (h *Handler) GetGenreCounts(w http.ResponseWriter, r *http.Request) error {
+	ids := r.URL.Query().Get("id")
+	if len(ids) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id query is not present")
+	}
+	counts, err := h.BookService.CountGenreBooks(r.Context(), ids)
+	if err != nil {
+		return err
+	}
+	bytes, _ := json.Marshal(counts)
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
 func (h *Handler) GetBooks(w http.ResponseWriter, r *http.Request) error {
 	filters := make(map[string]string, 0)
-	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-	if err != nil {
-		return errormiddleware.BadRequestError([]string{"bad query request", "offset must be present"}, err.Error())
+	// offset is optional and ignored when cursor is present
+	offset := 0
+	if r.URL.Query().Has("offset") {
+		var err error
+		offset, err = strconv.Atoi(r.URL.Query().Get("offset"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"bad query request", "offset must be a number"}, err.Error())
+		}
 	}
 	if offset < 0 {
 		return errormiddleware.BadRequestError([]string{"bad query request"}, "offset must be greater than -1")
 	}
 	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
 	if err != nil {
-		return errormiddleware.BadRequestError([]string{"bad query request", "offset must be present"}, err.Error())
+		return errormiddleware.BadRequestError([]string{"bad query request", "limit must be present"}, err.Error())
 	}
 	if limit <= 0 {
 		return errormiddleware.BadRequestError([]string{"bad query request"}, "limit must be greater than 0")
 	}
-	AllowedParams := []string{}
+	AllowedParams := []string{"author", "genres", "genresmode", "yearfrom", "yearto", "pagesfrom", "pagesto", "name", "search", "sort", "order"}
 
 	for _, v := range AllowedParams {
 		if r.URL.Query().Has(v) {
 			filters[v] = r.URL.Query().Get(v)
 		}
 	}
-	books, err := h.BookService.FindBooks(r.Context(), filters, offset, limit)
+	page, err := h.BookService.FindBooks(r.Context(), filters, r.URL.Query().Get("cursor"), offset, limit)
 	if err != nil {
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
-	bytes, _ := json.Marshal(books)
+	bytes, _ := json.Marshal(page)
 	w.Write(bytes)
 	return nil
 }
+
+// Name and pages are optional, they are taken from the document when omitted
 func (h *Handler) AddBookHandler(w http.ResponseWriter, r *http.Request) error {
-	r.ParseMultipartForm(10 << 20) //10 Mb
-	if h.BookService.IsBookExists(r.Context(), r.FormValue("name")) {
+	if err := h.parseUploadForm(w, r); err != nil {
+		return err
+	}
+	if len(r.FormValue("name")) > 0 && h.BookService.IsBookExists(r.Context(), r.FormValue("name")) {
 		return errormiddleware.NotUniqueError([]string{fmt.Sprintf("name %s already taken", r.FormValue("name"))}, "book with provided name already in database")
 	}
 
@@ -109,14 +159,13 @@ func (h *Handler) AddBookHandler(w http.ResponseWriter, r *http.Request) error {
 	}
 	defer cover.Close()
 
-	if filepath.Ext(header.Filename) != ".pdf" {
-		return errormiddleware.BadRequestError([]string{"file must have a .pdf extension"}, "wrong file extension")
+	bookFile, err := h.bookUpload(file, header)
+	if err != nil {
+		return err
 	}
-	switch filepath.Ext(coverHeader.Filename) {
-	case ".jpg", ".png", ".jpeg":
-		break
-	default:
-		return errormiddleware.BadRequestError([]string{"cover has a wrong extension", "available extensions: .jpg, .png, .jpeg"}, "wrong file extension")
+	coverFile, err := h.coverUpload(cover, coverHeader)
+	if err != nil {
+		return err
 	}
 
 	author, err := primitive.ObjectIDFromHex(r.FormValue("authorid"))
@@ -149,58 +198,263 @@ func (h *Handler) AddBookHandler(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return errormiddleware.BadRequestError([]string{"year must be a number"}, err.Error())
 	}
-	pages, err := strconv.Atoi(r.FormValue("pages"))
-	if err != nil {
-		return errormiddleware.BadRequestError([]string{"pages must be a number"}, err.Error())
+	pages := 0
+	if r.Form.Has("pages") {
+		pages, err = strconv.Atoi(r.FormValue("pages"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"pages must be a number"}, err.Error())
+		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// files are uploaded to the store within the request
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	file_path := fmt.Sprintf("./files/books/%s/book_%s%s", r.FormValue("name"), primitive.NewObjectID().Hex(), filepath.Ext(header.Filename))
-	cover_path := fmt.Sprintf("./files/books/%s/cover_%s%s", r.FormValue("name"), primitive.NewObjectID().Hex(), filepath.Ext(coverHeader.Filename))
-
 	query := &client.InsertBookQuery{
-		Name:      r.FormValue("name"),
-		AuthorId:  author,
-		GenresId:  genres,
-		Year:      year,
-		FilePath:  filepath.Base(file_path),
-		CoverPath: filepath.Base(cover_path),
-		Pages:     pages,
+		Name:     r.FormValue("name"),
+		AuthorId: author,
+		GenresId: genres,
+		Year:     year,
+		File:     bookFile,
+		Cover:    coverFile,
+		Pages:    pages,
 	}
 	book, err := h.BookService.AddBook(ctx, query)
 	if err != nil {
 		return err
 	}
-	err = os.MkdirAll("./files/books", 0644)
-	if err != nil {
-		return err
+
+	w.WriteHeader(http.StatusCreated)
+	bytes, _ := json.Marshal(&book)
+	w.Write(bytes)
+	return nil
+}
+
+// All form fields are optional. Files are replaced only if they were uploaded
+func (h *Handler) UpdateBook(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
 	}
-	err = os.MkdirAll(fmt.Sprintf("./files/books/%s", query.Name), 0644)
-	if err != nil {
+	if err := h.parseUploadForm(w, r); err != nil {
 		return err
 	}
-	destination, err := os.Create(file_path)
+
+	query := new(client.UpdateBookQuery)
+	if r.Form.Has("name") {
+		name := r.FormValue("name")
+		query.Name = &name
+	}
+	if r.Form.Has("authorid") {
+		author, err := primitive.ObjectIDFromHex(r.FormValue("authorid"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"authorid: invalid author id"}, err.Error())
+		}
+		query.AuthorId = &author
+	}
+	if r.Form.Has("genres") {
+		genres := make([]primitive.ObjectID, 0)
+		for _, val := range strings.Split(r.FormValue("genres"), ",") {
+			genre, err := primitive.ObjectIDFromHex(val)
+			if err != nil {
+				return errormiddleware.BadRequestError([]string{"invalid genre id"}, err.Error())
+			}
+			genres = append(genres, genre)
+		}
+		query.GenresId = &genres
+	}
+	if r.Form.Has("year") {
+		year, err := strconv.Atoi(r.FormValue("year"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"year must be a number"}, err.Error())
+		}
+		query.Year = &year
+	}
+	if r.Form.Has("pages") {
+		pages, err := strconv.Atoi(r.FormValue("pages"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"pages must be a number"}, err.Error())
+		}
+		query.Pages = &pages
+	}
+
+	file, header, err := r.FormFile("file")
+	if err == nil {
+		defer file.Close()
+		if query.File, err = h.bookUpload(file, header); err != nil {
+			return err
+		}
+	}
+	cover, coverHeader, err := r.FormFile("cover")
+	if err == nil {
+		defer cover.Close()
+		if query.Cover, err = h.coverUpload(cover, coverHeader); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	book, err := h.BookService.UpdateBook(ctx, id, query)
 	if err != nil {
 		return err
 	}
-	defer destination.Close()
-	if _, err := io.Copy(destination, file); err != nil {
+
+	w.WriteHeader(http.StatusOK)
+	bytes, _ := json.Marshal(book)
+	w.Write(bytes)
+	return nil
+}
+
+// Request body is limited by the sum of file limits, so oversized uploads are rejected without reading them whole
+func (h *Handler) parseUploadForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, h.MaxRequestSize())
+	if err := r.ParseMultipartForm(form_memory_size); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errormiddleware.TooLargeError([]string{fmt.Sprintf("request can't be larger than %d bytes", tooLarge.Limit)}, err.Error())
+		}
+		return errormiddleware.BadRequestError([]string{"request must be a multipart form"}, err.Error())
+	}
+	return nil
+}
+func (h *Handler) bookUpload(file multipart.File, header *multipart.FileHeader) (*client.UploadFile, error) {
+	if filepath.Ext(header.Filename) != ".pdf" {
+		return nil, errormiddleware.BadRequestError([]string{"file must have a .pdf extension"}, "wrong file extension")
+	}
+	return sniffUpload("file", file, header, h.maxFileSize(), map[string]string{"application/pdf": ".pdf"})
+}
+func (h *Handler) coverUpload(file multipart.File, header *multipart.FileHeader) (*client.UploadFile, error) {
+	switch filepath.Ext(header.Filename) {
+	case ".jpg", ".png", ".jpeg":
+		break
+	default:
+		return nil, errormiddleware.BadRequestError([]string{"cover has a wrong extension", "available extensions: .jpg, .png, .jpeg"}, "wrong file extension")
+	}
+	return sniffUpload("cover", file, header, h.maxCoverSize(), map[string]string{"image/jpeg": ".jpg", "image/png": ".png"})
+}
+
+// Content type is detected by magic bytes, file name is not trusted.
+// Stored extension is taken from detected type, so .jpeg covers are stored as .jpg
+func sniffUpload(field string, file multipart.File, header *multipart.FileHeader, limit int64, types map[string]string) (*client.UploadFile, error) {
+	if header.Size > limit {
+		return nil, errormiddleware.TooLargeError([]string{fmt.Sprintf("%s: file can't be larger than %d bytes", field, limit)}, fmt.Sprintf("uploaded file has %d bytes", header.Size))
+	}
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head[:n])
+	extension, ok := types[contentType]
+	if !ok {
+		return nil, errormiddleware.BadRequestError([]string{fmt.Sprintf("%s: content doesn't match it's extension", field)}, fmt.Sprintf("detected content type %s", contentType))
+	}
+	return &client.UploadFile{Body: file, Extension: extension}, nil
+}
+
+// Largest request body the handler accepts, it's an upload with both file and cover
+func (h *Handler) MaxRequestSize() int64 {
+	return h.maxFileSize() + h.maxCoverSize() + form_fields_size
+}
+func (h *Handler) maxFileSize() int64 {
+	if h.MaxFileSize > 0 {
+		return h.MaxFileSize
+	}
+	return default_max_file_size
+}
+func (h *Handler) maxCoverSize() int64 {
+	if h.MaxCoverSize > 0 {
+		return h.MaxCoverSize
+	}
+	return default_max_cover_size
+}
+func (h *Handler) DeleteBook(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.BookService.DeleteBook(ctx, id); err != nil {
 		return err
 	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func (h *Handler) GetBookFile(w http.ResponseWriter, r *http.Request) error {
+	return h.serveBookFile(w, r, false)
+}
+func (h *Handler) GetBookCover(w http.ResponseWriter, r *http.Request) error {
+	return h.serveBookFile(w, r, true)
+}
 
-	coverDest, err := os.Create(cover_path)
+// Range, If-Range, If-None-Match and If-Modified-Since requests are handled by http.ServeContent
+func (h *Handler) serveBookFile(w http.ResponseWriter, r *http.Request, cover bool) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
+	}
+	book, err := h.BookService.GetBook(r.Context(), id)
 	if err != nil {
 		return err
 	}
-	defer coverDest.Close()
-	if _, err := io.Copy(coverDest, cover); err != nil {
-		return err
+	name := book.FilePath
+	if cover {
+		name = book.CoverPath
+	}
+	if len(name) == 0 {
+		return errormiddleware.NotFoundError([]string{"file not exists"}, fmt.Sprintf("book %s has no file", id))
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	bytes, _ := json.Marshal(&book)
-	w.Write(bytes)
+	checksum := book.FileSha256
+	if cover {
+		checksum = book.CoverSha256
+	}
+	var file *client.File
+	if size := r.URL.Query().Get("size"); cover && len(size) > 0 {
+		if !client.IsCoverSize(size) {
+			return errormiddleware.BadRequestError([]string{fmt.Sprintf("size: must be one of %s", strings.Join(client.CoverSizes, ", "))}, fmt.Sprintf("received size %s", size))
+		}
+		// covers uploaded before thumbnails were introduced have none, then original is served
+		file, err = h.BookService.GetFile(r.Context(), client.ThumbnailKey(name, size))
+		var notFound *errormiddleware.Error
+		if err != nil && (!errors.As(err, &notFound) || notFound.Code != errormiddleware.NotFoundErrorCode) {
+			return err
+		}
+		if err == nil && len(checksum) > 0 {
+			checksum = fmt.Sprintf("%s-%s", checksum, size)
+		}
+	}
+	if file == nil {
+		file, err = h.BookService.GetFile(r.Context(), name)
+		if err != nil {
+			return err
+		}
+	}
+	defer file.Body.Close()
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	if len(checksum) > 0 {
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, checksum))
+	} else {
+		w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, file.ModTime.UnixNano(), file.Size))
+	}
+	if !cover {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": book.Name + filepath.Ext(name)}))
+	}
+	// files could be large, so server's write timeout must not interrupt them
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	http.ServeContent(w, r, name, file.ModTime, file.Body)
 	return nil
 }
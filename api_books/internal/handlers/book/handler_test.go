@@ -2,14 +2,19 @@ package book
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_books/internal/client"
 	mock "github.com/reversersed/go-web-services/tree/main/api_books/internal/handlers/book/mocks"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
@@ -32,7 +37,16 @@ func TestRegister(t *testing.T) {
 		Name   string
 		Path   string
 		Method string
-	}{}
+	}{
+		{"Add book", url_add_book, http.MethodPost},
+		{"Get books", url_get_books, http.MethodGet},
+		{"Find book by id", url_find_book_by_id, http.MethodGet},
+		{"Update book", url_book_by_id, http.MethodPatch},
+		{"Delete book", url_book_by_id, http.MethodDelete},
+		{"Get book file", url_book_file, http.MethodGet},
+		{"Get book cover", url_book_cover, http.MethodGet},
+		{"Get genre counts", url_genre_counts, http.MethodGet},
+	}
 	router := httprouter.New()
 	h.Register(router)
 	for _, registerCase := range registerCases {
@@ -47,7 +61,10 @@ func TestHandlers(t *testing.T) {
 	type handlerOptions struct {
 		Name           string
 		MockBehaviour  func(s *mock.MockService)
+		Query          string
+		Params         httprouter.Params
 		InputJson      func() *[]byte
+		ContentType    string
 		ExceptedStatus int
 		ExceptedError  error
 		ExceptedBody   string
@@ -57,7 +74,109 @@ func TestHandlers(t *testing.T) {
 		Handler     func(w http.ResponseWriter, r *http.Request) error
 		Method      string
 		Options     []handlerOptions
-	}{}
+	}{
+		//UpdateBook
+		{
+			HandlerName: "UpdateBook",
+			Handler:     h.UpdateBook,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present"),
+					ExceptedBody:   `{"messages":["id: parameter is required"],"dev_message":"id path is not present","code":"IE-0003"}`,
+				},
+				//Not a multipart form
+				{
+					Name:           "not multipart",
+					Params:         httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"request must be a multipart form"}, http.ErrNotMultipart.Error()),
+					ExceptedBody:   `{"messages":["request must be a multipart form"],"dev_message":"request Content-Type isn't multipart/form-data","code":"IE-0003"}`,
+				},
+				//Book not found
+				{
+					Name:        "book not found",
+					Params:      httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					InputJson:   func() *[]byte { return multipartForm(nil, nil) },
+					ContentType: form_content_type,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().UpdateBook(gomock.Any(), "000000000000000000000000", &client.UpdateBookQuery{}).Return(nil, errormiddleware.NotFoundError([]string{"book not exists"}, ""))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"book not exists"}, ""),
+					ExceptedBody:   `{"messages":["book not exists"],"code":"IE-0002"}`,
+				},
+				//Successful without files
+				{
+					Name:        "success",
+					Params:      httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					InputJson:   func() *[]byte { return multipartForm(nil, nil) },
+					ContentType: form_content_type,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().UpdateBook(gomock.Any(), "000000000000000000000000", &client.UpdateBookQuery{}).Return(&client.Book{Name: "Book name", Year: 2000}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"000000000000000000000000","name":"Book name","pages":0,"year":2000,"file":"","cover":"","filesha256":"","coversha256":""}`,
+				},
+			},
+		},
+		//DeleteBook
+		{
+			HandlerName: "DeleteBook",
+			Handler:     h.DeleteBook,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:   "success",
+					Params: httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteBook(gomock.Any(), "000000000000000000000000").Return(&client.Book{Name: "Book name"}, nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				//Service error
+				{
+					Name:   "service error",
+					Params: httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteBook(gomock.Any(), "000000000000000000000000").Return(nil, errormiddleware.NotFoundError([]string{"book not exists"}, ""))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"book not exists"}, ""),
+					ExceptedBody:   `{"messages":["book not exists"],"code":"IE-0002"}`,
+				},
+			},
+		},
+		//GetGenreCounts
+		{
+			HandlerName: "GetGenreCounts",
+			Handler:     h.GetGenreCounts,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:  "success",
+					Query: "?id=000000000000000000000000",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().CountGenreBooks(gomock.Any(), "000000000000000000000000").Return(map[string]int64{"000000000000000000000000": 3}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"000000000000000000000000":3}`,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id query is not present"),
+					ExceptedBody:   `{"messages":["id: parameter is required"],"dev_message":"id query is not present","code":"IE-0003"}`,
+				},
+			},
+		},
+	}
 	for _, tt := range testTable {
 		for _, testCase := range tt.Options {
 			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
@@ -72,9 +191,15 @@ func TestHandlers(t *testing.T) {
 				w := httptest.NewRecorder()
 				var r *http.Request
 				if testCase.InputJson != nil && testCase.InputJson() != nil {
-					r = httptest.NewRequest(tt.Method, "http://test", bytes.NewBuffer(*testCase.InputJson()))
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, bytes.NewBuffer(*testCase.InputJson()))
 				} else {
-					r = httptest.NewRequest(tt.Method, "http://test", nil)
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, nil)
+				}
+				if len(testCase.ContentType) > 0 {
+					r.Header.Set("Content-Type", testCase.ContentType)
+				}
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
 				}
 				err := errormiddleware.Middleware(tt.Handler)(w, r)
 				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
@@ -88,3 +213,249 @@ func TestHandlers(t *testing.T) {
 		}
 	}
 }
+
+const form_content_type = "multipart/form-data; boundary=boundary"
+
+// File fields are mapped to file name and content
+func multipartForm(fields map[string]string, files map[string][2]string) *[]byte {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.SetBoundary("boundary")
+	for k, v := range fields {
+		writer.WriteField(k, v)
+	}
+	for k, v := range files {
+		part, _ := writer.CreateFormFile(k, v[0])
+		part.Write([]byte(v[1]))
+	}
+	writer.Close()
+	data := body.Bytes()
+	return &data
+}
+func TestUploadValidation(t *testing.T) {
+	document := "%PDF-1.4\n1 0 obj\n<< /Type /Pages /Count 1 >>\nendobj\n"
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	limited := &Handler{Logger: h.Logger, MaxFileSize: 64, MaxCoverSize: 16}
+	params := httprouter.Params{{Key: "id", Value: "000000000000000000000000"}}
+
+	var table = []struct {
+		Name           string
+		Handler        func(service Service) func(w http.ResponseWriter, r *http.Request) error
+		Body           *[]byte
+		MockBehaviour  func(s *mock.MockService)
+		ExceptedStatus int
+		ExceptedBody   string
+	}{
+		{
+			Name: "content detected by magic bytes",
+			Handler: func(s Service) func(w http.ResponseWriter, r *http.Request) error {
+				h.BookService = s
+				return h.UpdateBook
+			},
+			Body: multipartForm(nil, map[string][2]string{"file": {"book.pdf", document}, "cover": {"cover.jpeg", png}}),
+			MockBehaviour: func(s *mock.MockService) {
+				s.EXPECT().UpdateBook(gomock.Any(), "000000000000000000000000", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, query *client.UpdateBookQuery) (*client.Book, error) {
+					assert.Equal(t, ".pdf", query.File.Extension)
+					assert.Equal(t, ".png", query.Cover.Extension)
+					return &client.Book{}, nil
+				})
+			},
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody:   `{"id":"000000000000000000000000","name":"","pages":0,"year":0,"file":"","cover":"","filesha256":"","coversha256":""}`,
+		},
+		{
+			Name: "content doesn't match extension",
+			Handler: func(s Service) func(w http.ResponseWriter, r *http.Request) error {
+				h.BookService = s
+				return h.UpdateBook
+			},
+			Body:           multipartForm(nil, map[string][2]string{"file": {"book.pdf", png}}),
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   `{"messages":["file: content doesn't match it's extension"],"dev_message":"detected content type image/png","code":"IE-0003"}`,
+		},
+		{
+			Name: "pages are optional",
+			Handler: func(s Service) func(w http.ResponseWriter, r *http.Request) error {
+				h.BookService = s
+				return h.AddBookHandler
+			},
+			Body: multipartForm(map[string]string{"authorid": "000000000000000000000000", "genres": "000000000000000000000000", "year": "2000"},
+				map[string][2]string{"file": {"book.pdf", document}, "cover": {"cover.png", png}}),
+			MockBehaviour: func(s *mock.MockService) {
+				s.EXPECT().AddBook(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, query *client.InsertBookQuery) (*client.Book, error) {
+					assert.Equal(t, 0, query.Pages)
+					assert.Empty(t, query.Name)
+					return &client.Book{Name: "Document title", Pages: 1}, nil
+				})
+			},
+			ExceptedStatus: http.StatusCreated,
+			ExceptedBody:   `{"id":"000000000000000000000000","name":"Document title","pages":1,"year":0,"file":"","cover":"","filesha256":"","coversha256":""}`,
+		},
+		{
+			Name: "cover too large",
+			Handler: func(s Service) func(w http.ResponseWriter, r *http.Request) error {
+				limited.BookService = s
+				return limited.UpdateBook
+			},
+			Body:           multipartForm(nil, map[string][2]string{"cover": {"cover.png", png + strings.Repeat("0", 16)}}),
+			ExceptedStatus: http.StatusRequestEntityTooLarge,
+			ExceptedBody:   `{"messages":["cover: file can't be larger than 16 bytes"],"dev_message":"uploaded file has 32 bytes","code":"IE-0008"}`,
+		},
+		{
+			Name: "request too large",
+			Handler: func(s Service) func(w http.ResponseWriter, r *http.Request) error {
+				limited.BookService = s
+				return limited.UpdateBook
+			},
+			Body:           multipartForm(nil, map[string][2]string{"file": {"book.pdf", document + strings.Repeat("0", 2<<20)}}),
+			ExceptedStatus: http.StatusRequestEntityTooLarge,
+			ExceptedBody:   fmt.Sprintf(`{"messages":["request can't be larger than %d bytes"],"dev_message":"http: request body too large","code":"IE-0008"}`, 64+16+form_fields_size),
+		},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			service := mock.NewMockService(ctrl)
+			if tt.MockBehaviour != nil {
+				tt.MockBehaviour(service)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "http://test", bytes.NewReader(*tt.Body))
+			r.Header.Set("Content-Type", form_content_type)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, params))
+
+			errormiddleware.Middleware(tt.Handler(service))(w, r)
+			assert.Equal(t, tt.ExceptedStatus, w.Result().StatusCode)
+			assert.Equal(t, tt.ExceptedBody, w.Body.String())
+		})
+	}
+}
+
+type fileBody struct {
+	*strings.Reader
+}
+
+func (fileBody) Close() error { return nil }
+
+func TestServeBookFile(t *testing.T) {
+	book := &client.Book{Name: "Book name", FilePath: "a1b2.pdf", CoverPath: "c3d4.png", FileSha256: "a1b2"}
+	params := httprouter.Params{{Key: "id", Value: "000000000000000000000000"}}
+	modTime := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	var table = []struct {
+		Name           string
+		Handler        func(w http.ResponseWriter, r *http.Request) error
+		Headers        map[string]string
+		ExceptedStatus int
+		ExceptedBody   string
+	}{
+		{"full file", h.GetBookFile, nil, http.StatusOK, "%PDF-1.4 book content"},
+		{"range request", h.GetBookFile, map[string]string{"Range": "bytes=0-7"}, http.StatusPartialContent, "%PDF-1.4"},
+		{"not modified", h.GetBookFile, map[string]string{"If-None-Match": `"a1b2"`}, http.StatusNotModified, ""},
+		{"missing cover", h.GetBookCover, nil, http.StatusNotFound, `{"messages":["file not exists"],"dev_message":"object c3d4.png not found","code":"IE-0002"}`},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			service := mock.NewMockService(ctrl)
+			service.EXPECT().GetBook(gomock.Any(), "000000000000000000000000").Return(book, nil)
+			service.EXPECT().GetFile(gomock.Any(), "a1b2.pdf").Return(&client.File{Body: fileBody{strings.NewReader("%PDF-1.4 book content")}, Size: 21, ModTime: modTime}, nil).AnyTimes()
+			service.EXPECT().GetFile(gomock.Any(), "c3d4.png").Return(nil, errormiddleware.NotFoundError([]string{"file not exists"}, "object c3d4.png not found")).AnyTimes()
+			h.BookService = service
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "http://test", nil)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, params))
+			for k, v := range tt.Headers {
+				r.Header.Set(k, v)
+			}
+
+			errormiddleware.Middleware(tt.Handler)(w, r)
+			assert.Equal(t, tt.ExceptedStatus, w.Result().StatusCode)
+			assert.Equal(t, tt.ExceptedBody, w.Body.String())
+			if tt.ExceptedStatus == http.StatusOK {
+				assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+				assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+				assert.Equal(t, `"a1b2"`, w.Header().Get("ETag"))
+				assert.Equal(t, modTime.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
+func TestServeCoverThumbnail(t *testing.T) {
+	book := &client.Book{Name: "Book name", CoverPath: "c3d4.png", CoverSha256: "c3d4"}
+	params := httprouter.Params{{Key: "id", Value: "000000000000000000000000"}}
+
+	var table = []struct {
+		Name           string
+		Size           string
+		MockBehaviour  func(s *mock.MockService)
+		ExceptedStatus int
+		ExceptedETag   string
+		ExceptedBody   string
+	}{
+		{
+			Name: "thumbnail",
+			Size: "small",
+			MockBehaviour: func(s *mock.MockService) {
+				s.EXPECT().GetFile(gomock.Any(), "c3d4_small.png").Return(&client.File{Body: fileBody{strings.NewReader("small")}, Size: 5}, nil)
+			},
+			ExceptedStatus: http.StatusOK,
+			ExceptedETag:   `"c3d4-small"`,
+			ExceptedBody:   "small",
+		},
+		{
+			Name: "original",
+			MockBehaviour: func(s *mock.MockService) {
+				s.EXPECT().GetFile(gomock.Any(), "c3d4.png").Return(&client.File{Body: fileBody{strings.NewReader("original")}, Size: 8}, nil)
+			},
+			ExceptedStatus: http.StatusOK,
+			ExceptedETag:   `"c3d4"`,
+			ExceptedBody:   "original",
+		},
+		{
+			Name: "cover without thumbnails",
+			Size: "large",
+			MockBehaviour: func(s *mock.MockService) {
+				s.EXPECT().GetFile(gomock.Any(), "c3d4_large.png").Return(nil, errormiddleware.NotFoundError([]string{"file not exists"}, ""))
+				s.EXPECT().GetFile(gomock.Any(), "c3d4.png").Return(&client.File{Body: fileBody{strings.NewReader("original")}, Size: 8}, nil)
+			},
+			ExceptedStatus: http.StatusOK,
+			ExceptedETag:   `"c3d4"`,
+			ExceptedBody:   "original",
+		},
+		{
+			Name:           "wrong size",
+			Size:           "huge",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   `{"messages":["size: must be one of small, medium, large"],"dev_message":"received size huge","code":"IE-0003"}`,
+		},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			service := mock.NewMockService(ctrl)
+			service.EXPECT().GetBook(gomock.Any(), "000000000000000000000000").Return(book, nil)
+			if tt.MockBehaviour != nil {
+				tt.MockBehaviour(service)
+			}
+			h.BookService = service
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "http://test?size="+tt.Size, nil)
+			r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, params))
+
+			errormiddleware.Middleware(h.GetBookCover)(w, r)
+			assert.Equal(t, tt.ExceptedStatus, w.Result().StatusCode)
+			assert.Equal(t, tt.ExceptedBody, w.Body.String())
+			if len(tt.ExceptedETag) > 0 {
+				assert.Equal(t, tt.ExceptedETag, w.Header().Get("ETag"))
+				assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
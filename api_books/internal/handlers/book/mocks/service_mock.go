@@ -50,19 +50,49 @@ func (mr *MockServiceMockRecorder) AddBook(ctx, query interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBook", reflect.TypeOf((*MockService)(nil).AddBook), ctx, query)
 }
 
+// CountGenreBooks mocks base method.
+func (m *MockService) CountGenreBooks(ctx context.Context, ids string) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountGenreBooks", ctx, ids)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountGenreBooks indicates an expected call of CountGenreBooks.
+func (mr *MockServiceMockRecorder) CountGenreBooks(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountGenreBooks", reflect.TypeOf((*MockService)(nil).CountGenreBooks), ctx, ids)
+}
+
+// DeleteBook mocks base method.
+func (m *MockService) DeleteBook(ctx context.Context, id string) (*client.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(*client.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockServiceMockRecorder) DeleteBook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockService)(nil).DeleteBook), ctx, id)
+}
+
 // FindBooks mocks base method.
-func (m *MockService) FindBooks(ctx context.Context, filters map[string]string, offset, limit int) ([]*client.Book, error) {
+func (m *MockService) FindBooks(ctx context.Context, filters map[string]string, cursor string, offset, limit int) (*client.BookPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindBooks", ctx, filters, offset, limit)
-	ret0, _ := ret[0].([]*client.Book)
+	ret := m.ctrl.Call(m, "FindBooks", ctx, filters, cursor, offset, limit)
+	ret0, _ := ret[0].(*client.BookPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindBooks indicates an expected call of FindBooks.
-func (mr *MockServiceMockRecorder) FindBooks(ctx, filters, offset, limit interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) FindBooks(ctx, filters, cursor, offset, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBooks", reflect.TypeOf((*MockService)(nil).FindBooks), ctx, filters, offset, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindBooks", reflect.TypeOf((*MockService)(nil).FindBooks), ctx, filters, cursor, offset, limit)
 }
 
 // GetBook mocks base method.
@@ -80,6 +110,21 @@ func (mr *MockServiceMockRecorder) GetBook(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockService)(nil).GetBook), ctx, id)
 }
 
+// GetFile mocks base method.
+func (m *MockService) GetFile(ctx context.Context, key string) (*client.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", ctx, key)
+	ret0, _ := ret[0].(*client.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockServiceMockRecorder) GetFile(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockService)(nil).GetFile), ctx, key)
+}
+
 // IsBookExists mocks base method.
 func (m *MockService) IsBookExists(ctx context.Context, name string) bool {
 	m.ctrl.T.Helper()
@@ -93,3 +138,18 @@ func (mr *MockServiceMockRecorder) IsBookExists(ctx, name interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBookExists", reflect.TypeOf((*MockService)(nil).IsBookExists), ctx, name)
 }
+
+// UpdateBook mocks base method.
+func (m *MockService) UpdateBook(ctx context.Context, id string, query *client.UpdateBookQuery) (*client.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, id, query)
+	ret0, _ := ret[0].(*client.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockServiceMockRecorder) UpdateBook(ctx, id, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockService)(nil).UpdateBook), ctx, id, query)
+}
@@ -0,0 +1,72 @@
+package rabbitmq
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_books/pkg/logging"
+)
+
+type genre_service interface {
+	OnGenreChanged(ctx context.Context, id string)
+	OnGenreDeleted(ctx context.Context, id string)
+}
+
+// Every instance keeps it's own cache, so queues are exclusive and named by the server
+type GenreReceiver struct {
+	connection *amqp.Connection
+	logger     *logging.Logger
+	channel    *amqp.Channel
+	service    genre_service
+}
+
+func NewGenreReceiver(connection *amqp.Connection, logger *logging.Logger, service genre_service) *GenreReceiver {
+	return &GenreReceiver{
+		connection: connection,
+		logger:     logger,
+		service:    service,
+	}
+}
+func (r *GenreReceiver) Start() {
+	ch, err := r.connection.Channel()
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	r.channel = ch
+
+	r.consume("GenreChangedExchange", r.service.OnGenreChanged)
+	r.consume("GenreDeletedExchange", r.service.OnGenreDeleted)
+	r.logger.Infof("Waiting for genre changes...")
+}
+func (r *GenreReceiver) consume(exchange string, handler func(ctx context.Context, id string)) {
+	queue, err := r.channel.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	err = r.channel.ExchangeDeclare(exchange, "fanout", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	err = r.channel.QueueBind(queue.Name, "#", exchange, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	messages, err := r.channel.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	go func() {
+		for message := range messages {
+			if r.channel.IsClosed() || r.connection.IsClosed() {
+				return
+			}
+			r.logger.Infof("Received message from %s", exchange)
+			handler(context.Background(), string(message.Body))
+		}
+	}()
+}
+func (r *GenreReceiver) Close() error {
+	return r.channel.Close()
+}
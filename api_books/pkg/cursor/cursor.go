@@ -0,0 +1,93 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cursor points to the document on the edge of a page.
+// Clients receive it as opaque string and must not rely on it's contents
+type Cursor struct {
+	// Sort field name
+	Field string `json:"f"`
+	// Sort field value of the document
+	Value interface{} `json:"v"`
+	// Id is used to break ties between documents with equal sort values
+	Id primitive.ObjectID `json:"id"`
+	// Backward cursor points to the previous page
+	Backward bool `json:"b,omitempty"`
+}
+
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+func Decode(cursor string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("cursor is not base64 encoded: %v", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cursor has wrong format: %v", err)
+	}
+	if len(c.Field) == 0 || c.Id.IsZero() {
+		return nil, fmt.Errorf("cursor has no position")
+	}
+	return &c, nil
+}
+
+// Filter selects documents that follow the cursor in it's direction.
+// Ascending is a sort direction of the listing itself
+func (c *Cursor) Filter(ascending bool) bson.M {
+	operator := "$lt"
+	if ascending != c.Backward {
+		operator = "$gt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{c.Field: bson.M{operator: c.Value}},
+		bson.M{c.Field: c.Value, "_id": bson.M{operator: c.Id}},
+	}}
+}
+
+// Sort direction to fetch documents in. Backward pages are fetched in reversed order
+func (c *Cursor) Direction(ascending bool) int {
+	if ascending != (c != nil && c.Backward) {
+		return 1
+	}
+	return -1
+}
+
+// Page trims items fetched with limit+1 and builds cursors around them.
+// Items of backward page are reversed back to listing order.
+// Key must return sort field value and id of the item
+func Page[T any](items []T, limit int, field string, current *Cursor, hasPrevious bool, key func(T) (interface{}, primitive.ObjectID)) ([]T, string, string) {
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	backward := current != nil && current.Backward
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	var next, prev string
+	if more || backward {
+		value, id := key(items[len(items)-1])
+		next = (&Cursor{Field: field, Value: value, Id: id}).Encode()
+	}
+	if (backward && more) || (!backward && (current != nil || hasPrevious)) {
+		value, id := key(items[0])
+		prev = (&Cursor{Field: field, Value: value, Id: id, Backward: true}).Encode()
+	}
+	return items, next, prev
+}
@@ -0,0 +1,256 @@
+package pdf
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Parser of PDF objects. Only syntax needed for metadata is supported, content streams are never parsed
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func (p *parser) object(depth int) (interface{}, error) {
+	if depth > maxNesting {
+		return nil, errTooDeep
+	}
+	p.skipSpaces()
+	if p.pos >= len(p.data) {
+		return nil, errSyntax
+	}
+	switch c := p.data[p.pos]; {
+	case c == '<' && p.peek(1) == '<':
+		p.pos += 2
+		return p.dictionary(depth)
+	case c == '<':
+		p.pos++
+		return p.hexString()
+	case c == '(':
+		p.pos++
+		return p.literalString()
+	case c == '[':
+		p.pos++
+		return p.array(depth)
+	case c == '/':
+		p.pos++
+		return decodeName(p.regular()), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	default:
+		switch word := p.regular(); word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return nil, errSyntax
+		}
+	}
+}
+func (p *parser) dictionary(depth int) (map[name]interface{}, error) {
+	dict := make(map[name]interface{})
+	for {
+		p.skipSpaces()
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return dict, nil
+		}
+		key, err := p.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		keyName, ok := key.(name)
+		if !ok {
+			return nil, errSyntax
+		}
+		value, err := p.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		dict[keyName] = value
+	}
+}
+func (p *parser) array(depth int) ([]interface{}, error) {
+	array := make([]interface{}, 0)
+	for {
+		p.skipSpaces()
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+		value, err := p.object(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+}
+
+// Integer could be the start of indirect reference "number generation R"
+func (p *parser) number() (interface{}, error) {
+	word := p.regular()
+	if integer, err := strconv.Atoi(word); err == nil {
+		if reference, ok := p.reference(integer); ok {
+			return reference, nil
+		}
+		return integer, nil
+	}
+	real, err := strconv.ParseFloat(word, 64)
+	if err != nil {
+		return nil, errSyntax
+	}
+	return real, nil
+}
+func (p *parser) reference(number int) (ref, bool) {
+	start := p.pos
+	p.skipSpaces()
+	generation, err := strconv.Atoi(p.regular())
+	if err == nil {
+		p.skipSpaces()
+		if p.regular() == "R" {
+			return ref{number: number, generation: generation}, true
+		}
+	}
+	p.pos = start
+	return ref{}, false
+}
+func (p *parser) literalString() ([]byte, error) {
+	var result bytes.Buffer
+	nesting := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			nesting++
+		case ')':
+			nesting--
+			if nesting == 0 {
+				return result.Bytes(), nil
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, errSyntax
+			}
+			c = p.data[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				result.WriteByte('\n')
+			case 'r':
+				result.WriteByte('\r')
+			case 't':
+				result.WriteByte('\t')
+			case 'b':
+				result.WriteByte('\b')
+			case 'f':
+				result.WriteByte('\f')
+			case '\r':
+				// escaped end of line is a line continuation
+				if p.peek(0) == '\n' {
+					p.pos++
+				}
+			case '\n':
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				value := int(c - '0')
+				for i := 0; i < 2 && p.peek(0) >= '0' && p.peek(0) <= '7'; i++ {
+					value = value*8 + int(p.data[p.pos]-'0')
+					p.pos++
+				}
+				result.WriteByte(byte(value))
+			default:
+				result.WriteByte(c)
+			}
+			continue
+		}
+		result.WriteByte(c)
+	}
+	return nil, errSyntax
+}
+func (p *parser) hexString() ([]byte, error) {
+	digits := make([]byte, 0)
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch {
+		case c == '>':
+			// missing last digit is assumed to be 0
+			if len(digits)%2 == 1 {
+				digits = append(digits, '0')
+			}
+			result := make([]byte, len(digits)/2)
+			for i := range result {
+				value, err := strconv.ParseUint(string(digits[i*2:i*2+2]), 16, 8)
+				if err != nil {
+					return nil, errSyntax
+				}
+				result[i] = byte(value)
+			}
+			return result, nil
+		case isSpace(c):
+		default:
+			digits = append(digits, c)
+		}
+	}
+	return nil, errSyntax
+}
+
+// Names could contain #xx escaped characters
+func decodeName(s string) name {
+	if !strings.Contains(s, "#") {
+		return name(s)
+	}
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '#' && i+2 < len(s) {
+			if value, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				result.WriteByte(byte(value))
+				i += 2
+				continue
+			}
+		}
+		result.WriteByte(s[i])
+	}
+	return name(result.String())
+}
+
+// Reads characters until whitespace or delimiter
+func (p *parser) regular() string {
+	start := p.pos
+	for p.pos < len(p.data) && !isSpace(p.data[p.pos]) && !isDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// Comments are skipped as whitespace
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case isSpace(c):
+			p.pos++
+		case c == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+func (p *parser) peek(offset int) byte {
+	if p.pos+offset >= len(p.data) {
+		return 0
+	}
+	return p.data[p.pos+offset]
+}
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+func isDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
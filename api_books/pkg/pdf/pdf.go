@@ -0,0 +1,236 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Metadata of PDF document. Title is empty if document has no title or it's encrypted
+type Metadata struct {
+	Pages int
+	Title string
+}
+
+const (
+	maxNesting  = 64
+	maxInflated = 64 << 20
+)
+
+var (
+	ErrNotPDF  = errors.New("file is not a pdf document")
+	ErrNoPages = errors.New("pdf document has no page tree")
+	errSyntax  = errors.New("pdf syntax error")
+	errTooDeep = errors.New("pdf object nesting is too deep")
+
+	objectHeader  = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	trailerHeader = regexp.MustCompile(`trailer\s*<<`)
+	streamStart   = regexp.MustCompile(`^\s*stream\r?\n`)
+)
+
+// Object types. Dictionaries are maps, arrays are slices, strings are []byte
+type (
+	name string
+	ref  struct{ number, generation int }
+)
+
+// Reads page count and title of the document.
+// Objects are collected from the whole file including compressed object streams,
+// so the xref table is not needed and damaged documents are still readable
+func Parse(r io.Reader) (*Metadata, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+	doc := &document{objects: make(map[int]interface{})}
+	doc.read(data)
+
+	meta := &Metadata{}
+	pages, ok := doc.pageCount()
+	if !ok {
+		return nil, ErrNoPages
+	}
+	meta.Pages = pages
+	if doc.encrypted {
+		return meta, nil
+	}
+	if info, ok := doc.resolve(doc.info).(map[name]interface{}); ok {
+		if title, ok := doc.resolve(info["Title"]).([]byte); ok {
+			meta.Title = decodeText(title)
+		}
+	}
+	return meta, nil
+}
+
+type document struct {
+	// later definitions override earlier ones, as incremental updates are appended to the file
+	objects   map[int]interface{}
+	info      interface{}
+	encrypted bool
+}
+
+func (d *document) read(data []byte) {
+	for _, match := range objectHeader.FindAllSubmatchIndex(data, -1) {
+		number, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		p := &parser{data: data, pos: match[1]}
+		object, err := p.object(0)
+		if err != nil {
+			continue
+		}
+		dict, ok := object.(map[name]interface{})
+		if ok {
+			if stream := streamStart.Find(data[p.pos:]); stream != nil {
+				start := p.pos + len(stream)
+				if end := bytes.Index(data[start:], []byte("endstream")); end >= 0 {
+					d.readStream(dict, data[start:start+end])
+				}
+			}
+		}
+		d.objects[number] = object
+	}
+	for _, match := range trailerHeader.FindAllIndex(data, -1) {
+		p := &parser{data: data, pos: match[1] - 2}
+		if object, err := p.object(0); err == nil {
+			d.readTrailer(object)
+		}
+	}
+}
+
+// Object streams contain other objects, xref streams contain trailer entries
+func (d *document) readStream(dict map[name]interface{}, raw []byte) {
+	switch dict["Type"] {
+	case name("XRef"):
+		d.readTrailer(dict)
+	case name("ObjStm"):
+		content, err := decodeStream(dict, raw)
+		if err != nil {
+			return
+		}
+		count, _ := dict["N"].(int)
+		first, _ := dict["First"].(int)
+		if first <= 0 || first > len(content) {
+			return
+		}
+		header := &parser{data: content[:first]}
+		for i := 0; i < count; i++ {
+			number, err := header.object(0)
+			if err != nil {
+				return
+			}
+			offset, err := header.object(0)
+			if err != nil {
+				return
+			}
+			n, ok := number.(int)
+			o, ok2 := offset.(int)
+			if !ok || !ok2 || first+o >= len(content) {
+				return
+			}
+			p := &parser{data: content, pos: first + o}
+			if object, err := p.object(0); err == nil {
+				d.objects[n] = object
+			}
+		}
+	}
+}
+func (d *document) readTrailer(object interface{}) {
+	dict, ok := object.(map[name]interface{})
+	if !ok {
+		return
+	}
+	if info, ok := dict["Info"]; ok {
+		d.info = info
+	}
+	if _, ok := dict["Encrypt"]; ok {
+		d.encrypted = true
+	}
+}
+
+// Page count is taken from the root of the page tree. If the root can't be found, the largest node is used
+func (d *document) pageCount() (int, bool) {
+	largest, found := 0, false
+	for _, object := range d.objects {
+		dict, ok := object.(map[name]interface{})
+		if !ok || dict["Type"] != name("Pages") {
+			continue
+		}
+		count, ok := d.resolve(dict["Count"]).(int)
+		if !ok || count < 0 {
+			continue
+		}
+		if _, hasParent := dict["Parent"]; !hasParent {
+			return count, true
+		}
+		if count > largest || !found {
+			largest, found = count, true
+		}
+	}
+	return largest, found
+}
+func (d *document) resolve(object interface{}) interface{} {
+	for i := 0; i < maxNesting; i++ {
+		reference, ok := object.(ref)
+		if !ok {
+			return object
+		}
+		object = d.objects[reference.number]
+	}
+	return nil
+}
+
+func decodeStream(dict map[name]interface{}, raw []byte) ([]byte, error) {
+	switch filter := dict["Filter"].(type) {
+	case nil:
+		return raw, nil
+	case name:
+		if filter != "FlateDecode" {
+			return nil, fmt.Errorf("unsupported stream filter %s", filter)
+		}
+	case []interface{}:
+		if len(filter) != 1 || filter[0] != name("FlateDecode") {
+			return nil, fmt.Errorf("unsupported stream filters %v", filter)
+		}
+	default:
+		return nil, errSyntax
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, maxInflated))
+	// streams are often cut short by a trailing end of line, so the data that was read is still used
+	if len(content) > 0 {
+		return content, nil
+	}
+	return nil, err
+}
+
+// Text strings are either UTF-16BE with byte order mark, UTF-8 with byte order mark or PDFDocEncoding.
+// PDFDocEncoding is decoded as Latin-1, which matches it for printable characters
+func decodeText(s []byte) string {
+	switch {
+	case len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF:
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	case bytes.HasPrefix(s, []byte{0xEF, 0xBB, 0xBF}):
+		return string(s[3:])
+	default:
+		runes := make([]rune, len(s))
+		for i, b := range s {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+}
@@ -0,0 +1,116 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const classicPDF = `%PDF-1.4
+%âãÏÓ
+1 0 obj
+<< /Type /Catalog /Pages 2 0 R >>
+endobj
+2 0 obj
+<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 3 >>
+endobj
+3 0 obj
+<< /Type /Pages /Parent 2 0 R /Kids [5 0 R 6 0 R] /Count 2 >>
+endobj
+4 0 obj
+<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>
+endobj
+5 0 obj
+<< /Type /Page /Parent 3 0 R /Resources << /Font << /F1 7 0 R >> >> >>
+endobj
+6 0 obj
+<< /Type /Page /Parent 3 0 R >>
+endobj
+7 0 obj
+<< /Length 8 >>
+stream
+BT ET ()
+endstream
+endobj
+8 0 obj
+<< /Title (Book \(second edition\)\041) /Author (Author) >>
+endobj
+trailer
+<< /Size 9 /Root 1 0 R /Info 8 0 R >>
+%%EOF
+`
+
+// Document with page tree and info in compressed object stream, referenced from xref stream
+func compressedPDF(t *testing.T) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 120 >>",
+		"<< /Title <FEFF041A043D0438043304300020D83DDCD6> >>",
+	}
+	var header, body strings.Builder
+	for i, v := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(v + "\n")
+	}
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte(header.String() + body.String()))
+	writer.Close()
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.5\n")
+	fmt.Fprintf(&document, "4 0 obj\n<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n", len(objects), header.Len(), compressed.Len())
+	document.Write(compressed.Bytes())
+	document.WriteString("\nendstream\nendobj\n")
+	document.WriteString("5 0 obj\n<< /Type /XRef /Root 1 0 R /Info 3 0 R /Size 6 /W [1 2 1] /Length 0 >>\nstream\n\nendstream\nendobj\n%%EOF\n")
+	return document.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	var table = []struct {
+		Name          string
+		Document      []byte
+		ExceptedPages int
+		ExceptedTitle string
+		ExceptedError error
+	}{
+		{"classic document", []byte(classicPDF), 3, "Book (second edition)!", nil},
+		{"object streams", compressedPDF(t), 120, "Книга 📖", nil},
+		{"encrypted document", []byte(strings.Replace(classicPDF, "/Info 8 0 R", "/Info 8 0 R /Encrypt 9 0 R", 1)), 3, "", nil},
+		{"no title", []byte(strings.Replace(classicPDF, "/Info 8 0 R", "", 1)), 3, "", nil},
+		{"not a pdf", []byte("\x89PNG\r\n\x1a\n"), 0, "", ErrNotPDF},
+		{"no pages", []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n"), 0, "", ErrNoPages},
+		{"broken syntax", []byte("%PDF-1.4\n1 0 obj\n<< /Type /Pages /Count 2 /Kids [ (unclosed"), 0, "", ErrNoPages},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			meta, err := Parse(bytes.NewReader(tt.Document))
+			assert.Equal(t, tt.ExceptedError, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.ExceptedPages, meta.Pages)
+			assert.Equal(t, tt.ExceptedTitle, meta.Title)
+		})
+	}
+}
+func TestParseObjects(t *testing.T) {
+	p := &parser{data: []byte(`<< /A [1 -2.5 true null 3 0 R] /B <48 65 6c6c6f> /C (a\nb\
+c) /D << /E /F#20 >> >>`)}
+	object, err := p.object(0)
+	assert.NoError(t, err)
+	assert.Equal(t, map[name]interface{}{
+		"A": []interface{}{1, -2.5, true, nil, ref{number: 3}},
+		"B": []byte("Hello"),
+		"C": []byte("a\nbc"),
+		"D": map[name]interface{}{"E": name("F ")},
+	}, object)
+
+	nested := &parser{data: bytes.Repeat([]byte("["), maxNesting+2)}
+	_, err = nested.object(0)
+	assert.Equal(t, errTooDeep, err)
+}
@@ -0,0 +1,84 @@
+package thumbnail
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// Images with more pixels are rejected before decoding, so small compressed file can't take all the memory
+const MaxPixels = 40_000_000
+
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// Scales image down so it's longest side is at most maxSide pixels. Aspect ratio is kept and images are never scaled up.
+// Every pixel of the result is an average of the source area it covers
+func Fit(src image.Image, maxSide int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			width, height = maxSide, max(1, height*maxSide/bounds.Dx())
+		} else {
+			width, height = max(1, width*maxSide/bounds.Dy()), maxSide
+		}
+	}
+	// colors are premultiplied, so transparent pixels don't darken the edges
+	source := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(source, source.Bounds(), src, bounds.Min, draw.Src)
+	if width == bounds.Dx() && height == bounds.Dy() {
+		return source
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		top, bottom := span(y, height, bounds.Dy())
+		for x := 0; x < width; x++ {
+			left, right := span(x, width, bounds.Dx())
+			var r, g, b, a, count int
+			for sy := top; sy < bottom; sy++ {
+				row := source.Pix[sy*source.Stride+left*4 : sy*source.Stride+right*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					a += int(row[i+3])
+				}
+				count += right - left
+			}
+			offset := y*result.Stride + x*4
+			result.Pix[offset] = uint8(r / count)
+			result.Pix[offset+1] = uint8(g / count)
+			result.Pix[offset+2] = uint8(b / count)
+			result.Pix[offset+3] = uint8(a / count)
+		}
+	}
+	return result
+}
+
+// Source pixels covered by destination pixel. Span is never empty
+func span(i, scaled, original int) (int, int) {
+	from, to := i*original/scaled, (i+1)*original/scaled
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// Decodes jpeg or png image. Dimensions are checked before the image is decoded
+func Decode(r io.ReadSeeker) (image.Image, string, error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, "", ErrTooLarge
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	return image.Decode(r)
+}
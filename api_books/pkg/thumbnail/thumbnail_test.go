@@ -0,0 +1,64 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFit(t *testing.T) {
+	var table = []struct {
+		Name           string
+		Width, Height  int
+		MaxSide        int
+		ExceptedWidth  int
+		ExceptedHeight int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 300, 900, 300, 100, 300},
+		{"square", 50, 50, 10, 10, 10},
+		{"not scaled up", 80, 60, 100, 80, 60},
+		{"thin line", 1000, 1, 100, 100, 1},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, tt.Width, tt.Height))
+			result := Fit(src, tt.MaxSide)
+			assert.Equal(t, tt.ExceptedWidth, result.Bounds().Dx())
+			assert.Equal(t, tt.ExceptedHeight, result.Bounds().Dy())
+		})
+	}
+}
+func TestFitAverages(t *testing.T) {
+	// black and white columns become gray
+	src := image.NewRGBA(image.Rect(10, 10, 14, 12))
+	for x := 10; x < 14; x++ {
+		for y := 10; y < 12; y++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+	result := Fit(src, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), result.Bounds())
+	assert.Equal(t, color.RGBA{127, 127, 127, 255}, result.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{127, 127, 127, 255}, result.RGBAAt(1, 0))
+}
+func TestDecode(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 3, 2)))
+
+	img, format, err := Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 3, img.Bounds().Dx())
+
+	_, _, err = Decode(bytes.NewReader([]byte("not an image")))
+	assert.Equal(t, image.ErrFormat, err)
+}
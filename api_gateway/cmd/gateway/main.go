@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,16 +13,29 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	_ "github.com/reversersed/go-web-services/tree/main/api_gateway/docs"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/author"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/book"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/genre"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/notification"
 	user "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/user"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/config"
+	ah "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/author"
 	bh "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/book"
 	gh "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/genre"
+	kh "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/keys"
+	nh "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/notification"
 	auth "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/user"
-	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/cache/freecache"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/mongo"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/oidc"
+	RabbitClient "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rabbitmq"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/ratelimit"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/shutdown"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/stream"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 	"github.com/rs/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -49,14 +63,43 @@ func main() {
 	logger.Info("router initializing...")
 	router := httprouter.New()
 
-	logger.Info("cache initializing...")
-	cache := freecache.NewCache(104857600) // 100 mb
+	logger.Infof("session store initializing (%s)...", config.Session.Backend)
+	var sessions session.Store
+	switch config.Session.Backend {
+	case "mongo":
+		db_client, err := mongo.NewClient(context.Background(), config.Database)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		sessions = session.NewMongoStore(db_client, "sessions", logger)
+	case "memory":
+		sessions = session.NewMemoryStore()
+	default:
+		logger.Fatalf("unknown session backend: %s", config.Session.Backend)
+	}
+
+	logger.Info("rabbitmq initializing...")
+	rabbit, err := RabbitClient.New(config.Rabbit, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
 
 	logger.Info("validator initializing...")
 	validator := validator.New()
 
 	logger.Info("services initializing....")
-	jwtService := jwt.NewService(cache, logger, validator, config.Jwt.SecretToken)
+	keys, err := jwt.LoadKeySet(config.Jwt.KeysDir, config.Jwt.SigningKey)
+	if err != nil {
+		logger.Fatalf("can't load jwt keys: %v", err)
+	}
+	jwtService := jwt.NewService(sessions, logger, validator, keys)
+
+	userReceiver := rabbitmq.NewUserReceiver(rabbit.Connection, logger, jwtService)
+	userReceiver.Start()
+
+	hub := stream.NewHub(16)
+	notificationReceiver := rabbitmq.NewNotificationReceiver(rabbit.Connection, logger, hub)
+	notificationReceiver.Start()
 
 	logger.Info("handlers registration...")
 	//swagger
@@ -67,20 +110,43 @@ func main() {
 		})
 	}
 
-	user_service := user.NewService(config.Urls.UserServiceURL, "/users", logger)
-	user_handler := &auth.Handler{Logger: logger, UserService: user_service, JwtService: jwtService, Validator: validator}
+	signer := &signature.Signer{Service: config.Signature.Service, Secret: config.Signature.Secret}
+
+	key_handler := &kh.Handler{Logger: logger, KeyService: keys}
+	key_handler.Register(router)
+
+	user_service := user.NewService(config.Urls.UserServiceURL, "/users", logger, signer)
+	user_handler := &auth.Handler{Logger: logger, UserService: user_service, JwtService: jwtService, Validator: validator, PasswordLimiter: ratelimit.New(5, 15*time.Minute)}
+	if len(config.Oidc.Issuer) > 0 {
+		user_handler.Oidc = oidc.NewProvider(&oidc.Config{
+			Name:         config.Oidc.Provider,
+			Issuer:       config.Oidc.Issuer,
+			ClientId:     config.Oidc.ClientId,
+			ClientSecret: config.Oidc.ClientSecret,
+			RedirectURL:  config.Oidc.RedirectURL,
+		}, nil)
+		user_handler.OidcRedirect = config.Oidc.SuccessRedirect
+	}
 	user_handler.Register(router)
 
-	book_service := book.NewService(config.Urls.BookServiceURL, "/books", logger)
+	book_service := book.NewService(config.Urls.BookServiceURL, "/books", logger, signer)
 	book_handler := &bh.Handler{Logger: logger, BookService: book_service, JwtService: jwtService, Validator: validator}
 	book_handler.Register(router)
 
-	genre_service := book.NewService(config.Urls.GenresServiceURL, "/genres", logger)
+	genre_service := genre.NewService(config.Urls.GenresServiceURL, "/genres", logger, signer)
 	genre_handler := &gh.Handler{Logger: logger, GenreService: genre_service, JwtService: jwtService, Validator: validator}
 	genre_handler.Register(router)
 
+	author_service := author.NewService(config.Urls.AuthorsServiceURL, "/authors", logger, signer)
+	author_handler := &ah.Handler{Logger: logger, AuthorService: author_service, JwtService: jwtService, Validator: validator}
+	author_handler.Register(router)
+
+	notification_service := notification.NewService(config.Urls.NotifServiceURL, "/notifications", logger, signer)
+	notification_handler := &nh.Handler{Logger: logger, NotificationService: notification_service, JwtService: jwtService, Hub: hub}
+	notification_handler.Register(router)
+
 	logger.Info("starting application...")
-	start(router, logger, config.Server)
+	start(router, logger, config.Server, hub, rabbit, userReceiver, notificationReceiver)
 }
 
 func start(router *httprouter.Router, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
@@ -18,30 +18,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/books": {
+        "/authors": {
             "get": {
-                "description": "Author and genres are fetching from another microservices and then storing in cache\nIf it's impossible to fetch author or genres, the field will be null",
+                "description": "You can use multiple ids in query using , separator\nExample: ?id=id1,id2,id3...",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "authors"
                 ],
-                "summary": "Finds a books by filters",
+                "summary": "Get authors by id",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "0",
-                        "description": "Offset to books. Must be present, starting with 0",
-                        "name": "offset",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "example": "15",
-                        "description": "Max amount of docs to return. Must be greater than 0",
-                        "name": "limit",
+                        "description": "Author IDs",
+                        "name": "id",
                         "in": "query",
                         "required": true
                     }
@@ -52,18 +43,18 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/book.Book"
+                                "$ref": "#/definitions/author.Author"
                             }
                         }
                     },
                     "400": {
-                        "description": "Returns if query was incorrect",
+                        "description": "Return's if received bad request",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "404": {
-                        "description": "Returns if there are no documents found",
+                        "description": "Return's if author was not found",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -82,84 +73,34 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Requires admin role to use",
+                "description": "Requires authors:write permission to use",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "authors"
                 ],
-                "summary": "Creates a new book",
+                "summary": "Adds an author",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "primitive object id to author of book",
-                        "name": "authorid",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "format": "binary",
-                        "description": "Must be an image file to book cover",
-                        "name": "cover",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "format": "binary",
-                        "description": "Must be a .pdf file to book",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Array of genre's Id's (must be primitive object id)",
-                        "name": "genres",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "maxLength": 32,
-                        "minLength": 4,
-                        "type": "string",
-                        "description": "Book's name. Must be unique",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "maximum": 5000,
-                        "type": "integer",
-                        "description": "Total number of pages in pdf file",
-                        "name": "pages",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "maximum": 2100,
-                        "minimum": 1400,
-                        "type": "integer",
-                        "name": "year",
-                        "in": "formData",
-                        "required": true
+                        "description": "Author's data",
+                        "name": "Author",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/author.AddAuthorQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Successful response. Added book",
+                        "description": "Successful response. Added author",
                         "schema": {
-                            "$ref": "#/definitions/book.Book"
+                            "$ref": "#/definitions/author.Author"
                         }
                     },
                     "400": {
-                        "description": "Return's if handler received wrong content-type",
+                        "description": "Return's if death year is less than birth year",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -191,26 +132,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/books/{id}": {
+        "/authors/all": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "books"
+                    "authors"
                 ],
-                "summary": "Get a book by id",
+                "summary": "Get all authors sorted by name",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Book Id",
-                        "name": "id",
-                        "in": "path",
+                        "example": "0",
+                        "description": "Offset to authors. Must be present, starting with 0",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "15",
+                        "description": "Max amount of docs to return. Must be greater than 0",
+                        "name": "limit",
+                        "in": "query",
                         "required": true
                     }
                 ],
@@ -218,17 +163,20 @@ const docTemplate = `{
                     "200": {
                         "description": "Successful response",
                         "schema": {
-                            "$ref": "#/definitions/book.Book"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/author.Author"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Return's if handler received wrong content-type",
+                        "description": "Returns if query was incorrect",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "404": {
-                        "description": "Return's if book is not exists",
+                        "description": "Return's if service does not have data",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -242,21 +190,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/genres": {
+        "/authors/search": {
             "get": {
-                "description": "You can use multiple ids in query using , separator\nExample: ?id=id1,id2,id3...",
+                "description": "Search is case-insensitive and matches any part of the name",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "genres"
+                    "authors"
                 ],
-                "summary": "Get genres by id",
+                "summary": "Search authors by name",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Genre IDs",
-                        "name": "id",
+                        "description": "Part of author's name",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0",
+                        "description": "Offset to authors. Must be present, starting with 0",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "15",
+                        "description": "Max amount of docs to return. Must be greater than 0",
+                        "name": "limit",
                         "in": "query",
                         "required": true
                     }
@@ -267,18 +231,18 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/genre.Genre"
+                                "$ref": "#/definitions/author.Author"
                             }
                         }
                     },
                     "400": {
-                        "description": "Return's if received bad request",
+                        "description": "Returns if query was incorrect",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "404": {
-                        "description": "Return's if genre was not found",
+                        "description": "Return's if no authors were found",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -290,41 +254,35 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/authors/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Requires admin role to use",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Requires authors:write permission to use",
                 "tags": [
-                    "genres"
+                    "authors"
                 ],
-                "summary": "Adds a genre",
+                "summary": "Deletes an author",
                 "parameters": [
                     {
-                        "description": "Genre name",
-                        "name": "Genre",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/genre.AddGenreQuery"
-                        }
+                        "type": "string",
+                        "description": "Author Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successful response. Added genre",
-                        "schema": {
-                            "$ref": "#/definitions/genre.Genre"
-                        }
+                    "204": {
+                        "description": "Successful response"
                     },
                     "400": {
-                        "description": "Return's if request body was empty",
+                        "description": "Return's if id is wrong",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -341,42 +299,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "500": {
-                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
-                        "schema": {
-                            "$ref": "#/definitions/errormiddleware.Error"
-                        }
-                    },
-                    "501": {
-                        "description": "Returns if query was incorrect",
-                        "schema": {
-                            "$ref": "#/definitions/errormiddleware.Error"
-                        }
-                    }
-                }
-            }
-        },
-        "/genres/all": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "genres"
-                ],
-                "summary": "Get all genres stored in database",
-                "responses": {
-                    "200": {
-                        "description": "Successful response",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/genre.Genre"
-                            }
-                        }
-                    },
                     "404": {
-                        "description": "Return's if service does not have data",
+                        "description": "Return's if author was not found",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -388,54 +312,78 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users": {
-            "get": {
-                "description": "Get user using Id or login, both params are optional, but one of them is necessary",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires authors:write permission to use\nOnly provided fields will be changed",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "authors"
                 ],
-                "summary": "Finds user by id or login",
+                "summary": "Updates an author",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User id",
+                        "description": "Author Id",
                         "name": "id",
-                        "in": "query"
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "example": "admin",
-                        "description": "User login",
-                        "name": "login",
-                        "in": "query"
+                        "description": "Fields to update",
+                        "name": "Author",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/author.UpdateAuthorQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "Successful response. Updated author",
                         "schema": {
-                            "$ref": "#/definitions/user.User"
+                            "$ref": "#/definitions/author.Author"
                         }
                     },
                     "400": {
-                        "description": "Returns when service didn't get a parameters",
+                        "description": "Return's if id is wrong or death year is less than birth year",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "404": {
-                        "description": "Returns when service can't find user by provided credentials (user not found)",
+                        "description": "Return's if author was not found",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "500": {
-                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -443,30 +391,2143 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/auth": {
+        "/books": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+                "description": "Author and genres are fetching from another microservices and then storing in cache\nIf it's impossible to fetch author or genres, the field will be null",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Finds a books by filters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "15",
+                        "description": "Max amount of docs to return. Must be greater than 0",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor to the next or previous page, taken from previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "0",
+                        "description": "Offset to books, starting with 0. Ignored when cursor is present",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author id",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Genre ids separated by ,",
+                        "name": "genres",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Whether book must have any or all of provided genres",
+                        "name": "genresmode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimal year of book",
+                        "name": "yearfrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal year of book",
+                        "name": "yearto",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimal number of pages",
+                        "name": "pagesfrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximal number of pages",
+                        "name": "pagesto",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Book's name prefix. Case-insensitive",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search by book's name and author's name",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "year",
+                            "pages"
+                        ],
+                        "type": "string",
+                        "default": "name",
+                        "description": "Field to sort by",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sorting direction",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Empty page is returned when nothing is found",
+                        "schema": {
+                            "$ref": "#/definitions/book.BookPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns if query or cursor was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires books:write permission to use",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Creates a new book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "primitive object id to author of book",
+                        "name": "authorid",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "binary",
+                        "description": "Must be an image file to book cover",
+                        "name": "cover",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "binary",
+                        "description": "Must be a .pdf file to book",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Array of genre's Id's (must be primitive object id)",
+                        "name": "genres",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "maxLength": 32,
+                        "minLength": 4,
+                        "type": "string",
+                        "description": "Book's name. Must be unique. Document's title is used if it's omitted",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "maximum": 5000,
+                        "type": "integer",
+                        "description": "Total number of pages in pdf file. Read from the document if it's omitted, otherwise must match it",
+                        "name": "pages",
+                        "in": "formData"
+                    },
+                    {
+                        "maximum": 2100,
+                        "minimum": 1400,
+                        "type": "integer",
+                        "name": "year",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successful response. Added book",
+                        "schema": {
+                            "$ref": "#/definitions/book.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if handler received wrong content-type or file content doesn't match it's extension",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if book's name already taken",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Return's if file or cover exceeds the size limit",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Get a book by id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/book.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if handler received wrong content-type",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if book is not exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires books:write permission to use\nBook's files are removed too",
+                "tags": [
+                    "books"
+                ],
+                "summary": "Deletes a book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response"
+                    },
+                    "400": {
+                        "description": "Return's if id is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if book is not exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires books:write permission to use\nAll fields are optional. Uploaded files replace the current ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Updates a book",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "primitive object id to author of book",
+                        "name": "authorid",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "format": "binary",
+                        "description": "Image file that will replace current cover",
+                        "name": "cover",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "format": "binary",
+                        "description": ".pdf file that will replace current one",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Array of genre's Id's (must be primitive object id)",
+                        "name": "genres",
+                        "in": "formData"
+                    },
+                    {
+                        "maxLength": 32,
+                        "minLength": 4,
+                        "type": "string",
+                        "description": "Book's name. Must be unique",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "maximum": 5000,
+                        "type": "integer",
+                        "description": "Total number of pages in pdf file. Must match the document if it's uploaded too",
+                        "name": "pages",
+                        "in": "formData"
+                    },
+                    {
+                        "maximum": 2100,
+                        "minimum": 1400,
+                        "type": "integer",
+                        "name": "year",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Updated book",
+                        "schema": {
+                            "$ref": "#/definitions/book.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if handler received wrong content-type or file content doesn't match it's extension",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if book is not exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if new book's name already taken",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "413": {
+                        "description": "Return's if file or cover exceeds the size limit",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/cover": {
+            "get": {
+                "description": "Supports Range requests. ETag and Last-Modified headers can be used for conditional requests",
+                "produces": [
+                    "image/png",
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Downloads book's cover",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "small",
+                            "medium",
+                            "large"
+                        ],
+                        "type": "string",
+                        "description": "Thumbnail size. Original cover is returned if it's omitted",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cover image",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Requested part of the image",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Cover was not modified"
+                    },
+                    "400": {
+                        "description": "Return's if size is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if book or it's cover is not exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested range is not satisfiable"
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/file": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Supports Range requests, so viewers can load file partially\nETag and Last-Modified headers can be used for conditional requests",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "books"
+                ],
+                "summary": "Downloads book's pdf file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Book Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "bytes=0-1023",
+                        "description": "Bytes range to load",
+                        "name": "Range",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Book file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "206": {
+                        "description": "Requested part of the file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "File was not modified"
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if book or it's file is not exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "416": {
+                        "description": "Requested range is not satisfiable"
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres": {
+            "get": {
+                "description": "You can use multiple ids and slugs in query using , separator\nExample: ?id=id1,fairy-tales,id3...",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Get genres by id or slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre IDs or slugs",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include amount of books in every genre",
+                        "name": "counts",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/genre.Genre"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if received bad request",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if genre was not found",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires genres:write permission to use",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Adds a genre",
+                "parameters": [
+                    {
+                        "description": "Genre data",
+                        "name": "Genre",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/genre.AddGenreQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successful response. Added genre",
+                        "schema": {
+                            "$ref": "#/definitions/genre.Genre"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if request body was empty",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if genre with this slug already exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/all": {
+            "get": {
+                "description": "Genres are sorted by name\nWith tree=true root genres are returned as items with nested children, limit and cursor are ignored",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Get all genres stored in database",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "15",
+                        "description": "Max amount of genres to return. 50 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor to the next or previous page, taken from previous response",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return genres as a tree",
+                        "name": "tree",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include amount of books in every genre",
+                        "name": "counts",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/genre.GenrePage"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns if query or cursor was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires genres:write permission to use\nGenre that is used by books can be deleted only with cascade=true, then it's removed from these books\nChildren of deleted genre are moved to it's parent",
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Deletes a genre",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Remove genre from books that use it",
+                        "name": "cascade",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response"
+                    },
+                    "400": {
+                        "description": "Return's if id or cascade is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if genre was not found",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if genre is used by books and cascade is not set",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires genres:write permission to use\nOnly provided fields will be changed. Slug is kept when genre is renamed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "genres"
+                ],
+                "summary": "Updates a genre",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Genre Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "Genre",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/genre.UpdateGenreQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Updated genre",
+                        "schema": {
+                            "$ref": "#/definitions/genre.Genre"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if id, slug or parent is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns when user has no rights to use this handler",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if genre was not found",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if genre with this slug already exists",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Notifications are sorted from newest to oldest",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get user's notifications",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "example": 15,
+                        "description": "Max amount of notifications to return, up to 100",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Amount of notifications to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "info",
+                            "warn",
+                            "security"
+                        ],
+                        "type": "string",
+                        "description": "Notification type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Return only notifications sent after the one with this id",
+                        "name": "after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/notification.NotificationPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns if limit or offset is not a number",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Marks all user's notifications as read",
+                "responses": {
+                    "204": {
+                        "description": "Successful response"
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Marks notification as read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response"
+                    },
+                    "400": {
+                        "description": "Return's if id is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if notification was not found",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events stream, every event is a notification with it's id as event id\nComments are sent periodically to keep connection alive\nNotifications missed after the event from Last-Event-ID header (or lastEventId query) are sent first.\nIf more than 100 notifications were missed, they are not sent, reset event with amount of them is sent instead and client should get them again from the list",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Stream of new user's notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the last received notification",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the last received notification, used when header can't be set",
+                        "name": "lastEventId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of notifications",
+                        "schema": {
+                            "$ref": "#/definitions/notification.Notification"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if last event id was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/unread": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get amount of unread notifications",
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/notification.UnreadCount"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Deletes a notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification Id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response"
+                    },
+                    "400": {
+                        "description": "Return's if id is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "User is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if notification was not found",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Get user using Id or login, both params are optional, but one of them is necessary",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Finds user by id or login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "admin",
+                        "description": "User login",
+                        "name": "login",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns when service didn't get a parameters",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns when service can't find user by provided credentials (user not found)",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Requires current password and the code from authenticator app or one of recovery codes",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Disables two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Password and the code",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.DisableTwoFactorQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response. Two-factor authentication is disabled"
+                    },
+                    "400": {
+                        "description": "Return's if password is wrong or two-factor authentication is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user or the code is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/enable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Verifies the first code from authenticator app. Recovery codes are returned only once and must be saved by user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Enables two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Code from authenticator app",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.TwoFactorCodeQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Two-factor authentication is enabled",
+                        "schema": {
+                            "$ref": "#/definitions/user.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if code is wrong or enrollment was not started",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generates a new TOTP secret. URI should be shown to user as QR code for authenticator app\nTwo-factor authentication is enabled only after the first code is verified",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Starts two-factor authentication enrollment",
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Returns secret and otpauth URI",
+                        "schema": {
+                            "$ref": "#/definitions/user.TwoFactorEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if two-factor authentication is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/auth": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Authorizes user's credentials by token. This needs to check if user's token is valid or get current authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Authorizes user",
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Returns user's login, roles and personal token and refresh token. Refresh token belongs to server-side session",
+                        "schema": {
+                            "$ref": "#/definitions/user.JwtResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Returns if user not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Finds user by login and password\nSets token to cookies\nLogin field can be provided with user login or email\nIf user has two-factor authentication enabled, no cookies are set. Returned pre-auth token must be sent to /users/auth/2fa with the code",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Authenticates user",
+                "parameters": [
+                    {
+                        "description": "User credentials",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UserAuthQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Returns user's login and roles",
+                        "schema": {
+                            "$ref": "#/definitions/user.JwtResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Password is correct, second factor is required",
+                        "schema": {
+                            "$ref": "#/definitions/user.PreAuthResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns when service can't find user by provided credentials (user not found)",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Returns when there were too many failed attempts from the account or ip. Message contains how long to wait",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns when provided data was not validated",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/auth/2fa": {
+            "post": {
+                "description": "Exchanges pre-auth token and the code for user's tokens. Sets token to cookies\nCode can be taken from authenticator app or be one of recovery codes, each recovery code works only once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Completes two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Pre-auth token and the code",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.TwoFactorAuthQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Returns user's login and roles",
+                        "schema": {
+                            "$ref": "#/definitions/user.JwtResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Returns when pre-auth token is expired or the code is wrong",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "423": {
+                        "description": "Returns when there were too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns when provided data was not validated",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/changename": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "New login must be unique. Login changing are available only 1 time per month",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user's login",
+                "parameters": [
+                    {
+                        "description": "New user login. Must be unique",
+                        "name": "NewLogin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserLoginQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. User's login was updated",
+                        "schema": {
+                            "$ref": "#/definitions/user.JwtResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Return's if user has login changing cooldown",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if user is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if new user's login already taken",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/delete": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Only user can delete his own account. To delete user he needs to confirm his password",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Deletes user's account",
+                "parameters": [
+                    {
+                        "description": "User password",
+                        "name": "Password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.DeleteUserQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response. User was deleted, need to remove his session"
+                    },
+                    "400": {
+                        "description": "Return's if user typed incorrect password",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if user is not authorized",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/email": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "If code field is empty: send or resend confirmation message to user's email\nMessage can be resended every 1 minutes\nIf code field is not empty: validate code and approve email, code is expired within 10 minutes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Confirm user's email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Confirmation code",
+                        "name": "code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Confirmation code was sent"
+                    },
+                    "204": {
+                        "description": "Successful response. Email was confirmed"
+                    },
+                    "400": {
+                        "description": "Return's if user's email already confirmed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "Return's if email can't be resend now (cooldown still active)",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Return's if service can't find user's code or code is expired",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends confirmation message to the new email. Current email is kept until the new one is confirmed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user's email",
+                "parameters": [
+                    {
+                        "description": "New email. Must be unique",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserEmailQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. New email is waiting for confirmation",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Return's if new email already taken",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/logout": {
+            "post": {
+                "description": "Ends current session and clears token cookies. Succeeds even if session is already expired",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Logs user out",
+                "responses": {
+                    "204": {
+                        "description": "Successful response. Session was ended"
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oidc/callback": {
+            "get": {
+                "description": "Verifies user's identity and signs user in. Identity is linked to the user with the same email if both sides confirmed it, otherwise a new user is created\nRedirects to the application with token cookies set. If user has 2FA enabled, pre-auth token is passed in url fragment instead",
+                "tags": [
+                    "users"
+                ],
+                "summary": "OpenID provider callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State that was sent to provider",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the application"
+                    },
+                    "400": {
+                        "description": "Returns when provider didn't return the code or user's email",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Returns when sign in is expired, state is wrong or identity was not verified",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Returns when email is taken by the user that can't be linked",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oidc/login": {
+            "get": {
+                "description": "Redirects to provider's sign in page. Authorization code flow with PKCE is used",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Sign in with OpenID provider",
+                "responses": {
+                    "302": {
+                        "description": "Redirect to provider"
+                    },
+                    "500": {
+                        "description": "Returns when provider is not available",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Current password must be provided. All other user's sessions are ended, current one gets new tokens",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user's password",
+                "parameters": [
+                    {
+                        "description": "Current and new passwords",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserPasswordQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response. Password was changed",
+                        "schema": {
+                            "$ref": "#/definitions/user.JwtResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Return's if user typed incorrect current password",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Return's if service can't authorize user",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns if query was incorrect",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password/forgot": {
+            "post": {
+                "description": "Sends a one-time password reset link to the email if it belongs to some user\nResponse is the same for unknown emails, so it can't be used to find out registered ones",
+                "tags": [
+                    "users"
                 ],
-                "description": "Authorizes user's credentials by token. This needs to check if user's token is valid or get current authenticated user",
-                "produces": [
-                    "application/json"
+                "summary": "Request password reset",
+                "parameters": [
+                    {
+                        "description": "User's email",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ForgotPasswordQuery"
+                        }
+                    }
                 ],
+                "responses": {
+                    "204": {
+                        "description": "Successful response. Reset link was sent if user exists"
+                    },
+                    "429": {
+                        "description": "Returns when client sent too many password recovery requests",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
+                    "501": {
+                        "description": "Returns when provided data was not validated",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password/reset": {
+            "post": {
+                "description": "Sets a new password using token from reset link. Token can be used only once and expires within 30 minutes\nAll user's sessions are ended after the password is changed",
                 "tags": [
                     "users"
                 ],
-                "summary": "Authorizes user",
+                "summary": "Reset user's password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.ResetPasswordQuery"
+                        }
+                    }
+                ],
                 "responses": {
-                    "200": {
-                        "description": "Successful response. Returns user's login, roles and personal token and refresh token. Refresh token stores in cache",
+                    "204": {
+                        "description": "Successful response. Password was changed"
+                    },
+                    "404": {
+                        "description": "Returns when token is invalid, expired or already used",
                         "schema": {
-                            "$ref": "#/definitions/user.JwtResponse"
+                            "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "401": {
-                        "description": "Returns if user not authorized",
+                    "429": {
+                        "description": "Returns when client sent too many password recovery requests",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -476,18 +2537,26 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
+                    },
+                    "501": {
+                        "description": "Returns when provided data was not validated",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/users/register": {
             "post": {
-                "description": "Finds user by login and password\nSets token to cookies\nLogin field can be provided with user login or email",
+                "description": "Creates a new instance of user and returns authorization principals. Sets the token cookies",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Authenticates user",
+                "summary": "Register user",
                 "parameters": [
                     {
                         "description": "User credentials",
@@ -495,19 +2564,19 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.UserAuthQuery"
+                            "$ref": "#/definitions/user.UserRegisterQuery"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response. Returns user's login and roles",
+                        "description": "Successful token response. Returns the same response as in authorization",
                         "schema": {
                             "$ref": "#/definitions/user.JwtResponse"
                         }
                     },
-                    "404": {
-                        "description": "Returns when service can't find user by provided credentials (user not found)",
+                    "409": {
+                        "description": "Returns when there's already exist user with provided login",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -527,65 +2596,65 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/changename": {
-            "patch": {
+        "/users/roles": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "New login must be unique. Login changing are available only 1 time per month",
+                "description": "Roles are sets of permissions. User gets new permissions on the next token refresh",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Update user's login",
+                "summary": "Grant role to user",
                 "parameters": [
                     {
-                        "description": "New user login. Must be unique",
-                        "name": "NewLogin",
+                        "description": "User's id and role",
+                        "name": "query",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.UpdateUserLoginQuery"
+                            "$ref": "#/definitions/user.ChangeUserRoleQuery"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response. User's login was updated",
+                        "description": "Successful response. Returns updated user",
                         "schema": {
-                            "$ref": "#/definitions/user.JwtResponse"
+                            "$ref": "#/definitions/user.User"
                         }
                     },
-                    "401": {
-                        "description": "Return's if service can't authorize user",
+                    "400": {
+                        "description": "Returns when role is unknown",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "403": {
-                        "description": "Return's if user has login changing cooldown",
+                    "401": {
+                        "description": "Return's if service can't authorize user",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "404": {
-                        "description": "Return's if user is not authorized",
+                    "403": {
+                        "description": "Returns when user has no permission to manage roles or tries to change own roles",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "409": {
-                        "description": "Return's if new user's login already taken",
+                    "404": {
+                        "description": "Returns when user not found",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "500": {
-                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "description": "Returns when there's some internal error that needs to be fixed",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -597,40 +2666,41 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/delete": {
+            },
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Only user can delete his own account. To delete user he needs to confirm his password",
+                "description": "User loses permissions of the role on the next token refresh. Base user role can't be revoked",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Deletes user's account",
+                "summary": "Revoke role from user",
                 "parameters": [
                     {
-                        "description": "User password",
-                        "name": "Password",
+                        "description": "User's id and role",
+                        "name": "query",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.DeleteUserQuery"
+                            "$ref": "#/definitions/user.ChangeUserRoleQuery"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Successful response. User was deleted, need to remove his session"
+                    "200": {
+                        "description": "Successful response. Returns updated user",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
                     },
                     "400": {
-                        "description": "Return's if user typed incorrect password",
+                        "description": "Returns when role is unknown or can't be revoked",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -641,14 +2711,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
+                    "403": {
+                        "description": "Returns when user has no permission to manage roles or tries to change own roles",
+                        "schema": {
+                            "$ref": "#/definitions/errormiddleware.Error"
+                        }
+                    },
                     "404": {
-                        "description": "Return's if user is not authorized",
+                        "description": "Returns when user not found",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
                     "500": {
-                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "description": "Returns when there's some internal error that needs to be fixed",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -662,40 +2738,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/email": {
-            "post": {
+        "/users/sessions": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "If code field is empty: send or resend confirmation message to user's email\nMessage can be resended every 1 minutes\nIf code field is not empty: validate code and approve email, code is expired within 10 minutes",
+                "description": "Returns sessions sorted by creation time, newest first. Session of current request is marked",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Confirm user's email",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Confirmation code",
-                        "name": "code",
-                        "in": "query"
-                    }
-                ],
+                "summary": "Get user's active sessions",
                 "responses": {
                     "200": {
-                        "description": "Successful response. Confirmation code was sent"
-                    },
-                    "204": {
-                        "description": "Successful response. Email was confirmed"
-                    },
-                    "400": {
-                        "description": "Return's if user's email already confirmed",
+                        "description": "Successful response",
                         "schema": {
-                            "$ref": "#/definitions/errormiddleware.Error"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/user.Session"
+                            }
                         }
                     },
                     "401": {
@@ -704,20 +2769,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "403": {
-                        "description": "Return's if email can't be resend now (cooldown still active)",
-                        "schema": {
-                            "$ref": "#/definitions/errormiddleware.Error"
-                        }
-                    },
-                    "404": {
-                        "description": "Return's if service can't find user's code or code is expired",
-                        "schema": {
-                            "$ref": "#/definitions/errormiddleware.Error"
-                        }
-                    },
                     "500": {
-                        "description": "Returns when there's some internal error that needs to be fixed or smtp server is not responding",
+                        "description": "Returns when there's some internal error that needs to be fixed",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -725,48 +2778,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/register": {
-            "post": {
-                "description": "Creates a new instance of user and returns authorization principals. Sets the token cookies",
-                "produces": [
-                    "application/json"
+        "/users/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Logs out the device that owns the session. Access token of that device is rejected on it's next request",
                 "tags": [
                     "users"
                 ],
-                "summary": "Register user",
+                "summary": "Revoke user's session",
                 "parameters": [
                     {
-                        "description": "User credentials",
-                        "name": "query",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user.UserRegisterQuery"
-                        }
+                        "type": "string",
+                        "description": "Session id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Successful token response. Returns the same response as in authorization",
-                        "schema": {
-                            "$ref": "#/definitions/user.JwtResponse"
-                        }
+                    "204": {
+                        "description": "Successful response. Session was revoked"
                     },
-                    "409": {
-                        "description": "Returns when there's already exist user with provided login",
+                    "401": {
+                        "description": "Return's if service can't authorize user",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "500": {
-                        "description": "Returns when there's some internal error that needs to be fixed",
+                    "404": {
+                        "description": "Return's if user has no session with provided id",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
                     },
-                    "501": {
-                        "description": "Returns when provided data was not validated",
+                    "500": {
+                        "description": "Returns when there's some internal error that needs to be fixed",
                         "schema": {
                             "$ref": "#/definitions/errormiddleware.Error"
                         }
@@ -776,17 +2826,95 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "author.AddAuthorQuery": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "bio": {
+                    "type": "string",
+                    "maxLength": 4096
+                },
+                "birthyear": {
+                    "type": "integer",
+                    "maximum": 2100,
+                    "minimum": 0,
+                    "example": 1828
+                },
+                "deathyear": {
+                    "type": "integer",
+                    "maximum": 2100,
+                    "minimum": 0,
+                    "example": 1910
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 4,
+                    "example": "Author name"
+                },
+                "photo": {
+                    "description": "Url to author's photo",
+                    "type": "string"
+                }
+            }
+        },
         "author.Author": {
             "type": "object",
             "required": [
                 "id"
             ],
             "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "birthyear": {
+                    "type": "integer"
+                },
+                "deathyear": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
+                },
+                "photo": {
+                    "description": "Url to author's photo",
+                    "type": "string"
+                }
+            }
+        },
+        "author.UpdateAuthorQuery": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string",
+                    "maxLength": 4096
+                },
+                "birthyear": {
+                    "type": "integer",
+                    "maximum": 2100,
+                    "minimum": 0,
+                    "example": 1828
+                },
+                "deathyear": {
+                    "type": "integer",
+                    "maximum": 2100,
+                    "minimum": 0,
+                    "example": 1910
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 4,
+                    "example": "Author name"
+                },
+                "photo": {
+                    "description": "Url to author's photo",
+                    "type": "string"
                 }
             }
         },
@@ -796,14 +2924,30 @@ const docTemplate = `{
                 "author": {
                     "$ref": "#/definitions/author.Author"
                 },
-                "cover": {
-                    "description": "Name of cover file",
+                "cover": {
+                    "description": "Name of cover file",
+                    "type": "string"
+                },
+                "covers": {
+                    "description": "Links to the cover and it's thumbnails. Empty if book has no cover",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/book.CoverUrls"
+                        }
+                    ]
+                },
+                "coversha256": {
+                    "description": "SHA-256 checksum of cover file",
                     "type": "string"
                 },
                 "file": {
                     "description": "Name of book file",
                     "type": "string"
                 },
+                "filesha256": {
+                    "description": "SHA-256 checksum of book file",
+                    "type": "string"
+                },
                 "genres": {
                     "type": "array",
                     "items": {
@@ -824,6 +2968,43 @@ const docTemplate = `{
                 }
             }
         },
+        "book.BookPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/book.Book"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "book.CoverUrls": {
+            "type": "object",
+            "properties": {
+                "large": {
+                    "type": "string"
+                },
+                "medium": {
+                    "type": "string"
+                },
+                "original": {
+                    "type": "string"
+                },
+                "small": {
+                    "type": "string"
+                }
+            }
+        },
         "errormiddleware.Code": {
             "type": "string",
             "enum": [
@@ -833,7 +3014,10 @@ const docTemplate = `{
                 "IE-0004",
                 "IE-0005",
                 "IE-0006",
-                "IE-0007"
+                "IE-0007",
+                "IE-0008",
+                "IE-0009",
+                "IE-0010"
             ],
             "x-enum-varnames": [
                 "InternalErrorCode",
@@ -842,7 +3026,10 @@ const docTemplate = `{
                 "ValidationErrorCode",
                 "UnauthorizedErrorCode",
                 "NotUniqueErrorCode",
-                "ForbiddenErrorCode"
+                "ForbiddenErrorCode",
+                "TooLargeErrorCode",
+                "TooManyRequestsErrorCode",
+                "LockedErrorCode"
             ]
         },
         "errormiddleware.Error": {
@@ -864,9 +3051,28 @@ const docTemplate = `{
         },
         "genre.AddGenreQuery": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 4,
+                    "example": "Fairy tales"
+                },
+                "parent": {
+                    "type": "string",
+                    "example": "6690e6dcfd658345b06c2a25"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "fairy-tales"
                 }
             }
         },
@@ -876,11 +3082,140 @@ const docTemplate = `{
                 "id"
             ],
             "properties": {
+                "books": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/genre.Genre"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
+                },
+                "parent": {
+                    "type": "string",
+                    "example": "6690e6dcfd658345b06c2a25"
+                },
+                "slug": {
+                    "type": "string",
+                    "example": "fairy-tales"
+                }
+            }
+        },
+        "genre.GenrePage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/genre.Genre"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "genre.UpdateGenreQuery": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1024
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 4,
+                    "example": "Fairy tales"
+                },
+                "parent": {
+                    "type": "string",
+                    "example": "6690e6dcfd658345b06c2a25"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "fairy-tales"
+                }
+            }
+        },
+        "notification.Notification": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6690e6dcfd658345b06c2a25"
+                },
+                "read": {
+                    "type": "boolean"
+                },
+                "sended": {
+                    "type": "object"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "security"
+                }
+            }
+        },
+        "notification.NotificationPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/notification.Notification"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "unread": {
+                    "type": "integer"
+                }
+            }
+        },
+        "notification.UnreadCount": {
+            "type": "object",
+            "properties": {
+                "unread": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "user.ChangeUserRoleQuery": {
+            "type": "object",
+            "required": [
+                "role",
+                "userid"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "userid": {
+                    "type": "string"
                 }
             }
         },
@@ -895,12 +3230,49 @@ const docTemplate = `{
                 }
             }
         },
+        "user.DisableTwoFactorQuery": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.ForgotPasswordQuery": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
         "user.JwtResponse": {
             "type": "object",
             "properties": {
                 "login": {
                     "type": "string"
                 },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "books:write"
+                    ]
+                },
                 "roles": {
                     "type": "array",
                     "items": {
@@ -909,6 +3281,129 @@ const docTemplate = `{
                 }
             }
         },
+        "user.PreAuthResponse": {
+            "type": "object",
+            "properties": {
+                "preauth": {
+                    "type": "string"
+                },
+                "twofactor": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "user.RecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "recoverycodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "a1b2c-3d4e5"
+                    ]
+                }
+            }
+        },
+        "user.ResetPasswordQuery": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "User!1password"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.Session": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "current": {
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string",
+                    "example": "Firefox on Windows"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "lastseen": {
+                    "type": "string"
+                },
+                "useragent": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.TwoFactorAuthQuery": {
+            "type": "object",
+            "required": [
+                "code",
+                "preauth"
+            ],
+            "properties": {
+                "code": {
+                    "description": "code from authenticator app or one of recovery codes",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "preauth": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.TwoFactorCodeQuery": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "user.TwoFactorEnrollment": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/Example:user?secret=JBSWY3DPEHPK3PXP\u0026issuer=Example"
+                }
+            }
+        },
+        "user.UpdateUserEmailQuery": {
+            "type": "object",
+            "required": [
+                "newemail"
+            ],
+            "properties": {
+                "newemail": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
         "user.UpdateUserLoginQuery": {
             "type": "object",
             "required": [
@@ -922,6 +3417,22 @@ const docTemplate = `{
                 }
             }
         },
+        "user.UpdateUserPasswordQuery": {
+            "type": "object",
+            "required": [
+                "newpassword",
+                "password"
+            ],
+            "properties": {
+                "newpassword": {
+                    "type": "string",
+                    "example": "User!1password"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
         "user.User": {
             "type": "object",
             "properties": {
@@ -937,11 +3448,17 @@ const docTemplate = `{
                 "login": {
                     "type": "string"
                 },
+                "pendingemail": {
+                    "type": "string"
+                },
                 "roles": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "twofactor": {
+                    "type": "boolean"
                 }
             }
         },
@@ -981,9 +3498,8 @@ const docTemplate = `{
                     "example": "user"
                 },
                 "password": {
+                    "description": "password policy is configured and checked by user service",
                     "type": "string",
-                    "maxLength": 32,
-                    "minLength": 8,
                     "example": "User!1password"
                 }
             }
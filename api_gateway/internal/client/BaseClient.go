@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"path"
 	"time"
@@ -18,28 +19,19 @@ type BaseClient struct {
 }
 
 func (c *BaseClient) SendPostGeneric(ctx context.Context, way string, body []byte) ([]byte, error) {
-	uri, err := c.Base.BuildURL(path.Join(c.Path, way), nil)
-	if err != nil {
-		return nil, err
-	}
-	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, uri, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed while request creation: %v", err)
-	}
-	response, err := c.Base.SendRequest(req)
-	if err != nil {
-		return nil, err
-	}
-	if response.Valid {
-		defer response.Body().Close()
-		return response.ReadBody()
-	}
-	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+	return c.sendGeneric(ctx, http.MethodPost, way, nil, bytes.NewBuffer(body))
 }
 func (c *BaseClient) SendGetGeneric(ctx context.Context, way string, params map[string][]string) ([]byte, error) {
+	return c.sendGeneric(ctx, http.MethodGet, way, params, nil)
+}
+func (c *BaseClient) SendPatchGeneric(ctx context.Context, way string, body []byte) ([]byte, error) {
+	return c.sendGeneric(ctx, http.MethodPatch, way, nil, bytes.NewBuffer(body))
+}
+func (c *BaseClient) SendDeleteGeneric(ctx context.Context, way string, params map[string][]string) error {
+	_, err := c.sendGeneric(ctx, http.MethodDelete, way, params, nil)
+	return err
+}
+func (c *BaseClient) sendGeneric(ctx context.Context, method string, way string, params map[string][]string, body io.Reader) ([]byte, error) {
 	uri, err := c.Base.BuildURL(path.Join(c.Path, way), params)
 	if err != nil {
 		return nil, err
@@ -47,7 +39,7 @@ func (c *BaseClient) SendGetGeneric(ctx context.Context, way string, params map[
 	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, uri, nil)
+	req, err := http.NewRequestWithContext(reqCtx, method, uri, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed while request creation: %v", err)
 	}
@@ -3,6 +3,30 @@ package author
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Author struct {
-	Id   primitive.ObjectID `json:"id" validate:"required,primitiveid"`
-	Name string             `json:"name"`
+	Id        primitive.ObjectID `json:"id" validate:"required,primitiveid"`
+	Name      string             `json:"name"`
+	Bio       string             `json:"bio,omitempty"`
+	BirthYear int                `json:"birthyear,omitempty"`
+	DeathYear int                `json:"deathyear,omitempty"`
+	// Url to author's photo
+	Photo string `json:"photo,omitempty"`
+}
+
+type AddAuthorQuery struct {
+	Name      string `json:"name" validate:"required,min=4,max=32" example:"Author name"`
+	Bio       string `json:"bio,omitempty" validate:"max=4096"`
+	BirthYear int    `json:"birthyear,omitempty" validate:"omitempty,gte=0,lte=2100" example:"1828"`
+	DeathYear int    `json:"deathyear,omitempty" validate:"omitempty,gte=0,lte=2100" example:"1910"`
+	// Url to author's photo
+	Photo string `json:"photo,omitempty" validate:"omitempty,url"`
+}
+
+// Only provided fields will be updated
+type UpdateAuthorQuery struct {
+	Name      *string `json:"name,omitempty" validate:"omitempty,min=4,max=32" example:"Author name"`
+	Bio       *string `json:"bio,omitempty" validate:"omitempty,max=4096"`
+	BirthYear *int    `json:"birthyear,omitempty" validate:"omitempty,gte=0,lte=2100" example:"1828"`
+	DeathYear *int    `json:"deathyear,omitempty" validate:"omitempty,gte=0,lte=2100" example:"1910"`
+	// Url to author's photo
+	Photo *string `json:"photo,omitempty" validate:"omitempty,url"`
 }
@@ -0,0 +1,107 @@
+package author
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	base "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
+)
+
+type client struct {
+	base.BaseClient
+}
+
+func NewService(baseURL, path string, logger *logging.Logger, signer *signature.Signer) *client {
+	return &client{BaseClient: base.BaseClient{
+		Path: path,
+		Base: &rest.RestClient{
+			BaseURL: baseURL,
+			HttpClient: &http.Client{
+				Timeout: 10 * time.Second,
+			},
+			Logger: logger,
+			Signer: signer,
+		},
+	}}
+}
+func (c *client) GetAuthors(ctx context.Context, id string) ([]*Author, error) {
+	body, err := c.SendGetGeneric(ctx, "", map[string][]string{"id": {id}})
+	if err != nil {
+		return nil, err
+	}
+	var authors []*Author
+	if err := json.Unmarshal(body, &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+func (c *client) GetAllAuthors(ctx context.Context, params url.Values) ([]*Author, error) {
+	body, err := c.SendGetGeneric(ctx, "/all", filterParams(params, "offset", "limit"))
+	if err != nil {
+		return nil, err
+	}
+	var authors []*Author
+	if err := json.Unmarshal(body, &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+func (c *client) FindAuthors(ctx context.Context, params url.Values) ([]*Author, error) {
+	body, err := c.SendGetGeneric(ctx, "/search", filterParams(params, "name", "offset", "limit"))
+	if err != nil {
+		return nil, err
+	}
+	var authors []*Author
+	if err := json.Unmarshal(body, &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+func (c *client) AddAuthor(ctx context.Context, query *AddAuthorQuery) (*Author, error) {
+	request, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.SendPostGeneric(ctx, "", request)
+	if err != nil {
+		return nil, err
+	}
+	var author Author
+	if err := json.Unmarshal(body, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+func (c *client) UpdateAuthor(ctx context.Context, id string, query *UpdateAuthorQuery) (*Author, error) {
+	request, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.SendPatchGeneric(ctx, url.PathEscape(id), request)
+	if err != nil {
+		return nil, err
+	}
+	var author Author
+	if err := json.Unmarshal(body, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+func (c *client) DeleteAuthor(ctx context.Context, id string) error {
+	return c.SendDeleteGeneric(ctx, url.PathEscape(id), nil)
+}
+func filterParams(params url.Values, allowed ...string) map[string][]string {
+	filters := make(map[string][]string, 0)
+	for _, v := range allowed {
+		if params.Has(v) {
+			filters[v] = []string{params.Get(v)}
+		}
+	}
+	return filters
+}
@@ -99,3 +99,20 @@ func TestPostGeneric(t *testing.T) {
 		})
 	}
 }
+func TestPatchGeneric(t *testing.T) {
+	body, err := c.SendPatchGeneric(context.Background(), "/item", []byte("patched"))
+	assert.NoError(t, err)
+
+	var response testResponse
+	json.Unmarshal(body, &response)
+	assert.Equal(t, "PATCH", response.Method)
+	assert.Equal(t, "patched", string(response.Body))
+	assert.Equal(t, "/test/item", response.Path)
+
+	_, err = c.SendPatchGeneric(context.Background(), "/error", nil)
+	assert.Equal(t, intErr, err)
+}
+func TestDeleteGeneric(t *testing.T) {
+	assert.NoError(t, c.SendDeleteGeneric(context.Background(), "/item", map[string][]string{"confirm": {"true"}}))
+	assert.Equal(t, intErr, c.SendDeleteGeneric(context.Background(), "/error", nil))
+}
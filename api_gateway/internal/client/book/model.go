@@ -1,6 +1,10 @@
 package book
 
 import (
+	"fmt"
+	"io"
+	"net/http"
+
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/author"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/genre"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,20 +21,80 @@ type Book struct {
 	FilePath string `json:"file"`
 	// Name of cover file
 	CoverPath string `json:"cover"`
+	// SHA-256 checksum of book file
+	FileSha256 string `json:"filesha256"`
+	// SHA-256 checksum of cover file
+	CoverSha256 string `json:"coversha256"`
+	// Links to the cover and it's thumbnails. Empty if book has no cover
+	Covers *CoverUrls `json:"covers,omitempty"`
+}
+
+// Thumbnails fit into 160, 320 and 640 pixels squares
+type CoverUrls struct {
+	Original string `json:"original"`
+	Small    string `json:"small"`
+	Medium   string `json:"medium"`
+	Large    string `json:"large"`
+}
+
+// Book service returns links relative to itself, they are replaced with gateway ones
+func (b *Book) setCoverUrls() {
+	if b.Covers == nil {
+		return
+	}
+	base := fmt.Sprintf("/api/v1/books/%s/cover", b.Id.Hex())
+	b.Covers = &CoverUrls{
+		Original: base,
+		Small:    base + "?size=small",
+		Medium:   base + "?size=medium",
+		Large:    base + "?size=large",
+	}
+}
+
+// Raw book file or cover response that should be passed to the client as is
+type BookFile struct {
+	Status int
+	Header http.Header
+	Body   io.ReadCloser
+}
+
+// Cursors are empty when there is no next or previous page
+type BookPage struct {
+	Items      []*Book `json:"items"`
+	Total      int64   `json:"total"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
 }
 
 type InsertBookQuery struct {
-	// Book's name. Must be unique
-	Name string `form:"name" validate:"required,min=4,max=32"`
+	// Book's name. Must be unique. Document's title is used if it's omitted
+	Name string `form:"name" validate:"omitempty,min=4,max=32"`
 	// primitive object id to author of book
 	AuthorId primitive.ObjectID `form:"authorid" validate:"required,primitiveid"`
 	// Array of genre's Id's (must be primitive object id)
 	GenresId []primitive.ObjectID `form:"genres" validate:"required"`
 	Year     int                  `form:"year" validate:"required,gte=1400,lte=2100"`
-	// Total number of pages in pdf file
-	Pages int `form:"pages" validate:"required,lte=5000"`
+	// Total number of pages in pdf file. Read from the document if it's omitted, otherwise must match it
+	Pages int `form:"pages" validate:"omitempty,lte=5000"`
 	// Must be a .pdf file to book
 	File string `form:"file" format:"binary" validate:"required"`
 	// Must be an image file to book cover
 	Cover string `form:"cover" format:"binary" validate:"required"`
 }
+
+// All fields are optional, only provided ones will be changed
+type UpdateBookQuery struct {
+	// Book's name. Must be unique
+	Name string `form:"name" validate:"omitempty,min=4,max=32"`
+	// primitive object id to author of book
+	AuthorId primitive.ObjectID `form:"authorid"`
+	// Array of genre's Id's (must be primitive object id)
+	GenresId []primitive.ObjectID `form:"genres"`
+	Year     int                  `form:"year" validate:"omitempty,gte=1400,lte=2100"`
+	// Total number of pages in pdf file. Must match the document if it's uploaded too
+	Pages int `form:"pages" validate:"omitempty,lte=5000"`
+	// .pdf file that will replace current one
+	File string `form:"file" format:"binary"`
+	// Image file that will replace current cover
+	Cover string `form:"cover" format:"binary"`
+}
@@ -7,19 +7,26 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"time"
 
 	base "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
 )
 
 type client struct {
 	base.BaseClient
+	// Files are streamed, so their client has no timeout for reading body
+	files *rest.RestClient
 }
 
-func NewService(baseURL, path string, logger *logging.Logger) *client {
+func NewService(baseURL, path string, logger *logging.Logger, signer *signature.Signer) *client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 10 * time.Second
+
 	return &client{BaseClient: base.BaseClient{
 		Path: path,
 		Base: &rest.RestClient{
@@ -28,8 +35,15 @@ func NewService(baseURL, path string, logger *logging.Logger) *client {
 				Timeout: 10 * time.Second,
 			},
 			Logger: logger,
+			Signer: signer,
 		},
-	}}
+	},
+		files: &rest.RestClient{
+			BaseURL:    baseURL,
+			HttpClient: &http.Client{Transport: transport},
+			Logger:     logger,
+			Signer:     signer,
+		}}
 }
 func (c *client) GetBook(ctx context.Context, id string) (*Book, error) {
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -41,14 +55,15 @@ func (c *client) GetBook(ctx context.Context, id string) (*Book, error) {
 	}
 	var book Book
 	json.Unmarshal(bookByte, &book)
+	book.setCoverUrls()
 
 	return &book, nil
 }
-func (c *client) FindBooks(ctx context.Context, params url.Values) ([]*Book, error) {
+func (c *client) FindBooks(ctx context.Context, params url.Values) (*BookPage, error) {
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	AllowedParams := []string{"offset", "limit"}
+	AllowedParams := []string{"offset", "limit", "cursor", "author", "genres", "genresmode", "yearfrom", "yearto", "pagesfrom", "pagesto", "name", "search", "sort", "order"}
 
 	filters := make(map[string][]string, 0)
 	for _, v := range AllowedParams {
@@ -60,19 +75,68 @@ func (c *client) FindBooks(ctx context.Context, params url.Values) ([]*Book, err
 	if err != nil {
 		return nil, err
 	}
-	var books []*Book
-	json.Unmarshal(bookBytes, &books)
-	return books, nil
+	var page BookPage
+	json.Unmarshal(bookBytes, &page)
+	for _, book := range page.Items {
+		book.setCoverUrls()
+	}
+	return &page, nil
 }
 func (c *client) AddBook(ctx context.Context, body io.Reader, contentType string) (*Book, error) {
-	uri, err := c.Base.BuildURL(c.Path, nil)
+	return c.sendMultipart(ctx, http.MethodPost, "", body, contentType)
+}
+func (c *client) UpdateBook(ctx context.Context, id string, body io.Reader, contentType string) (*Book, error) {
+	return c.sendMultipart(ctx, http.MethodPatch, url.PathEscape(id), body, contentType)
+}
+func (c *client) DeleteBook(ctx context.Context, id string) error {
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return c.SendDeleteGeneric(cntx, url.PathEscape(id), nil)
+}
+
+// File must be either "file" or "cover". Size selects cover thumbnail and is ignored when empty.
+// Conditional and range headers are passed to the book service
+func (c *client) GetBookFile(ctx context.Context, id, file, size string, header http.Header) (*BookFile, error) {
+	var query map[string][]string
+	if len(size) > 0 {
+		query = map[string][]string{"size": {size}}
+	}
+	uri, err := c.files.BuildURL(path.Join(c.Path, url.PathEscape(id), file), query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+	for _, key := range []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"} {
+		if value := header.Get(key); len(value) > 0 {
+			req.Header.Set(key, value)
+		}
+	}
+	response, err := c.files.SendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		return &BookFile{Status: response.StatusCode(), Header: response.Header(), Body: response.Body()}, nil
+	}
+	// range errors are written as plain text, so there is no error to decode
+	if response.StatusCode() == http.StatusRequestedRangeNotSatisfiable {
+		return &BookFile{Status: response.StatusCode(), Header: response.Header(), Body: http.NoBody}, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) sendMultipart(ctx context.Context, method, way string, body io.Reader, contentType string) (*Book, error) {
+	uri, err := c.Base.BuildURL(path.Join(c.Path, way), nil)
 	if err != nil {
 		return nil, err
 	}
 	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, uri, body)
+	req, err := http.NewRequestWithContext(reqCtx, method, uri, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed while request creation: %v", err)
 	}
@@ -87,6 +151,7 @@ func (c *client) AddBook(ctx context.Context, body io.Reader, contentType string
 		if err = json.NewDecoder(response.Body()).Decode(&b); err != nil {
 			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
 		}
+		b.setCoverUrls()
 		return &b, nil
 	}
 	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
@@ -2,11 +2,38 @@ package genre
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// Books is the amount of books with this exact genre, it's present only when counts are requested.
+// Children are present only in genre tree
 type Genre struct {
-	Id   primitive.ObjectID `json:"id" validate:"required,primitiveid"`
-	Name string             `json:"name"`
+	Id          primitive.ObjectID  `json:"id" validate:"required,primitiveid"`
+	Name        string              `json:"name"`
+	Slug        string              `json:"slug" example:"fairy-tales"`
+	Description string              `json:"description,omitempty"`
+	ParentId    *primitive.ObjectID `json:"parent,omitempty" swaggertype:"string" example:"6690e6dcfd658345b06c2a25"`
+	Books       *int64              `json:"books,omitempty" example:"12"`
+	Children    []*Genre            `json:"children,omitempty"`
 }
 
+// Slug is built from name if it's not provided
 type AddGenreQuery struct {
-	Name string `json:"name"`
+	Name        string `json:"name" validate:"required,min=4,max=32" example:"Fairy tales"`
+	Slug        string `json:"slug,omitempty" validate:"omitempty,max=64" example:"fairy-tales"`
+	Description string `json:"description,omitempty" validate:"max=1024"`
+	Parent      string `json:"parent,omitempty" validate:"omitempty,primitiveid" example:"6690e6dcfd658345b06c2a25"`
+}
+
+// Only provided fields will be updated. Empty parent moves genre to the root
+type UpdateGenreQuery struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=4,max=32" example:"Fairy tales"`
+	Slug        *string `json:"slug,omitempty" validate:"omitempty,max=64" example:"fairy-tales"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1024"`
+	Parent      *string `json:"parent,omitempty" validate:"omitempty,len=0|primitiveid" example:"6690e6dcfd658345b06c2a25"`
+}
+
+// Cursors are empty when there is no next or previous page
+type GenrePage struct {
+	Items      []*Genre `json:"items"`
+	Total      int64    `json:"total"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
 }
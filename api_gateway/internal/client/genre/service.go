@@ -1,19 +1,24 @@
 package genre
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	base "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
 )
 
 type client struct {
 	base.BaseClient
 }
 
-func NewService(baseURL, path string, logger *logging.Logger) *client {
+func NewService(baseURL, path string, logger *logging.Logger, signer *signature.Signer) *client {
 	return &client{BaseClient: base.BaseClient{
 		Path: path,
 		Base: &rest.RestClient{
@@ -22,6 +27,71 @@ func NewService(baseURL, path string, logger *logging.Logger) *client {
 				Timeout: 10 * time.Second,
 			},
 			Logger: logger,
+			Signer: signer,
 		},
 	}}
 }
+func (c *client) GetGenres(ctx context.Context, params url.Values) ([]*Genre, error) {
+	body, err := c.SendGetGeneric(ctx, "", filterParams(params, "id", "counts"))
+	if err != nil {
+		return nil, err
+	}
+	var genres []*Genre
+	if err := json.Unmarshal(body, &genres); err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+func (c *client) GetAllGenres(ctx context.Context, params url.Values) (*GenrePage, error) {
+	body, err := c.SendGetGeneric(ctx, "/all", filterParams(params, "limit", "cursor", "tree", "counts"))
+	if err != nil {
+		return nil, err
+	}
+	var page GenrePage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+func (c *client) AddGenre(ctx context.Context, query *AddGenreQuery) (*Genre, error) {
+	request, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.SendPostGeneric(ctx, "", request)
+	if err != nil {
+		return nil, err
+	}
+	var genre Genre
+	if err := json.Unmarshal(body, &genre); err != nil {
+		return nil, err
+	}
+	return &genre, nil
+}
+func (c *client) UpdateGenre(ctx context.Context, id string, query *UpdateGenreQuery) (*Genre, error) {
+	request, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.SendPatchGeneric(ctx, url.PathEscape(id), request)
+	if err != nil {
+		return nil, err
+	}
+	var genre Genre
+	if err := json.Unmarshal(body, &genre); err != nil {
+		return nil, err
+	}
+	return &genre, nil
+}
+func (c *client) DeleteGenre(ctx context.Context, id string, cascade bool) error {
+	return c.SendDeleteGeneric(ctx, url.PathEscape(id), map[string][]string{"cascade": {strconv.FormatBool(cascade)}})
+}
+func filterParams(params url.Values, allowed ...string) map[string][]string {
+	filters := make(map[string][]string, 0)
+	for _, v := range allowed {
+		if params.Has(v) {
+			filters[v] = []string{params.Get(v)}
+		}
+	}
+	return filters
+}
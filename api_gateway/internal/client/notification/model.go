@@ -0,0 +1,21 @@
+package notification
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type Notification struct {
+	Id      primitive.ObjectID  `json:"id" swaggertype:"string" example:"6690e6dcfd658345b06c2a25"`
+	Sended  primitive.Timestamp `json:"sended,omitempty" swaggertype:"object"`
+	Content string              `json:"content"`
+	Type    string              `json:"type" example:"security"`
+	Read    bool                `json:"read"`
+}
+
+// Unread is the amount of all unread user's notifications, ignoring filters
+type NotificationPage struct {
+	Items  []*Notification `json:"items"`
+	Total  int64           `json:"total"`
+	Unread int64           `json:"unread"`
+}
+type UnreadCount struct {
+	Unread int64 `json:"unread" example:"3"`
+}
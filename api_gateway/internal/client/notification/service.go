@@ -0,0 +1,75 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	base "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
+)
+
+type client struct {
+	base.BaseClient
+}
+
+func NewService(baseURL, path string, logger *logging.Logger, signer *signature.Signer) *client {
+	return &client{BaseClient: base.BaseClient{
+		Path: path,
+		Base: &rest.RestClient{
+			BaseURL: baseURL,
+			HttpClient: &http.Client{
+				Timeout: 10 * time.Second,
+			},
+			Logger: logger,
+			Signer: signer,
+		},
+	}}
+}
+func (c *client) GetNotifications(ctx context.Context, params url.Values) (*NotificationPage, error) {
+	body, err := c.SendGetGeneric(ctx, "", filterParams(params, "after", "type", "unread", "offset", "limit"))
+	if err != nil {
+		return nil, err
+	}
+	var page NotificationPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+func (c *client) CountUnread(ctx context.Context) (*UnreadCount, error) {
+	body, err := c.SendGetGeneric(ctx, "/unread", nil)
+	if err != nil {
+		return nil, err
+	}
+	var count UnreadCount
+	if err := json.Unmarshal(body, &count); err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+func (c *client) MarkRead(ctx context.Context, id string) error {
+	_, err := c.SendPatchGeneric(ctx, path.Join("/read", url.PathEscape(id)), nil)
+	return err
+}
+func (c *client) MarkAllRead(ctx context.Context) error {
+	_, err := c.SendPatchGeneric(ctx, "/read", nil)
+	return err
+}
+func (c *client) DeleteNotification(ctx context.Context, id string) error {
+	return c.SendDeleteGeneric(ctx, url.PathEscape(id), nil)
+}
+func filterParams(params url.Values, allowed ...string) map[string][]string {
+	filters := make(map[string][]string, 0)
+	for _, v := range allowed {
+		if params.Has(v) {
+			filters[v] = []string{params.Get(v)}
+		}
+	}
+	return filters
+}
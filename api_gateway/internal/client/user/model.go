@@ -1,6 +1,9 @@
 package user
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type User struct {
 	Id             string   `json:"id"`
@@ -8,6 +11,8 @@ type User struct {
 	Roles          []string `json:"roles"`
 	Email          string   `json:"email"`
 	EmailConfirmed bool     `json:"emailconfirmed"`
+	PendingEmail   string   `json:"pendingemail,omitempty"`
+	TwoFactor      bool     `json:"twofactor,omitempty"`
 }
 
 type UserAuthQuery struct {
@@ -17,7 +22,7 @@ type UserAuthQuery struct {
 type UserRegisterQuery struct {
 	Login    string `json:"login" validate:"required,min=4,max=16,onlyenglish" example:"user"`
 	Email    string `json:"email" validate:"required,email" example:"user@example.com"`
-	Password string `json:"password" validate:"required,min=8,max=32,lowercase,uppercase,digitrequired,specialsymbol" example:"User!1password"`
+	Password string `json:"password" validate:"required" example:"User!1password"` // password policy is configured and checked by user service
 }
 type DeleteUserQuery struct {
 	Password string `json:"password" validate:"required"`
@@ -25,10 +30,78 @@ type DeleteUserQuery struct {
 type UpdateUserLoginQuery struct {
 	NewLogin string `json:"newlogin" validate:"required,min=4,max=16,onlyenglish"`
 }
+type UpdateUserPasswordQuery struct {
+	Password    string `json:"password" validate:"required"`
+	NewPassword string `json:"newpassword" validate:"required" example:"User!1password"`
+}
+type UpdateUserEmailQuery struct {
+	NewEmail string `json:"newemail" validate:"required,email" example:"user@example.com"`
+}
+type ForgotPasswordQuery struct {
+	Email string `json:"email" validate:"required,email" example:"user@example.com"`
+}
+type ResetPasswordQuery struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required" example:"User!1password"`
+}
+type TwoFactorAuthQuery struct {
+	PreAuth string `json:"preauth" validate:"required,jwt"`
+	Code    string `json:"code" validate:"required" example:"123456"` // code from authenticator app or one of recovery codes
+}
+type TwoFactorCodeQuery struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+type DisableTwoFactorQuery struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required" example:"123456"`
+}
+type VerifyTwoFactorQuery struct {
+	UserId string `json:"userid"`
+	Code   string `json:"code"`
+}
+type ChangeUserRoleQuery struct {
+	UserId string `json:"userid" validate:"required,primitiveid"`
+	Role   string `json:"role" validate:"required" example:"editor"`
+}
+
+// Identity verified by OpenID provider, user service links it to the user or creates a new one
+type IdentitySignInQuery struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailverified"`
+	Login         string `json:"login"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/Example:user?secret=JBSWY3DPEHPK3PXP&issuer=Example"`
+}
+type RecoveryCodes struct {
+	Codes []string `json:"recoverycodes" example:"a1b2c-3d4e5"`
+}
+
+// Returned instead of JwtResponse when user has 2FA enabled.
+// Pre-auth token is exchanged for JwtResponse with the code
+type PreAuthResponse struct {
+	TwoFactor bool   `json:"twofactor" example:"true"`
+	PreAuth   string `json:"preauth"`
+}
 
 type JwtResponse struct {
 	Login        string       `json:"login"`
 	Roles        []string     `json:"roles"`
+	Permissions  []string     `json:"permissions,omitempty" example:"books:write"`
 	Token        *http.Cookie `json:"-"`
 	RefreshToken *http.Cookie `json:"-"`
 }
+
+type Session struct {
+	Id        string    `json:"id"`
+	Device    string    `json:"device" example:"Firefox on Windows"`
+	UserAgent string    `json:"useragent"`
+	IP        string    `json:"ip"`
+	Created   time.Time `json:"created"`
+	LastSeen  time.Time `json:"lastseen"`
+	Current   bool      `json:"current"`
+}
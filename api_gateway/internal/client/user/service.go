@@ -14,13 +14,15 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
 )
 
 type client struct {
 	Base.BaseClient
 }
 
-func NewService(BaseURL, path string, logger *logging.Logger) *client {
+func NewService(BaseURL, path string, logger *logging.Logger, signer *signature.Signer) *client {
 	return &client{BaseClient: Base.BaseClient{
 		Path: path,
 		Base: &rest.RestClient{
@@ -29,6 +31,7 @@ func NewService(BaseURL, path string, logger *logging.Logger) *client {
 				Timeout: 10 * time.Second,
 			},
 			Logger: logger,
+			Signer: signer,
 		},
 	}}
 }
@@ -118,6 +121,11 @@ func (c *client) AuthByLoginAndPassword(ctx context.Context, query *UserAuthQuer
 		return nil, fmt.Errorf("failed while request creation: %v", err)
 	}
 
+	//user service counts failed sign in attempts per client's ip
+	if client := session.ClientFromContext(ctx); len(client.IP) > 0 {
+		req.Header.Set("X-Real-IP", client.IP)
+	}
+
 	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
@@ -244,3 +252,360 @@ func (c *client) UpdateUserLogin(ctx context.Context, query *UpdateUserLoginQuer
 	}
 	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
 }
+func (c *client) UpdateUserPassword(ctx context.Context, query *UpdateUserPasswordQuery) (*User, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/password", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req = req.WithContext(reqCtx)
+	response, err := c.Base.SendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var u User
+		if err = json.NewDecoder(response.Body()).Decode(&u); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &u, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) UpdateUserEmail(ctx context.Context, query *UpdateUserEmailQuery) (*User, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/email", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req = req.WithContext(reqCtx)
+	response, err := c.Base.SendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var u User
+		if err = json.NewDecoder(response.Body()).Decode(&u); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &u, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) ForgotPassword(ctx context.Context, query *ForgotPasswordQuery) error {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/password/forgot", nil)
+	if err != nil {
+		return fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	response, err := c.Base.SendRequest(req.WithContext(reqCtx))
+	if err != nil {
+		return err
+	}
+	if response.Valid {
+		return nil
+	}
+	return errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) ResetPassword(ctx context.Context, query *ResetPasswordQuery) error {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/password/reset", nil)
+	if err != nil {
+		return fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	response, err := c.Base.SendRequest(req.WithContext(reqCtx))
+	if err != nil {
+		return err
+	}
+	if response.Valid {
+		return nil
+	}
+	return errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) EnrollTwoFactor(ctx context.Context) (*TwoFactorEnrollment, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/2fa/enroll", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	response, err := c.Base.SendRequest(req.WithContext(reqCtx))
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var enrollment TwoFactorEnrollment
+		if err = json.NewDecoder(response.Body()).Decode(&enrollment); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &enrollment, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) EnableTwoFactor(ctx context.Context, query *TwoFactorCodeQuery) (*RecoveryCodes, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/2fa/enable", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	response, err := c.Base.SendRequest(req.WithContext(reqCtx))
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var codes RecoveryCodes
+		if err = json.NewDecoder(response.Body()).Decode(&codes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &codes, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) DisableTwoFactor(ctx context.Context, query *DisableTwoFactorQuery) error {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/2fa/disable", nil)
+	if err != nil {
+		return fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed while request creation: %v", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	response, err := c.Base.SendRequest(req.WithContext(reqCtx))
+	if err != nil {
+		return err
+	}
+	if response.Valid {
+		return nil
+	}
+	return errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+
+// Second step of authentication, user's id is taken from verified pre-auth token
+func (c *client) VerifyTwoFactor(ctx context.Context, query *VerifyTwoFactorQuery) (*User, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/2fa/verify", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+	if client := session.ClientFromContext(ctx); len(client.IP) > 0 {
+		req.Header.Set("X-Real-IP", client.IP)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	response, err := c.Base.SendRequest(req.WithContext(reqCtx))
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var u User
+		if err = json.NewDecoder(response.Body()).Decode(&u); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &u, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) SignInWithIdentity(ctx context.Context, query *IdentitySignInQuery) (*User, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/oidc", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+	response, err := c.Base.SendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var u User
+		if err = json.NewDecoder(response.Body()).Decode(&u); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &u, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
+func (c *client) GrantRole(ctx context.Context, query *ChangeUserRoleQuery) (*User, error) {
+	return c.changeRole(ctx, http.MethodPost, query)
+}
+func (c *client) RevokeRole(ctx context.Context, query *ChangeUserRoleQuery) (*User, error) {
+	return c.changeRole(ctx, http.MethodDelete, query)
+}
+func (c *client) changeRole(ctx context.Context, method string, query *ChangeUserRoleQuery) (*User, error) {
+	c.Base.Logger.Info("building request url...")
+	uri, err := c.Base.BuildURL(c.Path+"/roles", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build url: %v", err)
+	}
+	if query == nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong request"}, "received nil query")
+	}
+	structs.DefaultTagName = "json"
+	body, err := json.Marshal(structs.Map(query))
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, method, uri, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed while request creation: %v", err)
+	}
+	response, err := c.Base.SendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.Valid {
+		defer response.Body().Close()
+		var u User
+		if err = json.NewDecoder(response.Body()).Decode(&u); err != nil {
+			return nil, fmt.Errorf("failed to unmarshall response body: %v", err)
+		}
+		return &u, nil
+	}
+	return nil, errormiddleware.NewError(response.Error.Message, response.Error.ErrorCode, response.Error.DeveloperMessage)
+}
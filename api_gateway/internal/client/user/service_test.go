@@ -9,6 +9,7 @@ import (
 
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
@@ -69,7 +70,7 @@ func TestFindUser(t *testing.T) {
 	}))
 	for _, findUserCase := range findUserCases {
 		t.Run(findUserCase.Name, func(t *testing.T) {
-			service := NewService(server.URL, "/users", logger)
+			service := NewService(server.URL, "/users", logger, nil)
 			user, err := service.FindUser(context.Background(), findUserCase.UserId, findUserCase.UserLogin)
 			if len(findUserCase.ErrorCode) > 0 {
 				assert.Error(t, err)
@@ -114,7 +115,7 @@ func TestUserEmailConfirmation(t *testing.T) {
 	}))
 	for _, userEmailCase := range userEmailCases {
 		t.Run(userEmailCase.Name, func(t *testing.T) {
-			service := NewService(server.URL, "/users", logger)
+			service := NewService(server.URL, "/users", logger, nil)
 			result, err := service.UserEmailConfirmation(context.Background(), userEmailCase.Code)
 			if len(userEmailCase.ErrorCode) > 0 {
 				assert.Error(t, err)
@@ -167,7 +168,7 @@ func TestAuthByLoginAndPassword(t *testing.T) {
 	}))
 	for _, authUserCase := range authUserCases {
 		t.Run(authUserCase.Name, func(t *testing.T) {
-			service := NewService(server.URL, "/users", logger)
+			service := NewService(server.URL, "/users", logger, nil)
 			user, err := service.AuthByLoginAndPassword(context.Background(), authUserCase.Query)
 			if len(authUserCase.ErrorCode) > 0 {
 				assert.Error(t, err)
@@ -183,6 +184,32 @@ func TestAuthByLoginAndPassword(t *testing.T) {
 		})
 	}
 }
+func TestAuthForwardsClientIp(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Real-IP") == "10.0.0.1" {
+			w.WriteHeader(http.StatusLocked)
+			w.Write(errormiddleware.LockedError([]string{""}, "").Marshall())
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		user, _ := json.Marshal(userList[0])
+		w.Write(user)
+	}))
+	service := NewService(server.URL, "/users", logger, nil)
+	request := httptest.NewRequest(http.MethodPost, "/", nil)
+
+	request.RemoteAddr = "10.0.0.2:1000"
+	_, err := service.AuthByLoginAndPassword(session.NewContext(context.Background(), request), &UserAuthQuery{Login: "admin", Password: "admin"})
+	assert.NoError(t, err)
+
+	request.RemoteAddr = "10.0.0.1:1000"
+	_, err = service.AuthByLoginAndPassword(session.NewContext(context.Background(), request), &UserAuthQuery{Login: "admin", Password: "admin"})
+	if assert.Error(t, err) {
+		assert.Equal(t, errormiddleware.LockedErrorCode, err.(*errormiddleware.Error).Code)
+	}
+}
 
 // Test RegisterUser() method
 var registerUserCases = []struct {
@@ -223,7 +250,7 @@ func TestRegisterUser(t *testing.T) {
 	}))
 	for _, registerUserCase := range registerUserCases {
 		t.Run(registerUserCase.Name, func(t *testing.T) {
-			service := NewService(server.URL, "/users", logger)
+			service := NewService(server.URL, "/users", logger, nil)
 			user, err := service.RegisterUser(context.Background(), registerUserCase.Query)
 			if len(registerUserCase.ErrorCode) > 0 {
 				assert.Error(t, err)
@@ -272,7 +299,7 @@ func TestDeleteUser(t *testing.T) {
 	}))
 	for _, deleteUserCase := range deleteUserCases {
 		t.Run(deleteUserCase.Name, func(t *testing.T) {
-			service := NewService(server.URL, "/users", logger)
+			service := NewService(server.URL, "/users", logger, nil)
 			err := service.DeleteUser(context.Background(), deleteUserCase.Query)
 			if len(deleteUserCase.ErrorCode) > 0 {
 				assert.Error(t, err)
@@ -330,7 +357,7 @@ func TestUpdateUserLogin(t *testing.T) {
 	}))
 	for _, updateUserLoginCase := range updateUserLoginCases {
 		t.Run(updateUserLoginCase.Name, func(t *testing.T) {
-			service := NewService(server.URL, "/users", logger)
+			service := NewService(server.URL, "/users", logger, nil)
 			user, err := service.UpdateUserLogin(context.Background(), updateUserLoginCase.Query)
 			if len(updateUserLoginCase.ErrorCode) > 0 {
 				assert.Error(t, err)
@@ -19,13 +19,57 @@ type UrlConfig struct {
 	AuthorsServiceURL string `env:"SRV_URL_AUTHORS" env-required:"true"`
 	GenresServiceURL  string `env:"SRV_URL_GENRES" env-required:"true"`
 }
+
+// Signing key is the latest key in directory if not specified
 type JwtConfig struct {
-	SecretToken string `env:"JWT_SECRET" env-required:"true"`
+	KeysDir    string `env:"JWT_KEYS_DIR" env-default:"config/keys"`
+	SigningKey string `env:"JWT_SIGNING_KEY"`
+}
+type DatabaseConfig struct {
+	Db_Host string `env:"DB_HOST" env-required:"true"`
+	Db_Base string `env:"DB_BASE" env-required:"true"`
+	Db_Port int    `env:"DB_PORT" env-required:"true"`
+	Db_Name string `env:"DB_NAME"`
+	Db_Pass string `env:"DB_PASS"`
+	Db_Auth string `env:"DB_AUTHDB"`
+}
+type RabbitConfig struct {
+	Rabbit_Host string `env:"RABBITMQ_HOST" env-required:"true"`
+	Rabbit_Port string `env:"RABBITMQ_PORT" env-required:"true"`
+	Rabbit_User string `env:"RABBITMQ_USER" env-required:"true"`
+	Rabbit_Pass string `env:"RABBITMQ_PASS" env-required:"true"`
+}
+
+// Sessions are kept in memory by default, mongo backend is needed when gateway is scaled
+type SessionConfig struct {
+	Backend string `env:"SESSION_BACKEND" env-default:"memory"`
+}
+
+// Secret is shared by all services, internal requests are signed with it
+type SignatureConfig struct {
+	Service string `env:"SERVICE_NAME" env-default:"api_gateway"`
+	Secret  string `env:"SERVICE_SECRET" env-required:"true"`
+}
+
+// Sign in with OpenID provider is disabled when issuer is empty.
+// User is redirected to success url after sign in, pre-auth token is passed in url fragment if user has 2FA
+type OidcConfig struct {
+	Provider        string `env:"OIDC_PROVIDER" env-default:"oidc"`
+	Issuer          string `env:"OIDC_ISSUER"`
+	ClientId        string `env:"OIDC_CLIENT_ID"`
+	ClientSecret    string `env:"OIDC_CLIENT_SECRET"`
+	RedirectURL     string `env:"OIDC_REDIRECT_URL"`
+	SuccessRedirect string `env:"OIDC_SUCCESS_REDIRECT" env-default:"/"`
 }
 type Config struct {
-	Server *ServerConfig
-	Urls   *UrlConfig
-	Jwt    *JwtConfig
+	Server    *ServerConfig
+	Urls      *UrlConfig
+	Jwt       *JwtConfig
+	Database  *DatabaseConfig
+	Rabbit    *RabbitConfig
+	Session   *SessionConfig
+	Signature *SignatureConfig
+	Oidc      *OidcConfig
 }
 
 var cfg *Config
@@ -36,8 +80,12 @@ func GetConfig() *Config {
 		logger := logging.GetLogger()
 		logger.Info("reading api config...")
 		srvCfg := &ServerConfig{}
+		signatureCfg := &SignatureConfig{}
 		urlCfg := &UrlConfig{}
 		jwtCfg := &JwtConfig{}
+		rabbitCfg := &RabbitConfig{}
+		sessionCfg := &SessionConfig{}
+		oidcCfg := &OidcConfig{}
 
 		if err := cleanenv.ReadConfig("config/.env", srvCfg); err != nil {
 			desc, _ := cleanenv.GetDescription(cfg, nil)
@@ -57,10 +105,44 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		if err := cleanenv.ReadConfig("config/.env", rabbitCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", sessionCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		var dbCfg *DatabaseConfig
+		if sessionCfg.Backend == "mongo" {
+			dbCfg = &DatabaseConfig{}
+			if err := cleanenv.ReadConfig("config/.env", dbCfg); err != nil {
+				desc, _ := cleanenv.GetDescription(cfg, nil)
+				logger.Error(desc)
+				logger.Fatal(err)
+			}
+		}
+		if err := cleanenv.ReadConfig("config/.env", signatureCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", oidcCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
 		cfg = &Config{
-			Server: srvCfg,
-			Urls:   urlCfg,
-			Jwt:    jwtCfg,
+			Server:    srvCfg,
+			Urls:      urlCfg,
+			Jwt:       jwtCfg,
+			Database:  dbCfg,
+			Rabbit:    rabbitCfg,
+			Session:   sessionCfg,
+			Signature: signatureCfg,
+			Oidc:      oidcCfg,
 		}
 	})
 	return cfg
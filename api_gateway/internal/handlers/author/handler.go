@@ -0,0 +1,245 @@
+package author
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/julienschmidt/httprouter"
+	model "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/author"
+	mw "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	valid "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
+)
+
+const (
+	url_authors        = "/api/v1/authors"
+	url_all_authors    = "/api/v1/authors/all"
+	url_search_authors = "/api/v1/authors/search"
+	url_author_by_id   = "/api/v1/authors/:id"
+)
+
+//go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
+
+type AuthorService interface {
+	GetAuthors(ctx context.Context, id string) ([]*model.Author, error)
+	GetAllAuthors(ctx context.Context, params url.Values) ([]*model.Author, error)
+	FindAuthors(ctx context.Context, params url.Values) ([]*model.Author, error)
+	AddAuthor(ctx context.Context, query *model.AddAuthorQuery) (*model.Author, error)
+	UpdateAuthor(ctx context.Context, id string, query *model.UpdateAuthorQuery) (*model.Author, error)
+	DeleteAuthor(ctx context.Context, id string) error
+}
+type JwtService interface {
+	Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc
+}
+type Handler struct {
+	Logger        *logging.Logger
+	AuthorService AuthorService
+	JwtService    JwtService
+	Validator     *valid.Validator
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodPost, url_authors, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.AddAuthor)), jwt.AuthorsWrite))
+	router.HandlerFunc(http.MethodGet, url_authors, h.Logger.Middleware(mw.Middleware(h.GetAuthors)))
+	router.HandlerFunc(http.MethodGet, url_all_authors, h.Logger.Middleware(mw.Middleware(h.GetAllAuthors)))
+	router.HandlerFunc(http.MethodGet, url_search_authors, h.Logger.Middleware(mw.Middleware(h.FindAuthors)))
+	router.HandlerFunc(http.MethodPatch, url_author_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.UpdateAuthor)), jwt.AuthorsWrite))
+	router.HandlerFunc(http.MethodDelete, url_author_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.DeleteAuthor)), jwt.AuthorsWrite))
+	h.Logger.Info("author handlers registered")
+}
+
+// @Summary Adds an author
+// @Description Requires authors:write permission to use
+// @Tags authors
+// @Produce json
+// @Param Author body model.AddAuthorQuery true "Author's data"
+// @Success 201 {object} model.Author "Successful response. Added author"
+// @Failure 400 {object} errormiddleware.Error "Return's if death year is less than birth year"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /authors [post]
+func (h *Handler) AddAuthor(w http.ResponseWriter, r *http.Request) error {
+	var query model.AddAuthorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	author, err := h.AuthorService.AddAuthor(ctx, &query)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	data, _ := json.Marshal(author)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Get authors by id
+// @Description You can use multiple ids in query using , separator
+// @Description Example: ?id=id1,id2,id3...
+// @Tags authors
+// @Produce json
+// @Param id query string true "Author IDs"
+// @Success 200 {array} model.Author "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Return's if received bad request"
+// @Failure 404 {object} errormiddleware.Error "Return's if author was not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Router /authors [get]
+func (h *Handler) GetAuthors(w http.ResponseWriter, r *http.Request) error {
+	id := r.URL.Query().Get("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"wrong request received"}, "id param is required")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	authors, err := h.AuthorService.GetAuthors(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(authors)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Get all authors sorted by name
+// @Tags authors
+// @Produce json
+// @Param offset query string true "Offset to authors. Must be present, starting with 0" example(0)
+// @Param limit query string true "Max amount of docs to return. Must be greater than 0" example(15)
+// @Success 200 {array} model.Author "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Failure 404 {object} errormiddleware.Error "Return's if service does not have data"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Router /authors/all [get]
+func (h *Handler) GetAllAuthors(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	authors, err := h.AuthorService.GetAllAuthors(ctx, r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(authors)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Search authors by name
+// @Description Search is case-insensitive and matches any part of the name
+// @Tags authors
+// @Produce json
+// @Param name query string true "Part of author's name"
+// @Param offset query string true "Offset to authors. Must be present, starting with 0" example(0)
+// @Param limit query string true "Max amount of docs to return. Must be greater than 0" example(15)
+// @Success 200 {array} model.Author "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Failure 404 {object} errormiddleware.Error "Return's if no authors were found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Router /authors/search [get]
+func (h *Handler) FindAuthors(w http.ResponseWriter, r *http.Request) error {
+	if len(r.URL.Query().Get("name")) == 0 {
+		return mw.BadRequestError([]string{"wrong request received"}, "name param is required")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	authors, err := h.AuthorService.FindAuthors(ctx, r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(authors)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Updates an author
+// @Description Requires authors:write permission to use
+// @Description Only provided fields will be changed
+// @Tags authors
+// @Produce json
+// @Param id path string true "Author Id"
+// @Param Author body model.UpdateAuthorQuery true "Fields to update"
+// @Success 200 {object} model.Author "Successful response. Updated author"
+// @Failure 400 {object} errormiddleware.Error "Return's if id is wrong or death year is less than birth year"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 404 {object} errormiddleware.Error "Return's if author was not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /authors/{id} [patch]
+func (h *Handler) UpdateAuthor(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	var query model.UpdateAuthorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	author, err := h.AuthorService.UpdateAuthor(ctx, id, &query)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(author)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Deletes an author
+// @Description Requires authors:write permission to use
+// @Tags authors
+// @Param id path string true "Author Id"
+// @Success 204 "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Return's if id is wrong"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 404 {object} errormiddleware.Error "Return's if author was not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /authors/{id} [delete]
+func (h *Handler) DeleteAuthor(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.AuthorService.DeleteAuthor(ctx, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
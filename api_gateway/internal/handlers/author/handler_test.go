@@ -0,0 +1,262 @@
+package author
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	model "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/author"
+	mock "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/author/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var h *Handler
+
+func TestMain(m *testing.M) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	h = &Handler{Logger: logger, Validator: validator.New()}
+
+	os.Exit(m.Run())
+}
+func TestRegister(t *testing.T) {
+	var registerCases = []struct {
+		Name   string
+		Path   string
+		Method string
+	}{
+		{"Add author", url_authors, http.MethodPost},
+		{"Get authors", url_authors, http.MethodGet},
+		{"Get all authors", url_all_authors, http.MethodGet},
+		{"Search authors", url_search_authors, http.MethodGet},
+		{"Update author", url_author_by_id, http.MethodPatch},
+		{"Delete author", url_author_by_id, http.MethodDelete},
+	}
+
+	ctrl := gomock.NewController(t)
+	jwt := mock.NewMockJwtService(ctrl)
+	h.JwtService = jwt
+	jwt.EXPECT().Middleware(gomock.Any(), gomock.Any()).AnyTimes()
+
+	router := httprouter.New()
+	h.Register(router)
+	for _, registerCase := range registerCases {
+		t.Run(registerCase.Name, func(t *testing.T) {
+			handler, _, _ := router.Lookup(registerCase.Method, registerCase.Path)
+			assert.NotNil(t, handler, "handler %s (%s) with method %s not found", registerCase.Name, registerCase.Path, registerCase.Method)
+		})
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	type handlerOptions struct {
+		Name           string
+		MockBehaviour  func(s *mock.MockAuthorService)
+		Query          string
+		Params         httprouter.Params
+		InputJson      func() *[]byte
+		ExceptedStatus int
+		ExceptedError  error
+		ExceptedBody   string
+	}
+	var testTable = []struct {
+		HandlerName string
+		Handler     func(w http.ResponseWriter, r *http.Request) error
+		Method      string
+		Options     []handlerOptions
+	}{
+		//AddAuthor
+		{
+			HandlerName: "AddAuthor",
+			Handler:     h.AddAuthor,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockAuthorService) {
+						s.EXPECT().AddAuthor(gomock.Any(), &model.AddAuthorQuery{Name: "Author name"}).Return(&model.Author{Id: primitive.NilObjectID, Name: "Author name"}, nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.AddAuthorQuery{Name: "Author name"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusCreated,
+					ExceptedBody:   `{"id":"000000000000000000000000","name":"Author name"}`,
+				},
+				//Nil body
+				{
+					Name:           "nil body",
+					ExceptedStatus: http.StatusInternalServerError,
+					ExceptedError:  errors.New("EOF"),
+					ExceptedBody:   `{"messages":["EOF"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
+				},
+				//Validation error
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.AddAuthorQuery{})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"name: field is required"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["name: field is required"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+			},
+		},
+		//GetAuthors
+		{
+			HandlerName: "GetAuthors",
+			Handler:     h.GetAuthors,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:  "success",
+					Query: "?id=000000000000000000000000",
+					MockBehaviour: func(s *mock.MockAuthorService) {
+						s.EXPECT().GetAuthors(gomock.Any(), "000000000000000000000000").Return([]*model.Author{{Name: "Author name"}}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `[{"id":"000000000000000000000000","name":"Author name"}]`,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"wrong request received"}, "id param is required"),
+					ExceptedBody:   `{"messages":["wrong request received"],"dev_message":"id param is required","code":"IE-0003"}`,
+				},
+			},
+		},
+		//FindAuthors
+		{
+			HandlerName: "FindAuthors",
+			Handler:     h.FindAuthors,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Empty name
+				{
+					Name:           "empty name",
+					Query:          "?offset=0&limit=5",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"wrong request received"}, "name param is required"),
+					ExceptedBody:   `{"messages":["wrong request received"],"dev_message":"name param is required","code":"IE-0003"}`,
+				},
+				//Service error
+				{
+					Name:  "service error",
+					Query: "?name=auth&offset=0&limit=5",
+					MockBehaviour: func(s *mock.MockAuthorService) {
+						s.EXPECT().FindAuthors(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.NotFoundError([]string{"authors not found"}, ""))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"authors not found"}, ""),
+					ExceptedBody:   `{"messages":["authors not found"],"code":"IE-0002"}`,
+				},
+			},
+		},
+		//UpdateAuthor
+		{
+			HandlerName: "UpdateAuthor",
+			Handler:     h.UpdateAuthor,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:   "success",
+					Params: httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					MockBehaviour: func(s *mock.MockAuthorService) {
+						s.EXPECT().UpdateAuthor(gomock.Any(), "000000000000000000000000", gomock.Any()).Return(&model.Author{Name: "Author name", BirthYear: 1828}, nil)
+					},
+					InputJson: func() *[]byte {
+						return &[]byte{'{', '}'}
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"000000000000000000000000","name":"Author name","birthyear":1828}`,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad request"}, "id route must be presented"),
+					ExceptedBody:   `{"messages":["bad request"],"dev_message":"id route must be presented","code":"IE-0003"}`,
+				},
+			},
+		},
+		//DeleteAuthor
+		{
+			HandlerName: "DeleteAuthor",
+			Handler:     h.DeleteAuthor,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:   "success",
+					Params: httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					MockBehaviour: func(s *mock.MockAuthorService) {
+						s.EXPECT().DeleteAuthor(gomock.Any(), "000000000000000000000000").Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				//Service error
+				{
+					Name:   "service error",
+					Params: httprouter.Params{{Key: "id", Value: "000000000000000000000000"}},
+					MockBehaviour: func(s *mock.MockAuthorService) {
+						s.EXPECT().DeleteAuthor(gomock.Any(), "000000000000000000000000").Return(errors.New("service error"))
+					},
+					ExceptedStatus: http.StatusInternalServerError,
+					ExceptedError:  errors.New("service error"),
+					ExceptedBody:   `{"messages":["service error"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
+				},
+			},
+		},
+	}
+	for _, tt := range testTable {
+		for _, testCase := range tt.Options {
+			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				service := mock.NewMockAuthorService(ctrl)
+				if testCase.MockBehaviour != nil {
+					testCase.MockBehaviour(service)
+				}
+				h.AuthorService = service
+
+				w := httptest.NewRecorder()
+				var r *http.Request
+				if testCase.InputJson != nil && testCase.InputJson() != nil {
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, bytes.NewBuffer(*testCase.InputJson()))
+				} else {
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, nil)
+				}
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
+				}
+				err := errormiddleware.Middleware(tt.Handler)(w, r)
+				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
+				assert.Equal(t, testCase.ExceptedError, err)
+
+				body := w.Body.String()
+				if assert.Len(t, body, len(testCase.ExceptedBody)) {
+					assert.Equal(t, testCase.ExceptedBody, body)
+				}
+			})
+		}
+	}
+}
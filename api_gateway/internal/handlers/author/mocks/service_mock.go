@@ -0,0 +1,169 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+
+// Package mock_author is a generated GoMock package.
+package mock_author
+
+import (
+	context "context"
+	http "net/http"
+	url "net/url"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	author "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/author"
+)
+
+// MockAuthorService is a mock of AuthorService interface.
+type MockAuthorService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthorServiceMockRecorder
+}
+
+// MockAuthorServiceMockRecorder is the mock recorder for MockAuthorService.
+type MockAuthorServiceMockRecorder struct {
+	mock *MockAuthorService
+}
+
+// NewMockAuthorService creates a new mock instance.
+func NewMockAuthorService(ctrl *gomock.Controller) *MockAuthorService {
+	mock := &MockAuthorService{ctrl: ctrl}
+	mock.recorder = &MockAuthorServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthorService) EXPECT() *MockAuthorServiceMockRecorder {
+	return m.recorder
+}
+
+// AddAuthor mocks base method.
+func (m *MockAuthorService) AddAuthor(ctx context.Context, query *author.AddAuthorQuery) (*author.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuthor", ctx, query)
+	ret0, _ := ret[0].(*author.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAuthor indicates an expected call of AddAuthor.
+func (mr *MockAuthorServiceMockRecorder) AddAuthor(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthor", reflect.TypeOf((*MockAuthorService)(nil).AddAuthor), ctx, query)
+}
+
+// DeleteAuthor mocks base method.
+func (m *MockAuthorService) DeleteAuthor(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAuthor", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAuthor indicates an expected call of DeleteAuthor.
+func (mr *MockAuthorServiceMockRecorder) DeleteAuthor(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAuthor", reflect.TypeOf((*MockAuthorService)(nil).DeleteAuthor), ctx, id)
+}
+
+// FindAuthors mocks base method.
+func (m *MockAuthorService) FindAuthors(ctx context.Context, params url.Values) ([]*author.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAuthors", ctx, params)
+	ret0, _ := ret[0].([]*author.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthors indicates an expected call of FindAuthors.
+func (mr *MockAuthorServiceMockRecorder) FindAuthors(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthors", reflect.TypeOf((*MockAuthorService)(nil).FindAuthors), ctx, params)
+}
+
+// GetAllAuthors mocks base method.
+func (m *MockAuthorService) GetAllAuthors(ctx context.Context, params url.Values) ([]*author.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllAuthors", ctx, params)
+	ret0, _ := ret[0].([]*author.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllAuthors indicates an expected call of GetAllAuthors.
+func (mr *MockAuthorServiceMockRecorder) GetAllAuthors(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllAuthors", reflect.TypeOf((*MockAuthorService)(nil).GetAllAuthors), ctx, params)
+}
+
+// GetAuthors mocks base method.
+func (m *MockAuthorService) GetAuthors(ctx context.Context, id string) ([]*author.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthors", ctx, id)
+	ret0, _ := ret[0].([]*author.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthors indicates an expected call of GetAuthors.
+func (mr *MockAuthorServiceMockRecorder) GetAuthors(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthors", reflect.TypeOf((*MockAuthorService)(nil).GetAuthors), ctx, id)
+}
+
+// UpdateAuthor mocks base method.
+func (m *MockAuthorService) UpdateAuthor(ctx context.Context, id string, query *author.UpdateAuthorQuery) (*author.Author, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAuthor", ctx, id, query)
+	ret0, _ := ret[0].(*author.Author)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAuthor indicates an expected call of UpdateAuthor.
+func (mr *MockAuthorServiceMockRecorder) UpdateAuthor(ctx, id, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAuthor", reflect.TypeOf((*MockAuthorService)(nil).UpdateAuthor), ctx, id, query)
+}
+
+// MockJwtService is a mock of JwtService interface.
+type MockJwtService struct {
+	ctrl     *gomock.Controller
+	recorder *MockJwtServiceMockRecorder
+}
+
+// MockJwtServiceMockRecorder is the mock recorder for MockJwtService.
+type MockJwtServiceMockRecorder struct {
+	mock *MockJwtService
+}
+
+// NewMockJwtService creates a new mock instance.
+func NewMockJwtService(ctrl *gomock.Controller) *MockJwtService {
+	mock := &MockJwtService{ctrl: ctrl}
+	mock.recorder = &MockJwtServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJwtService) EXPECT() *MockJwtServiceMockRecorder {
+	return m.recorder
+}
+
+// Middleware mocks base method.
+func (m *MockJwtService) Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{h}
+	for _, a := range permissions {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Middleware", varargs...)
+	ret0, _ := ret[0].(http.HandlerFunc)
+	return ret0
+}
+
+// Middleware indicates an expected call of Middleware.
+func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, permissions ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{h}, permissions...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Middleware", reflect.TypeOf((*MockJwtService)(nil).Middleware), varargs...)
+}
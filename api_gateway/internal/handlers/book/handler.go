@@ -8,10 +8,12 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	model "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/book"
 	mw "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
 	valid "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 )
@@ -20,17 +22,23 @@ const (
 	url_add_book       = "/api/v1/books"
 	url_get_book       = "/api/v1/books"
 	url_get_book_by_id = "/api/v1/books/:id"
+	url_book_by_id     = "/api/v1/books/:id"
+	url_book_file      = "/api/v1/books/:id/file"
+	url_book_cover     = "/api/v1/books/:id/cover"
 )
 
 //go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
 
 type BookService interface {
 	AddBook(ctx context.Context, body io.Reader, contentType string) (*model.Book, error)
-	FindBooks(ctx context.Context, params url.Values) ([]*model.Book, error)
+	FindBooks(ctx context.Context, params url.Values) (*model.BookPage, error)
 	GetBook(ctx context.Context, id string) (*model.Book, error)
+	UpdateBook(ctx context.Context, id string, body io.Reader, contentType string) (*model.Book, error)
+	DeleteBook(ctx context.Context, id string) error
+	GetBookFile(ctx context.Context, id, file, size string, header http.Header) (*model.BookFile, error)
 }
 type JwtService interface {
-	Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc
+	Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc
 }
 type Handler struct {
 	Logger      *logging.Logger
@@ -40,9 +48,13 @@ type Handler struct {
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, url_add_book, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.AddBook)), "admin"))
+	router.HandlerFunc(http.MethodPost, url_add_book, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.AddBook)), jwt.BooksWrite))
 	router.HandlerFunc(http.MethodGet, url_get_book, h.Logger.Middleware(mw.Middleware(h.FindBooks)))
 	router.HandlerFunc(http.MethodGet, url_get_book_by_id, h.Logger.Middleware(mw.Middleware(h.GetBook)))
+	router.HandlerFunc(http.MethodPatch, url_book_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.UpdateBook)), jwt.BooksWrite))
+	router.HandlerFunc(http.MethodDelete, url_book_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.DeleteBook)), jwt.BooksWrite))
+	router.HandlerFunc(http.MethodGet, url_book_file, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.GetBookFile))))
+	router.HandlerFunc(http.MethodGet, url_book_cover, h.Logger.Middleware(mw.Middleware(h.GetBookCover)))
 	h.Logger.Info("book handlers registered")
 }
 
@@ -74,14 +86,16 @@ func (h *Handler) GetBook(w http.ResponseWriter, r *http.Request) error {
 }
 
 // @Summary Creates a new book
-// @Description Requires admin role to use
+// @Description Requires books:write permission to use
 // @Tags books
 // @Produce json
 // @Param Book formData model.InsertBookQuery true "Book's name must be unique"
 // @Success 201 {object} model.Book "Successful response. Added book"
-// @Failure 400 {object} errormiddleware.Error "Return's if handler received wrong content-type"
+// @Failure 400 {object} errormiddleware.Error "Return's if handler received wrong content-type or file content doesn't match it's extension"
 // @Failure 401 {object} errormiddleware.Error "User is not authorized"
 // @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 409 {object} errormiddleware.Error "Return's if book's name already taken"
+// @Failure 413 {object} errormiddleware.Error "Return's if file or cover exceeds the size limit"
 // @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
 // @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
 // @Security ApiKeyAuth
@@ -106,21 +120,158 @@ func (h *Handler) AddBook(w http.ResponseWriter, r *http.Request) error {
 // @Description If it's impossible to fetch author or genres, the field will be null
 // @Tags books
 // @Produce json
-// @Param offset query string true "Offset to books. Must be present, starting with 0" example(0)
 // @Param limit query string true "Max amount of docs to return. Must be greater than 0" example(15)
-// @Success 200 {array} model.Book "Successful response"
-// @Failure 400 {object} errormiddleware.Error "Returns if query was incorrect"
-// @Failure 404 {object} errormiddleware.Error "Returns if there are no documents found"
+// @Param cursor query string false "Cursor to the next or previous page, taken from previous response"
+// @Param offset query string false "Offset to books, starting with 0. Ignored when cursor is present" example(0)
+// @Param author query string false "Author id"
+// @Param genres query string false "Genre ids separated by ,"
+// @Param genresmode query string false "Whether book must have any or all of provided genres" Enums(any, all) default(any)
+// @Param yearfrom query int false "Minimal year of book"
+// @Param yearto query int false "Maximal year of book"
+// @Param pagesfrom query int false "Minimal number of pages"
+// @Param pagesto query int false "Maximal number of pages"
+// @Param name query string false "Book's name prefix. Case-insensitive"
+// @Param search query string false "Full-text search by book's name and author's name"
+// @Param sort query string false "Field to sort by" Enums(name, year, pages) default(name)
+// @Param order query string false "Sorting direction" Enums(asc, desc) default(desc)
+// @Success 200 {object} model.BookPage "Successful response. Empty page is returned when nothing is found"
+// @Failure 400 {object} errormiddleware.Error "Returns if query or cursor was incorrect"
 // @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
 // @Router /books [get]
 func (h *Handler) FindBooks(w http.ResponseWriter, r *http.Request) error {
-	books, err := h.BookService.FindBooks(r.Context(), r.URL.Query())
+	page, err := h.BookService.FindBooks(r.Context(), r.URL.Query())
 	if err != nil {
 		return err
 	}
 
 	w.WriteHeader(http.StatusOK)
-	bookByte, _ := json.Marshal(books)
+	bookByte, _ := json.Marshal(page)
+	w.Write(bookByte)
+	return nil
+}
+
+// @Summary Updates a book
+// @Description Requires books:write permission to use
+// @Description All fields are optional. Uploaded files replace the current ones
+// @Tags books
+// @Produce json
+// @Param id path string true "Book Id"
+// @Param Book formData model.UpdateBookQuery false "Fields to update"
+// @Success 200 {object} model.Book "Successful response. Updated book"
+// @Failure 400 {object} errormiddleware.Error "Return's if handler received wrong content-type or file content doesn't match it's extension"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 404 {object} errormiddleware.Error "Return's if book is not exists"
+// @Failure 409 {object} errormiddleware.Error "Return's if new book's name already taken"
+// @Failure 413 {object} errormiddleware.Error "Return's if file or cover exceeds the size limit"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /books/{id} [patch]
+func (h *Handler) UpdateBook(w http.ResponseWriter, r *http.Request) error {
+	params := httprouter.ParamsFromContext(r.Context())
+	if len(params.ByName("id")) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return mw.BadRequestError([]string{"wrong request"}, fmt.Sprintf("Content Type %s not validated. Must be form-data.", r.Header.Get("Content-Type")))
+	}
+	book, err := h.BookService.UpdateBook(r.Context(), params.ByName("id"), r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	bookByte, _ := json.Marshal(book)
 	w.Write(bookByte)
 	return nil
 }
+
+// @Summary Deletes a book
+// @Description Requires books:write permission to use
+// @Description Book's files are removed too
+// @Tags books
+// @Param id path string true "Book Id"
+// @Success 204 "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Return's if id is wrong"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 404 {object} errormiddleware.Error "Return's if book is not exists"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /books/{id} [delete]
+func (h *Handler) DeleteBook(w http.ResponseWriter, r *http.Request) error {
+	params := httprouter.ParamsFromContext(r.Context())
+	if len(params.ByName("id")) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	if err := h.BookService.DeleteBook(r.Context(), params.ByName("id")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Downloads book's pdf file
+// @Description Supports Range requests, so viewers can load file partially
+// @Description ETag and Last-Modified headers can be used for conditional requests
+// @Tags books
+// @Produce application/pdf
+// @Param id path string true "Book Id"
+// @Param Range header string false "Bytes range to load" example(bytes=0-1023)
+// @Success 200 {file} file "Book file"
+// @Success 206 {file} file "Requested part of the file"
+// @Success 304 "File was not modified"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 404 {object} errormiddleware.Error "Return's if book or it's file is not exists"
+// @Failure 416 "Requested range is not satisfiable"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /books/{id}/file [get]
+func (h *Handler) GetBookFile(w http.ResponseWriter, r *http.Request) error {
+	return h.streamBookFile(w, r, "file", "")
+}
+
+// @Summary Downloads book's cover
+// @Description Supports Range requests. ETag and Last-Modified headers can be used for conditional requests
+// @Tags books
+// @Produce image/png,image/jpeg
+// @Param id path string true "Book Id"
+// @Param size query string false "Thumbnail size. Original cover is returned if it's omitted" Enums(small, medium, large)
+// @Success 200 {file} file "Cover image"
+// @Success 206 {file} file "Requested part of the image"
+// @Success 304 "Cover was not modified"
+// @Failure 400 {object} errormiddleware.Error "Return's if size is wrong"
+// @Failure 404 {object} errormiddleware.Error "Return's if book or it's cover is not exists"
+// @Failure 416 "Requested range is not satisfiable"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Router /books/{id}/cover [get]
+func (h *Handler) GetBookCover(w http.ResponseWriter, r *http.Request) error {
+	return h.streamBookFile(w, r, "cover", r.URL.Query().Get("size"))
+}
+func (h *Handler) streamBookFile(w http.ResponseWriter, r *http.Request, file, size string) error {
+	params := httprouter.ParamsFromContext(r.Context())
+	if len(params.ByName("id")) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	response, err := h.BookService.GetBookFile(r.Context(), params.ByName("id"), file, size, r.Header)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Range", "Content-Disposition", "Accept-Ranges", "ETag", "Last-Modified"} {
+		if value := response.Header.Get(key); len(value) > 0 {
+			w.Header().Set(key, value)
+		} else {
+			w.Header().Del(key)
+		}
+	}
+	// files could be large, so server's write timeout must not interrupt them
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.WriteHeader(response.Status)
+	if _, err := io.Copy(w, response.Body); err != nil {
+		h.Logger.Warnf("book %s %s streaming interrupted: %v", params.ByName("id"), file, err)
+	}
+	return nil
+}
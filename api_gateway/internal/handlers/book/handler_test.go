@@ -0,0 +1,114 @@
+package book
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	model "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/book"
+	mock "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/book/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var h *Handler
+
+func TestMain(m *testing.M) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	h = &Handler{Logger: logger, Validator: validator.New()}
+
+	os.Exit(m.Run())
+}
+func TestRegister(t *testing.T) {
+	var registerCases = []struct {
+		Name   string
+		Path   string
+		Method string
+	}{
+		{"Add book", url_add_book, http.MethodPost},
+		{"Find books", url_get_book, http.MethodGet},
+		{"Get book by id", url_get_book_by_id, http.MethodGet},
+		{"Update book", url_book_by_id, http.MethodPatch},
+		{"Delete book", url_book_by_id, http.MethodDelete},
+		{"Get book file", url_book_file, http.MethodGet},
+		{"Get book cover", url_book_cover, http.MethodGet},
+	}
+
+	ctrl := gomock.NewController(t)
+	jwt := mock.NewMockJwtService(ctrl)
+	h.JwtService = jwt
+	jwt.EXPECT().Middleware(gomock.Any(), gomock.Any()).AnyTimes()
+	jwt.EXPECT().Middleware(gomock.Any()).AnyTimes()
+
+	router := httprouter.New()
+	h.Register(router)
+	for _, registerCase := range registerCases {
+		t.Run(registerCase.Name, func(t *testing.T) {
+			handler, _, _ := router.Lookup(registerCase.Method, registerCase.Path)
+			assert.NotNil(t, handler, "handler %s (%s) with method %s not found", registerCase.Name, registerCase.Path, registerCase.Method)
+		})
+	}
+}
+func TestStreamBookFile(t *testing.T) {
+	params := httprouter.Params{{Key: "id", Value: "000000000000000000000000"}}
+
+	t.Run("partial content", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		service := mock.NewMockBookService(ctrl)
+		h.BookService = service
+
+		r := httptest.NewRequest(http.MethodGet, "http://test", nil)
+		r.Header.Set("Range", "bytes=0-3")
+		r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, params))
+		w := httptest.NewRecorder()
+		// logging middleware sets json content type, it must be replaced
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		service.EXPECT().GetBookFile(gomock.Any(), "000000000000000000000000", "file", "", r.Header).Return(&model.BookFile{
+			Status: http.StatusPartialContent,
+			Header: http.Header{
+				"Content-Type":  {"application/pdf"},
+				"Content-Range": {"bytes 0-3/100"},
+				"Etag":          {`"etag"`},
+			},
+			Body: io.NopCloser(strings.NewReader("%PDF")),
+		}, nil)
+
+		err := errormiddleware.Middleware(h.GetBookFile)(w, r)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusPartialContent, w.Result().StatusCode)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, "bytes 0-3/100", w.Header().Get("Content-Range"))
+		assert.Equal(t, `"etag"`, w.Header().Get("ETag"))
+		assert.Equal(t, "%PDF", w.Body.String())
+	})
+	t.Run("service error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		service := mock.NewMockBookService(ctrl)
+		h.BookService = service
+
+		r := httptest.NewRequest(http.MethodGet, "http://test?size=small", nil)
+		r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, params))
+		w := httptest.NewRecorder()
+
+		service.EXPECT().GetBookFile(gomock.Any(), "000000000000000000000000", "cover", "small", gomock.Any()).Return(nil, errormiddleware.NotFoundError([]string{"file not exists"}, ""))
+
+		err := errormiddleware.Middleware(h.GetBookCover)(w, r)
+		assert.Equal(t, errormiddleware.NotFoundError([]string{"file not exists"}, ""), err)
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		assert.Equal(t, `{"messages":["file not exists"],"code":"IE-0002"}`, w.Body.String())
+	})
+}
@@ -53,11 +53,25 @@ func (mr *MockBookServiceMockRecorder) AddBook(ctx, body, contentType interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBook", reflect.TypeOf((*MockBookService)(nil).AddBook), ctx, body, contentType)
 }
 
+// DeleteBook mocks base method.
+func (m *MockBookService) DeleteBook(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBook", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBook indicates an expected call of DeleteBook.
+func (mr *MockBookServiceMockRecorder) DeleteBook(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockBookService)(nil).DeleteBook), ctx, id)
+}
+
 // FindBooks mocks base method.
-func (m *MockBookService) FindBooks(ctx context.Context, params url.Values) ([]*book.Book, error) {
+func (m *MockBookService) FindBooks(ctx context.Context, params url.Values) (*book.BookPage, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "FindBooks", ctx, params)
-	ret0, _ := ret[0].([]*book.Book)
+	ret0, _ := ret[0].(*book.BookPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -83,6 +97,36 @@ func (mr *MockBookServiceMockRecorder) GetBook(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBook", reflect.TypeOf((*MockBookService)(nil).GetBook), ctx, id)
 }
 
+// GetBookFile mocks base method.
+func (m *MockBookService) GetBookFile(ctx context.Context, id, file, size string, header http.Header) (*book.BookFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBookFile", ctx, id, file, size, header)
+	ret0, _ := ret[0].(*book.BookFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBookFile indicates an expected call of GetBookFile.
+func (mr *MockBookServiceMockRecorder) GetBookFile(ctx, id, file, size, header interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBookFile", reflect.TypeOf((*MockBookService)(nil).GetBookFile), ctx, id, file, size, header)
+}
+
+// UpdateBook mocks base method.
+func (m *MockBookService) UpdateBook(ctx context.Context, id string, body io.Reader, contentType string) (*book.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBook", ctx, id, body, contentType)
+	ret0, _ := ret[0].(*book.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateBook indicates an expected call of UpdateBook.
+func (mr *MockBookServiceMockRecorder) UpdateBook(ctx, id, body, contentType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBook", reflect.TypeOf((*MockBookService)(nil).UpdateBook), ctx, id, body, contentType)
+}
+
 // MockJwtService is a mock of JwtService interface.
 type MockJwtService struct {
 	ctrl     *gomock.Controller
@@ -107,10 +151,10 @@ func (m *MockJwtService) EXPECT() *MockJwtServiceMockRecorder {
 }
 
 // Middleware mocks base method.
-func (m *MockJwtService) Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+func (m *MockJwtService) Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{h}
-	for _, a := range roles {
+	for _, a := range permissions {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "Middleware", varargs...)
@@ -119,8 +163,8 @@ func (m *MockJwtService) Middleware(h http.HandlerFunc, roles ...string) http.Ha
 }
 
 // Middleware indicates an expected call of Middleware.
-func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, roles ...interface{}) *gomock.Call {
+func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, permissions ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{h}, roles...)
+	varargs := append([]interface{}{h}, permissions...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Middleware", reflect.TypeOf((*MockJwtService)(nil).Middleware), varargs...)
 }
@@ -2,30 +2,39 @@ package genre
 
 import (
 	"context"
-	"io"
+	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/julienschmidt/httprouter"
-	_ "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/genre"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/genre"
 	mw "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
 	valid "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 )
 
 const (
-	url_genres     = "/api/v1/genres"
-	url_all_genres = "/api/v1/genres/all"
+	url_genres      = "/api/v1/genres"
+	url_all_genres  = "/api/v1/genres/all"
+	url_genre_by_id = "/api/v1/genres/:id"
 )
 
 //go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
 
 type Service interface {
-	SendPostGeneric(ctx context.Context, path string, body []byte) ([]byte, error)
-	SendGetGeneric(ctx context.Context, path string, params map[string][]string) ([]byte, error)
+	GetGenres(ctx context.Context, params url.Values) ([]*genre.Genre, error)
+	GetAllGenres(ctx context.Context, params url.Values) (*genre.GenrePage, error)
+	AddGenre(ctx context.Context, query *genre.AddGenreQuery) (*genre.Genre, error)
+	UpdateGenre(ctx context.Context, id string, query *genre.UpdateGenreQuery) (*genre.Genre, error)
+	DeleteGenre(ctx context.Context, id string, cascade bool) error
 }
+
 type JwtService interface {
-	Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc
+	Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc
 }
 type Handler struct {
 	Logger       *logging.Logger
@@ -35,50 +44,58 @@ type Handler struct {
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
-	router.HandlerFunc(http.MethodPost, url_genres, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.AddGenre)), "admin"))
+	router.HandlerFunc(http.MethodPost, url_genres, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.AddGenre)), jwt.GenresWrite))
 	router.HandlerFunc(http.MethodGet, url_genres, h.Logger.Middleware(mw.Middleware(h.GetGenre)))
 	router.HandlerFunc(http.MethodGet, url_all_genres, h.Logger.Middleware(mw.Middleware(h.GetAllGenre)))
+	router.HandlerFunc(http.MethodPatch, url_genre_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.UpdateGenre)), jwt.GenresWrite))
+	router.HandlerFunc(http.MethodDelete, url_genre_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.DeleteGenre)), jwt.GenresWrite))
 	h.Logger.Info("genre handlers registered")
 }
 
 // @Summary Adds a genre
-// @Description Requires admin role to use
+// @Description Requires genres:write permission to use
 // @Tags genres
 // @Produce json
-// @Param Genre body genre.AddGenreQuery true "Genre name"
+// @Param Genre body genre.AddGenreQuery true "Genre data"
 // @Success 201 {object} genre.Genre "Successful response. Added genre"
 // @Failure 400 {object} errormiddleware.Error "Return's if request body was empty"
 // @Failure 401 {object} errormiddleware.Error "User is not authorized"
 // @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 409 {object} errormiddleware.Error "Return's if genre with this slug already exists"
 // @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
 // @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
 // @Security ApiKeyAuth
 // @Router /genres [post]
 func (h *Handler) AddGenre(w http.ResponseWriter, r *http.Request) error {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-	defer cancel()
-
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
+	var query genre.AddGenreQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
 		return mw.BadRequestError([]string{"can't read request body"}, err.Error())
 	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
 
-	response, err := h.GenreService.SendPostGeneric(ctx, "", body)
+	response, err := h.GenreService.AddGenre(ctx, &query)
 	if err != nil {
 		return err
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	w.Write(response)
+	data, _ := json.Marshal(response)
+	w.Write(data)
 	return nil
 }
 
-// @Summary Get genres by id
-// @Description You can use multiple ids in query using , separator
-// @Description Example: ?id=id1,id2,id3...
+// @Summary Get genres by id or slug
+// @Description You can use multiple ids and slugs in query using , separator
+// @Description Example: ?id=id1,fairy-tales,id3...
 // @Tags genres
 // @Produce json
-// @Param id query string true "Genre IDs"
+// @Param id query string true "Genre IDs or slugs"
+// @Param counts query bool false "Include amount of books in every genre"
 // @Success 200 {array} genre.Genre "Successful response"
 // @Failure 400 {object} errormiddleware.Error "Return's if received bad request"
 // @Failure 404 {object} errormiddleware.Error "Return's if genre was not found"
@@ -93,35 +110,124 @@ func (h *Handler) GetGenre(w http.ResponseWriter, r *http.Request) error {
 		return mw.BadRequestError([]string{"wrong request received"}, "id param is required")
 	}
 
-	response, err := h.GenreService.SendGetGeneric(ctx, "", map[string][]string{
-		"id": {code},
-	})
+	response, err := h.GenreService.GetGenres(ctx, r.URL.Query())
 	if err != nil {
 		return err
 	}
 
 	w.WriteHeader(http.StatusOK)
-	w.Write(response)
+	data, _ := json.Marshal(response)
+	w.Write(data)
 	return nil
 }
 
 // @Summary Get all genres stored in database
+// @Description Genres are sorted by name
+// @Description With tree=true root genres are returned as items with nested children, limit and cursor are ignored
 // @Tags genres
 // @Produce json
-// @Success 200 {array} genre.Genre "Successful response"
-// @Failure 404 {object} errormiddleware.Error "Return's if service does not have data"
+// @Param limit query string false "Max amount of genres to return. 50 by default" example(15)
+// @Param cursor query string false "Cursor to the next or previous page, taken from previous response"
+// @Param tree query bool false "Return genres as a tree"
+// @Param counts query bool false "Include amount of books in every genre"
+// @Success 200 {object} genre.GenrePage "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Returns if query or cursor was incorrect"
 // @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
 // @Router /genres/all [get]
 func (h *Handler) GetAllGenre(w http.ResponseWriter, r *http.Request) error {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	response, err := h.GenreService.SendGetGeneric(ctx, "/all", nil)
+	response, err := h.GenreService.GetAllGenres(ctx, r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(response)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Updates a genre
+// @Description Requires genres:write permission to use
+// @Description Only provided fields will be changed. Slug is kept when genre is renamed
+// @Tags genres
+// @Produce json
+// @Param id path string true "Genre Id"
+// @Param Genre body genre.UpdateGenreQuery true "Fields to update"
+// @Success 200 {object} genre.Genre "Successful response. Updated genre"
+// @Failure 400 {object} errormiddleware.Error "Return's if id, slug or parent is wrong"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 404 {object} errormiddleware.Error "Return's if genre was not found"
+// @Failure 409 {object} errormiddleware.Error "Return's if genre with this slug already exists"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /genres/{id} [patch]
+func (h *Handler) UpdateGenre(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	var query genre.UpdateGenreQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return mw.BadRequestError([]string{"can't read request body"}, err.Error())
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.GenreService.UpdateGenre(ctx, id, &query)
 	if err != nil {
 		return err
 	}
 
 	w.WriteHeader(http.StatusOK)
-	w.Write(response)
+	data, _ := json.Marshal(response)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Deletes a genre
+// @Description Requires genres:write permission to use
+// @Description Genre that is used by books can be deleted only with cascade=true, then it's removed from these books
+// @Description Children of deleted genre are moved to it's parent
+// @Tags genres
+// @Param id path string true "Genre Id"
+// @Param cascade query bool false "Remove genre from books that use it"
+// @Success 204 "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Return's if id or cascade is wrong"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no rights to use this handler"
+// @Failure 404 {object} errormiddleware.Error "Return's if genre was not found"
+// @Failure 409 {object} errormiddleware.Error "Return's if genre is used by books and cascade is not set"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /genres/{id} [delete]
+func (h *Handler) DeleteGenre(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	cascade := false
+	if r.URL.Query().Has("cascade") {
+		value, err := strconv.ParseBool(r.URL.Query().Get("cascade"))
+		if err != nil {
+			return mw.BadRequestError([]string{"cascade: must be true or false"}, err.Error())
+		}
+		cascade = value
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.GenreService.DeleteGenre(ctx, id, cascade); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
@@ -0,0 +1,287 @@
+package genre
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/genre"
+	mock "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/genre/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var h *Handler
+
+func TestMain(m *testing.M) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	h = &Handler{Logger: logger, Validator: validator.New()}
+
+	os.Exit(m.Run())
+}
+func TestRegister(t *testing.T) {
+	var registerCases = []struct {
+		Name   string
+		Path   string
+		Method string
+	}{
+		{"Add genre", url_genres, http.MethodPost},
+		{"Get genres", url_genres, http.MethodGet},
+		{"Get all genres", url_all_genres, http.MethodGet},
+		{"Update genre", url_genre_by_id, http.MethodPatch},
+		{"Delete genre", url_genre_by_id, http.MethodDelete},
+	}
+
+	ctrl := gomock.NewController(t)
+	jwt := mock.NewMockJwtService(ctrl)
+	h.JwtService = jwt
+	jwt.EXPECT().Middleware(gomock.Any(), gomock.Any()).AnyTimes()
+
+	router := httprouter.New()
+	h.Register(router)
+	for _, registerCase := range registerCases {
+		t.Run(registerCase.Name, func(t *testing.T) {
+			handler, _, _ := router.Lookup(registerCase.Method, registerCase.Path)
+			assert.NotNil(t, handler, "handler %s (%s) with method %s not found", registerCase.Name, registerCase.Path, registerCase.Method)
+		})
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	type handlerOptions struct {
+		Name           string
+		MockBehaviour  func(s *mock.MockService)
+		Query          string
+		Params         httprouter.Params
+		InputJson      func() *[]byte
+		ExceptedStatus int
+		ExceptedError  error
+		ExceptedBody   string
+	}
+	newName := "New name"
+	genreId := httprouter.Params{{Key: "id", Value: "000000000000000000000000"}}
+	var testTable = []struct {
+		HandlerName string
+		Handler     func(w http.ResponseWriter, r *http.Request) error
+		Method      string
+		Options     []handlerOptions
+	}{
+		//AddGenre
+		{
+			HandlerName: "AddGenre",
+			Handler:     h.AddGenre,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().AddGenre(gomock.Any(), &genre.AddGenreQuery{Name: "Genre name"}).Return(&genre.Genre{Name: "Genre name", Slug: "genre-name"}, nil)
+					},
+					InputJson: func() *[]byte {
+						body := []byte(`{"name":"Genre name"}`)
+						return &body
+					},
+					ExceptedStatus: http.StatusCreated,
+					ExceptedBody:   `{"id":"000000000000000000000000","name":"Genre name","slug":"genre-name"}`,
+				},
+				//Validation error
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						body := []byte(`{}`)
+						return &body
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"name: field is required"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["name: field is required"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+			},
+		},
+		//GetGenre
+		{
+			HandlerName: "GetGenre",
+			Handler:     h.GetGenre,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:  "success",
+					Query: "?id=000000000000000000000000",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetGenres(gomock.Any(), url.Values{"id": {"000000000000000000000000"}}).Return([]*genre.Genre{{Name: "Genre name", Slug: "genre-name"}}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `[{"id":"000000000000000000000000","name":"Genre name","slug":"genre-name"}]`,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"wrong request received"}, "id param is required"),
+					ExceptedBody:   `{"messages":["wrong request received"],"dev_message":"id param is required","code":"IE-0003"}`,
+				},
+			},
+		},
+		//GetAllGenre
+		{
+			HandlerName: "GetAllGenre",
+			Handler:     h.GetAllGenre,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name:  "success",
+					Query: "?limit=1",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetAllGenres(gomock.Any(), url.Values{"limit": {"1"}}).Return(&genre.GenrePage{Items: []*genre.Genre{{Name: "Genre name", Slug: "genre-name"}}, Total: 2, NextCursor: "next"}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[{"id":"000000000000000000000000","name":"Genre name","slug":"genre-name"}],"total":2,"next_cursor":"next"}`,
+				},
+				{
+					Name:  "tree",
+					Query: "?tree=true&counts=true",
+					MockBehaviour: func(s *mock.MockService) {
+						count := int64(2)
+						s.EXPECT().GetAllGenres(gomock.Any(), url.Values{"tree": {"true"}, "counts": {"true"}}).Return(&genre.GenrePage{Items: []*genre.Genre{{Name: "Parent", Slug: "parent", Books: &count, Children: []*genre.Genre{{Name: "Child", Slug: "child"}}}}, Total: 2}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[{"id":"000000000000000000000000","name":"Parent","slug":"parent","books":2,"children":[{"id":"000000000000000000000000","name":"Child","slug":"child"}]}],"total":2}`,
+				},
+			},
+		},
+		//UpdateGenre
+		{
+			HandlerName: "UpdateGenre",
+			Handler:     h.UpdateGenre,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:   "success",
+					Params: genreId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().UpdateGenre(gomock.Any(), "000000000000000000000000", &genre.UpdateGenreQuery{Name: &newName}).Return(&genre.Genre{Name: "New name", Slug: "genre-name"}, nil)
+					},
+					InputJson: func() *[]byte {
+						body := []byte(`{"name":"New name"}`)
+						return &body
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"000000000000000000000000","name":"New name","slug":"genre-name"}`,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad request"}, "id route must be presented"),
+					ExceptedBody:   `{"messages":["bad request"],"dev_message":"id route must be presented","code":"IE-0003"}`,
+				},
+			},
+		},
+		//DeleteGenre
+		{
+			HandlerName: "DeleteGenre",
+			Handler:     h.DeleteGenre,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				//Without cascade
+				{
+					Name:   "without cascade",
+					Params: genreId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteGenre(gomock.Any(), "000000000000000000000000", false).Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				//Used by books
+				{
+					Name:   "used genre",
+					Params: genreId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteGenre(gomock.Any(), "000000000000000000000000", false).Return(errormiddleware.NewError([]string{"genre is used by 3 books, use cascade=true to remove it from them"}, errormiddleware.NotUniqueErrorCode, "genre 000000000000000000000000 is referenced by books"))
+					},
+					ExceptedStatus: http.StatusConflict,
+					ExceptedError:  errormiddleware.NotUniqueError([]string{"genre is used by 3 books, use cascade=true to remove it from them"}, "genre 000000000000000000000000 is referenced by books"),
+					ExceptedBody:   `{"messages":["genre is used by 3 books, use cascade=true to remove it from them"],"dev_message":"genre 000000000000000000000000 is referenced by books","code":"IE-0006"}`,
+				},
+				//Cascade
+				{
+					Name:   "cascade",
+					Query:  "?cascade=true",
+					Params: genreId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteGenre(gomock.Any(), "000000000000000000000000", true).Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				//Wrong cascade
+				{
+					Name:           "wrong cascade",
+					Query:          "?cascade=yes",
+					Params:         genreId,
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"cascade: must be true or false"}, `strconv.ParseBool: parsing "yes": invalid syntax`),
+					ExceptedBody:   `{"messages":["cascade: must be true or false"],"dev_message":"strconv.ParseBool: parsing \"yes\": invalid syntax","code":"IE-0003"}`,
+				},
+				//Service error
+				{
+					Name:   "service error",
+					Query:  "?cascade=true",
+					Params: genreId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteGenre(gomock.Any(), "000000000000000000000000", true).Return(errors.New("service error"))
+					},
+					ExceptedStatus: http.StatusInternalServerError,
+					ExceptedError:  errors.New("service error"),
+					ExceptedBody:   `{"messages":["service error"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
+				},
+			},
+		},
+	}
+	for _, tt := range testTable {
+		for _, testCase := range tt.Options {
+			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				service := mock.NewMockService(ctrl)
+				if testCase.MockBehaviour != nil {
+					testCase.MockBehaviour(service)
+				}
+				h.GenreService = service
+
+				w := httptest.NewRecorder()
+				var r *http.Request
+				if testCase.InputJson != nil && testCase.InputJson() != nil {
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, bytes.NewBuffer(*testCase.InputJson()))
+				} else {
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, nil)
+				}
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
+				}
+				err := errormiddleware.Middleware(tt.Handler)(w, r)
+				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
+				assert.Equal(t, testCase.ExceptedError, err)
+
+				body := w.Body.String()
+				if assert.Len(t, body, len(testCase.ExceptedBody)) {
+					assert.Equal(t, testCase.ExceptedBody, body)
+				}
+			})
+		}
+	}
+}
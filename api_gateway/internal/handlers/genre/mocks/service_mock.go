@@ -7,9 +7,11 @@ package mock_genre
 import (
 	context "context"
 	http "net/http"
+	url "net/url"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	genre "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/genre"
 )
 
 // MockService is a mock of Service interface.
@@ -35,34 +37,78 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
-// SendGetGeneric mocks base method.
-func (m *MockService) SendGetGeneric(ctx context.Context, path string, params map[string][]string) ([]byte, error) {
+// AddGenre mocks base method.
+func (m *MockService) AddGenre(ctx context.Context, query *genre.AddGenreQuery) (*genre.Genre, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendGetGeneric", ctx, path, params)
-	ret0, _ := ret[0].([]byte)
+	ret := m.ctrl.Call(m, "AddGenre", ctx, query)
+	ret0, _ := ret[0].(*genre.Genre)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// SendGetGeneric indicates an expected call of SendGetGeneric.
-func (mr *MockServiceMockRecorder) SendGetGeneric(ctx, path, params interface{}) *gomock.Call {
+// AddGenre indicates an expected call of AddGenre.
+func (mr *MockServiceMockRecorder) AddGenre(ctx, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendGetGeneric", reflect.TypeOf((*MockService)(nil).SendGetGeneric), ctx, path, params)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGenre", reflect.TypeOf((*MockService)(nil).AddGenre), ctx, query)
 }
 
-// SendPostGeneric mocks base method.
-func (m *MockService) SendPostGeneric(ctx context.Context, path string, body []byte) ([]byte, error) {
+// DeleteGenre mocks base method.
+func (m *MockService) DeleteGenre(ctx context.Context, id string, cascade bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendPostGeneric", ctx, path, body)
-	ret0, _ := ret[0].([]byte)
+	ret := m.ctrl.Call(m, "DeleteGenre", ctx, id, cascade)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGenre indicates an expected call of DeleteGenre.
+func (mr *MockServiceMockRecorder) DeleteGenre(ctx, id, cascade interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGenre", reflect.TypeOf((*MockService)(nil).DeleteGenre), ctx, id, cascade)
+}
+
+// GetAllGenres mocks base method.
+func (m *MockService) GetAllGenres(ctx context.Context, params url.Values) (*genre.GenrePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllGenres", ctx, params)
+	ret0, _ := ret[0].(*genre.GenrePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllGenres indicates an expected call of GetAllGenres.
+func (mr *MockServiceMockRecorder) GetAllGenres(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGenres", reflect.TypeOf((*MockService)(nil).GetAllGenres), ctx, params)
+}
+
+// GetGenres mocks base method.
+func (m *MockService) GetGenres(ctx context.Context, params url.Values) ([]*genre.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenres", ctx, params)
+	ret0, _ := ret[0].([]*genre.Genre)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGenres indicates an expected call of GetGenres.
+func (mr *MockServiceMockRecorder) GetGenres(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenres", reflect.TypeOf((*MockService)(nil).GetGenres), ctx, params)
+}
+
+// UpdateGenre mocks base method.
+func (m *MockService) UpdateGenre(ctx context.Context, id string, query *genre.UpdateGenreQuery) (*genre.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGenre", ctx, id, query)
+	ret0, _ := ret[0].(*genre.Genre)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// SendPostGeneric indicates an expected call of SendPostGeneric.
-func (mr *MockServiceMockRecorder) SendPostGeneric(ctx, path, body interface{}) *gomock.Call {
+// UpdateGenre indicates an expected call of UpdateGenre.
+func (mr *MockServiceMockRecorder) UpdateGenre(ctx, id, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendPostGeneric", reflect.TypeOf((*MockService)(nil).SendPostGeneric), ctx, path, body)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGenre", reflect.TypeOf((*MockService)(nil).UpdateGenre), ctx, id, query)
 }
 
 // MockJwtService is a mock of JwtService interface.
@@ -89,10 +135,10 @@ func (m *MockJwtService) EXPECT() *MockJwtServiceMockRecorder {
 }
 
 // Middleware mocks base method.
-func (m *MockJwtService) Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+func (m *MockJwtService) Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{h}
-	for _, a := range roles {
+	for _, a := range permissions {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "Middleware", varargs...)
@@ -101,8 +147,8 @@ func (m *MockJwtService) Middleware(h http.HandlerFunc, roles ...string) http.Ha
 }
 
 // Middleware indicates an expected call of Middleware.
-func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, roles ...interface{}) *gomock.Call {
+func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, permissions ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{h}, roles...)
+	varargs := append([]interface{}{h}, permissions...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Middleware", reflect.TypeOf((*MockJwtService)(nil).Middleware), varargs...)
 }
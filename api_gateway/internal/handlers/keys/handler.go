@@ -0,0 +1,40 @@
+package keys
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	mw "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+)
+
+const (
+	url_jwks = "/.well-known/jwks.json"
+)
+
+type KeyService interface {
+	JWKS() *jwt.JWKSet
+}
+type Handler struct {
+	Logger     *logging.Logger
+	KeyService KeyService
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, url_jwks, h.Logger.Middleware(mw.Middleware(h.GetKeys)))
+	h.Logger.Info("key handlers registered")
+}
+
+// Public keys that tokens issued by gateway are signed with.
+// Services download them to verify forwarded tokens, so the set is cached for a short time only
+func (h *Handler) GetKeys(w http.ResponseWriter, r *http.Request) error {
+	data, _ := json.Marshal(h.KeyService.JWKS())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
@@ -0,0 +1,40 @@
+package keys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKeys(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	keys, _ := jwt.NewKeySet("", map[string]ed25519.PrivateKey{"20240101": priv}, nil)
+
+	router := httprouter.New()
+	h := &Handler{Logger: logger, KeyService: keys}
+	h.Register(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url_jwks, nil))
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var set jwt.JWKSet
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &set))
+	if assert.Len(t, set.Keys, 1) {
+		assert.Equal(t, "20240101", set.Keys[0].KeyId)
+		assert.Equal(t, "EdDSA", set.Keys[0].Algorithm)
+	}
+}
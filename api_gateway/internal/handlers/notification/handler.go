@@ -0,0 +1,295 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/notification"
+	mw "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/stream"
+)
+
+const (
+	url_notifications        = "/api/v1/notifications"
+	url_notifications_unread = "/api/v1/notifications/unread"
+	url_notifications_read   = "/api/v1/notifications/read"
+	url_notifications_stream = "/api/v1/notifications/stream"
+	url_notification_read    = "/api/v1/notifications/read/:id"
+	url_notification_by_id   = "/api/v1/notifications/:id"
+
+	stream_heartbeat = 15 * time.Second
+	stream_replay    = "100"
+)
+
+//go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
+
+type Service interface {
+	GetNotifications(ctx context.Context, params url.Values) (*notification.NotificationPage, error)
+	CountUnread(ctx context.Context) (*notification.UnreadCount, error)
+	MarkRead(ctx context.Context, id string) error
+	MarkAllRead(ctx context.Context) error
+	DeleteNotification(ctx context.Context, id string) error
+}
+
+// Hub keeps users connected to this instance
+type Hub interface {
+	Subscribe(userId string) (<-chan stream.Event, func())
+}
+type JwtService interface {
+	Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc
+}
+type Handler struct {
+	Logger              *logging.Logger
+	JwtService          JwtService
+	NotificationService Service
+	Hub                 Hub
+	// Interval of comments that keep idle stream alive, 15 seconds if it's not set
+	Heartbeat time.Duration
+}
+
+func (h *Handler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, url_notifications, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.GetNotifications))))
+	router.HandlerFunc(http.MethodGet, url_notifications_unread, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.CountUnread))))
+	router.HandlerFunc(http.MethodGet, url_notifications_stream, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.Stream))))
+	router.HandlerFunc(http.MethodPatch, url_notifications_read, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.MarkAllRead))))
+	router.HandlerFunc(http.MethodPatch, url_notification_read, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.MarkRead))))
+	router.HandlerFunc(http.MethodDelete, url_notification_by_id, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.DeleteNotification))))
+	h.Logger.Info("notification handlers registered")
+}
+
+// @Summary Get user's notifications
+// @Description Notifications are sorted from newest to oldest
+// @Tags notifications
+// @Produce json
+// @Param limit query int true "Max amount of notifications to return, up to 100" example(15)
+// @Param offset query int false "Amount of notifications to skip"
+// @Param type query string false "Notification type" Enums(info, warn, security)
+// @Param unread query bool false "Return only unread notifications"
+// @Param after query string false "Return only notifications sent after the one with this id"
+// @Success 200 {object} notification.NotificationPage "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Returns if limit or offset is not a number"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /notifications [get]
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.NotificationService.GetNotifications(ctx, r.URL.Query())
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(response)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Get amount of unread notifications
+// @Tags notifications
+// @Produce json
+// @Success 200 {object} notification.UnreadCount "Successful response"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /notifications/unread [get]
+func (h *Handler) CountUnread(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	response, err := h.NotificationService.CountUnread(ctx)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	data, _ := json.Marshal(response)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Marks notification as read
+// @Tags notifications
+// @Param id path string true "Notification Id"
+// @Success 204 "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Return's if id is wrong"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 404 {object} errormiddleware.Error "Return's if notification was not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /notifications/read/{id} [patch]
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.NotificationService.MarkRead(ctx, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Marks all user's notifications as read
+// @Tags notifications
+// @Success 204 "Successful response"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /notifications/read [patch]
+func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.NotificationService.MarkAllRead(ctx); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Deletes a notification
+// @Tags notifications
+// @Param id path string true "Notification Id"
+// @Success 204 "Successful response"
+// @Failure 400 {object} errormiddleware.Error "Return's if id is wrong"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 404 {object} errormiddleware.Error "Return's if notification was not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Security ApiKeyAuth
+// @Router /notifications/{id} [delete]
+func (h *Handler) DeleteNotification(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return mw.BadRequestError([]string{"bad request"}, "id route must be presented")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.NotificationService.DeleteNotification(ctx, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Stream of new user's notifications
+// @Description Server-Sent Events stream, every event is a notification with it's id as event id
+// @Description Comments are sent periodically to keep connection alive
+// @Description Notifications missed after the event from Last-Event-ID header (or lastEventId query) are sent first.
+// @Description If more than 100 notifications were missed, they are not sent, reset event with amount of them is sent instead and client should get them again from the list
+// @Tags notifications
+// @Produce text/event-stream
+// @Param Last-Event-ID header string false "Id of the last received notification"
+// @Param lastEventId query string false "Id of the last received notification, used when header can't be set"
+// @Success 200 {object} notification.Notification "Stream of notifications"
+// @Failure 401 {object} errormiddleware.Error "User is not authorized"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns if last event id was incorrect"
+// @Security ApiKeyAuth
+// @Router /notifications/stream [get]
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) error {
+	userId, _ := r.Context().Value(rest.UserIdKey).(string)
+	if len(userId) == 0 {
+		return mw.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	// subscription is made before replay, so notifications sent in between are not lost
+	events, unsubscribe := h.Hub.Subscribe(userId)
+	defer unsubscribe()
+
+	lastId := r.Header.Get("Last-Event-ID")
+	if len(lastId) == 0 {
+		lastId = r.URL.Query().Get("lastEventId")
+	}
+	var missed []*notification.Notification
+	var reset bool
+	var total int64
+	if len(lastId) > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		page, err := h.NotificationService.GetNotifications(ctx, url.Values{"after": {lastId}, "limit": {stream_replay}})
+		cancel()
+		if err != nil {
+			return err
+		}
+		missed = page.Items
+		// page has only the newest of missed notifications, older ones can't be replayed in order
+		total = page.Total
+		reset = page.Total > int64(len(page.Items))
+	}
+
+	// stream lives longer than server's timeouts
+	controller := http.NewResponseController(w)
+	if err := controller.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// missed notifications are sorted from newest, they're sent from oldest.
+	// Same notification may come from subscription too, it's skipped there
+	replayed := make(map[string]struct{}, len(missed))
+	for i := len(missed) - 1; i >= 0; i-- {
+		id := missed[i].Id.Hex()
+		replayed[id] = struct{}{}
+		if !reset {
+			data, _ := json.Marshal(missed[i])
+			fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", id, data)
+		}
+	}
+	// when too many notifications were missed client is told to get them again with the list.
+	// Event has id of the newest missed one, so reconnected stream continues after it
+	if reset && len(missed) > 0 {
+		fmt.Fprintf(w, "id: %s\nevent: reset\ndata: {\"missed\":%d}\n\n", missed[0].Id.Hex(), total)
+	}
+	if err := controller.Flush(); err != nil {
+		return nil
+	}
+
+	heartbeat := h.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = stream_heartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case event, ok := <-events:
+			// subscription was dropped, client reconnects with it's last event id
+			if !ok {
+				return nil
+			}
+			if _, ok := replayed[event.Id]; ok {
+				delete(replayed, event.Id)
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", event.Id, event.Data)
+		case <-ticker.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/notification"
+	mock "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/handlers/notification/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/stream"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var h *Handler
+
+func TestMain(m *testing.M) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	h = &Handler{Logger: logger}
+
+	os.Exit(m.Run())
+}
+func TestRegister(t *testing.T) {
+	var registerCases = []struct {
+		Name   string
+		Path   string
+		Method string
+	}{
+		{"Get notifications", url_notifications, http.MethodGet},
+		{"Count unread", url_notifications_unread, http.MethodGet},
+		{"Stream", url_notifications_stream, http.MethodGet},
+		{"Mark all read", url_notifications_read, http.MethodPatch},
+		{"Mark read", url_notification_read, http.MethodPatch},
+		{"Delete notification", url_notification_by_id, http.MethodDelete},
+	}
+
+	ctrl := gomock.NewController(t)
+	jwt := mock.NewMockJwtService(ctrl)
+	h.JwtService = jwt
+	jwt.EXPECT().Middleware(gomock.Any()).AnyTimes()
+
+	router := httprouter.New()
+	h.Register(router)
+	for _, registerCase := range registerCases {
+		t.Run(registerCase.Name, func(t *testing.T) {
+			handler, _, _ := router.Lookup(registerCase.Method, registerCase.Path)
+			assert.NotNil(t, handler, "handler %s (%s) with method %s not found", registerCase.Name, registerCase.Path, registerCase.Method)
+		})
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	type handlerOptions struct {
+		Name           string
+		MockBehaviour  func(s *mock.MockService)
+		Query          string
+		Params         httprouter.Params
+		ExceptedStatus int
+		ExceptedError  error
+		ExceptedBody   string
+	}
+	notificationId := httprouter.Params{{Key: "id", Value: "000000000000000000000000"}}
+	var testTable = []struct {
+		HandlerName string
+		Handler     func(w http.ResponseWriter, r *http.Request) error
+		Method      string
+		Options     []handlerOptions
+	}{
+		//GetNotifications
+		{
+			HandlerName: "GetNotifications",
+			Handler:     h.GetNotifications,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:  "success",
+					Query: "?limit=10&type=security",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetNotifications(gomock.Any(), url.Values{"limit": {"10"}, "type": {"security"}}).Return(&notification.NotificationPage{Items: []*notification.Notification{{Content: "content", Type: "security"}}, Total: 1, Unread: 1}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[{"id":"000000000000000000000000","sended":{"T":0,"I":0},"content":"content","type":"security","read":false}],"total":1,"unread":1}`,
+				},
+				//Service error
+				{
+					Name:  "validation",
+					Query: "?limit=0",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetNotifications(gomock.Any(), url.Values{"limit": {"0"}}).Return(nil, errormiddleware.NewError([]string{"limit must be greater than 0"}, errormiddleware.ValidationErrorCode, "wrong notifications query"))
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"limit must be greater than 0"}, errormiddleware.ValidationErrorCode, "wrong notifications query"),
+					ExceptedBody:   `{"messages":["limit must be greater than 0"],"dev_message":"wrong notifications query","code":"IE-0004"}`,
+				},
+			},
+		},
+		//CountUnread
+		{
+			HandlerName: "CountUnread",
+			Handler:     h.CountUnread,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().CountUnread(gomock.Any()).Return(&notification.UnreadCount{Unread: 3}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"unread":3}`,
+				},
+				//Service error
+				{
+					Name: "service error",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().CountUnread(gomock.Any()).Return(nil, errors.New("error"))
+					},
+					ExceptedStatus: http.StatusInternalServerError,
+					ExceptedError:  errors.New("error"),
+					ExceptedBody:   `{"messages":["error"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
+				},
+			},
+		},
+		//MarkRead
+		{
+			HandlerName: "MarkRead",
+			Handler:     h.MarkRead,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:   "success",
+					Params: notificationId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().MarkRead(gomock.Any(), "000000000000000000000000").Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				//Not found
+				{
+					Name:   "not found",
+					Params: notificationId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().MarkRead(gomock.Any(), "000000000000000000000000").Return(errormiddleware.NotFoundError([]string{"notification with provided id not found"}, ""))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"notification with provided id not found"}, ""),
+					ExceptedBody:   `{"messages":["notification with provided id not found"],"code":"IE-0002"}`,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad request"}, "id route must be presented"),
+					ExceptedBody:   `{"messages":["bad request"],"dev_message":"id route must be presented","code":"IE-0003"}`,
+				},
+			},
+		},
+		//MarkAllRead
+		{
+			HandlerName: "MarkAllRead",
+			Handler:     h.MarkAllRead,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().MarkAllRead(gomock.Any()).Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+			},
+		},
+		//DeleteNotification
+		{
+			HandlerName: "DeleteNotification",
+			Handler:     h.DeleteNotification,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				//Successful
+				{
+					Name:   "success",
+					Params: notificationId,
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteNotification(gomock.Any(), "000000000000000000000000").Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				//Empty id
+				{
+					Name:           "empty id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad request"}, "id route must be presented"),
+					ExceptedBody:   `{"messages":["bad request"],"dev_message":"id route must be presented","code":"IE-0003"}`,
+				},
+			},
+		},
+	}
+	for _, tt := range testTable {
+		for _, testCase := range tt.Options {
+			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				service := mock.NewMockService(ctrl)
+				if testCase.MockBehaviour != nil {
+					testCase.MockBehaviour(service)
+				}
+				h.NotificationService = service
+
+				w := httptest.NewRecorder()
+				r := httptest.NewRequest(tt.Method, "http://test"+testCase.Query, nil)
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
+				}
+				err := errormiddleware.Middleware(tt.Handler)(w, r)
+				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
+				assert.Equal(t, testCase.ExceptedError, err)
+
+				body := w.Body.String()
+				if assert.Len(t, body, len(testCase.ExceptedBody)) {
+					assert.Equal(t, testCase.ExceptedBody, body)
+				}
+			})
+		}
+	}
+}
+func TestStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	id := func(hex string) primitive.ObjectID {
+		id, _ := primitive.ObjectIDFromHex(hex)
+		return id
+	}
+	request := func(userId string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://test", nil)
+		if len(userId) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), rest.UserIdKey, userId))
+		}
+		return r
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		err := errormiddleware.Middleware(h.Stream)(w, request(""))
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+		assert.Equal(t, errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty"), err)
+	})
+	t.Run("replay error", func(t *testing.T) {
+		service := mock.NewMockService(ctrl)
+		hub := mock.NewMockHub(ctrl)
+		h.NotificationService, h.Hub = service, hub
+
+		events := make(chan stream.Event)
+		hub.EXPECT().Subscribe("userid").Return(events, func() {})
+		service.EXPECT().GetNotifications(gomock.Any(), url.Values{"after": {"wrong"}, "limit": {"100"}}).Return(nil, errormiddleware.NewError([]string{"after must be a primitive id type"}, errormiddleware.ValidationErrorCode, "wrong notifications query"))
+
+		w := httptest.NewRecorder()
+		r := request("userid")
+		r.Header.Set("Last-Event-ID", "wrong")
+		err := errormiddleware.Middleware(h.Stream)(w, r)
+		assert.Equal(t, http.StatusNotImplemented, w.Result().StatusCode)
+		assert.Error(t, err)
+	})
+	t.Run("replay and live events", func(t *testing.T) {
+		service := mock.NewMockService(ctrl)
+		hub := mock.NewMockHub(ctrl)
+		h.NotificationService, h.Hub = service, hub
+
+		events := make(chan stream.Event, 2)
+		events <- stream.Event{Id: "000000000000000000000003", Data: []byte(`{"id":"000000000000000000000003"}`)}
+		events <- stream.Event{Id: "000000000000000000000004", Data: []byte(`{"id":"000000000000000000000004"}`)}
+		close(events)
+		unsubscribed := false
+		hub.EXPECT().Subscribe("userid").Return(events, func() { unsubscribed = true })
+		service.EXPECT().GetNotifications(gomock.Any(), url.Values{"after": {"000000000000000000000001"}, "limit": {"100"}}).Return(&notification.NotificationPage{Items: []*notification.Notification{
+			{Id: id("000000000000000000000003"), Content: "third"},
+			{Id: id("000000000000000000000002"), Content: "second"},
+		}}, nil)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "http://test?lastEventId=000000000000000000000001", nil)
+		r = r.WithContext(context.WithValue(r.Context(), rest.UserIdKey, "userid"))
+		err := errormiddleware.Middleware(h.Stream)(w, r)
+		assert.NoError(t, err)
+		assert.True(t, unsubscribed)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.Equal(t, "text/event-stream", w.Result().Header.Get("Content-Type"))
+		assert.Equal(t, "id: 000000000000000000000002\nevent: notification\ndata: {\"id\":\"000000000000000000000002\",\"sended\":{\"T\":0,\"I\":0},\"content\":\"second\",\"type\":\"\",\"read\":false}\n\n"+
+			"id: 000000000000000000000003\nevent: notification\ndata: {\"id\":\"000000000000000000000003\",\"sended\":{\"T\":0,\"I\":0},\"content\":\"third\",\"type\":\"\",\"read\":false}\n\n"+
+			"id: 000000000000000000000004\nevent: notification\ndata: {\"id\":\"000000000000000000000004\"}\n\n", w.Body.String())
+	})
+	t.Run("too many missed", func(t *testing.T) {
+		service := mock.NewMockService(ctrl)
+		hub := mock.NewMockHub(ctrl)
+		h.NotificationService, h.Hub = service, hub
+
+		events := make(chan stream.Event, 2)
+		events <- stream.Event{Id: "000000000000000000000003", Data: []byte(`{"id":"000000000000000000000003"}`)}
+		events <- stream.Event{Id: "000000000000000000000004", Data: []byte(`{"id":"000000000000000000000004"}`)}
+		close(events)
+		hub.EXPECT().Subscribe("userid").Return(events, func() {})
+		service.EXPECT().GetNotifications(gomock.Any(), url.Values{"after": {"000000000000000000000001"}, "limit": {"100"}}).Return(&notification.NotificationPage{Total: 150, Items: []*notification.Notification{
+			{Id: id("000000000000000000000003"), Content: "third"},
+			{Id: id("000000000000000000000002"), Content: "second"},
+		}}, nil)
+
+		w := httptest.NewRecorder()
+		r := request("userid")
+		r.Header.Set("Last-Event-ID", "000000000000000000000001")
+		err := errormiddleware.Middleware(h.Stream)(w, r)
+		assert.NoError(t, err)
+		assert.Equal(t, "id: 000000000000000000000003\nevent: reset\ndata: {\"missed\":150}\n\n"+
+			"id: 000000000000000000000004\nevent: notification\ndata: {\"id\":\"000000000000000000000004\"}\n\n", w.Body.String())
+	})
+	t.Run("heartbeat", func(t *testing.T) {
+		hub := mock.NewMockHub(ctrl)
+		h.Hub = hub
+		h.Heartbeat = 5 * time.Millisecond
+		defer func() { h.Heartbeat = 0 }()
+
+		hub.EXPECT().Subscribe("userid").Return(make(chan stream.Event), func() {})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		w := httptest.NewRecorder()
+		r := request("userid").WithContext(context.WithValue(ctx, rest.UserIdKey, "userid"))
+		err := errormiddleware.Middleware(h.Stream)(w, r)
+		assert.NoError(t, err)
+		assert.Contains(t, w.Body.String(), ": heartbeat\n\n")
+	})
+}
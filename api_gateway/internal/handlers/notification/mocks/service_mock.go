@@ -0,0 +1,191 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+
+// Package mock_notification is a generated GoMock package.
+package mock_notification
+
+import (
+	context "context"
+	http "net/http"
+	url "net/url"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	notification "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/notification"
+	stream "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/stream"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// CountUnread mocks base method.
+func (m *MockService) CountUnread(ctx context.Context) (*notification.UnreadCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", ctx)
+	ret0, _ := ret[0].(*notification.UnreadCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread.
+func (mr *MockServiceMockRecorder) CountUnread(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockService)(nil).CountUnread), ctx)
+}
+
+// DeleteNotification mocks base method.
+func (m *MockService) DeleteNotification(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotification", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotification indicates an expected call of DeleteNotification.
+func (mr *MockServiceMockRecorder) DeleteNotification(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotification", reflect.TypeOf((*MockService)(nil).DeleteNotification), ctx, id)
+}
+
+// GetNotifications mocks base method.
+func (m *MockService) GetNotifications(ctx context.Context, params url.Values) (*notification.NotificationPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", ctx, params)
+	ret0, _ := ret[0].(*notification.NotificationPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockServiceMockRecorder) GetNotifications(ctx, params interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockService)(nil).GetNotifications), ctx, params)
+}
+
+// MarkAllRead mocks base method.
+func (m *MockService) MarkAllRead(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllRead", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAllRead indicates an expected call of MarkAllRead.
+func (mr *MockServiceMockRecorder) MarkAllRead(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllRead", reflect.TypeOf((*MockService)(nil).MarkAllRead), ctx)
+}
+
+// MarkRead mocks base method.
+func (m *MockService) MarkRead(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockServiceMockRecorder) MarkRead(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockService)(nil).MarkRead), ctx, id)
+}
+
+// MockHub is a mock of Hub interface.
+type MockHub struct {
+	ctrl     *gomock.Controller
+	recorder *MockHubMockRecorder
+}
+
+// MockHubMockRecorder is the mock recorder for MockHub.
+type MockHubMockRecorder struct {
+	mock *MockHub
+}
+
+// NewMockHub creates a new mock instance.
+func NewMockHub(ctrl *gomock.Controller) *MockHub {
+	mock := &MockHub{ctrl: ctrl}
+	mock.recorder = &MockHubMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHub) EXPECT() *MockHubMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockHub) Subscribe(userId string) (<-chan stream.Event, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", userId)
+	ret0, _ := ret[0].(<-chan stream.Event)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockHubMockRecorder) Subscribe(userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockHub)(nil).Subscribe), userId)
+}
+
+// MockJwtService is a mock of JwtService interface.
+type MockJwtService struct {
+	ctrl     *gomock.Controller
+	recorder *MockJwtServiceMockRecorder
+}
+
+// MockJwtServiceMockRecorder is the mock recorder for MockJwtService.
+type MockJwtServiceMockRecorder struct {
+	mock *MockJwtService
+}
+
+// NewMockJwtService creates a new mock instance.
+func NewMockJwtService(ctrl *gomock.Controller) *MockJwtService {
+	mock := &MockJwtService{ctrl: ctrl}
+	mock.recorder = &MockJwtServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJwtService) EXPECT() *MockJwtServiceMockRecorder {
+	return m.recorder
+}
+
+// Middleware mocks base method.
+func (m *MockJwtService) Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{h}
+	for _, a := range permissions {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Middleware", varargs...)
+	ret0, _ := ret[0].(http.HandlerFunc)
+	return ret0
+}
+
+// Middleware indicates an expected call of Middleware.
+func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, permissions ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{h}, permissions...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Middleware", reflect.TypeOf((*MockJwtService)(nil).Middleware), varargs...)
+}
@@ -2,9 +2,12 @@ package user
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -13,6 +16,10 @@ import (
 	mw "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/oidc"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/ratelimit"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 	valid "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 )
 
@@ -23,6 +30,24 @@ const (
 	url_find_user         = "/api/v1/users"
 	url_delete_user       = "/api/v1/users/delete"
 	url_update_user_login = "/api/v1/users/changename"
+	url_logout            = "/api/v1/users/logout"
+	url_sessions          = "/api/v1/users/sessions"
+	url_session           = "/api/v1/users/sessions/:id"
+	url_update_password   = "/api/v1/users/password"
+	url_update_email      = "/api/v1/users/email"
+	url_password_forgot   = "/api/v1/users/password/forgot"
+	url_password_reset    = "/api/v1/users/password/reset"
+	url_auth_twofactor    = "/api/v1/users/auth/2fa"
+	url_twofactor_enroll  = "/api/v1/users/2fa/enroll"
+	url_twofactor_enable  = "/api/v1/users/2fa/enable"
+	url_twofactor_disable = "/api/v1/users/2fa/disable"
+	url_user_roles        = "/api/v1/users/roles"
+	url_oidc              = "/api/v1/users/oidc"
+	url_oidc_login        = "/api/v1/users/oidc/login"
+	url_oidc_callback     = "/api/v1/users/oidc/callback"
+
+	oidc_state_cookie = "oidcState"
+	oidc_state_ttl    = 10 * time.Minute
 )
 
 //go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
@@ -34,17 +59,50 @@ type UserService interface {
 	FindUser(ctx context.Context, userid string, login string) (*model.User, error)
 	DeleteUser(ctx context.Context, query *model.DeleteUserQuery) error
 	UpdateUserLogin(ctx context.Context, query *model.UpdateUserLoginQuery) (*model.User, error)
+	UpdateUserPassword(ctx context.Context, query *model.UpdateUserPasswordQuery) (*model.User, error)
+	UpdateUserEmail(ctx context.Context, query *model.UpdateUserEmailQuery) (*model.User, error)
+	ForgotPassword(ctx context.Context, query *model.ForgotPasswordQuery) error
+	ResetPassword(ctx context.Context, query *model.ResetPasswordQuery) error
+	EnrollTwoFactor(ctx context.Context) (*model.TwoFactorEnrollment, error)
+	EnableTwoFactor(ctx context.Context, query *model.TwoFactorCodeQuery) (*model.RecoveryCodes, error)
+	DisableTwoFactor(ctx context.Context, query *model.DisableTwoFactorQuery) error
+	VerifyTwoFactor(ctx context.Context, query *model.VerifyTwoFactorQuery) (*model.User, error)
+	SignInWithIdentity(ctx context.Context, query *model.IdentitySignInQuery) (*model.User, error)
+	GrantRole(ctx context.Context, query *model.ChangeUserRoleQuery) (*model.User, error)
+	RevokeRole(ctx context.Context, query *model.ChangeUserRoleQuery) (*model.User, error)
 }
 type JwtService interface {
-	Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc
-	GenerateAccessToken(u *model.User) (*model.JwtResponse, error)
+	Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc
+	GenerateAccessToken(ctx context.Context, u *model.User) (*model.JwtResponse, error)
 	GetUserClaims(token string) (*model.JwtResponse, error)
+	RevokeSession(ctx context.Context, refreshToken string) error
+	GetSessions(ctx context.Context, userId string, current string) ([]*model.Session, error)
+	RevokeUserSession(ctx context.Context, userId string, sessionId string) error
+	GeneratePreAuthToken(u *model.User) (*model.PreAuthResponse, error)
+	VerifyPreAuthToken(token string) (string, error)
+}
+type OidcProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Identity, error)
 }
 type Handler struct {
 	Logger      *logging.Logger
 	JwtService  JwtService
 	UserService UserService
 	Validator   *valid.Validator
+	// Limits password recovery requests, so they can't be used to spam emails or guess tokens
+	PasswordLimiter *ratelimit.Limiter
+	// Sign in with OpenID provider is not registered if provider is nil
+	Oidc         OidcProvider
+	OidcRedirect string
+}
+
+// Kept in cookie between redirect to provider and callback, so callback can't be called with someone else's code
+type oidcState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
@@ -55,9 +113,148 @@ func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, url_find_user, h.Logger.Middleware(mw.Middleware(h.FindUser)))
 	router.HandlerFunc(http.MethodDelete, url_delete_user, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.DeleteUser))))
 	router.HandlerFunc(http.MethodPatch, url_update_user_login, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.UpdateUserLogin))))
+	router.HandlerFunc(http.MethodPost, url_logout, h.Logger.Middleware(mw.Middleware(h.Logout)))
+	router.HandlerFunc(http.MethodGet, url_sessions, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.GetSessions))))
+	router.HandlerFunc(http.MethodDelete, url_session, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.RevokeSession))))
+	router.HandlerFunc(http.MethodPatch, url_update_password, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.UpdateUserPassword))))
+	router.HandlerFunc(http.MethodPatch, url_update_email, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.UpdateUserEmail))))
+	router.HandlerFunc(http.MethodPost, url_password_forgot, h.Logger.Middleware(mw.Middleware(h.PasswordLimiter.Middleware(h.ForgotPassword))))
+	router.HandlerFunc(http.MethodPost, url_password_reset, h.Logger.Middleware(mw.Middleware(h.PasswordLimiter.Middleware(h.ResetPassword))))
+	router.HandlerFunc(http.MethodPost, url_auth_twofactor, h.Logger.Middleware(mw.Middleware(h.AuthenticateTwoFactor)))
+	router.HandlerFunc(http.MethodPost, url_twofactor_enroll, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.EnrollTwoFactor))))
+	router.HandlerFunc(http.MethodPost, url_twofactor_enable, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.EnableTwoFactor))))
+	router.HandlerFunc(http.MethodPost, url_twofactor_disable, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.DisableTwoFactor))))
+	router.HandlerFunc(http.MethodPost, url_user_roles, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.GrantRole)), jwt.RolesWrite))
+	router.HandlerFunc(http.MethodDelete, url_user_roles, h.JwtService.Middleware(h.Logger.Middleware(mw.Middleware(h.RevokeRole)), jwt.RolesWrite))
+	if h.Oidc != nil {
+		router.HandlerFunc(http.MethodGet, url_oidc_login, h.Logger.Middleware(mw.Middleware(h.OidcLogin)))
+		router.HandlerFunc(http.MethodGet, url_oidc_callback, h.Logger.Middleware(mw.Middleware(h.OidcCallback)))
+		h.Logger.Infof("sign in with %s registered", h.Oidc.Name())
+	}
 	h.Logger.Info("auth handlers registered")
 }
 
+// @Summary Logs user out
+// @Description Ends current session and clears token cookies. Succeeds even if session is already expired
+// @Tags users
+// @Success 204 "Successful response. Session was ended"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Router /users/logout [post]
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) error {
+	jwt.ClearCookies(w)
+	if cookie, err := r.Cookie(jwt.RefreshCookieName); err == nil && len(cookie.Value) > 0 {
+		if err := h.JwtService.RevokeSession(r.Context(), cookie.Value); err != nil {
+			if e, ok := err.(*mw.Error); !ok || e.Code != mw.NotFoundErrorCode {
+				return err
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Request password reset
+// @Description Sends a one-time password reset link to the email if it belongs to some user
+// @Description Response is the same for unknown emails, so it can't be used to find out registered ones
+// @Tags users
+// @Param query body model.ForgotPasswordQuery true "User's email"
+// @Success 204 "Successful response. Reset link was sent if user exists"
+// @Failure 429 {object} errormiddleware.Error "Returns when client sent too many password recovery requests"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed or smtp server is not responding"
+// @Failure 501 {object} errormiddleware.Error "Returns when provided data was not validated"
+// @Router /users/password/forgot [post]
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+	var query model.ForgotPasswordQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong query format")
+	}
+	if err := h.UserService.ForgotPassword(r.Context(), &query); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Reset user's password
+// @Description Sets a new password using token from reset link. Token can be used only once and expires within 30 minutes
+// @Description All user's sessions are ended after the password is changed
+// @Tags users
+// @Param query body model.ResetPasswordQuery true "Reset token and new password"
+// @Success 204 "Successful response. Password was changed"
+// @Failure 404 {object} errormiddleware.Error "Returns when token is invalid, expired or already used"
+// @Failure 429 {object} errormiddleware.Error "Returns when client sent too many password recovery requests"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns when provided data was not validated"
+// @Router /users/password/reset [post]
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+	var query model.ResetPasswordQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong query format")
+	}
+	if err := h.UserService.ResetPassword(r.Context(), &query); err != nil {
+		return err
+	}
+	jwt.ClearCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// @Summary Get user's active sessions
+// @Description Returns sessions sorted by creation time, newest first. Session of current request is marked
+// @Tags users
+// @Produce json
+// @Success 200 {array} model.Session "Successful response"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Security ApiKeyAuth
+// @Router /users/sessions [get]
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	userId, _ := r.Context().Value(rest.UserIdKey).(string)
+	current, _ := r.Context().Value(rest.SessionIdKey).(string)
+	sessions, err := h.JwtService.GetSessions(r.Context(), userId, current)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(sessions)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Revoke user's session
+// @Description Logs out the device that owns the session. Access token of that device is rejected on it's next request
+// @Tags users
+// @Param id path string true "Session id"
+// @Success 204 "Successful response. Session was revoked"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 404 {object} errormiddleware.Error "Return's if user has no session with provided id"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Security ApiKeyAuth
+// @Router /users/sessions/{id} [delete]
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) error {
+	params := httprouter.ParamsFromContext(r.Context())
+	if len(params.ByName("id")) == 0 {
+		return mw.BadRequestError([]string{"session id must be provided"}, "received empty session id")
+	}
+	userId, _ := r.Context().Value(rest.UserIdKey).(string)
+	if err := h.JwtService.RevokeUserSession(r.Context(), userId, params.ByName("id")); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // @Summary Update user's login
 // @Description New login must be unique. Login changing are available only 1 time per month
 // @Tags users
@@ -89,7 +286,7 @@ func (h *Handler) UpdateUserLogin(w http.ResponseWriter, r *http.Request) error
 	if err != nil {
 		return err
 	}
-	token, err := h.JwtService.GenerateAccessToken(user)
+	token, err := h.JwtService.GenerateAccessToken(session.NewContext(ctx, r), user)
 	if err != nil {
 		h.Logger.Warn(err)
 		return err
@@ -103,6 +300,86 @@ func (h *Handler) UpdateUserLogin(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
+// @Summary Update user's password
+// @Description Current password must be provided. All other user's sessions are ended, current one gets new tokens
+// @Tags users
+// @Produce json
+// @Param query body model.UpdateUserPasswordQuery true "Current and new passwords"
+// @Success 200 {object} model.JwtResponse "Successful response. Password was changed"
+// @Failure 400 {object} errormiddleware.Error "Return's if user typed incorrect current password"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /users/password [patch]
+func (h *Handler) UpdateUserPassword(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	var query model.UpdateUserPasswordQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.UserService.UpdateUserPassword(ctx, &query)
+	if err != nil {
+		return err
+	}
+	// sessions started before the change are revoked, so current one is replaced by a new session
+	token, err := h.JwtService.GenerateAccessToken(session.NewContext(ctx, r), user)
+	if err != nil {
+		h.Logger.Warn(err)
+		return err
+	}
+	data, _ := json.Marshal(token)
+
+	http.SetCookie(w, token.Token)
+	http.SetCookie(w, token.RefreshToken)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Update user's email
+// @Description Sends confirmation message to the new email. Current email is kept until the new one is confirmed
+// @Tags users
+// @Produce json
+// @Param query body model.UpdateUserEmailQuery true "New email. Must be unique"
+// @Success 200 {object} model.User "Successful response. New email is waiting for confirmation"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 409 {object} errormiddleware.Error "Return's if new email already taken"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /users/email [patch]
+func (h *Handler) UpdateUserEmail(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	var query model.UpdateUserEmailQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.UserService.UpdateUserEmail(ctx, &query)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(user)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
 // @Summary Deletes user's account
 // @Description Only user can delete his own account. To delete user he needs to confirm his password
 // @Tags users
@@ -196,7 +473,7 @@ func (h *Handler) FindUser(w http.ResponseWriter, r *http.Request) error {
 // @Description Authorizes user's credentials by token. This needs to check if user's token is valid or get current authenticated user
 // @Produce json
 // @Tags users
-// @Success 200 {object} model.JwtResponse "Successful response. Returns user's login, roles and personal token and refresh token. Refresh token stores in cache"
+// @Success 200 {object} model.JwtResponse "Successful response. Returns user's login, roles and personal token and refresh token. Refresh token belongs to server-side session"
 // @Failure 401 {object} errormiddleware.Error "Returns if user not authorized"
 // @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
 // @Security ApiKeyAuth
@@ -204,12 +481,17 @@ func (h *Handler) FindUser(w http.ResponseWriter, r *http.Request) error {
 func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) error {
 	w.Header().Set("Content-Type", "application/json")
 
-	tokenString, err := r.Cookie(jwt.TokenCookieName)
-	if err != nil {
-		return mw.UnauthorizedError([]string{"user not authorized"}, err.Error())
+	// token may be refreshed by middleware when access cookie is expired, refreshed one is in context
+	tokenString, _ := r.Context().Value(rest.TokenKey).(string)
+	if len(tokenString) == 0 {
+		cookie, err := r.Cookie(jwt.TokenCookieName)
+		if err != nil {
+			return mw.UnauthorizedError([]string{"user not authorized"}, err.Error())
+		}
+		tokenString = cookie.Value
 	}
 
-	token, err := h.JwtService.GetUserClaims(tokenString.Value)
+	token, err := h.JwtService.GetUserClaims(tokenString)
 	if err != nil {
 		h.Logger.Warn(err)
 		return err
@@ -225,11 +507,14 @@ func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) error {
 // @Description Finds user by login and password
 // @Description Sets token to cookies
 // @Description Login field can be provided with user login or email
+// @Description If user has two-factor authentication enabled, no cookies are set. Returned pre-auth token must be sent to /users/auth/2fa with the code
 // @Produce json
 // @Tags users
 // @Param query body model.UserAuthQuery true "User credentials"
 // @Success 200 {object} model.JwtResponse "Successful response. Returns user's login and roles
+// @Success 202 {object} model.PreAuthResponse "Password is correct, second factor is required"
 // @Failure 404 {object} errormiddleware.Error "Returns when service can't find user by provided credentials (user not found)"
+// @Failure 423 {object} errormiddleware.Error "Returns when there were too many failed attempts from the account or ip. Message contains how long to wait"
 // @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
 // @Failure 501 {object} errormiddleware.Error "Returns when provided data was not validated"
 // @Router /users/auth [post]
@@ -244,11 +529,69 @@ func (h *Handler) Authenticate(w http.ResponseWriter, r *http.Request) error {
 	if err := h.Validator.Struct(query); err != nil {
 		return mw.ValidationError(err.(validator.ValidationErrors), "wrong query format")
 	}
-	model, err := h.UserService.AuthByLoginAndPassword(r.Context(), &query)
+	ctx := session.NewContext(r.Context(), r)
+	model, err := h.UserService.AuthByLoginAndPassword(ctx, &query)
+	if err != nil {
+		return err
+	}
+	if model != nil && model.TwoFactor {
+		preauth, err := h.JwtService.GeneratePreAuthToken(model)
+		if err != nil {
+			h.Logger.Warn(err)
+			return err
+		}
+		data, _ := json.Marshal(preauth)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(data)
+		return nil
+	}
+	token, err := h.JwtService.GenerateAccessToken(ctx, model)
+	if err != nil {
+		h.Logger.Warn(err)
+		return err
+	}
+	data, _ := json.Marshal(token)
+
+	http.SetCookie(w, token.Token)
+	http.SetCookie(w, token.RefreshToken)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Completes two-factor authentication
+// @Description Exchanges pre-auth token and the code for user's tokens. Sets token to cookies
+// @Description Code can be taken from authenticator app or be one of recovery codes, each recovery code works only once
+// @Produce json
+// @Tags users
+// @Param query body model.TwoFactorAuthQuery true "Pre-auth token and the code"
+// @Success 200 {object} model.JwtResponse "Successful response. Returns user's login and roles"
+// @Failure 401 {object} errormiddleware.Error "Returns when pre-auth token is expired or the code is wrong"
+// @Failure 423 {object} errormiddleware.Error "Returns when there were too many failed attempts"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns when provided data was not validated"
+// @Router /users/auth/2fa [post]
+func (h *Handler) AuthenticateTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	defer r.Body.Close()
+	var query model.TwoFactorAuthQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong query format")
+	}
+	userId, err := h.JwtService.VerifyPreAuthToken(query.PreAuth)
 	if err != nil {
 		return err
 	}
-	token, err := h.JwtService.GenerateAccessToken(model)
+	ctx := session.NewContext(r.Context(), r)
+	user, err := h.UserService.VerifyTwoFactor(ctx, &model.VerifyTwoFactorQuery{UserId: userId, Code: query.Code})
+	if err != nil {
+		return err
+	}
+	token, err := h.JwtService.GenerateAccessToken(ctx, user)
 	if err != nil {
 		h.Logger.Warn(err)
 		return err
@@ -262,6 +605,266 @@ func (h *Handler) Authenticate(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// @Summary Grant role to user
+// @Description Roles are sets of permissions. User gets new permissions on the next token refresh
+// @Tags users
+// @Produce json
+// @Param query body model.ChangeUserRoleQuery true "User's id and role"
+// @Success 200 {object} model.User "Successful response. Returns updated user"
+// @Failure 400 {object} errormiddleware.Error "Returns when role is unknown"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no permission to manage roles or tries to change own roles"
+// @Failure 404 {object} errormiddleware.Error "Returns when user not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /users/roles [post]
+func (h *Handler) GrantRole(w http.ResponseWriter, r *http.Request) error {
+	return h.changeRole(w, r, h.UserService.GrantRole)
+}
+
+// @Summary Revoke role from user
+// @Description User loses permissions of the role on the next token refresh. Base user role can't be revoked
+// @Tags users
+// @Produce json
+// @Param query body model.ChangeUserRoleQuery true "User's id and role"
+// @Success 200 {object} model.User "Successful response. Returns updated user"
+// @Failure 400 {object} errormiddleware.Error "Returns when role is unknown or can't be revoked"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 403 {object} errormiddleware.Error "Returns when user has no permission to manage roles or tries to change own roles"
+// @Failure 404 {object} errormiddleware.Error "Returns when user not found"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /users/roles [delete]
+func (h *Handler) RevokeRole(w http.ResponseWriter, r *http.Request) error {
+	return h.changeRole(w, r, h.UserService.RevokeRole)
+}
+func (h *Handler) changeRole(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, query *model.ChangeUserRoleQuery) (*model.User, error)) error {
+	w.Header().Set("Content-Type", "application/json")
+	var query model.ChangeUserRoleQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := change(ctx, &query)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(user)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Sign in with OpenID provider
+// @Description Redirects to provider's sign in page. Authorization code flow with PKCE is used
+// @Tags users
+// @Success 302 "Redirect to provider"
+// @Failure 500 {object} errormiddleware.Error "Returns when provider is not available"
+// @Router /users/oidc/login [get]
+func (h *Handler) OidcLogin(w http.ResponseWriter, r *http.Request) error {
+	var state oidcState
+	var err error
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *value, err = oidc.NewToken(); err != nil {
+			return err
+		}
+	}
+	uri, err := h.Oidc.AuthCodeURL(r.Context(), state.State, state.Nonce, oidc.Challenge(state.Verifier))
+	if err != nil {
+		h.Logger.Warn(err)
+		return err
+	}
+	data, _ := json.Marshal(state)
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidc_state_cookie,
+		Value:    base64.RawURLEncoding.EncodeToString(data),
+		MaxAge:   int(oidc_state_ttl / time.Second),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		Path:     url_oidc,
+	})
+	http.Redirect(w, r, uri, http.StatusFound)
+	return nil
+}
+
+// @Summary OpenID provider callback
+// @Description Verifies user's identity and signs user in. Identity is linked to the user with the same email if both sides confirmed it, otherwise a new user is created
+// @Description Redirects to the application with token cookies set. If user has 2FA enabled, pre-auth token is passed in url fragment instead
+// @Tags users
+// @Param code query string true "Authorization code"
+// @Param state query string true "State that was sent to provider"
+// @Success 302 "Redirect to the application"
+// @Failure 400 {object} errormiddleware.Error "Returns when provider didn't return the code or user's email"
+// @Failure 401 {object} errormiddleware.Error "Returns when sign in is expired, state is wrong or identity was not verified"
+// @Failure 409 {object} errormiddleware.Error "Returns when email is taken by the user that can't be linked"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Router /users/oidc/callback [get]
+func (h *Handler) OidcCallback(w http.ResponseWriter, r *http.Request) error {
+	cookie, err := r.Cookie(oidc_state_cookie)
+	// state is used once, so it is cleared whatever the result is
+	http.SetCookie(w, &http.Cookie{Name: oidc_state_cookie, MaxAge: -1, HttpOnly: true, Secure: true, SameSite: http.SameSiteLaxMode, Path: url_oidc})
+	if err != nil {
+		return mw.UnauthorizedError([]string{"sign in is expired, try again"}, "oidc state cookie not found")
+	}
+	var state oidcState
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil || len(state.State) == 0 {
+		return mw.UnauthorizedError([]string{"sign in is expired, try again"}, fmt.Sprintf("can't decode oidc state cookie: %v", err))
+	}
+	query := r.URL.Query()
+	if providerError := query.Get("error"); len(providerError) > 0 {
+		return mw.UnauthorizedError([]string{"provider refused sign in"}, fmt.Sprintf("%s: %s", providerError, query.Get("error_description")))
+	}
+	if subtle.ConstantTimeCompare([]byte(state.State), []byte(query.Get("state"))) != 1 {
+		return mw.UnauthorizedError([]string{"wrong sign in state"}, "state from provider does not match the cookie")
+	}
+	if len(query.Get("code")) == 0 {
+		return mw.BadRequestError([]string{"provider didn't return authorization code"}, "code query parameter is empty")
+	}
+	identity, err := h.Oidc.Exchange(r.Context(), query.Get("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		h.Logger.Warnf("can't verify %s identity: %v", h.Oidc.Name(), err)
+		return mw.UnauthorizedError([]string{"can't verify user's identity"}, err.Error())
+	}
+	ctx := session.NewContext(r.Context(), r)
+	user, err := h.UserService.SignInWithIdentity(ctx, &model.IdentitySignInQuery{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		Login:         identity.Username,
+	})
+	if err != nil {
+		return err
+	}
+	redirect := h.OidcRedirect
+	if len(redirect) == 0 {
+		redirect = "/"
+	}
+	if user.TwoFactor {
+		preauth, err := h.JwtService.GeneratePreAuthToken(user)
+		if err != nil {
+			h.Logger.Warn(err)
+			return err
+		}
+		// fragment is not sent to servers, so token doesn't get into logs
+		http.Redirect(w, r, redirect+"#"+url.Values{"preauth": {preauth.PreAuth}}.Encode(), http.StatusFound)
+		return nil
+	}
+	token, err := h.JwtService.GenerateAccessToken(ctx, user)
+	if err != nil {
+		h.Logger.Warn(err)
+		return err
+	}
+	http.SetCookie(w, token.Token)
+	http.SetCookie(w, token.RefreshToken)
+	http.Redirect(w, r, redirect, http.StatusFound)
+	return nil
+}
+
+// @Summary Starts two-factor authentication enrollment
+// @Description Generates a new TOTP secret. URI should be shown to user as QR code for authenticator app
+// @Description Two-factor authentication is enabled only after the first code is verified
+// @Tags users
+// @Produce json
+// @Success 200 {object} model.TwoFactorEnrollment "Successful response. Returns secret and otpauth URI"
+// @Failure 400 {object} errormiddleware.Error "Return's if two-factor authentication is already enabled"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Security ApiKeyAuth
+// @Router /users/2fa/enroll [post]
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	enrollment, err := h.UserService.EnrollTwoFactor(ctx)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(enrollment)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Enables two-factor authentication
+// @Description Verifies the first code from authenticator app. Recovery codes are returned only once and must be saved by user
+// @Tags users
+// @Produce json
+// @Param query body model.TwoFactorCodeQuery true "Code from authenticator app"
+// @Success 200 {object} model.RecoveryCodes "Successful response. Two-factor authentication is enabled"
+// @Failure 400 {object} errormiddleware.Error "Return's if code is wrong or enrollment was not started"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /users/2fa/enable [post]
+func (h *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	var query model.TwoFactorCodeQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.UserService.EnableTwoFactor(ctx, &query)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(codes)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+	return nil
+}
+
+// @Summary Disables two-factor authentication
+// @Description Requires current password and the code from authenticator app or one of recovery codes
+// @Tags users
+// @Param query body model.DisableTwoFactorQuery true "Password and the code"
+// @Success 204 "Successful response. Two-factor authentication is disabled"
+// @Failure 400 {object} errormiddleware.Error "Return's if password is wrong or two-factor authentication is not enabled"
+// @Failure 401 {object} errormiddleware.Error "Return's if service can't authorize user or the code is wrong"
+// @Failure 500 {object} errormiddleware.Error "Returns when there's some internal error that needs to be fixed"
+// @Failure 501 {object} errormiddleware.Error "Returns if query was incorrect"
+// @Security ApiKeyAuth
+// @Router /users/2fa/disable [post]
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	var query model.DisableTwoFactorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	if err := h.Validator.Struct(query); err != nil {
+		return mw.ValidationError(err.(validator.ValidationErrors), "wrong request")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.UserService.DisableTwoFactor(ctx, &query); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 // @Summary Register user
 // @Description Creates a new instance of user and returns authorization principals. Sets the token cookies
 // @Produce json
@@ -287,7 +890,7 @@ func (h *Handler) UserRegister(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	token, err := h.JwtService.GenerateAccessToken(model)
+	token, err := h.JwtService.GenerateAccessToken(session.NewContext(r.Context(), r), model)
 	if err != nil {
 		h.Logger.Warn(err)
 		return err
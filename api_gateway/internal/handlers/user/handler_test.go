@@ -2,6 +2,8 @@ package user
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +11,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
@@ -17,6 +20,8 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/jwt"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/oidc"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
@@ -45,12 +50,32 @@ func TestRegister(t *testing.T) {
 		{"Find user", url_find_user, http.MethodGet},
 		{"Delete user account", url_delete_user, http.MethodDelete},
 		{"Update user login", url_update_user_login, http.MethodPatch},
+		{"Logout", url_logout, http.MethodPost},
+		{"Get sessions", url_sessions, http.MethodGet},
+		{"Revoke session", "/api/v1/users/sessions/sessionid", http.MethodDelete},
+		{"Update user password", url_update_password, http.MethodPatch},
+		{"Update user email", url_update_email, http.MethodPatch},
+		{"Forgot password", url_password_forgot, http.MethodPost},
+		{"Reset password", url_password_reset, http.MethodPost},
+		{"Two-factor authentication", url_auth_twofactor, http.MethodPost},
+		{"Two-factor enrollment", url_twofactor_enroll, http.MethodPost},
+		{"Enable two-factor", url_twofactor_enable, http.MethodPost},
+		{"Disable two-factor", url_twofactor_disable, http.MethodPost},
+		{"Grant role", url_user_roles, http.MethodPost},
+		{"Revoke role", url_user_roles, http.MethodDelete},
+		{"OpenID sign in", url_oidc_login, http.MethodGet},
+		{"OpenID callback", url_oidc_callback, http.MethodGet},
 	}
 
 	ctrl := gomock.NewController(t)
 	jwt := mock.NewMockJwtService(ctrl)
 	h.JwtService = jwt
 	jwt.EXPECT().Middleware(gomock.Any()).AnyTimes()
+	jwt.EXPECT().Middleware(gomock.Any(), gomock.Any()).AnyTimes()
+	provider := mock.NewMockOidcProvider(ctrl)
+	provider.EXPECT().Name().Return("provider").AnyTimes()
+	h.Oidc = provider
+	defer func() { h.Oidc = nil }()
 
 	router := httprouter.New()
 	h.Register(router)
@@ -67,6 +92,9 @@ func TestHandlers(t *testing.T) {
 		Name           string
 		MockBehaviour  func(s *mock.MockUserService, j *mock.MockJwtService)
 		TokenCookie    string
+		RefreshCookie  string
+		UserId         string
+		Params         httprouter.Params
 		InputJson      func() *[]byte
 		ExceptedStatus int
 		ExceptedError  error
@@ -78,6 +106,195 @@ func TestHandlers(t *testing.T) {
 		Method      string
 		Options     []handlerOptions
 	}{
+		//Logout
+		{
+			HandlerName: "Logout",
+			Handler:     h.Logout,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().RevokeSession(gomock.Any(), "REFRESH").Return(nil)
+					},
+					RefreshCookie:  "REFRESH",
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name: "session already expired",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().RevokeSession(gomock.Any(), "REFRESH").Return(errormiddleware.NotFoundError([]string{"session not found"}, ""))
+					},
+					RefreshCookie:  "REFRESH",
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name:           "no cookies",
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name: "store error",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().RevokeSession(gomock.Any(), "REFRESH").Return(errors.New("store error"))
+					},
+					RefreshCookie:  "REFRESH",
+					ExceptedStatus: http.StatusInternalServerError,
+					ExceptedError:  errors.New("store error"),
+					ExceptedBody:   `{"messages":["store error"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
+				},
+			},
+		},
+		//ForgotPassword
+		{
+			HandlerName: "ForgotPassword",
+			Handler:     h.ForgotPassword,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().ForgotPassword(gomock.Any(), &model.ForgotPasswordQuery{Email: "user@example.com"}).Return(nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ForgotPasswordQuery{Email: "user@example.com"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name: "validation error",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ForgotPasswordQuery{Email: "user"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"email must be a valid email"}, errormiddleware.ValidationErrorCode, "wrong query format"),
+					ExceptedBody:   `{"messages":["email must be a valid email"],"dev_message":"wrong query format","code":"IE-0004"}`,
+				},
+				{
+					Name: "service error",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().ForgotPassword(gomock.Any(), gomock.Any()).Return(errors.New("can't send email message"))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ForgotPasswordQuery{Email: "user@example.com"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusInternalServerError,
+					ExceptedError:  errors.New("can't send email message"),
+					ExceptedBody:   `{"messages":["can't send email message"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
+				},
+			},
+		},
+		//ResetPassword
+		{
+			HandlerName: "ResetPassword",
+			Handler:     h.ResetPassword,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().ResetPassword(gomock.Any(), &model.ResetPasswordQuery{Token: "token", Password: "User!1password"}).Return(nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ResetPasswordQuery{Token: "token", Password: "User!1password"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ResetPasswordQuery{Token: "token"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"password: field is required"}, errormiddleware.ValidationErrorCode, "wrong query format"),
+					ExceptedBody:   `{"messages":["password: field is required"],"dev_message":"wrong query format","code":"IE-0004"}`,
+				},
+				{
+					Name: "weak password",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().ResetPassword(gomock.Any(), gomock.Any()).Return(errormiddleware.NewError([]string{"password must contain at least one uppercase character"}, errormiddleware.ValidationErrorCode, "password does not match the policy"))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ResetPasswordQuery{Token: "token", Password: "password"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"password must contain at least one uppercase character"}, errormiddleware.ValidationErrorCode, "password does not match the policy"),
+					ExceptedBody:   `{"messages":["password must contain at least one uppercase character"],"dev_message":"password does not match the policy","code":"IE-0004"}`,
+				},
+				{
+					Name: "expired token",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().ResetPassword(gomock.Any(), gomock.Any()).Return(errormiddleware.NotFoundError([]string{"reset token is invalid or expired"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ResetPasswordQuery{Token: "token", Password: "User!1password"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"reset token is invalid or expired"}, ""),
+					ExceptedBody:   `{"messages":["reset token is invalid or expired"],"code":"IE-0002"}`,
+				},
+			},
+		},
+		//GetSessions
+		{
+			HandlerName: "GetSessions",
+			Handler:     h.GetSessions,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().GetSessions(gomock.Any(), "userid", "current").Return([]*model.Session{
+							{Id: "current", Device: "Firefox on Windows", IP: "127.0.0.1", Created: time.Unix(0, 0).UTC(), LastSeen: time.Unix(60, 0).UTC(), Current: true},
+						}, nil)
+					},
+					UserId:         "userid",
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `[{"id":"current","device":"Firefox on Windows","useragent":"","ip":"127.0.0.1","created":"1970-01-01T00:00:00Z","lastseen":"1970-01-01T00:01:00Z","current":true}]`,
+				},
+			},
+		},
+		//RevokeSession
+		{
+			HandlerName: "RevokeSession",
+			Handler:     h.RevokeSession,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().RevokeUserSession(gomock.Any(), "userid", "sessionid").Return(nil)
+					},
+					UserId:         "userid",
+					Params:         httprouter.Params{{Key: "id", Value: "sessionid"}},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name: "not found",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().RevokeUserSession(gomock.Any(), "userid", "sessionid").Return(errormiddleware.NotFoundError([]string{"session not found"}, "no session"))
+					},
+					UserId:         "userid",
+					Params:         httprouter.Params{{Key: "id", Value: "sessionid"}},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"session not found"}, "no session"),
+					ExceptedBody:   `{"messages":["session not found"],"dev_message":"no session","code":"IE-0002"}`,
+				},
+				{
+					Name:           "no id",
+					UserId:         "userid",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"session id must be provided"}, "received empty session id"),
+					ExceptedBody:   `{"messages":["session id must be provided"],"dev_message":"received empty session id","code":"IE-0003"}`,
+				},
+			},
+		},
 		//UpdateUserLogin
 		{
 			HandlerName: "UpdateUserLogin",
@@ -97,7 +314,7 @@ func TestHandlers(t *testing.T) {
 							s.EXPECT().UpdateUserLogin(gomock.Any(), gomock.Any()).Return(
 								usr, nil,
 							),
-							j.EXPECT().GenerateAccessToken(usr).Return(&model.JwtResponse{
+							j.EXPECT().GenerateAccessToken(gomock.Any(), usr).Return(&model.JwtResponse{
 								Login:        usr.Login,
 								Roles:        usr.Roles,
 								Token:        &http.Cookie{Name: jwt.TokenCookieName, Value: "EXAMPLE TOKEN"},
@@ -158,7 +375,7 @@ func TestHandlers(t *testing.T) {
 							s.EXPECT().UpdateUserLogin(gomock.Any(), gomock.Any()).Return(
 								usr, nil,
 							),
-							j.EXPECT().GenerateAccessToken(usr).Return(nil, errors.New("jwt service error")),
+							j.EXPECT().GenerateAccessToken(gomock.Any(), usr).Return(nil, errors.New("jwt service error")),
 						)
 					},
 					InputJson: func() *[]byte {
@@ -171,6 +388,120 @@ func TestHandlers(t *testing.T) {
 				},
 			},
 		},
+		//UpdateUserPassword
+		{
+			HandlerName: "UpdateUserPassword",
+			Handler:     h.UpdateUserPassword,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						usr := &model.User{Login: "user", Roles: []string{"user"}}
+						gomock.InOrder(
+							s.EXPECT().UpdateUserPassword(gomock.Any(), &model.UpdateUserPasswordQuery{Password: "password", NewPassword: "User!1password"}).Return(usr, nil),
+							j.EXPECT().GenerateAccessToken(gomock.Any(), usr).Return(&model.JwtResponse{
+								Login:        usr.Login,
+								Roles:        usr.Roles,
+								Token:        &http.Cookie{Name: jwt.TokenCookieName, Value: "EXAMPLE TOKEN"},
+								RefreshToken: &http.Cookie{Name: jwt.RefreshCookieName, Value: "TOKEN"},
+							}, nil),
+						)
+					},
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserPasswordQuery{Password: "password", NewPassword: "User!1password"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"login":"user","roles":["user"]}`,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserPasswordQuery{Password: "password"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"newpassword: field is required"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["newpassword: field is required"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+				{
+					Name: "weak password",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.NewError([]string{"password must be more than 8 characters length"}, errormiddleware.ValidationErrorCode, "password does not match the policy"))
+					},
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserPasswordQuery{Password: "password", NewPassword: "short"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"password must be more than 8 characters length"}, errormiddleware.ValidationErrorCode, "password does not match the policy"),
+					ExceptedBody:   `{"messages":["password must be more than 8 characters length"],"dev_message":"password does not match the policy","code":"IE-0004"}`,
+				},
+				{
+					Name: "wrong password",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.BadRequestError([]string{"wrong password"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserPasswordQuery{Password: "password", NewPassword: "User!1password"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"wrong password"}, ""),
+					ExceptedBody:   `{"messages":["wrong password"],"code":"IE-0003"}`,
+				},
+			},
+		},
+		//UpdateUserEmail
+		{
+			HandlerName: "UpdateUserEmail",
+			Handler:     h.UpdateUserEmail,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().UpdateUserEmail(gomock.Any(), &model.UpdateUserEmailQuery{NewEmail: "new@example.com"}).Return(&model.User{
+							Id:           "1",
+							Login:        "user",
+							Roles:        []string{"user"},
+							Email:        "user@example.com",
+							PendingEmail: "new@example.com",
+						}, nil)
+					},
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserEmailQuery{NewEmail: "new@example.com"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"1","login":"user","roles":["user"],"email":"user@example.com","emailconfirmed":false,"pendingemail":"new@example.com"}`,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserEmailQuery{NewEmail: "email"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"newemail must be a valid email"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["newemail must be a valid email"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+				{
+					Name: "email taken",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().UpdateUserEmail(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.NotUniqueError([]string{"email already taken"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byt, _ := json.Marshal(&model.UpdateUserEmailQuery{NewEmail: "new@example.com"})
+						return &byt
+					},
+					ExceptedStatus: http.StatusConflict,
+					ExceptedError:  errormiddleware.NotUniqueError([]string{"email already taken"}, ""),
+					ExceptedBody:   `{"messages":["email already taken"],"code":"IE-0006"}`,
+				},
+			},
+		},
 		//DeleteUser
 		{
 			HandlerName: "DeleteUser",
@@ -302,6 +633,269 @@ func TestHandlers(t *testing.T) {
 				},
 			},
 		},
+		//Two-factor authentication
+		{
+			HandlerName: "AuthenticateTwoFactor",
+			Handler:     h.AuthenticateTwoFactor,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						usr := &model.User{Id: "userId", Login: "user", Roles: []string{"user"}, TwoFactor: true}
+						gomock.InOrder(
+							j.EXPECT().VerifyPreAuthToken("a.b.c").Return("userId", nil),
+							s.EXPECT().VerifyTwoFactor(gomock.Any(), &model.VerifyTwoFactorQuery{UserId: "userId", Code: "123456"}).Return(usr, nil),
+							j.EXPECT().GenerateAccessToken(gomock.Any(), usr).Return(&model.JwtResponse{
+								Login:        usr.Login,
+								Roles:        usr.Roles,
+								Token:        &http.Cookie{Name: jwt.TokenCookieName, Value: "EXAMPLE TOKEN"},
+								RefreshToken: &http.Cookie{Name: jwt.RefreshCookieName, Value: "TOKEN"},
+							}, nil),
+						)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorAuthQuery{PreAuth: "a.b.c", Code: "123456"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"login":"user","roles":["user"]}`,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorAuthQuery{PreAuth: "token"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"preauth must be a JWT token", "code: field is required"}, errormiddleware.ValidationErrorCode, "wrong query format"),
+					ExceptedBody:   `{"messages":["preauth must be a JWT token","code: field is required"],"dev_message":"wrong query format","code":"IE-0004"}`,
+				},
+				{
+					Name: "expired pre-auth token",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().VerifyPreAuthToken("a.b.c").Return("", errormiddleware.UnauthorizedError([]string{"pre-auth token is invalid or expired"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorAuthQuery{PreAuth: "a.b.c", Code: "123456"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"pre-auth token is invalid or expired"}, ""),
+					ExceptedBody:   `{"messages":["pre-auth token is invalid or expired"],"code":"IE-0005"}`,
+				},
+				{
+					Name: "wrong code",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						j.EXPECT().VerifyPreAuthToken("a.b.c").Return("userId", nil)
+						s.EXPECT().VerifyTwoFactor(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.UnauthorizedError([]string{"wrong recovery code"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorAuthQuery{PreAuth: "a.b.c", Code: "654321"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"wrong recovery code"}, ""),
+					ExceptedBody:   `{"messages":["wrong recovery code"],"code":"IE-0005"}`,
+				},
+			},
+		},
+		//Two-factor enrollment
+		{
+			HandlerName: "EnrollTwoFactor",
+			Handler:     h.EnrollTwoFactor,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().EnrollTwoFactor(gomock.Any()).Return(&model.TwoFactorEnrollment{Secret: "SECRET", URI: "otpauth://totp/Example:user?secret=SECRET"}, nil)
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"secret":"SECRET","uri":"otpauth://totp/Example:user?secret=SECRET"}`,
+				},
+				{
+					Name: "already enabled",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().EnrollTwoFactor(gomock.Any()).Return(nil, errormiddleware.BadRequestError([]string{"two-factor authentication is already enabled"}, ""))
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"two-factor authentication is already enabled"}, ""),
+					ExceptedBody:   `{"messages":["two-factor authentication is already enabled"],"code":"IE-0003"}`,
+				},
+			},
+		},
+		//Enable two-factor authentication
+		{
+			HandlerName: "EnableTwoFactor",
+			Handler:     h.EnableTwoFactor,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().EnableTwoFactor(gomock.Any(), &model.TwoFactorCodeQuery{Code: "123456"}).Return(&model.RecoveryCodes{Codes: []string{"a1b2c-3d4e5"}}, nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorCodeQuery{Code: "123456"})
+						return &byte
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"recoverycodes":["a1b2c-3d4e5"]}`,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorCodeQuery{})
+						return &byte
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"code: field is required"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["code: field is required"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+				{
+					Name: "wrong code",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().EnableTwoFactor(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.BadRequestError([]string{"wrong code"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.TwoFactorCodeQuery{Code: "654321"})
+						return &byte
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"wrong code"}, ""),
+					ExceptedBody:   `{"messages":["wrong code"],"code":"IE-0003"}`,
+				},
+			},
+		},
+		//Grant role
+		{
+			HandlerName: "GrantRole",
+			Handler:     h.GrantRole,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().GrantRole(gomock.Any(), &model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31", Role: "editor"}).Return(&model.User{Id: "66a9e5e9ab3b5e2f0c4e2d31", Login: "user", Roles: []string{"user", "editor"}, Email: "user@example.com"}, nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31", Role: "editor"})
+						return &byte
+					},
+					UserId:         "adminId",
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"66a9e5e9ab3b5e2f0c4e2d31","login":"user","roles":["user","editor"],"email":"user@example.com","emailconfirmed":false}`,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31"})
+						return &byte
+					},
+					UserId:         "adminId",
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"role: field is required"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["role: field is required"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+				{
+					Name: "own roles",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().GrantRole(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.ForbiddenError([]string{"you can't change your own roles"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31", Role: "editor"})
+						return &byte
+					},
+					UserId:         "66a9e5e9ab3b5e2f0c4e2d31",
+					ExceptedStatus: http.StatusForbidden,
+					ExceptedError:  errormiddleware.ForbiddenError([]string{"you can't change your own roles"}, ""),
+					ExceptedBody:   `{"messages":["you can't change your own roles"],"code":"IE-0007"}`,
+				},
+			},
+		},
+		//Revoke role
+		{
+			HandlerName: "RevokeRole",
+			Handler:     h.RevokeRole,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().RevokeRole(gomock.Any(), &model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31", Role: "editor"}).Return(&model.User{Id: "66a9e5e9ab3b5e2f0c4e2d31", Login: "user", Roles: []string{"user"}, Email: "user@example.com"}, nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31", Role: "editor"})
+						return &byte
+					},
+					UserId:         "adminId",
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"66a9e5e9ab3b5e2f0c4e2d31","login":"user","roles":["user"],"email":"user@example.com","emailconfirmed":false}`,
+				},
+				{
+					Name: "wrong user id",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ChangeUserRoleQuery{UserId: "user", Role: "editor"})
+						return &byte
+					},
+					UserId:         "adminId",
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"userid must be a primitive id type"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["userid must be a primitive id type"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+				{
+					Name: "base role",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().RevokeRole(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.BadRequestError([]string{"user role can't be revoked"}, ""))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.ChangeUserRoleQuery{UserId: "66a9e5e9ab3b5e2f0c4e2d31", Role: "user"})
+						return &byte
+					},
+					UserId:         "adminId",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"user role can't be revoked"}, ""),
+					ExceptedBody:   `{"messages":["user role can't be revoked"],"code":"IE-0003"}`,
+				},
+			},
+		},
+		//Disable two-factor authentication
+		{
+			HandlerName: "DisableTwoFactor",
+			Handler:     h.DisableTwoFactor,
+			Method:      http.MethodPost,
+			Options: []handlerOptions{
+				{
+					Name: "success",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().DisableTwoFactor(gomock.Any(), &model.DisableTwoFactorQuery{Password: "password", Code: "a1b2c-3d4e5"}).Return(nil)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.DisableTwoFactorQuery{Password: "password", Code: "a1b2c-3d4e5"})
+						return &byte
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name: "validation",
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.DisableTwoFactorQuery{Code: "123456"})
+						return &byte
+					},
+					UserId:         "userId",
+					ExceptedStatus: http.StatusNotImplemented,
+					ExceptedError:  errormiddleware.NewError([]string{"password: field is required"}, errormiddleware.ValidationErrorCode, "wrong request"),
+					ExceptedBody:   `{"messages":["password: field is required"],"dev_message":"wrong request","code":"IE-0004"}`,
+				},
+			},
+		},
 		//Authentication
 		{
 			HandlerName: "Authenticate",
@@ -319,7 +913,7 @@ func TestHandlers(t *testing.T) {
 						}
 						gomock.InOrder(
 							s.EXPECT().AuthByLoginAndPassword(gomock.Any(), gomock.Any()).Return(usr, nil),
-							j.EXPECT().GenerateAccessToken(usr).Return(&model.JwtResponse{
+							j.EXPECT().GenerateAccessToken(gomock.Any(), usr).Return(&model.JwtResponse{
 								Login:        usr.Login,
 								Roles:        usr.Roles,
 								Token:        &http.Cookie{Name: jwt.TokenCookieName, Value: "EXAMPLE TOKEN"},
@@ -337,6 +931,23 @@ func TestHandlers(t *testing.T) {
 					ExceptedStatus: http.StatusOK,
 					ExceptedBody:   "{\"login\":\"user\",\"roles\":[\"user\"]}",
 				},
+				//Two-factor authentication enabled
+				{
+					Name: "two factor required",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						usr := &model.User{Id: "userId", Login: "user", TwoFactor: true}
+						gomock.InOrder(
+							s.EXPECT().AuthByLoginAndPassword(gomock.Any(), gomock.Any()).Return(usr, nil),
+							j.EXPECT().GeneratePreAuthToken(usr).Return(&model.PreAuthResponse{TwoFactor: true, PreAuth: "a.b.c"}, nil),
+						)
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.UserAuthQuery{Login: "user", Password: "usr"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusAccepted,
+					ExceptedBody:   `{"twofactor":true,"preauth":"a.b.c"}`,
+				},
 				//Nil body
 				{
 					Name:           "nil body",
@@ -369,12 +980,26 @@ func TestHandlers(t *testing.T) {
 					ExceptedError:  errors.New("wrong password"),
 					ExceptedBody:   `{"messages":["wrong password"],"dev_message":"Something wrong happened while service executing","code":"IE-0001"}`,
 				},
+				//Account locked
+				{
+					Name: "account locked",
+					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
+						s.EXPECT().AuthByLoginAndPassword(gomock.Any(), gomock.Any()).Return(nil, errormiddleware.NewError([]string{"too many failed sign in attempts, try again in 60 seconds"}, errormiddleware.LockedErrorCode, "sign in is temporarily locked"))
+					},
+					InputJson: func() *[]byte {
+						byte, _ := json.Marshal(&model.UserAuthQuery{Login: "user", Password: "password"})
+						return &byte
+					},
+					ExceptedStatus: http.StatusLocked,
+					ExceptedError:  errormiddleware.NewError([]string{"too many failed sign in attempts, try again in 60 seconds"}, errormiddleware.LockedErrorCode, "sign in is temporarily locked"),
+					ExceptedBody:   `{"messages":["too many failed sign in attempts, try again in 60 seconds"],"dev_message":"sign in is temporarily locked","code":"IE-0010"}`,
+				},
 				//Jwt service error
 				{
 					Name: "jwt service error",
 					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
 						s.EXPECT().AuthByLoginAndPassword(gomock.Any(), gomock.Any()).Return(nil, nil)
-						j.EXPECT().GenerateAccessToken(nil).Return(nil, errors.New("wrong model"))
+						j.EXPECT().GenerateAccessToken(gomock.Any(), nil).Return(nil, errors.New("wrong model"))
 					},
 					InputJson: func() *[]byte {
 						byte, _ := json.Marshal(&model.UserAuthQuery{Login: "user", Password: "password"})
@@ -442,7 +1067,7 @@ func TestHandlers(t *testing.T) {
 						}
 						gomock.InOrder(
 							s.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Return(usr, nil),
-							j.EXPECT().GenerateAccessToken(usr).Return(&model.JwtResponse{
+							j.EXPECT().GenerateAccessToken(gomock.Any(), usr).Return(&model.JwtResponse{
 								Login:        usr.Login,
 								Roles:        usr.Roles,
 								Token:        &http.Cookie{Name: jwt.TokenCookieName, Value: "EXAMPLE TOKEN"},
@@ -502,7 +1127,7 @@ func TestHandlers(t *testing.T) {
 					Name: "jwt service error",
 					MockBehaviour: func(s *mock.MockUserService, j *mock.MockJwtService) {
 						s.EXPECT().RegisterUser(gomock.Any(), gomock.Any()).Return(&model.User{}, nil)
-						j.EXPECT().GenerateAccessToken(gomock.Any()).Return(nil, errors.New("wrong model"))
+						j.EXPECT().GenerateAccessToken(gomock.Any(), gomock.Any()).Return(nil, errors.New("wrong model"))
 					},
 					InputJson: func() *[]byte {
 						byte, _ := json.Marshal(&model.UserRegisterQuery{
@@ -545,6 +1170,19 @@ func TestHandlers(t *testing.T) {
 						Value: testCase.TokenCookie,
 					})
 				}
+				if len(testCase.RefreshCookie) > 0 {
+					r.AddCookie(&http.Cookie{
+						Name:  jwt.RefreshCookieName,
+						Value: testCase.RefreshCookie,
+					})
+				}
+				if len(testCase.UserId) > 0 {
+					r = r.WithContext(context.WithValue(r.Context(), rest.UserIdKey, testCase.UserId))
+					r = r.WithContext(context.WithValue(r.Context(), rest.SessionIdKey, "current"))
+				}
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
+				}
 				err := errormiddleware.Middleware(tt.Handler)(w, r)
 				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
 				assert.Equal(t, testCase.ExceptedError, err)
@@ -557,3 +1195,174 @@ func TestHandlers(t *testing.T) {
 		}
 	}
 }
+func TestOidcLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := mock.NewMockOidcProvider(ctrl)
+	h.Oidc = provider
+	defer func() { h.Oidc = nil }()
+
+	var challenge string
+	provider.EXPECT().AuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, state, nonce, c string) (string, error) {
+		challenge = c
+		return "http://provider/authorize?state=" + state, nil
+	})
+	w := httptest.NewRecorder()
+	err := errormiddleware.Middleware(h.OidcLogin)(w, httptest.NewRequest(http.MethodGet, "http://test", nil))
+	if !assert.NoError(t, err) || !assert.Equal(t, http.StatusFound, w.Result().StatusCode) {
+		return
+	}
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, oidc_state_cookie, cookies[0].Name)
+		assert.Equal(t, url_oidc, cookies[0].Path)
+		assert.True(t, cookies[0].HttpOnly)
+
+		state := decodeOidcState(t, cookies[0].Value)
+		assert.Equal(t, "http://provider/authorize?state="+state.State, w.Result().Header.Get("Location"))
+		assert.Equal(t, oidc.Challenge(state.Verifier), challenge)
+		assert.NotEqual(t, state.State, state.Nonce)
+	}
+
+	provider.EXPECT().AuthCodeURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("provider is not available"))
+	w = httptest.NewRecorder()
+	errormiddleware.Middleware(h.OidcLogin)(w, httptest.NewRequest(http.MethodGet, "http://test", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	assert.Empty(t, w.Result().Cookies())
+}
+func TestOidcCallback(t *testing.T) {
+	state := &oidcState{State: "state", Nonce: "nonce", Verifier: "verifier"}
+	identity := &oidc.Identity{Provider: "provider", Subject: "subject", Email: "user@example.com", EmailVerified: true, Username: "user"}
+	query := &model.IdentitySignInQuery{Provider: "provider", Subject: "subject", Email: "user@example.com", EmailVerified: true, Login: "user"}
+	cases := []struct {
+		Name             string
+		State            *oidcState
+		Query            string
+		MockBehaviour    func(p *mock.MockOidcProvider, s *mock.MockUserService, j *mock.MockJwtService)
+		ExceptedStatus   int
+		ExceptedLocation string
+		ExceptedCookies  []string
+	}{
+		{
+			Name:           "no state cookie",
+			Query:          "code=code&state=state",
+			ExceptedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "wrong state",
+			State:          state,
+			Query:          "code=code&state=other",
+			ExceptedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "provider error",
+			State:          state,
+			Query:          "error=access_denied&state=state",
+			ExceptedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "empty code",
+			State:          state,
+			Query:          "state=state",
+			ExceptedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:  "identity not verified",
+			State: state,
+			Query: "code=code&state=state",
+			MockBehaviour: func(p *mock.MockOidcProvider, s *mock.MockUserService, j *mock.MockJwtService) {
+				p.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(nil, oidc.ErrInvalidToken)
+			},
+			ExceptedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:  "email is taken",
+			State: state,
+			Query: "code=code&state=state",
+			MockBehaviour: func(p *mock.MockOidcProvider, s *mock.MockUserService, j *mock.MockJwtService) {
+				p.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(identity, nil)
+				s.EXPECT().SignInWithIdentity(gomock.Any(), query).Return(nil, errormiddleware.NotUniqueError([]string{"email already taken"}, ""))
+			},
+			ExceptedStatus: http.StatusConflict,
+		},
+		{
+			Name:  "successful sign in",
+			State: state,
+			Query: "code=code&state=state",
+			MockBehaviour: func(p *mock.MockOidcProvider, s *mock.MockUserService, j *mock.MockJwtService) {
+				p.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(identity, nil)
+				u := &model.User{Id: "id", Login: "user", Roles: []string{"user"}}
+				s.EXPECT().SignInWithIdentity(gomock.Any(), query).Return(u, nil)
+				j.EXPECT().GenerateAccessToken(gomock.Any(), u).Return(&model.JwtResponse{
+					Login:        "user",
+					Roles:        []string{"user"},
+					Token:        &http.Cookie{Name: jwt.TokenCookieName, Value: "token"},
+					RefreshToken: &http.Cookie{Name: jwt.RefreshCookieName, Value: "refresh"},
+				}, nil)
+			},
+			ExceptedStatus:   http.StatusFound,
+			ExceptedLocation: "/app",
+			ExceptedCookies:  []string{jwt.TokenCookieName, jwt.RefreshCookieName},
+		},
+		{
+			Name:  "user has two-factor",
+			State: state,
+			Query: "code=code&state=state",
+			MockBehaviour: func(p *mock.MockOidcProvider, s *mock.MockUserService, j *mock.MockJwtService) {
+				p.EXPECT().Exchange(gomock.Any(), "code", "verifier", "nonce").Return(identity, nil)
+				u := &model.User{Id: "id", Login: "user", Roles: []string{"user"}, TwoFactor: true}
+				s.EXPECT().SignInWithIdentity(gomock.Any(), query).Return(u, nil)
+				j.EXPECT().GeneratePreAuthToken(u).Return(&model.PreAuthResponse{TwoFactor: true, PreAuth: "preauth"}, nil)
+			},
+			ExceptedStatus:   http.StatusFound,
+			ExceptedLocation: "/app#preauth=preauth",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			provider := mock.NewMockOidcProvider(ctrl)
+			provider.EXPECT().Name().Return("provider").AnyTimes()
+			user := mock.NewMockUserService(ctrl)
+			jwtService := mock.NewMockJwtService(ctrl)
+			if tt.MockBehaviour != nil {
+				tt.MockBehaviour(provider, user, jwtService)
+			}
+			h.Oidc = provider
+			h.OidcRedirect = "/app"
+			h.UserService = user
+			h.JwtService = jwtService
+			defer func() { h.Oidc, h.OidcRedirect = nil, "" }()
+
+			r := httptest.NewRequest(http.MethodGet, "http://test/callback?"+tt.Query, nil)
+			if tt.State != nil {
+				data, _ := json.Marshal(tt.State)
+				r.AddCookie(&http.Cookie{Name: oidc_state_cookie, Value: base64.RawURLEncoding.EncodeToString(data)})
+			}
+			w := httptest.NewRecorder()
+			errormiddleware.Middleware(h.OidcCallback)(w, r)
+
+			assert.Equal(t, tt.ExceptedStatus, w.Result().StatusCode)
+			assert.Equal(t, tt.ExceptedLocation, w.Result().Header.Get("Location"))
+			var cookies []string
+			for _, cookie := range w.Result().Cookies() {
+				if cookie.Name == oidc_state_cookie {
+					assert.Negative(t, cookie.MaxAge, "state cookie must be cleared")
+					continue
+				}
+				cookies = append(cookies, cookie.Name)
+			}
+			assert.Equal(t, tt.ExceptedCookies, cookies)
+		})
+	}
+}
+func decodeOidcState(t *testing.T, value string) *oidcState {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var state oidcState
+	if !assert.NoError(t, json.Unmarshal(data, &state)) {
+		t.FailNow()
+	}
+	return &state
+}
@@ -11,6 +11,7 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	user "github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/user"
+	oidc "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/oidc"
 )
 
 // MockUserService is a mock of UserService interface.
@@ -65,6 +66,50 @@ func (mr *MockUserServiceMockRecorder) DeleteUser(ctx, query interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), ctx, query)
 }
 
+// DisableTwoFactor mocks base method.
+func (m *MockUserService) DisableTwoFactor(ctx context.Context, query *user.DisableTwoFactorQuery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTwoFactor", ctx, query)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTwoFactor indicates an expected call of DisableTwoFactor.
+func (mr *MockUserServiceMockRecorder) DisableTwoFactor(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTwoFactor", reflect.TypeOf((*MockUserService)(nil).DisableTwoFactor), ctx, query)
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockUserService) EnableTwoFactor(ctx context.Context, query *user.TwoFactorCodeQuery) (*user.RecoveryCodes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", ctx, query)
+	ret0, _ := ret[0].(*user.RecoveryCodes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockUserServiceMockRecorder) EnableTwoFactor(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockUserService)(nil).EnableTwoFactor), ctx, query)
+}
+
+// EnrollTwoFactor mocks base method.
+func (m *MockUserService) EnrollTwoFactor(ctx context.Context) (*user.TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactor", ctx)
+	ret0, _ := ret[0].(*user.TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTwoFactor indicates an expected call of EnrollTwoFactor.
+func (mr *MockUserServiceMockRecorder) EnrollTwoFactor(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactor", reflect.TypeOf((*MockUserService)(nil).EnrollTwoFactor), ctx)
+}
+
 // FindUser mocks base method.
 func (m *MockUserService) FindUser(ctx context.Context, userid, login string) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +125,35 @@ func (mr *MockUserServiceMockRecorder) FindUser(ctx, userid, login interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUser", reflect.TypeOf((*MockUserService)(nil).FindUser), ctx, userid, login)
 }
 
+// ForgotPassword mocks base method.
+func (m *MockUserService) ForgotPassword(ctx context.Context, query *user.ForgotPasswordQuery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, query)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockUserServiceMockRecorder) ForgotPassword(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockUserService)(nil).ForgotPassword), ctx, query)
+}
+
+// GrantRole mocks base method.
+func (m *MockUserService) GrantRole(ctx context.Context, query *user.ChangeUserRoleQuery) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantRole", ctx, query)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantRole indicates an expected call of GrantRole.
+func (mr *MockUserServiceMockRecorder) GrantRole(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantRole", reflect.TypeOf((*MockUserService)(nil).GrantRole), ctx, query)
+}
+
 // RegisterUser mocks base method.
 func (m *MockUserService) RegisterUser(ctx context.Context, query *user.UserRegisterQuery) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +169,65 @@ func (mr *MockUserServiceMockRecorder) RegisterUser(ctx, query interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockUserService)(nil).RegisterUser), ctx, query)
 }
 
+// ResetPassword mocks base method.
+func (m *MockUserService) ResetPassword(ctx context.Context, query *user.ResetPasswordQuery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, query)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserServiceMockRecorder) ResetPassword(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserService)(nil).ResetPassword), ctx, query)
+}
+
+// RevokeRole mocks base method.
+func (m *MockUserService) RevokeRole(ctx context.Context, query *user.ChangeUserRoleQuery) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRole", ctx, query)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeRole indicates an expected call of RevokeRole.
+func (mr *MockUserServiceMockRecorder) RevokeRole(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRole", reflect.TypeOf((*MockUserService)(nil).RevokeRole), ctx, query)
+}
+
+// SignInWithIdentity mocks base method.
+func (m *MockUserService) SignInWithIdentity(ctx context.Context, query *user.IdentitySignInQuery) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignInWithIdentity", ctx, query)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignInWithIdentity indicates an expected call of SignInWithIdentity.
+func (mr *MockUserServiceMockRecorder) SignInWithIdentity(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignInWithIdentity", reflect.TypeOf((*MockUserService)(nil).SignInWithIdentity), ctx, query)
+}
+
+// UpdateUserEmail mocks base method.
+func (m *MockUserService) UpdateUserEmail(ctx context.Context, query *user.UpdateUserEmailQuery) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserEmail", ctx, query)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserEmail indicates an expected call of UpdateUserEmail.
+func (mr *MockUserServiceMockRecorder) UpdateUserEmail(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserEmail", reflect.TypeOf((*MockUserService)(nil).UpdateUserEmail), ctx, query)
+}
+
 // UpdateUserLogin mocks base method.
 func (m *MockUserService) UpdateUserLogin(ctx context.Context, query *user.UpdateUserLoginQuery) (*user.User, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +243,21 @@ func (mr *MockUserServiceMockRecorder) UpdateUserLogin(ctx, query interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserLogin", reflect.TypeOf((*MockUserService)(nil).UpdateUserLogin), ctx, query)
 }
 
+// UpdateUserPassword mocks base method.
+func (m *MockUserService) UpdateUserPassword(ctx context.Context, query *user.UpdateUserPasswordQuery) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, query)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockUserServiceMockRecorder) UpdateUserPassword(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockUserService)(nil).UpdateUserPassword), ctx, query)
+}
+
 // UserEmailConfirmation mocks base method.
 func (m *MockUserService) UserEmailConfirmation(ctx context.Context, code string) (int, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +273,21 @@ func (mr *MockUserServiceMockRecorder) UserEmailConfirmation(ctx, code interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserEmailConfirmation", reflect.TypeOf((*MockUserService)(nil).UserEmailConfirmation), ctx, code)
 }
 
+// VerifyTwoFactor mocks base method.
+func (m *MockUserService) VerifyTwoFactor(ctx context.Context, query *user.VerifyTwoFactorQuery) (*user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyTwoFactor", ctx, query)
+	ret0, _ := ret[0].(*user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyTwoFactor indicates an expected call of VerifyTwoFactor.
+func (mr *MockUserServiceMockRecorder) VerifyTwoFactor(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyTwoFactor", reflect.TypeOf((*MockUserService)(nil).VerifyTwoFactor), ctx, query)
+}
+
 // MockJwtService is a mock of JwtService interface.
 type MockJwtService struct {
 	ctrl     *gomock.Controller
@@ -149,18 +312,48 @@ func (m *MockJwtService) EXPECT() *MockJwtServiceMockRecorder {
 }
 
 // GenerateAccessToken mocks base method.
-func (m *MockJwtService) GenerateAccessToken(u *user.User) (*user.JwtResponse, error) {
+func (m *MockJwtService) GenerateAccessToken(ctx context.Context, u *user.User) (*user.JwtResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateAccessToken", u)
+	ret := m.ctrl.Call(m, "GenerateAccessToken", ctx, u)
 	ret0, _ := ret[0].(*user.JwtResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateAccessToken indicates an expected call of GenerateAccessToken.
-func (mr *MockJwtServiceMockRecorder) GenerateAccessToken(u interface{}) *gomock.Call {
+func (mr *MockJwtServiceMockRecorder) GenerateAccessToken(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAccessToken", reflect.TypeOf((*MockJwtService)(nil).GenerateAccessToken), ctx, u)
+}
+
+// GeneratePreAuthToken mocks base method.
+func (m *MockJwtService) GeneratePreAuthToken(u *user.User) (*user.PreAuthResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GeneratePreAuthToken", u)
+	ret0, _ := ret[0].(*user.PreAuthResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GeneratePreAuthToken indicates an expected call of GeneratePreAuthToken.
+func (mr *MockJwtServiceMockRecorder) GeneratePreAuthToken(u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeneratePreAuthToken", reflect.TypeOf((*MockJwtService)(nil).GeneratePreAuthToken), u)
+}
+
+// GetSessions mocks base method.
+func (m *MockJwtService) GetSessions(ctx context.Context, userId, current string) ([]*user.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, userId, current)
+	ret0, _ := ret[0].([]*user.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockJwtServiceMockRecorder) GetSessions(ctx, userId, current interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateAccessToken", reflect.TypeOf((*MockJwtService)(nil).GenerateAccessToken), u)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockJwtService)(nil).GetSessions), ctx, userId, current)
 }
 
 // GetUserClaims mocks base method.
@@ -179,10 +372,10 @@ func (mr *MockJwtServiceMockRecorder) GetUserClaims(token interface{}) *gomock.C
 }
 
 // Middleware mocks base method.
-func (m *MockJwtService) Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+func (m *MockJwtService) Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc {
 	m.ctrl.T.Helper()
 	varargs := []interface{}{h}
-	for _, a := range roles {
+	for _, a := range permissions {
 		varargs = append(varargs, a)
 	}
 	ret := m.ctrl.Call(m, "Middleware", varargs...)
@@ -191,8 +384,118 @@ func (m *MockJwtService) Middleware(h http.HandlerFunc, roles ...string) http.Ha
 }
 
 // Middleware indicates an expected call of Middleware.
-func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, roles ...interface{}) *gomock.Call {
+func (mr *MockJwtServiceMockRecorder) Middleware(h interface{}, permissions ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{h}, roles...)
+	varargs := append([]interface{}{h}, permissions...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Middleware", reflect.TypeOf((*MockJwtService)(nil).Middleware), varargs...)
 }
+
+// RevokeSession mocks base method.
+func (m *MockJwtService) RevokeSession(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockJwtServiceMockRecorder) RevokeSession(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockJwtService)(nil).RevokeSession), ctx, refreshToken)
+}
+
+// RevokeUserSession mocks base method.
+func (m *MockJwtService) RevokeUserSession(ctx context.Context, userId, sessionId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSession", ctx, userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSession indicates an expected call of RevokeUserSession.
+func (mr *MockJwtServiceMockRecorder) RevokeUserSession(ctx, userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSession", reflect.TypeOf((*MockJwtService)(nil).RevokeUserSession), ctx, userId, sessionId)
+}
+
+// VerifyPreAuthToken mocks base method.
+func (m *MockJwtService) VerifyPreAuthToken(token string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyPreAuthToken", token)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyPreAuthToken indicates an expected call of VerifyPreAuthToken.
+func (mr *MockJwtServiceMockRecorder) VerifyPreAuthToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyPreAuthToken", reflect.TypeOf((*MockJwtService)(nil).VerifyPreAuthToken), token)
+}
+
+// MockOidcProvider is a mock of OidcProvider interface.
+type MockOidcProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockOidcProviderMockRecorder
+}
+
+// MockOidcProviderMockRecorder is the mock recorder for MockOidcProvider.
+type MockOidcProviderMockRecorder struct {
+	mock *MockOidcProvider
+}
+
+// NewMockOidcProvider creates a new mock instance.
+func NewMockOidcProvider(ctrl *gomock.Controller) *MockOidcProvider {
+	mock := &MockOidcProvider{ctrl: ctrl}
+	mock.recorder = &MockOidcProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOidcProvider) EXPECT() *MockOidcProviderMockRecorder {
+	return m.recorder
+}
+
+// AuthCodeURL mocks base method.
+func (m *MockOidcProvider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthCodeURL", ctx, state, nonce, challenge)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthCodeURL indicates an expected call of AuthCodeURL.
+func (mr *MockOidcProviderMockRecorder) AuthCodeURL(ctx, state, nonce, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthCodeURL", reflect.TypeOf((*MockOidcProvider)(nil).AuthCodeURL), ctx, state, nonce, challenge)
+}
+
+// Exchange mocks base method.
+func (m *MockOidcProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*oidc.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, code, verifier, nonce)
+	ret0, _ := ret[0].(*oidc.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockOidcProviderMockRecorder) Exchange(ctx, code, verifier, nonce interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockOidcProvider)(nil).Exchange), ctx, code, verifier, nonce)
+}
+
+// Name mocks base method.
+func (m *MockOidcProvider) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockOidcProviderMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockOidcProvider)(nil).Name))
+}
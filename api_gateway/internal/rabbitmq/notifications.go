@@ -0,0 +1,85 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/stream"
+)
+
+type notification_hub interface {
+	Publish(userId string, event stream.Event)
+}
+
+// Notification service publishes every stored notification once, no matter how many instances are running.
+// User may be connected to any gateway instance, so every instance receives all notifications
+// and delivers them to it's own subscribers
+type NotificationReceiver struct {
+	connection *amqp.Connection
+	logger     *logging.Logger
+	channel    *amqp.Channel
+	hub        notification_hub
+}
+
+func NewNotificationReceiver(connection *amqp.Connection, logger *logging.Logger, hub notification_hub) *NotificationReceiver {
+	return &NotificationReceiver{
+		connection: connection,
+		logger:     logger,
+		hub:        hub,
+	}
+}
+func (r *NotificationReceiver) Start() {
+	ch, err := r.connection.Channel()
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	r.channel = ch
+
+	queue, err := r.channel.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	err = r.channel.ExchangeDeclare("NotificationSentExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	err = r.channel.QueueBind(queue.Name, "#", "NotificationSentExchange", false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	messages, err := r.channel.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	go func() {
+		for message := range messages {
+			if r.channel.IsClosed() || r.connection.IsClosed() {
+				return
+			}
+			r.handle(message.Body)
+		}
+	}()
+	r.logger.Infof("Waiting for sent notifications...")
+}
+func (r *NotificationReceiver) handle(body []byte) {
+	var query struct {
+		UserId       string          `json:"userid"`
+		Notification json.RawMessage `json:"notification"`
+	}
+	if err := json.Unmarshal(body, &query); err != nil || len(query.UserId) == 0 {
+		r.logger.Errorf("can't read notification sent message: %v", err)
+		return
+	}
+	var notification struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(query.Notification, &notification); err != nil || len(notification.Id) == 0 {
+		r.logger.Errorf("can't read sent notification: %v", err)
+		return
+	}
+	r.hub.Publish(query.UserId, stream.Event{Id: notification.Id, Data: query.Notification})
+}
+func (r *NotificationReceiver) Close() error {
+	return r.channel.Close()
+}
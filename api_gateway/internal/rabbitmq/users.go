@@ -0,0 +1,128 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+)
+
+const header_changed_at = "changedAt"
+
+type session_service interface {
+	RevokeUserSessions(ctx context.Context, userId string, before time.Time) error
+	UpdateUserRoles(ctx context.Context, userId string, roles []string) error
+}
+
+// Sessions of deleted user or user who changed login or password are revoked.
+// Sessions started after the event (e.g. the one issued with new login) stay alive.
+// Sessions of user whose roles were changed get new roles, tokens get them on refresh.
+// Every instance may keep it's own sessions, so queues are exclusive and named by the server
+type UserReceiver struct {
+	connection *amqp.Connection
+	logger     *logging.Logger
+	channel    *amqp.Channel
+	service    session_service
+}
+
+func NewUserReceiver(connection *amqp.Connection, logger *logging.Logger, service session_service) *UserReceiver {
+	return &UserReceiver{
+		connection: connection,
+		logger:     logger,
+		service:    service,
+	}
+}
+func (r *UserReceiver) Start() {
+	ch, err := r.connection.Channel()
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	r.channel = ch
+
+	r.consume("UserDeletedExchange", r.revoke(func(body []byte) string {
+		return string(body)
+	}))
+	r.consume("UserPasswordChangedExchange", r.revoke(func(body []byte) string {
+		return string(body)
+	}))
+	r.consume("UserLoginChangedExchange", r.revoke(r.loginChangedUser))
+	r.consume("UserRolesChangedExchange", func(message amqp.Delivery) {
+		var query struct {
+			UserId string   `json:"userid"`
+			Roles  []string `json:"roles"`
+		}
+		if err := json.Unmarshal(message.Body, &query); err != nil || len(query.UserId) == 0 {
+			r.logger.Errorf("can't read user roles changed message: %v", err)
+			return
+		}
+		r.service.UpdateUserRoles(context.Background(), query.UserId, query.Roles)
+	})
+	r.logger.Infof("Waiting for user changes...")
+}
+
+// Revokes sessions of the user the message is about
+func (r *UserReceiver) revoke(userId func(body []byte) string) func(message amqp.Delivery) {
+	return func(message amqp.Delivery) {
+		id := userId(message.Body)
+		if len(id) == 0 {
+			return
+		}
+		r.service.RevokeUserSessions(context.Background(), id, changedAt(message))
+	}
+}
+
+func (r *UserReceiver) loginChangedUser(body []byte) string {
+	var query struct {
+		UserId string `json:"userid"`
+	}
+	if err := json.Unmarshal(body, &query); err != nil {
+		r.logger.Errorf("can't read user login changed message: %v", err)
+	}
+	return query.UserId
+}
+
+// Message timestamp has only seconds, sessions created in the same second would be kept or revoked by mistake,
+// so exact time in milliseconds is taken from header
+func changedAt(message amqp.Delivery) time.Time {
+	if millis, ok := message.Headers[header_changed_at].(int64); ok {
+		return time.UnixMilli(millis)
+	}
+	if !message.Timestamp.IsZero() {
+		return message.Timestamp
+	}
+	return time.Now()
+}
+func (r *UserReceiver) consume(exchange string, handle func(message amqp.Delivery)) {
+	queue, err := r.channel.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	err = r.channel.ExchangeDeclare(exchange, "fanout", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	err = r.channel.QueueBind(queue.Name, "#", exchange, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	messages, err := r.channel.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	go func() {
+		for message := range messages {
+			if r.channel.IsClosed() || r.connection.IsClosed() {
+				return
+			}
+			r.logger.Infof("Received message from %s", exchange)
+			handle(message)
+		}
+	}()
+}
+func (r *UserReceiver) Close() error {
+	return r.channel.Close()
+}
@@ -0,0 +1,58 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type revokedSessions struct {
+	userId string
+	before time.Time
+}
+
+func (s *revokedSessions) RevokeUserSessions(ctx context.Context, userId string, before time.Time) error {
+	s.userId = userId
+	s.before = before
+	return nil
+}
+func (s *revokedSessions) UpdateUserRoles(ctx context.Context, userId string, roles []string) error {
+	return nil
+}
+
+func TestChangedAt(t *testing.T) {
+	changed := time.UnixMilli(time.Now().UnixMilli())
+
+	message := amqp.Delivery{Timestamp: changed.Truncate(time.Second), Headers: amqp.Table{header_changed_at: changed.UnixMilli()}}
+	assert.True(t, changed.Equal(changedAt(message)))
+
+	message = amqp.Delivery{Timestamp: changed.Truncate(time.Second)}
+	assert.True(t, changed.Truncate(time.Second).Equal(changedAt(message)))
+
+	assert.WithinDuration(t, time.Now(), changedAt(amqp.Delivery{}), time.Second)
+}
+func TestLoginChanged(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	sessions := &revokedSessions{}
+	receiver := NewUserReceiver(nil, &logging.Logger{Entry: logrus.NewEntry(log)}, sessions)
+
+	// message is consumed later than login was changed, session issued with new login must not be revoked
+	changed := time.UnixMilli(time.Now().Add(-time.Minute).UnixMilli())
+	receiver.revoke(receiver.loginChangedUser)(amqp.Delivery{
+		Timestamp: changed,
+		Headers:   amqp.Table{header_changed_at: changed.UnixMilli()},
+		Body:      []byte(`{"userid":"userid","newlogin":"login"}`),
+	})
+	assert.Equal(t, "userid", sessions.userId)
+	assert.True(t, changed.Equal(sessions.before))
+
+	sessions.userId = ""
+	receiver.revoke(receiver.loginChangedUser)(amqp.Delivery{Body: []byte(`not a json`)})
+	assert.Empty(t, sessions.userId)
+}
@@ -11,13 +11,16 @@ import (
 type Code string
 
 const (
-	InternalErrorCode     Code = "IE-0001"
-	NotFoundErrorCode     Code = "IE-0002"
-	BadRequestErrorCode   Code = "IE-0003"
-	ValidationErrorCode   Code = "IE-0004"
-	UnauthorizedErrorCode Code = "IE-0005"
-	NotUniqueErrorCode    Code = "IE-0006"
-	ForbiddenErrorCode    Code = "IE-0007"
+	InternalErrorCode        Code = "IE-0001"
+	NotFoundErrorCode        Code = "IE-0002"
+	BadRequestErrorCode      Code = "IE-0003"
+	ValidationErrorCode      Code = "IE-0004"
+	UnauthorizedErrorCode    Code = "IE-0005"
+	NotUniqueErrorCode       Code = "IE-0006"
+	ForbiddenErrorCode       Code = "IE-0007"
+	TooLargeErrorCode        Code = "IE-0008"
+	TooManyRequestsErrorCode Code = "IE-0009"
+	LockedErrorCode          Code = "IE-0010"
 )
 
 type Error struct {
@@ -110,3 +113,12 @@ func NotUniqueError(message []string, dev_message string) *Error {
 func ForbiddenError(message []string, dev_message string) *Error {
 	return NewError(message, ForbiddenErrorCode, dev_message)
 }
+func TooLargeError(message []string, dev_message string) *Error {
+	return NewError(message, TooLargeErrorCode, dev_message)
+}
+func TooManyRequestsError(message []string, dev_message string) *Error {
+	return NewError(message, TooManyRequestsErrorCode, dev_message)
+}
+func LockedError(message []string, dev_message string) *Error {
+	return NewError(message, LockedErrorCode, dev_message)
+}
@@ -30,6 +30,12 @@ func Middleware(h Handler) Handler {
 					w.WriteHeader(http.StatusConflict)
 				case ForbiddenErrorCode:
 					w.WriteHeader(http.StatusForbidden)
+				case TooLargeErrorCode:
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				case TooManyRequestsErrorCode:
+					w.WriteHeader(http.StatusTooManyRequests)
+				case LockedErrorCode:
+					w.WriteHeader(http.StatusLocked)
 				default:
 					w.WriteHeader(http.StatusBadRequest)
 				}
@@ -0,0 +1,204 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cristalhq/jwt/v3"
+)
+
+var (
+	ErrNoSigningKey = errors.New("jwt: no signing key")
+	ErrUnknownKey   = errors.New("jwt: token is signed with unknown key")
+)
+
+type key struct {
+	id       string
+	signer   jwt.Signer
+	verifier jwt.Verifier
+	public   ed25519.PublicKey
+}
+
+// Tokens are signed with EdDSA by the signing key and carry it's id in kid header.
+// Other keys of the set are only used to verify tokens, so the signing key can be rotated:
+// new key is added to the set, then it becomes signing key, and the old one is removed
+// when tokens signed by it are expired
+type KeySet struct {
+	signing *key
+	keys    map[string]*key
+}
+
+// Private keys are used for both signing and verifying, public keys are verification only
+func NewKeySet(signing string, private map[string]ed25519.PrivateKey, public map[string]ed25519.PublicKey) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*key)}
+	for id, pub := range public {
+		verifier, err := jwt.NewVerifierEdDSA(pub)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		set.keys[id] = &key{id: id, verifier: verifier, public: pub}
+	}
+	for id, priv := range private {
+		signer, err := jwt.NewSignerEdDSA(priv)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		pub := priv.Public().(ed25519.PublicKey)
+		verifier, _ := jwt.NewVerifierEdDSA(pub)
+		set.keys[id] = &key{id: id, signer: signer, verifier: verifier, public: pub}
+	}
+	if len(signing) == 0 {
+		// the latest private key is used when signing key is not specified
+		ids := make([]string, 0, len(private))
+		for id := range private {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if len(ids) == 0 {
+			return set, nil
+		}
+		signing = ids[len(ids)-1]
+	}
+	k, ok := set.keys[signing]
+	if !ok || k.signer == nil {
+		return nil, fmt.Errorf("signing key %s has no private key in the set", signing)
+	}
+	set.signing = k
+	return set, nil
+}
+
+// Loads *.pem keys from directory, file name is used as key id.
+// If directory has no keys, a new one is generated and saved there
+func LoadKeySet(dir string, signing string) (*KeySet, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		file, err := GenerateKey(dir)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	private := make(map[string]ed25519.PrivateKey)
+	public := make(map[string]ed25519.PublicKey)
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".pem")
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("key %s is not pem encoded", id)
+		}
+		switch block.Type {
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", id, err)
+			}
+			priv, ok := parsed.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("key %s is not ed25519 key", id)
+			}
+			private[id] = priv
+		case "PUBLIC KEY":
+			parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %w", id, err)
+			}
+			pub, ok := parsed.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("key %s is not ed25519 key", id)
+			}
+			public[id] = pub
+		default:
+			return nil, fmt.Errorf("key %s has unsupported pem type %s", id, block.Type)
+		}
+	}
+	return NewKeySet(signing, private, public)
+}
+
+// Generates a new private key in directory, current date is used as key id
+func GenerateKey(dir string) (string, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	data, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, time.Now().UTC().Format("20060102150405")+".pem")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: data}), 0600); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+func (k *KeySet) Sign(claims interface{}) (*jwt.Token, error) {
+	if k == nil || k.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return jwt.NewBuilder(k.signing.signer, jwt.WithKeyID(k.signing.id)).Build(claims)
+}
+func (k *KeySet) Verify(raw string) (*jwt.Token, error) {
+	token, err := jwt.ParseString(raw)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrUnknownKey
+	}
+	verifying, ok := k.keys[token.Header().KeyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return jwt.ParseAndVerifyString(raw, verifying.verifier)
+}
+
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyId     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Public part of the set, as described in RFC 8037
+func (k *KeySet) JWKS() *JWKSet {
+	set := &JWKSet{Keys: make([]JWK, 0)}
+	if k == nil {
+		return set
+	}
+	for _, v := range k.keys {
+		set.Keys = append(set.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(v.public),
+			KeyId:     v.id,
+			Algorithm: string(jwt.EdDSA),
+			Use:       "sig",
+		})
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyId < set.Keys[j].KeyId })
+	return set
+}
@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cristalhq/jwt/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+var testKeys = func() *KeySet {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	keys, err := NewKeySet("", map[string]ed25519.PrivateKey{"test": priv}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}()
+
+func TestKeyRotation(t *testing.T) {
+	_, old, _ := ed25519.GenerateKey(rand.Reader)
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+
+	before, err := NewKeySet("", map[string]ed25519.PrivateKey{"1": old}, nil)
+	assert.NoError(t, err)
+	oldToken, err := before.Sign(UserClaims{Login: "user"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", oldToken.Header().KeyID)
+	assert.Equal(t, jwt.EdDSA, oldToken.Header().Algorithm)
+
+	// new key is signing one, old key still verifies issued tokens
+	after, err := NewKeySet("", map[string]ed25519.PrivateKey{"2": current}, map[string]ed25519.PublicKey{"1": old.Public().(ed25519.PublicKey)})
+	assert.NoError(t, err)
+	newToken, err := after.Sign(UserClaims{Login: "user"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", newToken.Header().KeyID)
+	_, err = after.Verify(oldToken.String())
+	assert.NoError(t, err)
+	_, err = before.Verify(newToken.String())
+	assert.Equal(t, ErrUnknownKey, err)
+
+	// token with forged kid is not accepted
+	forged, _ := NewKeySet("", map[string]ed25519.PrivateKey{"2": old}, nil)
+	forgedToken, _ := forged.Sign(UserClaims{Login: "admin"})
+	_, err = after.Verify(forgedToken.String())
+	assert.Equal(t, jwt.ErrInvalidSignature, err)
+
+	_, err = NewKeySet("1", nil, map[string]ed25519.PublicKey{"1": old.Public().(ed25519.PublicKey)})
+	assert.Error(t, err)
+
+	jwks := after.JWKS()
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "1", jwks.Keys[0].KeyId)
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(current.Public().(ed25519.PublicKey)), jwks.Keys[1].X)
+		assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+	}
+}
+func TestLoadKeySet(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+
+	generated, err := LoadKeySet(dir, "")
+	assert.NoError(t, err)
+	token, err := generated.Sign(UserClaims{Login: "user"})
+	assert.NoError(t, err)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.pem"))
+	assert.Len(t, files, 1)
+
+	loaded, err := LoadKeySet(dir, "")
+	assert.NoError(t, err)
+	_, err = loaded.Verify(token.String())
+	assert.NoError(t, err)
+
+	_, err = LoadKeySet(dir, "unknown")
+	assert.Error(t, err)
+
+	os.WriteFile(filepath.Join(dir, "broken.pem"), []byte("not a key"), 0600)
+	_, err = LoadKeySet(dir, "")
+	assert.Error(t, err)
+}
@@ -2,78 +2,95 @@ package jwt
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"slices"
 	"time"
 
-	"github.com/cristalhq/jwt/v3"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 )
 
-func (s *jwtService) Middleware(h http.HandlerFunc, roles ...string) http.HandlerFunc {
+// Checks that user's roles give all of the permissions. Roles are taken from the token,
+// so changes of user's roles take effect when the token is refreshed.
+// Access token is accepted only while it's session exists, so signed out and revoked tokens are rejected at once
+func (s *jwtService) Middleware(h http.HandlerFunc, permissions ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		var claims *UserClaims
 		cookie, err := r.Cookie(TokenCookieName)
-		if err != nil {
-			unauthorized(w, err)
-			return
+		if err == nil {
+			s.Logger.Info("parsing and verifying token...")
+			claims, err = s.verify(cookie.Value)
 		}
-		headertoken := cookie.Value
-		key := []byte(s.secret)
-		verifier, err := jwt.NewVerifierHS(jwt.HS256, key)
-		if err != nil {
-			s.Logger.Errorf("error creating verifier for key. key length = %d, error = %v", len(key), err)
-			unauthorized(w, err)
-			return
+		if err == nil && !claims.IsValidAt(time.Now()) {
+			err = errors.New("access token is expired")
 		}
-		s.Logger.Info("parsing and verifying token...")
-		token, err := jwt.ParseAndVerifyString(headertoken, verifier)
-		if err != nil {
-			unauthorized(w, err)
-			return
-		}
-
-		var claims UserClaims
-		if err := json.Unmarshal(token.RawClaims(), &claims); err != nil {
-			unauthorized(w, err)
-			return
+		if err == nil {
+			err = s.checkSession(r.Context(), claims)
 		}
-		if !claims.IsValidAt(time.Now()) {
-			refreshCookie, err := r.Cookie(RefreshCookieName)
-			if err != nil {
-				http.SetCookie(w, &http.Cookie{Name: TokenCookieName, MaxAge: -1})
-				http.SetCookie(w, &http.Cookie{Name: RefreshCookieName, MaxAge: -1})
+		if err != nil {
+			// tokens signed by removed keys are replaced the same way as expired ones,
+			// user is identified by session, not by the old token
+			refreshCookie, rerr := r.Cookie(RefreshCookieName)
+			if rerr != nil {
+				ClearCookies(w)
 				unauthorized(w, err)
 				return
 			}
-			token, err := s.UpdateRefreshToken(refreshCookie.Value)
+			token, err := s.UpdateRefreshToken(session.NewContext(r.Context(), r), refreshCookie.Value)
 			if err != nil {
-				http.SetCookie(w, &http.Cookie{Name: TokenCookieName, MaxAge: -1})
-				http.SetCookie(w, &http.Cookie{Name: RefreshCookieName, MaxAge: -1})
+				ClearCookies(w)
 				unauthorized(w, err)
 				return
 			}
 			http.SetCookie(w, token.Token)
-			http.SetCookie(w, token.RefreshToken)
+			if token.RefreshToken != nil {
+				http.SetCookie(w, token.RefreshToken)
+			}
+			claims, err = s.verify(token.Token.Value)
+			if err != nil {
+				unauthorized(w, err)
+				return
+			}
+			cookie = token.Token
 		}
-		if len(roles) > 0 {
-			var errorRoles []string
-			for _, role := range roles {
-				if len(role) > 0 && !slices.Contains(claims.Roles, role) {
-					errorRoles = append(errorRoles, fmt.Sprintf("user has no %s right", role))
+		if len(permissions) > 0 {
+			granted := Permissions(claims.Roles)
+			var errorPermissions []string
+			for _, permission := range permissions {
+				if len(permission) > 0 && !slices.Contains(granted, permission) {
+					errorPermissions = append(errorPermissions, fmt.Sprintf("user has no %s permission", permission))
 				}
 			}
-			if len(errorRoles) > 0 {
-				forbidden(w, errorRoles)
+			if len(errorPermissions) > 0 {
+				forbidden(w, errorPermissions)
 				return
 			}
 		}
 		ctx := context.WithValue(r.Context(), rest.UserIdKey, claims.ID)
+		ctx = context.WithValue(ctx, rest.RolesKey, claims.Roles)
+		ctx = context.WithValue(ctx, rest.SessionIdKey, claims.Session)
+		ctx = context.WithValue(ctx, rest.TokenKey, cookie.Value)
 		h(w, r.WithContext(ctx))
 	}
 }
+
+// Session is removed on sign out, revocation, password change and user deletion
+func (s *jwtService) checkSession(ctx context.Context, claims *UserClaims) error {
+	if len(claims.Session) == 0 {
+		return errors.New("access token has no session")
+	}
+	found, err := s.Store.FindById(ctx, claims.Session)
+	if err != nil {
+		return err
+	}
+	if found.UserId != claims.ID {
+		return fmt.Errorf("session %s belongs to other user", claims.Session)
+	}
+	return nil
+}
 func unauthorized(w http.ResponseWriter, err error) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(http.StatusUnauthorized)
@@ -1,7 +1,7 @@
 package jwt
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,14 +9,13 @@ import (
 
 	"github.com/cristalhq/jwt/v3"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/user"
-	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/cache/freecache"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var testCases = []struct {
@@ -27,17 +26,20 @@ var testCases = []struct {
 	StatusCode   int
 }{
 	{"default user authorization", "userid", []string{"user"}, "", http.StatusOK},
-	{"default admin authorization", "userid", []string{"user", "admin"}, "admin", http.StatusOK},
+	{"default admin authorization", "userid", []string{"user", "admin"}, RolesWrite, http.StatusOK},
 	{"admin authorization as user", "uid", []string{"user", "admin"}, "", http.StatusOK},
-	{"user authorization as admin", "uid", []string{"user"}, "admin", http.StatusForbidden},
+	{"user authorization as admin", "uid", []string{"user"}, RolesWrite, http.StatusForbidden},
+	{"editor authorization", "uid", []string{"user", "editor"}, BooksWrite, http.StatusOK},
+	{"editor authorization as admin", "uid", []string{"user", "editor"}, RolesWrite, http.StatusForbidden},
+	{"unknown role authorization", "uid", []string{"user", "unknown"}, BooksWrite, http.StatusForbidden},
 }
 
 func TestMiddleware(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, val, "secretCode")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, val, testKeys)
 
 	for _, testCase := range testCases {
 		t.Run(testCase.Name, func(t *testing.T) {
@@ -50,7 +52,7 @@ func TestMiddleware(t *testing.T) {
 				Id:    testCase.Uid,
 				Roles: testCase.UserRole,
 			}
-			token, err := service.GenerateAccessToken(u)
+			token, err := service.GenerateAccessToken(context.Background(), u)
 			assert.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodGet, "http://test", nil)
@@ -67,8 +69,8 @@ func TestEmptyRequestMiddleware(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, val, "")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, val, nil)
 	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
 	}))
@@ -81,8 +83,8 @@ func TestNilKeyMiddleware(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, val, "")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, val, nil)
 	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -111,8 +113,8 @@ func TestNilTokenMiddleware(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, val, "secretCode")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, val, testKeys)
 	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
@@ -126,13 +128,11 @@ func TestOldTokenMiddleware(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, val, "secretCode")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, val, testKeys)
 	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
-	signer, _ := jwt.NewSignerHS(jwt.HS256, []byte("secretCode"))
-	builder := jwt.NewBuilder(signer)
 
 	u := &user.User{
 		Id:    "userId",
@@ -150,22 +150,115 @@ func TestOldTokenMiddleware(t *testing.T) {
 		Login: u.Login,
 		Email: u.Email,
 	}
-	token, _ := builder.Build(claims)
+	token, _ := testKeys.Sign(claims)
 
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "http://test", nil)
 
-	refreshToken := primitive.NewObjectID().Hex()
+	refreshToken, _ := session.NewToken()
 	r.AddCookie(&http.Cookie{Name: TokenCookieName, Value: token.String()})
 
 	handler.ServeHTTP(w, r)
 	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
 
 	r.AddCookie(&http.Cookie{Name: RefreshCookieName, Value: refreshToken})
-	userBytes, _ := json.Marshal(u)
-	cache.Set([]byte(refreshToken), userBytes, int((7*24*time.Hour)/time.Second))
+	store.Create(context.Background(), &session.Session{
+		UserId:    u.Id,
+		Login:     u.Login,
+		Roles:     u.Roles,
+		Email:     u.Email,
+		TokenHash: session.Hash(refreshToken),
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, RefreshCookieName, cookies[1].Name)
+		assert.NotEqual(t, refreshToken, cookies[1].Value)
+	}
+}
+func TestForgedTokenMiddleware(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, validator.New(), testKeys)
+	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), RolesWrite)
+
+	response, err := service.GenerateAccessToken(context.Background(), &user.User{Id: "userId", Roles: []string{"user"}})
+	assert.NoError(t, err)
+
+	// token with admin rights signed by unknown key is replaced with token from user's session
+	signer, _ := jwt.NewSignerHS(jwt.HS256, []byte("secretCode"))
+	forged, _ := jwt.NewBuilder(signer).Build(UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: "userId", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Roles:            []string{"user", "admin"},
+	})
+	r := httptest.NewRequest(http.MethodGet, "http://test", nil)
+	r.AddCookie(&http.Cookie{Name: TokenCookieName, Value: forged.String()})
+	r.AddCookie(response.RefreshToken)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+}
+func TestPermissions(t *testing.T) {
+	assert.Empty(t, Permissions(nil))
+	assert.Empty(t, Permissions([]string{"user", "unknown"}))
+	assert.Equal(t, []string{AuthorsWrite, BooksWrite, GenresWrite}, Permissions([]string{"user", "editor"}))
+	assert.Equal(t, []string{AuthorsWrite, BooksWrite, GenresWrite, RolesWrite}, Permissions([]string{"editor", "admin"}))
+}
+func TestRevokedSessionMiddleware(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, validator.New(), testKeys)
+	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	response, err := service.GenerateAccessToken(context.Background(), &user.User{Id: "userId", Roles: []string{"user"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, int(access_ttl/time.Second), response.Token.MaxAge)
+
+	r := httptest.NewRequest(http.MethodGet, "http://test", nil)
+	r.AddCookie(response.Token)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 
+	// access token is not accepted after sign out, even though it's not expired
+	assert.NoError(t, service.RevokeSession(context.Background(), response.RefreshToken.Value))
 	w = httptest.NewRecorder()
 	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+}
+func TestExpiredCookieMiddleware(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	service := NewService(session.NewMemoryStore(), logger, validator.New(), testKeys)
+	handler := service.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "userId", r.Context().Value(rest.UserIdKey))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	response, err := service.GenerateAccessToken(context.Background(), &user.User{Id: "userId", Roles: []string{"user"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// browser doesn't send access cookie after it's max age, token is refreshed by session
+	r := httptest.NewRequest(http.MethodGet, "http://test", nil)
+	r.AddCookie(response.RefreshToken)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
 	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	if cookies := w.Result().Cookies(); assert.NotEmpty(t, cookies) {
+		assert.Equal(t, TokenCookieName, cookies[0].Name)
+	}
 }
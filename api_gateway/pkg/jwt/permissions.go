@@ -0,0 +1,32 @@
+package jwt
+
+import "slices"
+
+const (
+	BooksWrite   = "books:write"
+	GenresWrite  = "genres:write"
+	AuthorsWrite = "authors:write"
+	RolesWrite   = "roles:write"
+)
+
+// Roles are granted to users by user service, every role is a set of permissions.
+// Unknown roles have no permissions
+var rolePermissions = map[string][]string{
+	"user":   {},
+	"editor": {BooksWrite, GenresWrite, AuthorsWrite},
+	"admin":  {BooksWrite, GenresWrite, AuthorsWrite, RolesWrite},
+}
+
+// Returns sorted permissions of all roles without duplicates
+func Permissions(roles []string) []string {
+	permissions := make([]string, 0)
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	slices.Sort(permissions)
+	return permissions
+}
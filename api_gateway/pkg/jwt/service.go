@@ -1,121 +1,325 @@
 package jwt
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/cristalhq/jwt/v3"
 	"github.com/go-playground/validator/v10"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/user"
-	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/cache"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 	valid "github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
 	TokenCookieName   string = "authTokenCookie"
 	RefreshCookieName string = "refreshTokenCookie"
+
+	access_ttl     = 60 * time.Minute
+	session_ttl    = 7 * 24 * time.Hour
+	rotation_grace = 10 * time.Second
+	preauth_ttl    = 5 * time.Minute
+	// pre-auth tokens are signed with the same keys, purpose keeps them from being used as access tokens
+	preauth_purpose = "2fa"
 )
 
 type UserClaims struct {
 	jwt.RegisteredClaims
-	Login string   `json:"login"`
-	Roles []string `json:"roles"`
-	Email string   `json:"email"`
+	Login   string   `json:"login"`
+	Roles   []string `json:"roles"`
+	Email   string   `json:"email"`
+	Session string   `json:"sid,omitempty"`
+	Purpose string   `json:"purpose,omitempty"`
 }
 
 type jwtService struct {
 	Logger    *logging.Logger
-	Cache     cache.Cache
+	Store     session.Store
 	Validator *valid.Validator
-	secret    string
+	keys      *KeySet
+	grace     time.Duration
 }
 
-func NewService(cache cache.Cache, logger *logging.Logger, validate *valid.Validator, secret string) *jwtService {
-	return &jwtService{Logger: logger, Cache: cache, Validator: validate, secret: secret}
+func NewService(store session.Store, logger *logging.Logger, validate *valid.Validator, keys *KeySet) *jwtService {
+	return &jwtService{Logger: logger, Store: store, Validator: validate, keys: keys, grace: rotation_grace}
 }
-func (j *jwtService) UpdateRefreshToken(refreshToken string) (*user.JwtResponse, error) {
-	if err := j.Validator.Var(refreshToken, "primitiveid"); err != nil {
+
+// Refresh token is rotated on every use. If the previous token of the session is presented again,
+// someone else owns a copy of it, so the whole session is revoked. The only exception is
+// concurrent requests sent right before rotation, they get a new access token without a new refresh token
+func (j *jwtService) UpdateRefreshToken(ctx context.Context, refreshToken string) (*user.JwtResponse, error) {
+	if err := j.Validator.Var(refreshToken, "required,base64rawurl"); err != nil {
 		return nil, errormiddleware.ValidationError(err.(validator.ValidationErrors), "wrong refresh token format")
 	}
-	defer j.Cache.Delete([]byte(refreshToken))
-
-	userBytes, err := j.Cache.Get([]byte(refreshToken))
+	hash := session.Hash(refreshToken)
+	s, err := j.Store.FindByToken(ctx, hash)
 	if err != nil {
-		j.Logger.Warn(err)
-		return nil, errormiddleware.NotFoundError([]string{"couldn't get refresh token from cache"}, err.Error())
+		if errors.Is(err, session.ErrNotFound) {
+			return nil, errormiddleware.NotFoundError([]string{"refresh token not found or expired"}, err.Error())
+		}
+		j.Logger.Error(err)
+		return nil, err
 	}
-	var u user.User
-	err = json.Unmarshal(userBytes, &u)
+	if s.TokenHash != hash {
+		if time.Since(s.RotatedAt) < j.grace {
+			return j.buildResponse(s, "")
+		}
+		j.Logger.Warnf("refresh token of session %s (user %s) was used twice, revoking session", s.Id, s.UserId)
+		if err := j.Store.Revoke(ctx, s.Id); err != nil && !errors.Is(err, session.ErrNotFound) {
+			j.Logger.Errorf("can't revoke session %s: %v", s.Id, err)
+		}
+		return nil, errormiddleware.UnauthorizedError([]string{"refresh token was already used"}, fmt.Sprintf("session %s revoked due to refresh token reuse", s.Id))
+	}
+
+	next, err := session.NewToken()
 	if err != nil {
+		return nil, err
+	}
+	if err := j.Store.Rotate(ctx, s.Id, hash, session.Hash(next), session.ClientFromContext(ctx), time.Now().Add(session_ttl)); err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			// token was rotated by concurrent request
+			return j.buildResponse(s, "")
+		}
 		j.Logger.Error(err)
 		return nil, err
 	}
-	return j.GenerateAccessToken(&u)
+	return j.buildResponse(s, next)
 }
-func (j *jwtService) GenerateAccessToken(u *user.User) (*user.JwtResponse, error) {
-	signer, err := jwt.NewSignerHS(jwt.HS256, []byte(j.secret))
+
+// Starts a new session for user
+func (j *jwtService) GenerateAccessToken(ctx context.Context, u *user.User) (*user.JwtResponse, error) {
+	if j.keys == nil || j.keys.signing == nil {
+		j.Logger.Warn(ErrNoSigningKey)
+		return nil, ErrNoSigningKey
+	}
+
+	j.Logger.Info("creating refresh token...")
+	refreshToken, err := session.NewToken()
 	if err != nil {
-		j.Logger.Warn(err)
 		return nil, err
 	}
-	builder := jwt.NewBuilder(signer)
+	now := time.Now()
+	client := session.ClientFromContext(ctx)
+	s := &session.Session{
+		UserId:    u.Id,
+		Login:     u.Login,
+		Email:     u.Email,
+		Roles:     u.Roles,
+		TokenHash: session.Hash(refreshToken),
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		CreatedAt: now,
+		RotatedAt: now,
+		ExpiresAt: now.Add(session_ttl),
+	}
+	if err := j.Store.Create(ctx, s); err != nil {
+		j.Logger.Errorf("can't create session: %v", err)
+		return nil, err
+	}
+	return j.buildResponse(s, refreshToken)
+}
+
+// Revokes session by it's refresh token, used to log out current device
+func (j *jwtService) RevokeSession(ctx context.Context, refreshToken string) error {
+	s, err := j.Store.FindByToken(ctx, session.Hash(refreshToken))
+	if err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			return errormiddleware.NotFoundError([]string{"session not found"}, err.Error())
+		}
+		return err
+	}
+	if err := j.Store.Revoke(ctx, s.Id); err != nil && !errors.Is(err, session.ErrNotFound) {
+		return err
+	}
+	j.Logger.Infof("session %s of user %s revoked", s.Id, s.UserId)
+	return nil
+}
+
+// Lists active sessions of user, current session is marked
+func (j *jwtService) GetSessions(ctx context.Context, userId string, current string) ([]*user.Session, error) {
+	sessions, err := j.Store.List(ctx, userId)
+	if err != nil {
+		j.Logger.Errorf("can't list sessions of user %s: %v", userId, err)
+		return nil, err
+	}
+	response := make([]*user.Session, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, &user.Session{
+			Id:        s.Id,
+			Device:    session.Device(s.UserAgent),
+			UserAgent: s.UserAgent,
+			IP:        s.IP,
+			Created:   s.CreatedAt,
+			LastSeen:  s.RotatedAt,
+			Current:   s.Id == current,
+		})
+	}
+	return response, nil
+}
+
+// Revokes one of user's sessions by it's id
+func (j *jwtService) RevokeUserSession(ctx context.Context, userId string, sessionId string) error {
+	sessions, err := j.Store.List(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if s.Id != sessionId {
+			continue
+		}
+		if err := j.Store.Revoke(ctx, s.Id); err != nil && !errors.Is(err, session.ErrNotFound) {
+			return err
+		}
+		j.Logger.Infof("session %s of user %s revoked", s.Id, userId)
+		return nil
+	}
+	return errormiddleware.NotFoundError([]string{"session not found"}, fmt.Sprintf("user %s has no session %s", userId, sessionId))
+}
+
+// Revokes all user's sessions that were created before provided time
+func (j *jwtService) RevokeUserSessions(ctx context.Context, userId string, before time.Time) error {
+	count, err := j.Store.RevokeUser(ctx, userId, before)
+	if err != nil {
+		j.Logger.Errorf("can't revoke sessions of user %s: %v", userId, err)
+		return err
+	}
+	j.Logger.Infof("revoked %d sessions of user %s", count, userId)
+	return nil
+}
+
+// Roles are kept in sessions, so refreshed tokens get new roles without signing in again
+func (j *jwtService) UpdateUserRoles(ctx context.Context, userId string, roles []string) error {
+	count, err := j.Store.SetUserRoles(ctx, userId, roles)
+	if err != nil {
+		j.Logger.Errorf("can't update roles of user %s sessions: %v", userId, err)
+		return err
+	}
+	j.Logger.Infof("updated roles of %d sessions of user %s to %v", count, userId, roles)
+	return nil
+}
 
+// Removes token cookies from client
+func ClearCookies(w http.ResponseWriter) {
+	for _, name := range []string{TokenCookieName, RefreshCookieName} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			MaxAge:   -1,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+			Path:     "/",
+		})
+	}
+}
+
+// Refresh token cookie is not set if token is empty
+func (j *jwtService) buildResponse(s *session.Session, refreshToken string) (*user.JwtResponse, error) {
 	claims := UserClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        u.Id,
-			Audience:  u.Roles,
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 60)),
+			ID:        s.UserId,
+			Audience:  s.Roles,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(access_ttl)),
 		},
-		Roles: u.Roles,
-		Login: u.Login,
-		Email: u.Email,
+		Roles:   s.Roles,
+		Login:   s.Login,
+		Email:   s.Email,
+		Session: s.Id,
 	}
-	token, err := builder.Build(claims)
+	token, err := j.keys.Sign(claims)
 	if err != nil {
 		j.Logger.Warn(err)
 		return nil, err
 	}
 
-	j.Logger.Info("creating refresh token...")
-	refreshTokenUuid := primitive.NewObjectID().Hex()
-	userBytes, _ := json.Marshal(u)
-	j.Cache.Set([]byte(refreshTokenUuid), userBytes, int((7*24*time.Hour)/time.Second))
-
 	responseToken := &user.JwtResponse{
-		Login: u.Login,
-		Roles: u.Roles,
+		Login:       s.Login,
+		Roles:       s.Roles,
+		Permissions: Permissions(s.Roles),
 		Token: &http.Cookie{
 			Name:     TokenCookieName,
 			Value:    token.String(),
-			MaxAge:   (int)((31 * 24 * time.Hour) / time.Second),
+			MaxAge:   (int)(access_ttl / time.Second),
 			HttpOnly: true,
 			Secure:   true,
 			SameSite: http.SameSiteLaxMode,
 			Path:     "/",
 		},
-		RefreshToken: &http.Cookie{
+	}
+	if len(refreshToken) > 0 {
+		responseToken.RefreshToken = &http.Cookie{
 			Name:     RefreshCookieName,
-			Value:    refreshTokenUuid,
+			Value:    refreshToken,
 			MaxAge:   (int)((31 * 24 * time.Hour) / time.Second),
 			HttpOnly: true,
 			Secure:   true,
 			SameSite: http.SameSiteLaxMode,
 			Path:     "/",
-		},
+		}
 	}
 	return responseToken, nil
 }
 func (j *jwtService) GetUserClaims(token string) (*user.JwtResponse, error) {
-	verifier, err := jwt.NewVerifierHS(jwt.HS256, []byte(j.secret))
+	claims, err := j.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	j.Logger.Infof("user %s authorized with %v rights", claims.Login, claims.Roles)
+	return &user.JwtResponse{
+		Login:       claims.Login,
+		Roles:       claims.Roles,
+		Permissions: Permissions(claims.Roles),
+	}, nil
+}
+
+// Issues short-lived token that proves user has passed the password check and waits for the second factor
+func (j *jwtService) GeneratePreAuthToken(u *user.User) (*user.PreAuthResponse, error) {
+	claims := UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        u.Id,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(preauth_ttl)),
+		},
+		Login:   u.Login,
+		Purpose: preauth_purpose,
+	}
+	token, err := j.keys.Sign(claims)
 	if err != nil {
+		j.Logger.Warn(err)
 		return nil, err
 	}
+	return &user.PreAuthResponse{TwoFactor: true, PreAuth: token.String()}, nil
+}
 
-	claimToken, err := jwt.ParseAndVerifyString(token, verifier)
+// Returns id of user the pre-auth token was issued to
+func (j *jwtService) VerifyPreAuthToken(token string) (string, error) {
+	claims, err := j.parse(token)
+	if err != nil {
+		return "", errormiddleware.UnauthorizedError([]string{"pre-auth token is invalid"}, err.Error())
+	}
+	if claims.Purpose != preauth_purpose || !claims.IsValidAt(time.Now()) {
+		return "", errormiddleware.UnauthorizedError([]string{"pre-auth token is invalid or expired"}, fmt.Sprintf("token of user %s has purpose %s", claims.ID, claims.Purpose))
+	}
+	return claims.ID, nil
+}
+
+// Checks access token signature, expiration is not checked
+func (j *jwtService) verify(token string) (*UserClaims, error) {
+	claims, err := j.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.Purpose) > 0 {
+		return nil, fmt.Errorf("%s token can't be used as access token", claims.Purpose)
+	}
+	return claims, nil
+}
+func (j *jwtService) parse(token string) (*UserClaims, error) {
+	claimToken, err := j.keys.Verify(token)
 	if err != nil {
 		return nil, err
 	}
@@ -124,9 +328,5 @@ func (j *jwtService) GetUserClaims(token string) (*user.JwtResponse, error) {
 	if err := json.Unmarshal(claimToken.RawClaims(), &claims); err != nil {
 		return nil, err
 	}
-	j.Logger.Infof("user %s authorized with %v rights", claims.Login, claims.Roles)
-	return &user.JwtResponse{
-		Login: claims.Login,
-		Roles: claims.Roles,
-	}, nil
+	return &claims, nil
 }
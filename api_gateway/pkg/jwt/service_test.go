@@ -1,12 +1,18 @@
 package jwt
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/cristalhq/jwt/v3"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/internal/client/user"
-	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/cache/freecache"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/session"
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/validator"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
@@ -15,10 +21,10 @@ import (
 )
 
 var generateTokenCases = []struct {
-	Name   string
-	User   user.User
-	Secret string
-	Err    error
+	Name string
+	User user.User
+	Keys *KeySet
+	Err  error
 }{
 	{
 		Name: "User token generating",
@@ -28,13 +34,12 @@ var generateTokenCases = []struct {
 			Roles: []string{"user"},
 			Email: "email@example.com",
 		},
-		Secret: "secretKey",
+		Keys: testKeys,
 	},
 	{
-		Name:   "nil secret key",
-		User:   user.User{},
-		Secret: "",
-		Err:    jwt.Error("jwt: key is nil"),
+		Name: "nil signing key",
+		User: user.User{},
+		Err:  ErrNoSigningKey,
 	},
 }
 
@@ -42,12 +47,12 @@ func TestGenerateToken(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
+	store := session.NewMemoryStore()
 
 	for _, testCase := range generateTokenCases {
 		t.Run(testCase.Name, func(t *testing.T) {
-			service := NewService(cache, logger, val, testCase.Secret)
-			response, err := service.GenerateAccessToken(&testCase.User)
+			service := NewService(store, logger, val, testCase.Keys)
+			response, err := service.GenerateAccessToken(context.Background(), &testCase.User)
 
 			assert.Equal(t, testCase.Err, err)
 			if response != nil {
@@ -58,10 +63,10 @@ func TestGenerateToken(t *testing.T) {
 }
 
 var updateTokenCases = []struct {
-	Name   string
-	User   user.User
-	Secret string
-	Err    error
+	Name string
+	User user.User
+	Keys *KeySet
+	Err  error
 }{
 	{
 		Name: "User token updating",
@@ -71,7 +76,7 @@ var updateTokenCases = []struct {
 			Roles: []string{"user"},
 			Email: "email@example.com",
 		},
-		Secret: "secretKey",
+		Keys: testKeys,
 	},
 }
 
@@ -79,36 +84,152 @@ func TestUpdateToken(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
+	store := session.NewMemoryStore()
 
 	for _, testCase := range updateTokenCases {
 		t.Run(testCase.Name, func(t *testing.T) {
-			service := NewService(cache, logger, val, testCase.Secret)
+			service := NewService(store, logger, val, testCase.Keys)
+			service.grace = 0
 
-			token, err := service.GenerateAccessToken(&testCase.User)
+			token, err := service.GenerateAccessToken(context.Background(), &testCase.User)
 			assert.NoError(t, err)
 
-			response, err := service.UpdateRefreshToken(token.RefreshToken.Value)
+			response, err := service.UpdateRefreshToken(context.Background(), token.RefreshToken.Value)
 			assert.Equal(t, err, testCase.Err)
 
 			if response != nil {
 				assert.Equal(t, response.Login, testCase.User.Login)
 			}
 
-			_, err = service.UpdateRefreshToken(token.RefreshToken.Value)
+			_, err = service.UpdateRefreshToken(context.Background(), token.RefreshToken.Value)
 			assert.Error(t, err)
 		})
 	}
 }
 
+func TestRefreshTokenReuse(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, validator.New(), testKeys)
+	u := &user.User{Id: "userId", Login: "User", Roles: []string{"user"}}
+
+	token, err := service.GenerateAccessToken(context.Background(), u)
+	assert.NoError(t, err)
+	first := token.RefreshToken.Value
+
+	rotated, err := service.UpdateRefreshToken(context.Background(), first)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, rotated.RefreshToken.Value)
+
+	// concurrent request with the same token gets only access token
+	concurrent, err := service.UpdateRefreshToken(context.Background(), first)
+	assert.NoError(t, err)
+	assert.NotNil(t, concurrent.Token)
+	assert.Nil(t, concurrent.RefreshToken)
+
+	service.grace = 0
+	_, err = service.UpdateRefreshToken(context.Background(), first)
+	assert.Equal(t, errormiddleware.UnauthorizedErrorCode, err.(*errormiddleware.Error).Code)
+
+	// whole session is revoked, so the latest token is not valid either
+	_, err = service.UpdateRefreshToken(context.Background(), rotated.RefreshToken.Value)
+	assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+}
+func TestRevokeSessions(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, validator.New(), testKeys)
+	u := &user.User{Id: "userId", Login: "User", Roles: []string{"user"}}
+
+	first, _ := service.GenerateAccessToken(context.Background(), u)
+	second, _ := service.GenerateAccessToken(context.Background(), u)
+	other, _ := service.GenerateAccessToken(context.Background(), &user.User{Id: "otherId"})
+
+	assert.NoError(t, service.RevokeSession(context.Background(), first.RefreshToken.Value))
+	_, err := service.UpdateRefreshToken(context.Background(), first.RefreshToken.Value)
+	assert.Error(t, err)
+
+	before := time.Now()
+	service.GenerateAccessToken(context.Background(), u)
+	assert.NoError(t, service.RevokeUserSessions(context.Background(), u.Id, before))
+
+	sessions, _ := store.List(context.Background(), u.Id)
+	assert.Len(t, sessions, 1)
+	_, err = service.UpdateRefreshToken(context.Background(), second.RefreshToken.Value)
+	assert.Error(t, err)
+	_, err = service.UpdateRefreshToken(context.Background(), other.RefreshToken.Value)
+	assert.NoError(t, err)
+}
+func TestUpdateUserRoles(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, validator.New(), testKeys)
+	u := &user.User{Id: "userId", Login: "User", Roles: []string{"user"}}
+
+	token, err := service.GenerateAccessToken(context.Background(), u)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, token.Permissions)
+	assert.NoError(t, service.UpdateUserRoles(context.Background(), u.Id, []string{"user", "editor"}))
+
+	// access token keeps old roles until it's refreshed
+	claims, err := service.GetUserClaims(token.Token.Value)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"user"}, claims.Roles)
+	}
+	refreshed, err := service.UpdateRefreshToken(context.Background(), token.RefreshToken.Value)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"user", "editor"}, refreshed.Roles)
+		assert.Equal(t, []string{AuthorsWrite, BooksWrite, GenresWrite}, refreshed.Permissions)
+		claims, err = service.GetUserClaims(refreshed.Token.Value)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user", "editor"}, claims.Roles)
+	}
+}
+func TestUserSessions(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, validator.New(), testKeys)
+	u := &user.User{Id: "userId", Login: "User", Roles: []string{"user"}}
+
+	r := httptest.NewRequest(http.MethodPost, "http://test", nil)
+	r.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0")
+	token, err := service.GenerateAccessToken(session.NewContext(context.Background(), r), u)
+	assert.NoError(t, err)
+	parsed, err := jwt.ParseString(token.Token.Value)
+	assert.NoError(t, err)
+	var claims UserClaims
+	assert.NoError(t, json.Unmarshal(parsed.RawClaims(), &claims))
+	service.GenerateAccessToken(context.Background(), u)
+
+	sessions, err := service.GetSessions(context.Background(), u.Id, claims.Session)
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.False(t, sessions[0].Current)
+		assert.True(t, sessions[1].Current)
+		assert.Equal(t, "Firefox on Linux", sessions[1].Device)
+		assert.Equal(t, "192.0.2.1", sessions[1].IP)
+	}
+
+	err = service.RevokeUserSession(context.Background(), "otherId", claims.Session)
+	assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+	assert.NoError(t, service.RevokeUserSession(context.Background(), u.Id, claims.Session))
+	_, err = service.UpdateRefreshToken(context.Background(), token.RefreshToken.Value)
+	assert.Error(t, err)
+}
 func TestWrongRefreshToken(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	val := validator.New()
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, val, "secret")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, val, testKeys)
 
-	_, err := service.UpdateRefreshToken("")
+	_, err := service.UpdateRefreshToken(context.Background(), "")
 	assert.Error(t, err)
 }
 
@@ -116,8 +237,8 @@ func TestGetUserClaims(t *testing.T) {
 	log, _ := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 
-	cache := freecache.NewCache(0)
-	service := NewService(cache, logger, nil, "secret")
+	store := session.NewMemoryStore()
+	service := NewService(store, logger, nil, testKeys)
 
 	user := &user.User{
 		Id:    primitive.NewObjectID().Hex(),
@@ -125,11 +246,44 @@ func TestGetUserClaims(t *testing.T) {
 		Roles: []string{"user", "admin"},
 		Email: "user@example.com",
 	}
-	token, err := service.GenerateAccessToken(user)
+	token, err := service.GenerateAccessToken(context.Background(), user)
 	assert.NoError(t, err)
 
-	claims, err := service.GetUserClaims(token.Token.Value)
+	parsed, err := jwt.ParseString(token.Token.Value)
 	assert.NoError(t, err)
+	var claims UserClaims
+	assert.NoError(t, json.Unmarshal(parsed.RawClaims(), &claims))
 	assert.Equal(t, user.Login, claims.Login)
 	assert.Equal(t, user.Roles, claims.Roles)
 }
+func TestPreAuthToken(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	service := NewService(session.NewMemoryStore(), logger, validator.New(), testKeys)
+	u := &user.User{Id: "userId", Login: "User", Roles: []string{"admin"}}
+
+	response, err := service.GeneratePreAuthToken(u)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, response.TwoFactor)
+	userId, err := service.VerifyPreAuthToken(response.PreAuth)
+	assert.NoError(t, err)
+	assert.Equal(t, u.Id, userId)
+
+	_, err = service.GetUserClaims(response.PreAuth)
+	assert.Error(t, err, "pre-auth token must not be accepted as access token")
+
+	access, _ := service.GenerateAccessToken(context.Background(), u)
+	_, err = service.VerifyPreAuthToken(access.Token.Value)
+	assert.Error(t, err, "access token must not be accepted as pre-auth token")
+
+	expired, _ := testKeys.Sign(UserClaims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: u.Id, ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Second))},
+		Purpose:          preauth_purpose,
+	})
+	_, err = service.VerifyPreAuthToken(expired.String())
+	if assert.Error(t, err) {
+		assert.Equal(t, errormiddleware.UnauthorizedErrorCode, err.(*errormiddleware.Error).Code)
+	}
+}
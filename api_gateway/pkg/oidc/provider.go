@@ -0,0 +1,288 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cristalhq/jwt/v3"
+)
+
+var (
+	ErrUnknownKey   = errors.New("id token is signed by unknown key")
+	ErrInvalidToken = errors.New("id token is invalid")
+)
+
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Identity is a verified user of identity provider
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+type idClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// Provider is OpenID Connect relying party that uses authorization code flow with PKCE.
+// Provider metadata is discovered on first use, so gateway can start while provider is not available
+type Provider struct {
+	cfg    *Config
+	client *http.Client
+
+	mutex    sync.Mutex
+	metadata *discovery
+	keys     map[string]*rsa.PublicKey
+}
+
+func NewProvider(cfg *Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// Builds url of provider's authorization page, challenge is made of PKCE verifier with S256 method
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientId)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchanges authorization code for id token and verifies it
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientId)
+	form.Set("code_verifier", verifier)
+	if len(p.cfg.ClientSecret) > 0 {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	response, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	var tokens struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("can't decode token response: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || len(tokens.Error) > 0 {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", response.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	claims, err := p.verify(ctx, tokens.IdToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrInvalidToken)
+	}
+	return &Identity{
+		Provider:      p.cfg.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Username:      claims.PreferredUsername,
+	}, nil
+}
+func (p *Provider) verify(ctx context.Context, raw string) (*idClaims, error) {
+	token, err := jwt.ParseString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if token.Header().Algorithm != jwt.RS256 {
+		return nil, fmt.Errorf("%w: unsupported algorithm %s", ErrInvalidToken, token.Header().Algorithm)
+	}
+	key, err := p.key(ctx, token.Header().KeyID)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := jwt.NewVerifierRS(jwt.RS256, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifier.Verify(token.Payload(), token.Signature()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	var claims idClaims
+	if err := json.Unmarshal(token.RawClaims(), &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	metadata, _ := p.discover(ctx)
+	switch {
+	case !claims.IsIssuer(metadata.Issuer):
+		return nil, fmt.Errorf("%w: wrong issuer %s", ErrInvalidToken, claims.Issuer)
+	case !claims.IsForAudience(p.cfg.ClientId):
+		return nil, fmt.Errorf("%w: token is issued for other client", ErrInvalidToken)
+	case claims.ExpiresAt == nil || !claims.IsValidAt(time.Now()):
+		return nil, fmt.Errorf("%w: token is expired", ErrInvalidToken)
+	case len(claims.Subject) == 0:
+		return nil, fmt.Errorf("%w: subject is empty", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata discovery
+	if err := p.get(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("can't discover provider %s: %v", p.cfg.Name, err)
+	}
+	if metadata.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("provider %s returned issuer %s, %s excepted", p.cfg.Name, metadata.Issuer, p.cfg.Issuer)
+	}
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// Keys are fetched again when token is signed by unknown key, so provider's key rotation is handled
+func (p *Provider) key(ctx context.Context, id string) (*rsa.PublicKey, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if key, ok := p.keys[id]; ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyId   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.get(ctx, metadata.JwksURI, &set); err != nil {
+		return nil, fmt.Errorf("can't fetch provider keys: %v", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" || (len(k.Use) > 0 && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.KeyId] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	p.keys = keys
+	if key, ok := p.keys[id]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+func (p *Provider) get(ctx context.Context, uri string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	response, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", uri, response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// Random url safe string, used for state, nonce and PKCE verifier
+func NewToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// PKCE challenge made with S256 method
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
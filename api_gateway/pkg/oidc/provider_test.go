@@ -0,0 +1,178 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cristalhq/jwt/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// Minimal OpenID provider: discovery, jwks and token endpoint that checks PKCE verifier
+type mockProvider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	nonce     string
+	claims    func(claims *idClaims)
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&discovery{
+			Issuer:                m.URL,
+			AuthorizationEndpoint: m.URL + "/authorize",
+			TokenEndpoint:         m.URL + "/token",
+			JwksURI:               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "code" || Challenge(r.Form.Get("code_verifier")) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		claims := &idClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    m.URL,
+				Subject:   "subject",
+				Audience:  []string{"client"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+			Nonce:             m.nonce,
+			Email:             "user@example.com",
+			EmailVerified:     true,
+			PreferredUsername: "user",
+		}
+		if m.claims != nil {
+			m.claims(claims)
+		}
+		signer, _ := jwt.NewSignerRS(jwt.RS256, m.key)
+		token, _ := jwt.NewBuilder(signer, jwt.WithKeyID("key")).Build(claims)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": token.String(), "token_type": "Bearer"})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+func (m *mockProvider) config() *Config {
+	return &Config{Name: "mock", Issuer: m.URL, ClientId: "client", RedirectURL: "http://gateway/callback"}
+}
+
+// Follows authorization url like a browser would, provider remembers the challenge and nonce
+func (m *mockProvider) authorize(t *testing.T, p *Provider) (verifier string) {
+	verifier, _ = NewToken()
+	m.nonce, _ = NewToken()
+	uri, err := p.AuthCodeURL(context.Background(), "state", m.nonce, Challenge(verifier))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	parsed, _ := url.Parse(uri)
+	m.challenge = parsed.Query().Get("code_challenge")
+	return verifier
+}
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockProvider(t)
+	p := NewProvider(m.config(), nil)
+
+	uri, err := p.AuthCodeURL(context.Background(), "state", "nonce", Challenge("verifier"))
+	if assert.NoError(t, err) {
+		parsed, _ := url.Parse(uri)
+		assert.Equal(t, "/authorize", parsed.Path)
+		query := parsed.Query()
+		assert.Equal(t, "code", query.Get("response_type"))
+		assert.Equal(t, "client", query.Get("client_id"))
+		assert.Equal(t, "http://gateway/callback", query.Get("redirect_uri"))
+		assert.Equal(t, "openid email profile", query.Get("scope"))
+		assert.Equal(t, "state", query.Get("state"))
+		assert.Equal(t, "nonce", query.Get("nonce"))
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		assert.Equal(t, Challenge("verifier"), query.Get("code_challenge"))
+	}
+}
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	p := NewProvider(m.config(), nil)
+
+	verifier := m.authorize(t, p)
+	identity, err := p.Exchange(context.Background(), "code", verifier, m.nonce)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Identity{Provider: "mock", Subject: "subject", Email: "user@example.com", EmailVerified: true, Username: "user"}, identity)
+	}
+}
+func TestExchangeErrors(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Verifier func(verifier string) string
+		Nonce    func(nonce string) string
+		Claims   func(claims *idClaims)
+	}{
+		{Name: "wrong verifier", Verifier: func(string) string { return "other" }},
+		{Name: "wrong nonce", Nonce: func(string) string { return "other" }},
+		{Name: "wrong issuer", Claims: func(c *idClaims) { c.Issuer = "http://other" }},
+		{Name: "wrong audience", Claims: func(c *idClaims) { c.Audience = []string{"other"} }},
+		{Name: "expired", Claims: func(c *idClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }},
+		{Name: "empty subject", Claims: func(c *idClaims) { c.Subject = "" }},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			m := newMockProvider(t)
+			m.claims = tt.Claims
+			p := NewProvider(m.config(), nil)
+
+			verifier := m.authorize(t, p)
+			nonce := m.nonce
+			if tt.Verifier != nil {
+				verifier = tt.Verifier(verifier)
+			}
+			if tt.Nonce != nil {
+				nonce = tt.Nonce(nonce)
+			}
+			_, err := p.Exchange(context.Background(), "code", verifier, nonce)
+			assert.Error(t, err)
+		})
+	}
+}
+func TestForeignKey(t *testing.T) {
+	m := newMockProvider(t)
+	p := NewProvider(m.config(), nil)
+	verifier := m.authorize(t, p)
+
+	// token is signed by other key with the same id, e.g. by someone who doesn't own provider's key
+	published := m.key.PublicKey
+	m.key, _ = rsa.GenerateKey(rand.Reader, 2048)
+	p.keys = map[string]*rsa.PublicKey{"key": &published}
+
+	_, err := p.Exchange(context.Background(), "code", verifier, m.nonce)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+func TestDiscoveryFailure(t *testing.T) {
+	p := NewProvider(&Config{Name: "offline", Issuer: "http://127.0.0.1:1"}, nil)
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.Error(t, err)
+}
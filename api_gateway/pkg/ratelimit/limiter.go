@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+)
+
+type window struct {
+	count int
+	reset time.Time
+}
+
+// Limiter allows a number of requests per client ip in a fixed time window.
+// Counters are kept in process, so every gateway instance limits on it's own
+type Limiter struct {
+	sync.Mutex
+	requests int
+	period   time.Duration
+	windows  map[string]*window
+	sweep    time.Time
+}
+
+func New(requests int, period time.Duration) *Limiter {
+	return &Limiter{
+		requests: requests,
+		period:   period,
+		windows:  make(map[string]*window),
+	}
+}
+
+// Counts request of the key. If limit is reached, returns false and time left until the window is reset
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	if now.After(l.sweep) {
+		for k, w := range l.windows {
+			if !now.Before(w.reset) {
+				delete(l.windows, k)
+			}
+		}
+		l.sweep = now.Add(l.period)
+	}
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &window{reset: now.Add(l.period)}
+		l.windows[key] = w
+	}
+	if w.count >= l.requests {
+		return false, w.reset.Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// Nil limiter allows every request
+func (l *Limiter) Middleware(h errormiddleware.Handler) errormiddleware.Handler {
+	if l == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) error {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		if ok, retry := l.Allow(ip); !ok {
+			seconds := int(math.Ceil(retry.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			return errormiddleware.TooManyRequestsError([]string{fmt.Sprintf("too many requests, try again in %d seconds", seconds)}, fmt.Sprintf("rate limit exceeded for %s", ip))
+		}
+		return h(w, r)
+	}
+}
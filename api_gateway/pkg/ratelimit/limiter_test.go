@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/errormiddleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	limiter := New(2, 50*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.Allow("127.0.0.1")
+		assert.True(t, ok)
+	}
+	ok, retry := limiter.Allow("127.0.0.1")
+	assert.False(t, ok)
+	assert.Greater(t, retry, time.Duration(0))
+
+	ok, _ = limiter.Allow("127.0.0.2")
+	assert.True(t, ok, "other keys must have their own window")
+
+	time.Sleep(60 * time.Millisecond)
+	ok, _ = limiter.Allow("127.0.0.1")
+	assert.True(t, ok, "window must be reset after period")
+}
+func TestMiddleware(t *testing.T) {
+	limiter := New(1, time.Minute)
+	handler := errormiddleware.Middleware(limiter.Middleware(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "http://test", nil)
+	r.RemoteAddr = "127.0.0.1:1000"
+	assert.NoError(t, handler(w, r))
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	w = httptest.NewRecorder()
+	r.RemoteAddr = "127.0.0.1:2000"
+	err := handler(w, r)
+	if assert.Error(t, err) {
+		assert.Equal(t, errormiddleware.TooManyRequestsErrorCode, err.(*errormiddleware.Error).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, w.Result().StatusCode)
+	assert.Equal(t, "60", w.Result().Header.Get("Retry-After"))
+}
+func TestNilLimiter(t *testing.T) {
+	var limiter *Limiter
+	handler := limiter.Middleware(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		assert.NoError(t, handler(w, httptest.NewRequest(http.MethodPost, "http://test", nil)))
+		assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	}
+}
@@ -24,6 +24,9 @@ func (r *CustomResponse) ReadBody() ([]byte, error) {
 func (r *CustomResponse) StatusCode() int {
 	return r.response.StatusCode
 }
+func (r *CustomResponse) Header() http.Header {
+	return r.response.Header
+}
 
 type CustomError struct {
 	Message          []string             `json:"messages,omitempty"`
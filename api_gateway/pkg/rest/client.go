@@ -10,18 +10,23 @@ import (
 	"strings"
 
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
 )
 
 type key string
 
 const (
-	UserIdKey key = "user_id"
+	UserIdKey    key = "user_id"
+	SessionIdKey key = "session_id"
+	RolesKey     key = "roles"
+	TokenKey     key = "token"
 )
 
 type RestClient struct {
 	BaseURL    string
 	HttpClient *http.Client
 	Logger     *logging.Logger
+	Signer     *signature.Signer
 }
 
 func (c *RestClient) SendRequest(r *http.Request) (*CustomResponse, error) {
@@ -34,10 +39,18 @@ func (c *RestClient) SendRequest(r *http.Request) (*CustomResponse, error) {
 	}
 
 	c.Logger.Infof("sending request to %s", r.URL)
-	//reading userid from context and adding it to header
-	userId, valid := r.Context().Value(UserIdKey).(string)
-	if valid && len(userId) > 0 {
-		r.Header.Add("User", userId)
+	//reading user from context and signing request on user's behalf
+	if c.Signer != nil {
+		userId, _ := r.Context().Value(UserIdKey).(string)
+		roles, _ := r.Context().Value(RolesKey).([]string)
+		if err := c.Signer.Sign(r, userId, roles); err != nil {
+			return nil, err
+		}
+	}
+	//forwarding user's token, so services can verify it with gateway's public keys
+	token, valid := r.Context().Value(TokenKey).(string)
+	if valid && len(token) > 0 {
+		r.Header.Set("Authorization", "Bearer "+token)
 	}
 
 	response, err := c.HttpClient.Do(r)
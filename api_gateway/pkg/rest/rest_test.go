@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/signature"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
@@ -228,7 +229,10 @@ func TestUserHeader(t *testing.T) {
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		body, _ := json.Marshal(struct{ User string }{User: r.Header.Get("User")})
+		caller, err := signature.Verify(r, "secret", 1<<20)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user"}, caller.Roles)
+		body, _ := json.Marshal(struct{ User, Authorization string }{User: caller.UserId, Authorization: r.Header.Get("Authorization")})
 		w.Write(body)
 	}))
 
@@ -236,16 +240,20 @@ func TestUserHeader(t *testing.T) {
 		BaseURL:    server.URL,
 		Logger:     logger,
 		HttpClient: &http.Client{Timeout: 5 * time.Second},
+		Signer:     &signature.Signer{Service: "api_gateway", Secret: "secret"},
 	}
 	ctx := context.WithValue(context.Background(), UserIdKey, "userKeyId")
+	ctx = context.WithValue(ctx, RolesKey, []string{"user"})
+	ctx = context.WithValue(ctx, TokenKey, "token")
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
 	assert.NoError(t, err)
 	response, err := client.SendRequest(request)
 	assert.NoError(t, err)
 
-	type body struct{ User string }
+	type body struct{ User, Authorization string }
 	var Body body
 	err = json.NewDecoder(response.Body()).Decode(&Body)
 	assert.NoError(t, err)
 	assert.Equal(t, Body.User, "userKeyId")
+	assert.Equal(t, "Bearer token", Body.Authorization)
 }
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Client describes device that started or refreshed the session
+type Client struct {
+	UserAgent string
+	IP        string
+}
+type clientKey struct{}
+
+func NewContext(ctx context.Context, r *http.Request) context.Context {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return context.WithValue(ctx, clientKey{}, Client{UserAgent: r.UserAgent(), IP: ip})
+}
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientKey{}).(Client)
+	return client
+}
+
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"YaBrowser/", "Yandex Browser"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+}
+var systems = []struct{ token, name string }{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// Human readable device name built from user agent, e.g. "Firefox on Windows"
+func Device(userAgent string) string {
+	var browser, system string
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+	switch {
+	case len(browser) > 0 && len(system) > 0:
+		return browser + " on " + system
+	case len(browser) > 0:
+		return browser
+	case len(system) > 0:
+		return system
+	}
+	return "Unknown device"
+}
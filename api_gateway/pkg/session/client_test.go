@@ -0,0 +1,36 @@
+package session
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevice(t *testing.T) {
+	var table = []struct {
+		UserAgent string
+		Excepted  string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.5; rv:127.0) Gecko/20100101 Firefox/127.0", "Firefox on macOS"},
+		{"curl/8.8.0", "Unknown device"},
+		{"", "Unknown device"},
+	}
+	for _, tt := range table {
+		t.Run(tt.Excepted, func(t *testing.T) {
+			assert.Equal(t, tt.Excepted, Device(tt.UserAgent))
+		})
+	}
+}
+func TestClientContext(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://test", nil)
+	r.RemoteAddr = "[::1]:52000"
+	r.Header.Set("User-Agent", "curl/8.8.0")
+
+	assert.Equal(t, Client{}, ClientFromContext(context.Background()))
+	assert.Equal(t, Client{UserAgent: "curl/8.8.0", IP: "::1"}, ClientFromContext(NewContext(context.Background(), r)))
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Memory store keeps sessions in process, so they are lost on restart and not shared between gateway instances
+type memoryStore struct {
+	sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{sessions: make(map[string]*Session)}
+}
+func (m *memoryStore) Create(ctx context.Context, session *Session) error {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	for id, s := range m.sessions {
+		if s.ExpiresAt.Before(now) {
+			delete(m.sessions, id)
+		}
+	}
+	session.Id = primitive.NewObjectID().Hex()
+	stored := *session
+	m.sessions[session.Id] = &stored
+	return nil
+}
+func (m *memoryStore) FindByToken(ctx context.Context, hash string) (*Session, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, s := range m.sessions {
+		if s.TokenHash == hash || s.PreviousHash == hash {
+			if s.ExpiresAt.Before(time.Now()) {
+				return nil, ErrNotFound
+			}
+			found := *s
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+func (m *memoryStore) FindById(ctx context.Context, id string) (*Session, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.ExpiresAt.Before(time.Now()) {
+		return nil, ErrNotFound
+	}
+	found := *s
+	return &found, nil
+}
+func (m *memoryStore) Rotate(ctx context.Context, id, previous, next string, client Client, expires time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+
+	s, ok := m.sessions[id]
+	if !ok || s.TokenHash != previous {
+		return ErrNotFound
+	}
+	s.PreviousHash = previous
+	s.TokenHash = next
+	s.RotatedAt = time.Now()
+	s.UserAgent = client.UserAgent
+	s.IP = client.IP
+	s.ExpiresAt = expires
+	return nil
+}
+func (m *memoryStore) List(ctx context.Context, userId string) ([]*Session, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	now := time.Now()
+	sessions := make([]*Session, 0)
+	for _, s := range m.sessions {
+		if s.UserId == userId && s.ExpiresAt.After(now) {
+			found := *s
+			sessions = append(sessions, &found)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.After(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+func (m *memoryStore) Revoke(ctx context.Context, id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
+func (m *memoryStore) RevokeUser(ctx context.Context, userId string, before time.Time) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var count int64
+	for id, s := range m.sessions {
+		if s.UserId == userId && s.CreatedAt.Before(before) {
+			delete(m.sessions, id)
+			count++
+		}
+	}
+	return count, nil
+}
+func (m *memoryStore) SetUserRoles(ctx context.Context, userId string, roles []string) (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	var count int64
+	for _, s := range m.sessions {
+		if s.UserId == userId {
+			s.Roles = append([]string(nil), roles...)
+			count++
+		}
+	}
+	return count, nil
+}
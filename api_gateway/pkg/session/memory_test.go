@@ -0,0 +1,85 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotate(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	session := &Session{UserId: "user", TokenHash: "first", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, store.Create(ctx, session))
+
+	assert.NoError(t, store.Rotate(ctx, session.Id, "first", "second", Client{IP: "127.0.0.1"}, time.Now().Add(time.Hour)))
+	assert.Equal(t, ErrNotFound, store.Rotate(ctx, session.Id, "first", "third", Client{}, time.Now().Add(time.Hour)))
+
+	byPrevious, err := store.FindByToken(ctx, "first")
+	assert.NoError(t, err)
+	assert.Equal(t, "second", byPrevious.TokenHash)
+	assert.Equal(t, "127.0.0.1", byPrevious.IP)
+	byCurrent, err := store.FindByToken(ctx, "second")
+	assert.NoError(t, err)
+	assert.Equal(t, session.Id, byCurrent.Id)
+	byId, err := store.FindById(ctx, session.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", byId.TokenHash)
+}
+func TestExpiredSession(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	expired := &Session{UserId: "user", TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Second)}
+	store.Create(ctx, expired)
+
+	_, err := store.FindByToken(ctx, "expired")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = store.FindById(ctx, expired.Id)
+	assert.Equal(t, ErrNotFound, err)
+	sessions, _ := store.List(ctx, "user")
+	assert.Empty(t, sessions)
+}
+func TestRevokeUser(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+	store.Create(ctx, &Session{UserId: "user", TokenHash: "old", CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)})
+	store.Create(ctx, &Session{UserId: "user", TokenHash: "new", CreatedAt: now.Add(time.Second), ExpiresAt: now.Add(time.Hour)})
+	store.Create(ctx, &Session{UserId: "other", TokenHash: "other", CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)})
+
+	count, err := store.RevokeUser(ctx, "user", now)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	sessions, _ := store.List(ctx, "user")
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, "new", sessions[0].TokenHash)
+		assert.Equal(t, ErrNotFound, store.Revoke(ctx, "unknown"))
+		assert.NoError(t, store.Revoke(ctx, sessions[0].Id))
+	}
+	sessions, _ = store.List(ctx, "other")
+	assert.Len(t, sessions, 1)
+}
+func TestSetUserRoles(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+	store.Create(ctx, &Session{UserId: "user", TokenHash: "first", Roles: []string{"user"}, ExpiresAt: now.Add(time.Hour)})
+	store.Create(ctx, &Session{UserId: "user", TokenHash: "second", Roles: []string{"user"}, ExpiresAt: now.Add(time.Hour)})
+	store.Create(ctx, &Session{UserId: "other", TokenHash: "other", Roles: []string{"user"}, ExpiresAt: now.Add(time.Hour)})
+
+	count, err := store.SetUserRoles(ctx, "user", []string{"user", "editor"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	sessions, _ := store.List(ctx, "user")
+	for _, s := range sessions {
+		assert.Equal(t, []string{"user", "editor"}, s.Roles)
+	}
+	sessions, _ = store.List(ctx, "other")
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, []string{"user"}, sessions[0].Roles)
+	}
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mongo store is shared between gateway instances. Expired sessions are removed by ttl index
+type mongoStore struct {
+	collection *mongo.Collection
+	logger     *logging.Logger
+}
+
+func NewMongoStore(storage *mongo.Database, collection string, logger *logging.Logger) Store {
+	store := &mongoStore{
+		collection: storage.Collection(collection),
+		logger:     logger,
+	}
+	store.createIndexes()
+	return store
+}
+func (m *mongoStore) createIndexes() {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetName("token").SetUnique(true)},
+		{Keys: bson.D{{Key: "previous", Value: 1}}, Options: options.Index().SetName("previous").SetSparse(true)},
+		{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "created", Value: -1}}, Options: options.Index().SetName("userid")},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	names, err := m.collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		m.logger.Errorf("can't create session indexes: %v", err)
+		return
+	}
+	m.logger.Infof("session indexes created: %v", names)
+}
+func (m *mongoStore) Create(ctx context.Context, session *Session) error {
+	session.Id = primitive.NewObjectID().Hex()
+	if _, err := m.collection.InsertOne(ctx, session); err != nil {
+		return err
+	}
+	return nil
+}
+func (m *mongoStore) FindByToken(ctx context.Context, hash string) (*Session, error) {
+	filter := bson.M{
+		"$or":       bson.A{bson.M{"token": hash}, bson.M{"previous": hash}},
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}
+	var session Session
+	if err := m.collection.FindOne(ctx, filter).Decode(&session); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+func (m *mongoStore) FindById(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	if err := m.collection.FindOne(ctx, bson.M{"_id": id, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&session); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+func (m *mongoStore) Rotate(ctx context.Context, id, previous, next string, client Client, expires time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"token":     next,
+		"previous":  previous,
+		"rotated":   time.Now(),
+		"useragent": client.UserAgent,
+		"ip":        client.IP,
+		"expiresAt": expires,
+	}}
+	result, err := m.collection.UpdateOne(ctx, bson.M{"_id": id, "token": previous}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+func (m *mongoStore) List(ctx context.Context, userId string) ([]*Session, error) {
+	filter := bson.M{"userid": userId, "expiresAt": bson.M{"$gt": time.Now()}}
+	result, err := m.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0)
+	if err := result.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+func (m *mongoStore) Revoke(ctx context.Context, id string) error {
+	result, err := m.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+func (m *mongoStore) RevokeUser(ctx context.Context, userId string, before time.Time) (int64, error) {
+	result, err := m.collection.DeleteMany(ctx, bson.M{"userid": userId, "created": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+func (m *mongoStore) SetUserRoles(ctx context.Context, userId string, roles []string) (int64, error) {
+	result, err := m.collection.UpdateMany(ctx, bson.M{"userid": userId}, bson.M{"$set": bson.M{"roles": roles}})
+	if err != nil {
+		return 0, err
+	}
+	return result.MatchedCount, nil
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("session not found")
+
+// Session is created on every sign in and lives while it's refresh token is rotated.
+// Only hashes of refresh tokens are stored, previous hash is kept to detect token reuse
+type Session struct {
+	Id           string    `bson:"_id"`
+	UserId       string    `bson:"userid"`
+	Login        string    `bson:"login"`
+	Email        string    `bson:"email"`
+	Roles        []string  `bson:"roles"`
+	TokenHash    string    `bson:"token"`
+	PreviousHash string    `bson:"previous,omitempty"`
+	UserAgent    string    `bson:"useragent"`
+	IP           string    `bson:"ip"`
+	CreatedAt    time.Time `bson:"created"`
+	RotatedAt    time.Time `bson:"rotated"` // last time session was used to refresh tokens
+	ExpiresAt    time.Time `bson:"expiresAt"`
+}
+
+type Store interface {
+	Create(ctx context.Context, session *Session) error
+	// Finds session by it's current or previous refresh token hash
+	FindByToken(ctx context.Context, hash string) (*Session, error)
+	// Finds active session by it's id, returns ErrNotFound if session was revoked or expired
+	FindById(ctx context.Context, id string) (*Session, error)
+	// Replaces token hash only if it's still equal to previous one, so one token can't be rotated twice.
+	// Returns ErrNotFound if session was rotated or revoked in the meantime
+	Rotate(ctx context.Context, id, previous, next string, client Client, expires time.Time) error
+	List(ctx context.Context, userId string) ([]*Session, error)
+	Revoke(ctx context.Context, id string) error
+	// Revokes user's sessions created before the provided time
+	RevokeUser(ctx context.Context, userId string, before time.Time) (int64, error)
+	// Replaces roles of all user's sessions, tokens get new roles when they are refreshed
+	SetUserRoles(ctx context.Context, userId string, roles []string) (int64, error)
+}
+
+// Refresh tokens are random strings, they don't carry any data
+func NewToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
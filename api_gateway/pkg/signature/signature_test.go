@@ -0,0 +1,205 @@
+package signature
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_gateway/pkg/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	signer := &Signer{Service: "api_gateway", Secret: "secret"}
+
+	var table = []struct {
+		Name          string
+		Request       func() *http.Request
+		ExceptedUser  string
+		ExceptedError error
+	}{
+		{"signed by user", func() *http.Request {
+			r := httptest.NewRequest(http.MethodPatch, "http://test/users/login?id=1", nil)
+			signer.Sign(r, "userid", []string{"user", "admin"})
+			return r
+		}, "userid", nil},
+		{"signed with body", func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "http://test/users", strings.NewReader(`{"login":"user"}`))
+			signer.Sign(r, "userid", []string{"user"})
+			return r
+		}, "userid", nil},
+		{"body replaced", func() *http.Request {
+			r := httptest.NewRequest(http.MethodPost, "http://test/users", strings.NewReader(`{"login":"user"}`))
+			signer.Sign(r, "userid", []string{"user"})
+			r.Body = io.NopCloser(strings.NewReader(`{"login":"admin"}`))
+			return r
+		}, "", ErrWrongSignature},
+		{"nonce replaced", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+			signer.Sign(r, "userid", []string{"user"})
+			r.Header.Set(HeaderNonce, "nonce")
+			return r
+		}, "", ErrWrongSignature},
+		{"without nonce", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+			signer.Sign(r, "userid", []string{"user"})
+			r.Header.Del(HeaderNonce)
+			return r
+		}, "", ErrNotSigned},
+		{"signed by service", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/stats", nil)
+			signer.Sign(r, "", nil)
+			return r
+		}, "", nil},
+		{"not signed", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+			r.Header.Set(HeaderUser, "userid")
+			return r
+		}, "", ErrNotSigned},
+		{"user replaced", func() *http.Request {
+			r := httptest.NewRequest(http.MethodDelete, "http://test/users", nil)
+			signer.Sign(r, "userid", []string{"user"})
+			r.Header.Set(HeaderUser, "otheruser")
+			return r
+		}, "", ErrWrongSignature},
+		{"roles extended", func() *http.Request {
+			r := httptest.NewRequest(http.MethodDelete, "http://test/users", nil)
+			signer.Sign(r, "userid", []string{"user"})
+			r.Header.Set(HeaderRoles, "user,admin")
+			return r
+		}, "", ErrWrongSignature},
+		{"other path", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+			signer.Sign(r, "userid", []string{"user"})
+			r.URL.Path = "/users/delete"
+			return r
+		}, "", ErrWrongSignature},
+		{"other secret", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+			(&Signer{Service: "api_gateway", Secret: "guess"}).Sign(r, "userid", nil)
+			return r
+		}, "", ErrWrongSignature},
+		{"expired", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+			timestamp := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+			r.Header.Set(HeaderTimestamp, timestamp)
+			r.Header.Set(HeaderNonce, "nonce")
+			r.Header.Set(HeaderSignature, sign("secret", http.MethodGet, "/users", timestamp, "nonce", "", "", "", ""))
+			return r
+		}, "", ErrExpiredSignature},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := tt.Request()
+			caller, err := Verify(r, "secret", 1024)
+			assert.Equal(t, tt.ExceptedError, err)
+			if err == nil {
+				assert.Equal(t, "api_gateway", caller.Service)
+				assert.Equal(t, tt.ExceptedUser, caller.UserId)
+				// handler can read the body after verification
+				if r.Body != nil {
+					_, err := io.ReadAll(r.Body)
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
+func TestMiddleware(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller := CallerFromContext(r.Context())
+		assert.Equal(t, "userid", caller.UserId)
+		assert.Equal(t, []string{"user"}, caller.Roles)
+		w.WriteHeader(http.StatusNoContent)
+	}), "secret", 1024, logger)
+
+	r := httptest.NewRequest(http.MethodGet, "http://test/users", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+
+	(&Signer{Service: "api_gateway", Secret: "secret"}).Sign(r, "userid", []string{"user"})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+
+	// captured request can't be sent again
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+}
+
+// Fails the test if body is read
+type unreadBody struct {
+	t *testing.T
+}
+
+func (b *unreadBody) Read(p []byte) (int, error) {
+	b.t.Error("body must not be read before signature is checked")
+	return 0, io.EOF
+}
+func (b *unreadBody) Close() error {
+	return nil
+}
+func TestVerifyBody(t *testing.T) {
+	signer := &Signer{Service: "api_gateway", Secret: "secret"}
+
+	r := httptest.NewRequest(http.MethodPost, "http://test/users", strings.NewReader(`{"login":"user"}`))
+	signer.Sign(r, "userid", nil)
+	r.Header.Set(HeaderUser, "otheruser")
+	r.Body = &unreadBody{t: t}
+	_, err := Verify(r, "secret", 1024)
+	assert.Equal(t, ErrWrongSignature, err)
+
+	r = httptest.NewRequest(http.MethodPost, "http://test/users", strings.NewReader(strings.Repeat("a", 2048)))
+	signer.Sign(r, "userid", nil)
+	_, err = Verify(r, "secret", 1024)
+	var tooLarge *http.MaxBytesError
+	if assert.ErrorAs(t, err, &tooLarge) {
+		assert.EqualValues(t, 1024, tooLarge.Limit)
+	}
+}
+func TestMiddlewareTooLarge(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request must not be passed to handler")
+	}), "secret", 1024, logger)
+
+	r := httptest.NewRequest(http.MethodPost, "http://test/books", strings.NewReader(strings.Repeat("a", 2048)))
+	(&Signer{Service: "api_gateway", Secret: "secret"}).Sign(r, "userid", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Result().StatusCode)
+}
+func TestSignedBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://test/users", strings.NewReader(`{"login":"user"}`))
+	err := (&Signer{Service: "api_gateway", Secret: "secret"}).Sign(r, "", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, r.Header.Get(HeaderDigest))
+	// body is still sent after signing
+	body, err := io.ReadAll(r.Body)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"login":"user"}`, string(body))
+	}
+}
+func TestReplayGuard(t *testing.T) {
+	guard := newReplayGuard()
+	assert.True(t, guard.use("signature"))
+	assert.False(t, guard.use("signature"))
+	assert.True(t, guard.use("other"))
+
+	guard.used["signature"] = time.Now().Add(-time.Second)
+	guard.cleaned = time.Time{}
+	assert.True(t, guard.use("signature"))
+}
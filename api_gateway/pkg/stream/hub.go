@@ -0,0 +1,92 @@
+package stream
+
+import "sync"
+
+// Event is delivered to every subscription of the user
+type Event struct {
+	Id   string
+	Data []byte
+}
+
+type subscription struct {
+	events chan Event
+}
+
+// Hub keeps subscriptions of users connected to this instance.
+// Subscription that can't keep up is closed instead of blocking publisher,
+// client is expected to reconnect and resume from it's last event
+type Hub struct {
+	sync.Mutex
+	buffer        int
+	subscriptions map[string]map[*subscription]struct{}
+}
+
+func NewHub(buffer int) *Hub {
+	return &Hub{
+		buffer:        buffer,
+		subscriptions: make(map[string]map[*subscription]struct{}),
+	}
+}
+
+// Returns channel of user's events and function to cancel subscription.
+// Channel is closed when subscription is cancelled, dropped or hub is closed
+func (h *Hub) Subscribe(userId string) (<-chan Event, func()) {
+	h.Lock()
+	defer h.Unlock()
+
+	sub := &subscription{events: make(chan Event, h.buffer)}
+	if _, ok := h.subscriptions[userId]; !ok {
+		h.subscriptions[userId] = make(map[*subscription]struct{})
+	}
+	h.subscriptions[userId][sub] = struct{}{}
+
+	return sub.events, func() {
+		h.Lock()
+		defer h.Unlock()
+		h.remove(userId, sub)
+	}
+}
+func (h *Hub) Publish(userId string, event Event) {
+	h.Lock()
+	defer h.Unlock()
+
+	for sub := range h.subscriptions[userId] {
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(userId, sub)
+		}
+	}
+}
+
+// Returns amount of active subscriptions of the user
+func (h *Hub) Subscribers(userId string) int {
+	h.Lock()
+	defer h.Unlock()
+	return len(h.subscriptions[userId])
+}
+func (h *Hub) Close() error {
+	h.Lock()
+	defer h.Unlock()
+
+	for userId, subs := range h.subscriptions {
+		for sub := range subs {
+			h.remove(userId, sub)
+		}
+	}
+	return nil
+}
+func (h *Hub) remove(userId string, sub *subscription) {
+	subs, ok := h.subscriptions[userId]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.events)
+	if len(subs) == 0 {
+		delete(h.subscriptions, userId)
+	}
+}
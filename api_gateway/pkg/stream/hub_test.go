@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	hub := NewHub(1)
+	first, cancelFirst := hub.Subscribe("user")
+	second, cancelSecond := hub.Subscribe("user")
+	other, cancelOther := hub.Subscribe("other")
+	defer cancelFirst()
+	defer cancelSecond()
+	defer cancelOther()
+
+	hub.Publish("user", Event{Id: "1", Data: []byte("data")})
+
+	assert.Equal(t, Event{Id: "1", Data: []byte("data")}, <-first)
+	assert.Equal(t, Event{Id: "1", Data: []byte("data")}, <-second)
+	assert.Len(t, other, 0)
+}
+func TestSlowSubscriber(t *testing.T) {
+	hub := NewHub(1)
+	events, cancel := hub.Subscribe("user")
+
+	hub.Publish("user", Event{Id: "1"})
+	hub.Publish("user", Event{Id: "2"})
+
+	assert.Equal(t, 0, hub.Subscribers("user"))
+	event, ok := <-events
+	assert.True(t, ok)
+	assert.Equal(t, "1", event.Id)
+	_, ok = <-events
+	assert.False(t, ok)
+
+	assert.NotPanics(t, cancel)
+}
+func TestUnsubscribe(t *testing.T) {
+	hub := NewHub(1)
+	events, cancel := hub.Subscribe("user")
+	assert.Equal(t, 1, hub.Subscribers("user"))
+
+	cancel()
+	cancel()
+	assert.Equal(t, 0, hub.Subscribers("user"))
+	_, ok := <-events
+	assert.False(t, ok)
+
+	assert.NotPanics(t, func() { hub.Publish("user", Event{Id: "1"}) })
+}
+func TestClose(t *testing.T) {
+	hub := NewHub(1)
+	first, _ := hub.Subscribe("user")
+	second, _ := hub.Subscribe("other")
+
+	assert.NoError(t, hub.Close())
+	_, ok := <-first
+	assert.False(t, ok)
+	_, ok = <-second
+	assert.False(t, ok)
+}
@@ -16,10 +16,13 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/client/db"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/config"
 	genre "github.com/reversersed/go-web-services/tree/main/api_genres/internal/handlers/genre"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/cache/freecache"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/mongo"
+	RabbitClient "github.com/reversersed/go-web-services/tree/main/api_genres/pkg/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/shutdown"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/signature"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/validator"
 )
 
@@ -42,18 +45,25 @@ func main() {
 		logger.Fatal(err)
 	}
 
+	logger.Info("rabbitmq initializing...")
+	rabbit, err := RabbitClient.New(config.Rabbit, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	rabbitSender := rabbitmq.NewSender(rabbit.Connection, logger)
+
 	logger.Info("services initializing...")
 	storage := db.NewStorage(db_client, "genres", logger)
-	service := client.NewService(storage, logger, cache, validator.New())
+	service := client.NewService(storage, logger, cache, validator.New(), rabbitSender, config.Urls, &signature.Signer{Service: config.Signature.Service, Secret: config.Signature.Secret})
 
 	logger.Info("handlers registration...")
 	handler := genre.Handler{Logger: logger, Service: service}
 	handler.Register(router)
 
 	logger.Info("starting application...")
-	start(router, logger, config.Server)
+	start(signature.Middleware(router, config.Signature.Secret, config.Signature.MaxBodySize, logger), logger, config.Server, rabbit, rabbitSender)
 }
-func start(router *httprouter.Router, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
+func start(router http.Handler, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
 	var server *http.Server
 	var listener net.Listener
 
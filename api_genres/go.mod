@@ -5,6 +5,7 @@ go 1.22.1
 require (
 	github.com/go-playground/validator/v10 v10.22.0
 	github.com/julienschmidt/httprouter v1.3.0
+	github.com/rabbitmq/amqp091-go v1.10.0
 	go.mongodb.org/mongo-driver v1.16.0
 )
 
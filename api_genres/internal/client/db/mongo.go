@@ -3,15 +3,19 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/cursor"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/slug"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type db struct {
@@ -25,9 +29,27 @@ func NewStorage(storage *mongo.Database, collection string, logger *logging.Logg
 		collection: storage.Collection(collection),
 		logger:     logger,
 	}
-	defer db.seedGenres()
+	db.seedGenres()
+	db.migrateSlugs()
+	db.createIndexes()
 	return db
 }
+func (d *db) createIndexes() {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetName("slug").SetUnique(true)},
+		{Keys: bson.D{{Key: "parent", Value: 1}}, Options: options.Index().SetName("parent")},
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("name")},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	names, err := d.collection.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		d.logger.Errorf("can't create genre indexes: %v", err)
+		return
+	}
+	d.logger.Infof("genre indexes created: %v", names)
+}
 func (d *db) seedGenres() {
 	d.Lock()
 	defer d.Unlock()
@@ -41,12 +63,14 @@ func (d *db) seedGenres() {
 	defer cancel()
 
 	preinstalledGenres := []string{"Детектив", "Фантастика", "Комиксы", "Бизнес-менеджмент", "Хобби", "История", "Легкое чтение", "Серьезное чтение"}
+	seeded := make(map[string]primitive.ObjectID)
 
 	d.logger.Infof("trying to seed %d genres...", len(preinstalledGenres))
 	for _, v := range preinstalledGenres {
 		d.logger.Infof("seeding genre %s...", v)
 		genre := &client.Genre{
 			Name: v,
+			Slug: slug.Make(v),
 		}
 		response, err := d.collection.InsertOne(ctx, genre)
 		if err != nil {
@@ -56,20 +80,66 @@ func (d *db) seedGenres() {
 		if !ok {
 			d.logger.Fatalf("can't create id for genre")
 		}
+		seeded[v] = id
 		d.logger.Infof("genre %s seeded with id %v", v, id.Hex())
 	}
 	d.logger.Infof("seeding manual genres...")
 	hex, _ := primitive.ObjectIDFromHex("6690e6dcfd658345b06c2a25")
-	d.collection.InsertOne(ctx, &client.Genre{Id: hex, Name: "Детские книги"})
+	d.collection.InsertOne(ctx, &client.Genre{Id: hex, Name: "Детские книги", Slug: slug.Make("Детские книги")})
+	parent := seeded["Фантастика"]
 	hex, _ = primitive.ObjectIDFromHex("6690e6dcfd658345b06c2a12")
-	d.collection.InsertOne(ctx, &client.Genre{Id: hex, Name: "Фентези"})
+	d.collection.InsertOne(ctx, &client.Genre{Id: hex, Name: "Фентези", Slug: slug.Make("Фентези"), ParentId: &parent})
 	d.logger.Infof("genres seeded")
 }
-func (d *db) GetGenre(ctx context.Context, id []primitive.ObjectID) ([]*client.Genre, error) {
+
+// Genres created before slugs were introduced get slugs built from their names.
+// Names that give the same slug are distinguished by a number suffix
+func (d *db) migrateSlugs() {
+	d.Lock()
+	defer d.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := d.collection.Find(ctx, bson.M{"slug": bson.M{"$in": bson.A{nil, ""}}})
+	if err != nil {
+		d.logger.Errorf("can't find genres without slugs: %v", err)
+		return
+	}
+	genres := make([]*client.Genre, 0)
+	if err := result.All(ctx, &genres); err != nil {
+		d.logger.Errorf("can't read genres without slugs: %v", err)
+		return
+	}
+	for _, genre := range genres {
+		base := slug.Make(genre.Name)
+		if len(base) == 0 {
+			base = genre.Id.Hex()
+		}
+		candidate := base
+		for i := 2; ; i++ {
+			count, err := d.collection.CountDocuments(ctx, bson.M{"slug": candidate})
+			if err != nil {
+				d.logger.Errorf("can't check genre slug %s: %v", candidate, err)
+				return
+			}
+			if count == 0 {
+				break
+			}
+			candidate = fmt.Sprintf("%s-%d", base, i)
+		}
+		if _, err := d.collection.UpdateByID(ctx, genre.Id, bson.M{"$set": bson.M{"slug": candidate}}); err != nil {
+			d.logger.Errorf("can't set slug of genre %s: %v", genre.Id.Hex(), err)
+			return
+		}
+		d.logger.Infof("genre %s got slug %s", genre.Id.Hex(), candidate)
+	}
+}
+func (d *db) GetGenre(ctx context.Context, id []primitive.ObjectID, slugs []string) ([]*client.Genre, error) {
 	d.RLock()
 	defer d.RUnlock()
 
-	filter := bson.M{"_id": bson.M{"$in": id}}
+	filter := bson.M{"$or": bson.A{bson.M{"_id": bson.M{"$in": id}}, bson.M{"slug": bson.M{"$in": slugs}}}}
 	result, err := d.collection.Find(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -91,6 +161,9 @@ func (d *db) AddGenre(ctx context.Context, genre *client.Genre) (*client.Genre,
 	defer d.Unlock()
 
 	result, err := d.collection.InsertOne(ctx, genre)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, errormiddleware.NotUniqueError([]string{"slug: genre with this slug already exists"}, err.Error())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -103,22 +176,121 @@ func (d *db) AddGenre(ctx context.Context, genre *client.Genre) (*client.Genre,
 
 	return genre, nil
 }
-func (d *db) GetAllGenres(ctx context.Context) ([]*client.Genre, error) {
+
+// Genres are listed by name in ascending order
+func (d *db) GetAllGenres(ctx context.Context, pageCursor string, limit int) (*client.GenrePage, error) {
 	d.RLock()
 	defer d.RUnlock()
 
-	result, err := d.collection.Find(ctx, bson.D{})
+	var current *cursor.Cursor
+	if len(pageCursor) > 0 {
+		var err error
+		current, err = cursor.Decode(pageCursor)
+		if err != nil || current.Field != "name" {
+			return nil, errormiddleware.BadRequestError([]string{"cursor: invalid cursor"}, fmt.Sprintf("received wrong cursor %s: %v", pageCursor, err))
+		}
+	}
+
+	total, err := d.collection.CountDocuments(ctx, bson.D{})
 	if err != nil {
 		return nil, err
 	}
 
-	var genres []*client.Genre
+	filter := bson.M{}
+	if current != nil {
+		filter = current.Filter(true)
+	}
+	direction := current.Direction(true)
+	options := options.Find().SetLimit(int64(limit + 1)).SetSort(bson.D{{Key: "name", Value: direction}, {Key: "_id", Value: direction}})
+
+	result, err := d.collection.Find(ctx, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	genres := make([]*client.Genre, 0)
 	err = result.All(ctx, &genres)
 	if err != nil {
 		return nil, err
 	}
-	if len(genres) == 0 {
-		return nil, errormiddleware.NotFoundError([]string{"there's no genres"}, "marshalled array contained 0 elements")
+
+	items, next, prev := cursor.Page(genres, limit, "name", current, false, func(g *client.Genre) (interface{}, primitive.ObjectID) {
+		return g.Name, g.Id
+	})
+	return &client.GenrePage{Items: items, Total: total, NextCursor: next, PrevCursor: prev}, nil
+}
+
+// Returns every genre sorted by name, used to build genre tree
+func (d *db) GetGenreList(ctx context.Context) ([]*client.Genre, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	result, err := d.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	genres := make([]*client.Genre, 0)
+	if err := result.All(ctx, &genres); err != nil {
+		return nil, err
 	}
 	return genres, nil
 }
+func (d *db) UpdateGenre(ctx context.Context, genre *client.Genre) error {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.ReplaceOne(ctx, bson.M{"_id": genre.Id}, genre)
+	if mongo.IsDuplicateKeyError(err) {
+		return errormiddleware.NotUniqueError([]string{"slug: genre with this slug already exists"}, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"genre with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) DeleteGenre(ctx context.Context, id primitive.ObjectID) error {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"genre with provided id not found"}, fmt.Sprintf("deleted count was == %d", result.DeletedCount))
+	}
+	return nil
+}
+
+// Children of the genre are moved to the new parent or to the root if it's nil. Returns ids of moved genres
+func (d *db) MoveChildren(ctx context.Context, from primitive.ObjectID, to *primitive.ObjectID) ([]primitive.ObjectID, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	result, err := d.collection.Find(ctx, bson.M{"parent": from}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	children := make([]*client.Genre, 0)
+	if err := result.All(ctx, &children); err != nil {
+		return nil, err
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+	update := bson.M{"$unset": bson.M{"parent": ""}}
+	if to != nil {
+		update = bson.M{"$set": bson.M{"parent": *to}}
+	}
+	if _, err := d.collection.UpdateMany(ctx, bson.M{"parent": from}, update); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(children))
+	for _, child := range children {
+		ids = append(ids, child.Id)
+	}
+	return ids, nil
+}
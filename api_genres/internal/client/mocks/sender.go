@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+
+// Package mock_client is a generated GoMock package.
+package mock_client
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSender is a mock of Sender interface.
+type MockSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockSenderMockRecorder
+}
+
+// MockSenderMockRecorder is the mock recorder for MockSender.
+type MockSenderMockRecorder struct {
+	mock *MockSender
+}
+
+// NewMockSender creates a new mock instance.
+func NewMockSender(ctrl *gomock.Controller) *MockSender {
+	mock := &MockSender{ctrl: ctrl}
+	mock.recorder = &MockSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSender) EXPECT() *MockSenderMockRecorder {
+	return m.recorder
+}
+
+// SendGenreChangedMessage mocks base method.
+func (m *MockSender) SendGenreChangedMessage(ctx context.Context, genreId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendGenreChangedMessage", ctx, genreId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendGenreChangedMessage indicates an expected call of SendGenreChangedMessage.
+func (mr *MockSenderMockRecorder) SendGenreChangedMessage(ctx, genreId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendGenreChangedMessage", reflect.TypeOf((*MockSender)(nil).SendGenreChangedMessage), ctx, genreId)
+}
+
+// SendGenreDeletedMessage mocks base method.
+func (m *MockSender) SendGenreDeletedMessage(ctx context.Context, genreId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendGenreDeletedMessage", ctx, genreId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendGenreDeletedMessage indicates an expected call of SendGenreDeletedMessage.
+func (mr *MockSenderMockRecorder) SendGenreDeletedMessage(ctx, genreId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendGenreDeletedMessage", reflect.TypeOf((*MockSender)(nil).SendGenreDeletedMessage), ctx, genreId)
+}
@@ -51,32 +51,90 @@ func (mr *MockStorageMockRecorder) AddGenre(ctx, genre interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGenre", reflect.TypeOf((*MockStorage)(nil).AddGenre), ctx, genre)
 }
 
+// DeleteGenre mocks base method.
+func (m *MockStorage) DeleteGenre(ctx context.Context, id primitive.ObjectID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGenre", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGenre indicates an expected call of DeleteGenre.
+func (mr *MockStorageMockRecorder) DeleteGenre(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGenre", reflect.TypeOf((*MockStorage)(nil).DeleteGenre), ctx, id)
+}
+
 // GetAllGenres mocks base method.
-func (m *MockStorage) GetAllGenres(ctx context.Context) ([]*client.Genre, error) {
+func (m *MockStorage) GetAllGenres(ctx context.Context, cursor string, limit int) (*client.GenrePage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllGenres", ctx)
-	ret0, _ := ret[0].([]*client.Genre)
+	ret := m.ctrl.Call(m, "GetAllGenres", ctx, cursor, limit)
+	ret0, _ := ret[0].(*client.GenrePage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllGenres indicates an expected call of GetAllGenres.
-func (mr *MockStorageMockRecorder) GetAllGenres(ctx interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetAllGenres(ctx, cursor, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGenres", reflect.TypeOf((*MockStorage)(nil).GetAllGenres), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGenres", reflect.TypeOf((*MockStorage)(nil).GetAllGenres), ctx, cursor, limit)
 }
 
 // GetGenre mocks base method.
-func (m *MockStorage) GetGenre(ctx context.Context, id []primitive.ObjectID) ([]*client.Genre, error) {
+func (m *MockStorage) GetGenre(ctx context.Context, id []primitive.ObjectID, slugs []string) ([]*client.Genre, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetGenre", ctx, id)
+	ret := m.ctrl.Call(m, "GetGenre", ctx, id, slugs)
 	ret0, _ := ret[0].([]*client.Genre)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetGenre indicates an expected call of GetGenre.
-func (mr *MockStorageMockRecorder) GetGenre(ctx, id interface{}) *gomock.Call {
+func (mr *MockStorageMockRecorder) GetGenre(ctx, id, slugs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenre", reflect.TypeOf((*MockStorage)(nil).GetGenre), ctx, id, slugs)
+}
+
+// GetGenreList mocks base method.
+func (m *MockStorage) GetGenreList(ctx context.Context) ([]*client.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenreList", ctx)
+	ret0, _ := ret[0].([]*client.Genre)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGenreList indicates an expected call of GetGenreList.
+func (mr *MockStorageMockRecorder) GetGenreList(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenreList", reflect.TypeOf((*MockStorage)(nil).GetGenreList), ctx)
+}
+
+// MoveChildren mocks base method.
+func (m *MockStorage) MoveChildren(ctx context.Context, from primitive.ObjectID, to *primitive.ObjectID) ([]primitive.ObjectID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveChildren", ctx, from, to)
+	ret0, _ := ret[0].([]primitive.ObjectID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveChildren indicates an expected call of MoveChildren.
+func (mr *MockStorageMockRecorder) MoveChildren(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveChildren", reflect.TypeOf((*MockStorage)(nil).MoveChildren), ctx, from, to)
+}
+
+// UpdateGenre mocks base method.
+func (m *MockStorage) UpdateGenre(ctx context.Context, genre *client.Genre) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGenre", ctx, genre)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGenre indicates an expected call of UpdateGenre.
+func (mr *MockStorageMockRecorder) UpdateGenre(ctx, genre interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenre", reflect.TypeOf((*MockStorage)(nil).GetGenre), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGenre", reflect.TypeOf((*MockStorage)(nil).UpdateGenre), ctx, genre)
 }
@@ -2,11 +2,37 @@ package client
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// Books and children are not stored, they are filled only when requested
 type Genre struct {
-	Id   primitive.ObjectID `json:"id" bson:"_id,omitempty" validate:"primitiveid"`
-	Name string             `json:"name" bson:"name" validate:"min=4,max=32"`
+	Id          primitive.ObjectID  `json:"id" bson:"_id,omitempty" validate:"primitiveid"`
+	Name        string              `json:"name" bson:"name" validate:"min=4,max=32"`
+	Slug        string              `json:"slug" bson:"slug"`
+	Description string              `json:"description,omitempty" bson:"description,omitempty"`
+	ParentId    *primitive.ObjectID `json:"parent,omitempty" bson:"parent,omitempty"`
+	Books       *int64              `json:"books,omitempty" bson:"-"`
+	Children    []*Genre            `json:"children,omitempty" bson:"-"`
 }
 
+// Slug is built from name if it's not provided
 type AddGenreQuery struct {
-	Name string `json:"name" validate:"min=4,max=32"`
+	Name        string `json:"name" validate:"min=4,max=32"`
+	Slug        string `json:"slug,omitempty" validate:"omitempty,max=64"`
+	Description string `json:"description,omitempty" validate:"max=1024"`
+	Parent      string `json:"parent,omitempty" validate:"omitempty,primitiveid"`
+}
+
+// Only provided fields will be updated. Empty parent moves genre to the root
+type UpdateGenreQuery struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=4,max=32"`
+	Slug        *string `json:"slug,omitempty" validate:"omitempty,max=64"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1024"`
+	Parent      *string `json:"parent,omitempty" validate:"omitempty,len=0|primitiveid"`
+}
+
+// Cursors are empty when there is no next or previous page
+type GenrePage struct {
+	Items      []*Genre `json:"items"`
+	Total      int64    `json:"total"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	PrevCursor string   `json:"prev_cursor,omitempty"`
 }
@@ -4,38 +4,72 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
+	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/config"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/cache"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/signature"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/slug"
 	valid "github.com/reversersed/go-web-services/tree/main/api_genres/pkg/validator"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+//go:generate mockgen -source=service.go -destination=mocks/sender.go
+
+// Books service keeps genres in it's cache, so it's notified about changes
+type Sender interface {
+	SendGenreChangedMessage(ctx context.Context, genreId string) error
+	SendGenreDeletedMessage(ctx context.Context, genreId string) error
+}
+
+const max_genre_depth = 16
+
 type service struct {
 	storage   Storage
 	logger    *logging.Logger
 	cache     cache.Cache
 	validator *valid.Validator
+	sender    Sender
+	bookApi   *BaseClient
 }
 
-func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator) *service {
-	return &service{storage: storage, logger: logger, cache: cache, validator: validator}
+func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator, sender Sender, cfg *config.UrlConfig, signer *signature.Signer) *service {
+	return &service{
+		storage:   storage,
+		logger:    logger,
+		cache:     cache,
+		validator: validator,
+		sender:    sender,
+		bookApi: &BaseClient{
+			Base: &rest.RestClient{BaseURL: cfg.BookApiAdress, HttpClient: &http.Client{Timeout: 5 * time.Second}, Logger: logger, Signer: signer},
+			Path: "/stats",
+		},
+	}
 }
-func (s *service) GetGenre(ctx context.Context, id string) ([]*Genre, error) {
+
+// Genres could be requested by ids and slugs. Slugs are always looked up in the storage
+func (s *service) GetGenre(ctx context.Context, id string, counts bool) ([]*Genre, error) {
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	ids := strings.Split(id, ",")
 	primitives := make([]primitive.ObjectID, 0, len(ids))
+	slugs := make([]string, 0)
 	genre := make([]*Genre, 0, len(ids))
 	var err error
 	for _, cnvrt := range ids {
 		hex, err := primitive.ObjectIDFromHex(cnvrt)
 		if err != nil {
-			return nil, errormiddleware.BadRequestError([]string{"wrong request params"}, fmt.Sprintf("can't convert value %s to object hex. Must be primitive id. %v", cnvrt, err))
+			if !slug.Valid(cnvrt) {
+				return nil, errormiddleware.BadRequestError([]string{"wrong request params"}, fmt.Sprintf("value %s is neither object id nor slug: %v", cnvrt, err))
+			}
+			slugs = append(slugs, cnvrt)
+			continue
 		}
 		bytes, err := s.cache.Get([]byte(cnvrt))
 		if err == nil {
@@ -46,7 +80,7 @@ func (s *service) GetGenre(ctx context.Context, id string) ([]*Genre, error) {
 		primitives = append(primitives, hex)
 	}
 	if len(genre) < len(ids) {
-		genre, err = s.storage.GetGenre(cntx, primitives)
+		genre, err = s.storage.GetGenre(cntx, primitives, slugs)
 		if err != nil {
 			return nil, err
 		}
@@ -59,18 +93,55 @@ func (s *service) GetGenre(ctx context.Context, id string) ([]*Genre, error) {
 		s.logger.Infof("got %d items from cache", len(genre))
 	}
 
+	if counts {
+		s.fillBookCounts(ctx, genre)
+	}
 	return genre, nil
 }
-func (s *service) GetAllGenres(ctx context.Context) ([]*Genre, error) {
+func (s *service) GetAllGenres(ctx context.Context, cursor string, limit int, counts bool) (*GenrePage, error) {
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	genres, err := s.storage.GetAllGenres(cntx)
+	page, err := s.storage.GetAllGenres(cntx, cursor, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return genres, nil
+	if counts {
+		s.fillBookCounts(ctx, page.Items)
+	}
+	return page, nil
+}
+
+// Whole tree is returned at once, root genres are items of the page. Children are sorted by name.
+// Genres with unknown parent are treated as roots
+func (s *service) GetGenreTree(ctx context.Context, counts bool) (*GenrePage, error) {
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	genres, err := s.storage.GetGenreList(cntx)
+	if err != nil {
+		return nil, err
+	}
+	if counts {
+		s.fillBookCounts(ctx, genres)
+	}
+
+	byId := make(map[primitive.ObjectID]*Genre, len(genres))
+	for _, g := range genres {
+		byId[g.Id] = g
+	}
+	roots := make([]*Genre, 0)
+	for _, g := range genres {
+		if g.ParentId != nil {
+			if parent, ok := byId[*g.ParentId]; ok && parent != g {
+				parent.Children = append(parent.Children, g)
+				continue
+			}
+		}
+		roots = append(roots, g)
+	}
+	return &GenrePage{Items: roots, Total: int64(len(genres))}, nil
 }
 func (s *service) AddGenre(ctx context.Context, query *AddGenreQuery) (*Genre, error) {
 	if err := s.validator.Struct(query); err != nil {
@@ -79,8 +150,21 @@ func (s *service) AddGenre(ctx context.Context, query *AddGenreQuery) (*Genre, e
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	genreSlug, err := makeSlug(query.Slug, query.Name)
+	if err != nil {
+		return nil, err
+	}
 	genre := &Genre{
-		Name: query.Name,
+		Name:        query.Name,
+		Slug:        genreSlug,
+		Description: query.Description,
+	}
+	if len(query.Parent) > 0 {
+		parent, _ := primitive.ObjectIDFromHex(query.Parent)
+		if err := s.checkParent(cntx, primitive.NilObjectID, parent); err != nil {
+			return nil, err
+		}
+		genre.ParentId = &parent
 	}
 	response, err := s.storage.AddGenre(cntx, genre)
 	if err != nil {
@@ -91,3 +175,175 @@ func (s *service) AddGenre(ctx context.Context, query *AddGenreQuery) (*Genre, e
 	s.logger.Infof("created new genre: %v", response)
 	return response, nil
 }
+
+// Slug is not changed when genre is renamed, so links to the genre stay valid
+func (s *service) UpdateGenre(ctx context.Context, id string, query *UpdateGenreQuery) (*Genre, error) {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong genre id"}, err.Error())
+	}
+	if err := s.validator.Struct(query); err != nil {
+		return nil, errormiddleware.ValidationError(err, "wrong query")
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	genres, err := s.storage.GetGenre(cntx, []primitive.ObjectID{pId}, []string{})
+	if err != nil {
+		return nil, err
+	}
+	if len(genres) == 0 {
+		return nil, errormiddleware.NotFoundError([]string{"genre with provided id not found"}, fmt.Sprintf("genre %s was not found", id))
+	}
+	genre := genres[0]
+	if query.Name != nil {
+		genre.Name = *query.Name
+	}
+	if query.Slug != nil {
+		genre.Slug, err = makeSlug(*query.Slug, genre.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if query.Description != nil {
+		genre.Description = *query.Description
+	}
+	if query.Parent != nil {
+		if len(*query.Parent) == 0 {
+			genre.ParentId = nil
+		} else {
+			parent, _ := primitive.ObjectIDFromHex(*query.Parent)
+			if err := s.checkParent(cntx, pId, parent); err != nil {
+				return nil, err
+			}
+			genre.ParentId = &parent
+		}
+	}
+	if err := s.storage.UpdateGenre(cntx, genre); err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(genre)
+	s.cache.Set([]byte(genre.Id.Hex()), data, int((time.Hour*6)/time.Second))
+	if err := s.sender.SendGenreChangedMessage(ctx, genre.Id.Hex()); err != nil {
+		s.logger.Errorf("can't send genre changed message: %v", err)
+	}
+	s.logger.Infof("updated genre: %v", genre)
+	return genre, nil
+}
+
+// Genre that is used by books is deleted only with cascade, then books that reference it are cleaned up
+// by books service when it receives deletion message. Children of deleted genre are moved to it's parent
+func (s *service) DeleteGenre(ctx context.Context, id string, cascade bool) error {
+	pId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong genre id"}, err.Error())
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	genres, err := s.storage.GetGenre(cntx, []primitive.ObjectID{pId}, []string{})
+	if err != nil {
+		return err
+	}
+	if len(genres) == 0 {
+		return errormiddleware.NotFoundError([]string{"genre with provided id not found"}, fmt.Sprintf("genre %s was not found", id))
+	}
+	if !cascade {
+		counts, err := s.bookCounts(cntx, []string{pId.Hex()})
+		if err != nil {
+			return err
+		}
+		if count := counts[pId.Hex()]; count > 0 {
+			return errormiddleware.NotUniqueError([]string{fmt.Sprintf("genre is used by %d books, use cascade=true to remove it from them", count)}, fmt.Sprintf("genre %s is referenced by books", id))
+		}
+	}
+	if err := s.storage.DeleteGenre(cntx, pId); err != nil {
+		return err
+	}
+	s.cache.Delete([]byte(pId.Hex()))
+	children, err := s.storage.MoveChildren(cntx, pId, genres[0].ParentId)
+	if err != nil {
+		s.logger.Errorf("can't move children of deleted genre %s: %v", id, err)
+	}
+	for _, child := range children {
+		s.cache.Delete([]byte(child.Hex()))
+	}
+	if err := s.sender.SendGenreDeletedMessage(ctx, pId.Hex()); err != nil {
+		s.logger.Errorf("can't send genre deleted message: %v", err)
+	}
+	s.logger.Warnf("genre %s has been deleted", id)
+	return nil
+}
+
+// Parent must exist and genre can't be moved under itself or it's descendants
+func (s *service) checkParent(ctx context.Context, id, parent primitive.ObjectID) error {
+	current := parent
+	for depth := 0; ; depth++ {
+		if current == id {
+			return errormiddleware.BadRequestError([]string{"parent: genre can't be placed under itself or it's children"}, fmt.Sprintf("genre %s is an ancestor of %s", id.Hex(), parent.Hex()))
+		}
+		if depth >= max_genre_depth {
+			return errormiddleware.BadRequestError([]string{fmt.Sprintf("parent: genres can't be nested deeper than %d levels", max_genre_depth)}, fmt.Sprintf("parent %s is too deep", parent.Hex()))
+		}
+		genres, err := s.storage.GetGenre(ctx, []primitive.ObjectID{current}, []string{})
+		if err != nil {
+			return err
+		}
+		if len(genres) == 0 {
+			if current == parent {
+				return errormiddleware.BadRequestError([]string{"parent: genre not found"}, fmt.Sprintf("parent genre %s not exists", parent.Hex()))
+			}
+			return nil
+		}
+		if genres[0].ParentId == nil {
+			return nil
+		}
+		current = *genres[0].ParentId
+	}
+}
+
+// Counts are optional, so genres are returned without them if books service is not available
+func (s *service) fillBookCounts(ctx context.Context, genres []*Genre) {
+	if len(genres) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(genres))
+	for _, g := range genres {
+		ids = append(ids, g.Id.Hex())
+	}
+	counts, err := s.bookCounts(ctx, ids)
+	if err != nil {
+		s.logger.Errorf("can't get book counts of genres: %v", err)
+		return
+	}
+	for _, g := range genres {
+		count := counts[g.Id.Hex()]
+		g.Books = &count
+	}
+}
+
+// Returns how many books use every genre, genres without books may be missing
+func (s *service) bookCounts(ctx context.Context, ids []string) (map[string]int64, error) {
+	body, err := s.bookApi.SendGetGeneric(ctx, "/genres", map[string][]string{"id": {strings.Join(ids, ",")}})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64)
+	if err := json.Unmarshal(body, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+func makeSlug(provided, name string) (string, error) {
+	if len(provided) > 0 {
+		if !slug.Valid(provided) {
+			return "", errormiddleware.BadRequestError([]string{"slug: must contain only lowercase latin letters and digits separated by dashes"}, fmt.Sprintf("received slug %s", provided))
+		}
+		return provided, nil
+	}
+	generated := slug.Make(name)
+	if len(generated) == 0 {
+		return "", errormiddleware.BadRequestError([]string{"slug: can't be built from name, provide it explicitly"}, fmt.Sprintf("name %s has no usable characters", name))
+	}
+	return generated, nil
+}
@@ -1 +1,211 @@
-package client
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/client"
+	mock "github.com/reversersed/go-web-services/tree/main/api_genres/internal/client/mocks"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/cache/freecache"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/validator"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAddGenre(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), mock.NewMockSender(ctrl), &config.UrlConfig{}, nil)
+
+	t.Run("slug from name", func(t *testing.T) {
+		storage.EXPECT().AddGenre(gomock.Any(), &client.Genre{Name: "Children's literature", Slug: "childrens-literature"}).DoAndReturn(func(_ context.Context, genre *client.Genre) (*client.Genre, error) {
+			genre.Id = primitive.NewObjectID()
+			return genre, nil
+		})
+		genre, err := service.AddGenre(context.Background(), &client.AddGenreQuery{Name: "Children's literature"})
+		assert.NoError(t, err)
+		assert.Equal(t, "childrens-literature", genre.Slug)
+	})
+	t.Run("wrong slug", func(t *testing.T) {
+		_, err := service.AddGenre(context.Background(), &client.AddGenreQuery{Name: "Fairy tales", Slug: "Fairy tales"})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("with parent", func(t *testing.T) {
+		parent, root := primitive.NewObjectID(), primitive.NewObjectID()
+		gomock.InOrder(
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{parent}, []string{}).Return([]*client.Genre{{Id: parent, ParentId: &root}}, nil),
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{root}, []string{}).Return([]*client.Genre{{Id: root}}, nil),
+			storage.EXPECT().AddGenre(gomock.Any(), &client.Genre{Name: "Fairy tales", Slug: "fairy-tales", ParentId: &parent}).Return(&client.Genre{Id: primitive.NewObjectID(), ParentId: &parent}, nil),
+		)
+		genre, err := service.AddGenre(context.Background(), &client.AddGenreQuery{Name: "Fairy tales", Parent: parent.Hex()})
+		assert.NoError(t, err)
+		assert.Equal(t, parent, *genre.ParentId)
+	})
+	t.Run("unknown parent", func(t *testing.T) {
+		parent := primitive.NewObjectID()
+		storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{parent}, []string{}).Return([]*client.Genre{}, nil)
+		_, err := service.AddGenre(context.Background(), &client.AddGenreQuery{Name: "Fairy tales", Parent: parent.Hex()})
+		assert.Equal(t, errormiddleware.BadRequestError([]string{"parent: genre not found"}, "parent genre "+parent.Hex()+" not exists"), err)
+	})
+}
+func TestUpdateGenreParent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	sender := mock.NewMockSender(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), sender, &config.UrlConfig{}, nil)
+
+	genre, child := primitive.NewObjectID(), primitive.NewObjectID()
+	t.Run("moved under own child", func(t *testing.T) {
+		gomock.InOrder(
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{genre}, []string{}).Return([]*client.Genre{{Id: genre, Slug: "genre"}}, nil),
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{child}, []string{}).Return([]*client.Genre{{Id: child, ParentId: &genre}}, nil),
+		)
+		parent := child.Hex()
+		_, err := service.UpdateGenre(context.Background(), genre.Hex(), &client.UpdateGenreQuery{Parent: &parent})
+		assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+	})
+	t.Run("moved to root and renamed", func(t *testing.T) {
+		name, parent := "New name", ""
+		gomock.InOrder(
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{child}, []string{}).Return([]*client.Genre{{Id: child, Name: "Old name", Slug: "old-name", ParentId: &genre}}, nil),
+			storage.EXPECT().UpdateGenre(gomock.Any(), &client.Genre{Id: child, Name: "New name", Slug: "old-name"}).Return(nil),
+			sender.EXPECT().SendGenreChangedMessage(gomock.Any(), child.Hex()).Return(nil),
+		)
+		updated, err := service.UpdateGenre(context.Background(), child.Hex(), &client.UpdateGenreQuery{Name: &name, Parent: &parent})
+		assert.NoError(t, err)
+		assert.Nil(t, updated.ParentId)
+	})
+}
+func TestDeleteGenre(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	genre, parent, used := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/stats/genres", r.URL.Path)
+		body, _ := json.Marshal(map[string]int64{used.Hex(): 3})
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	sender := mock.NewMockSender(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), sender, &config.UrlConfig{BookApiAdress: server.URL}, nil)
+
+	t.Run("unused genre", func(t *testing.T) {
+		gomock.InOrder(
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{genre}, []string{}).Return([]*client.Genre{{Id: genre, ParentId: &parent}}, nil),
+			storage.EXPECT().DeleteGenre(gomock.Any(), genre).Return(nil),
+			storage.EXPECT().MoveChildren(gomock.Any(), genre, &parent).Return([]primitive.ObjectID{primitive.NewObjectID()}, nil),
+			sender.EXPECT().SendGenreDeletedMessage(gomock.Any(), genre.Hex()).Return(nil),
+		)
+		assert.NoError(t, service.DeleteGenre(context.Background(), genre.Hex(), false))
+	})
+	t.Run("used genre", func(t *testing.T) {
+		storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{used}, []string{}).Return([]*client.Genre{{Id: used}}, nil)
+		err := service.DeleteGenre(context.Background(), used.Hex(), false)
+		assert.Equal(t, errormiddleware.NotUniqueError([]string{"genre is used by 3 books, use cascade=true to remove it from them"}, "genre "+used.Hex()+" is referenced by books"), err)
+	})
+	t.Run("cascade", func(t *testing.T) {
+		gomock.InOrder(
+			storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{used}, []string{}).Return([]*client.Genre{{Id: used}}, nil),
+			storage.EXPECT().DeleteGenre(gomock.Any(), used).Return(nil),
+			storage.EXPECT().MoveChildren(gomock.Any(), used, nil).Return(nil, nil),
+			sender.EXPECT().SendGenreDeletedMessage(gomock.Any(), used.Hex()).Return(nil),
+		)
+		assert.NoError(t, service.DeleteGenre(context.Background(), used.Hex(), true))
+	})
+}
+func TestDeleteGenreWithoutBooksService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	// genre is not deleted if it can't be checked that books don't use it
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), mock.NewMockSender(ctrl), &config.UrlConfig{BookApiAdress: "http://127.0.0.1:1"}, nil)
+
+	genre := primitive.NewObjectID()
+	storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{genre}, []string{}).Return([]*client.Genre{{Id: genre}}, nil)
+	assert.Error(t, service.DeleteGenre(context.Background(), genre.Hex(), false))
+}
+func TestGetGenreTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	parent, child, grandchild, orphan := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	missing := primitive.NewObjectID()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/stats/genres", r.URL.Path)
+		body, _ := json.Marshal(map[string]int64{parent.Hex(): 3, child.Hex(): 1})
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), mock.NewMockSender(ctrl), &config.UrlConfig{BookApiAdress: server.URL}, nil)
+
+	storage.EXPECT().GetGenreList(gomock.Any()).Return([]*client.Genre{
+		{Id: parent, Name: "Children's literature"},
+		{Id: child, Name: "Fairy tales", ParentId: &parent},
+		{Id: orphan, Name: "Orphan", ParentId: &missing},
+		{Id: grandchild, Name: "Russian fairy tales", ParentId: &child},
+	}, nil)
+
+	page, err := service.GetGenreTree(context.Background(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), page.Total)
+	if assert.Len(t, page.Items, 2) {
+		assert.Equal(t, parent, page.Items[0].Id)
+		assert.Equal(t, orphan, page.Items[1].Id)
+		assert.Equal(t, int64(3), *page.Items[0].Books)
+		assert.Equal(t, int64(0), *page.Items[1].Books)
+		if assert.Len(t, page.Items[0].Children, 1) {
+			assert.Equal(t, int64(1), *page.Items[0].Children[0].Books)
+			assert.Equal(t, grandchild, page.Items[0].Children[0].Children[0].Id)
+		}
+	}
+}
+func TestGetGenreBySlug(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	// books service is not available, so genres are returned without counts
+	service := client.NewService(storage, logger, freecache.NewCache(1024*1024), validator.New(), mock.NewMockSender(ctrl), &config.UrlConfig{BookApiAdress: "http://127.0.0.1:1"}, nil)
+
+	id := primitive.NewObjectID()
+	storage.EXPECT().GetGenre(gomock.Any(), []primitive.ObjectID{id}, []string{"fairy-tales"}).Return([]*client.Genre{{Id: id}, {Slug: "fairy-tales"}}, nil)
+
+	genres, err := service.GetGenre(context.Background(), id.Hex()+",fairy-tales", true)
+	assert.NoError(t, err)
+	assert.Len(t, genres, 2)
+	assert.Nil(t, genres[0].Books)
+
+	_, err = service.GetGenre(context.Background(), "Fairy tales", false)
+	assert.Equal(t, errormiddleware.BadRequestErrorCode, err.(*errormiddleware.Error).Code)
+}
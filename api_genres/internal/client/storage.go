@@ -9,7 +9,11 @@ import (
 //go:generate mockgen -source=storage.go -destination=mocks/storage.go
 
 type Storage interface {
-	GetGenre(ctx context.Context, id []primitive.ObjectID) ([]*Genre, error)
+	GetGenre(ctx context.Context, id []primitive.ObjectID, slugs []string) ([]*Genre, error)
 	AddGenre(ctx context.Context, genre *Genre) (*Genre, error)
-	GetAllGenres(ctx context.Context) ([]*Genre, error)
+	GetAllGenres(ctx context.Context, cursor string, limit int) (*GenrePage, error)
+	GetGenreList(ctx context.Context) ([]*Genre, error)
+	UpdateGenre(ctx context.Context, genre *Genre) error
+	DeleteGenre(ctx context.Context, id primitive.ObjectID) error
+	MoveChildren(ctx context.Context, from primitive.ObjectID, to *primitive.ObjectID) ([]primitive.ObjectID, error)
 }
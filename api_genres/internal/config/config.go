@@ -20,9 +20,29 @@ type DatabaseConfig struct {
 	Db_Pass string `env:"DB_PASS"`
 	Db_Auth string `env:"DB_AUTHDB"`
 }
+type RabbitConfig struct {
+	Rabbit_Host string `env:"RABBITMQ_HOST" env-required:"true"`
+	Rabbit_Port string `env:"RABBITMQ_PORT" env-required:"true"`
+	Rabbit_User string `env:"RABBITMQ_USER" env-required:"true"`
+	Rabbit_Pass string `env:"RABBITMQ_PASS" env-required:"true"`
+}
+type UrlConfig struct {
+	BookApiAdress string `env:"BOOK_API_URL" env-required:"true"`
+}
+
+// Secret is shared by all services, internal requests are signed with it.
+// Signed requests with body larger than MaxBodySize bytes are rejected
+type SignatureConfig struct {
+	Service     string `env:"SERVICE_NAME" env-default:"api_genres"`
+	Secret      string `env:"SERVICE_SECRET" env-required:"true"`
+	MaxBodySize int64  `env:"SERVICE_MAX_BODY_SIZE" env-default:"1048576"`
+}
 type Config struct {
-	Server   *ServerConfig
-	Database *DatabaseConfig
+	Server    *ServerConfig
+	Database  *DatabaseConfig
+	Rabbit    *RabbitConfig
+	Urls      *UrlConfig
+	Signature *SignatureConfig
 }
 
 var cfg *Config
@@ -33,7 +53,10 @@ func GetConfig() *Config {
 		logger := logging.GetLogger()
 		logger.Info("reading api config...")
 		srvCfg := &ServerConfig{}
+		signatureCfg := &SignatureConfig{}
 		dbCfg := &DatabaseConfig{}
+		rabbitCfg := &RabbitConfig{}
+		urlCfg := &UrlConfig{}
 
 		if err := cleanenv.ReadConfig("config/.env", srvCfg); err != nil {
 			desc, _ := cleanenv.GetDescription(cfg, nil)
@@ -48,9 +71,27 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		if err := cleanenv.ReadConfig("config/.env", rabbitCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", urlCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", signatureCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
 		cfg = &Config{
-			Server:   srvCfg,
-			Database: dbCfg,
+			Server:    srvCfg,
+			Database:  dbCfg,
+			Rabbit:    rabbitCfg,
+			Urls:      urlCfg,
+			Signature: signatureCfg,
 		}
 	})
 	return cfg
@@ -3,7 +3,9 @@ package genre
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -15,14 +17,20 @@ import (
 //go:generate mockgen -source=handler.go -destination=mocks/service_mock.go
 
 const (
-	url_genres    = "/genres"
-	url_all_genre = "/genres/all"
+	url_genres      = "/genres"
+	url_all_genre   = "/genres/all"
+	url_genre_by_id = "/genres/:id"
+
+	default_genres_limit = 50
 )
 
 type Service interface {
-	GetGenre(ctx context.Context, id string) ([]*client.Genre, error)
+	GetGenre(ctx context.Context, id string, counts bool) ([]*client.Genre, error)
 	AddGenre(ctx context.Context, genre *client.AddGenreQuery) (*client.Genre, error)
-	GetAllGenres(ctx context.Context) ([]*client.Genre, error)
+	GetAllGenres(ctx context.Context, cursor string, limit int, counts bool) (*client.GenrePage, error)
+	GetGenreTree(ctx context.Context, counts bool) (*client.GenrePage, error)
+	UpdateGenre(ctx context.Context, id string, query *client.UpdateGenreQuery) (*client.Genre, error)
+	DeleteGenre(ctx context.Context, id string, cascade bool) error
 }
 type Handler struct {
 	Logger  *logging.Logger
@@ -33,6 +41,8 @@ func (h *Handler) Register(route *httprouter.Router) {
 	route.HandlerFunc(http.MethodPost, url_genres, h.Logger.Middleware(errormiddleware.Middleware(h.AddGenre)))
 	route.HandlerFunc(http.MethodGet, url_genres, h.Logger.Middleware(errormiddleware.Middleware(h.GetGenre)))
 	route.HandlerFunc(http.MethodGet, url_all_genre, h.Logger.Middleware(errormiddleware.Middleware(h.GetAll)))
+	route.HandlerFunc(http.MethodPatch, url_genre_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.UpdateGenre)))
+	route.HandlerFunc(http.MethodDelete, url_genre_by_id, h.Logger.Middleware(errormiddleware.Middleware(h.DeleteGenre)))
 }
 
 func (h *Handler) AddGenre(w http.ResponseWriter, r *http.Request) error {
@@ -60,10 +70,14 @@ func (h *Handler) GetGenre(w http.ResponseWriter, r *http.Request) error {
 	if len(code) == 0 {
 		return errormiddleware.BadRequestError([]string{"code param must contain at least 1 id"}, "wrong query (try use ?code=id1,id2,id3...)")
 	}
+	counts, err := boolQuery(r, "counts")
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	genres, err := h.Service.GetGenre(ctx, code)
+	genres, err := h.Service.GetGenre(ctx, code, counts)
 	if err != nil {
 		return err
 	}
@@ -73,16 +87,102 @@ func (h *Handler) GetGenre(w http.ResponseWriter, r *http.Request) error {
 	w.Write(body)
 	return nil
 }
+
+// Tree is returned without pagination, so limit and cursor are ignored
 func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) error {
+	counts, err := boolQuery(r, "counts")
+	if err != nil {
+		return err
+	}
+	tree, err := boolQuery(r, "tree")
+	if err != nil {
+		return err
+	}
+	if tree {
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+
+		page, err := h.Service.GetGenreTree(ctx, counts)
+		if err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusOK)
+		body, _ := json.Marshal(page)
+		w.Write(body)
+		return nil
+	}
+	limit := default_genres_limit
+	if r.URL.Query().Has("limit") {
+		var err error
+		limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"bad query request", "limit must be a number"}, err.Error())
+		}
+		if limit <= 0 {
+			return errormiddleware.BadRequestError([]string{"bad query request"}, "limit must be greater than 0")
+		}
+	}
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	genres, err := h.Service.GetAllGenres(ctx)
+	page, err := h.Service.GetAllGenres(ctx, r.URL.Query().Get("cursor"), limit, counts)
 	if err != nil {
 		return err
 	}
 	w.WriteHeader(http.StatusOK)
-	body, _ := json.Marshal(&genres)
+	body, _ := json.Marshal(page)
+	w.Write(body)
+	return nil
+}
+func (h *Handler) UpdateGenre(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
+	}
+	var query client.UpdateGenreQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	genre, err := h.Service.UpdateGenre(ctx, id, &query)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusOK)
+	body, _ := json.Marshal(genre)
 	w.Write(body)
 	return nil
 }
+func (h *Handler) DeleteGenre(w http.ResponseWriter, r *http.Request) error {
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if len(id) == 0 {
+		return errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present")
+	}
+	cascade, err := boolQuery(r, "cascade")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Service.DeleteGenre(ctx, id, cascade); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func boolQuery(r *http.Request, name string) (bool, error) {
+	if !r.URL.Query().Has(name) {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(r.URL.Query().Get(name))
+	if err != nil {
+		return false, errormiddleware.BadRequestError([]string{fmt.Sprintf("%s: must be true or false", name)}, err.Error())
+	}
+	return value, nil
+}
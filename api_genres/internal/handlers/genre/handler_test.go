@@ -2,6 +2,7 @@ package genre
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/internal/client"
 	mock "github.com/reversersed/go-web-services/tree/main/api_genres/internal/handlers/genre/mocks"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
@@ -48,6 +50,16 @@ func TestRegister(t *testing.T) {
 			Path:   url_all_genre,
 			Method: http.MethodGet,
 		},
+		{
+			Name:   "Update genre handler",
+			Path:   url_genre_by_id,
+			Method: http.MethodPatch,
+		},
+		{
+			Name:   "Delete genre handler",
+			Path:   url_genre_by_id,
+			Method: http.MethodDelete,
+		},
 	}
 	router := httprouter.New()
 	h.Register(router)
@@ -63,17 +75,172 @@ func TestHandlers(t *testing.T) {
 	type handlerOptions struct {
 		Name           string
 		MockBehaviour  func(s *mock.MockService)
+		Query          string
+		Params         httprouter.Params
 		InputJson      func() *[]byte
 		ExceptedStatus int
 		ExceptedError  error
 		ExceptedBody   string
 	}
+	name := "New genre"
 	var testTable = []struct {
 		HandlerName string
 		Handler     func(w http.ResponseWriter, r *http.Request) error
 		Method      string
 		Options     []handlerOptions
-	}{}
+	}{
+		//GetAll
+		{
+			HandlerName: "GetAll",
+			Handler:     h.GetAll,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				//Default limit
+				{
+					Name: "default limit",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetAllGenres(gomock.Any(), "", default_genres_limit, false).Return(&client.GenrePage{Items: []*client.Genre{{Name: "Genre", Slug: "genre"}}, Total: 1}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[{"id":"000000000000000000000000","name":"Genre","slug":"genre"}],"total":1}`,
+				},
+				//Empty page
+				{
+					Name:  "empty page",
+					Query: "?cursor=next&limit=5",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetAllGenres(gomock.Any(), "next", 5, false).Return(&client.GenrePage{Items: []*client.Genre{}, Total: 1}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[],"total":1}`,
+				},
+				//Tree with counts
+				{
+					Name:  "tree",
+					Query: "?tree=true&counts=true&limit=0",
+					MockBehaviour: func(s *mock.MockService) {
+						count := int64(2)
+						s.EXPECT().GetGenreTree(gomock.Any(), true).Return(&client.GenrePage{Items: []*client.Genre{{Name: "Parent", Slug: "parent", Books: &count, Children: []*client.Genre{{Name: "Child", Slug: "child"}}}}, Total: 2}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[{"id":"000000000000000000000000","name":"Parent","slug":"parent","books":2,"children":[{"id":"000000000000000000000000","name":"Child","slug":"child"}]}],"total":2}`,
+				},
+				//Wrong tree flag
+				{
+					Name:           "wrong tree",
+					Query:          "?tree=yes",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"tree: must be true or false"}, `strconv.ParseBool: parsing "yes": invalid syntax`),
+					ExceptedBody:   `{"messages":["tree: must be true or false"],"dev_message":"strconv.ParseBool: parsing \"yes\": invalid syntax","code":"IE-0003"}`,
+				},
+				//Wrong limit
+				{
+					Name:           "wrong limit",
+					Query:          "?limit=0",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad query request"}, "limit must be greater than 0"),
+					ExceptedBody:   `{"messages":["bad query request"],"dev_message":"limit must be greater than 0","code":"IE-0003"}`,
+				},
+			},
+		},
+		//GetGenre
+		{
+			HandlerName: "GetGenre",
+			Handler:     h.GetGenre,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name:  "by slug with counts",
+					Query: "?id=fairy-tales&counts=1",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetGenre(gomock.Any(), "fairy-tales", true).Return([]*client.Genre{{Name: "Fairy tales", Slug: "fairy-tales"}}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `[{"id":"000000000000000000000000","name":"Fairy tales","slug":"fairy-tales"}]`,
+				},
+			},
+		},
+		//UpdateGenre
+		{
+			HandlerName: "UpdateGenre",
+			Handler:     h.UpdateGenre,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				{
+					Name:   "successful update",
+					Params: httprouter.Params{{Key: "id", Value: "6669b3b4e9ec3a8ed94d0ea8"}},
+					InputJson: func() *[]byte {
+						body := []byte(`{"name":"New genre"}`)
+						return &body
+					},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().UpdateGenre(gomock.Any(), "6669b3b4e9ec3a8ed94d0ea8", &client.UpdateGenreQuery{Name: &name}).Return(&client.Genre{Name: "New genre"}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"id":"000000000000000000000000","name":"New genre","slug":""}`,
+				},
+				{
+					Name:           "no id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present"),
+					ExceptedBody:   `{"messages":["id: parameter is required"],"dev_message":"id path is not present","code":"IE-0003"}`,
+				},
+				{
+					Name:   "genre not found",
+					Params: httprouter.Params{{Key: "id", Value: "6669b3b4e9ec3a8ed94d0ea8"}},
+					InputJson: func() *[]byte {
+						body := []byte(`{"name":"New genre"}`)
+						return &body
+					},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().UpdateGenre(gomock.Any(), "6669b3b4e9ec3a8ed94d0ea8", gomock.Any()).Return(nil, errormiddleware.NotFoundError([]string{"genre not found"}, "no documents"))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"genre not found"}, "no documents"),
+					ExceptedBody:   `{"messages":["genre not found"],"dev_message":"no documents","code":"IE-0002"}`,
+				},
+			},
+		},
+		//DeleteGenre
+		{
+			HandlerName: "DeleteGenre",
+			Handler:     h.DeleteGenre,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				{
+					Name:   "successful delete",
+					Params: httprouter.Params{{Key: "id", Value: "6669b3b4e9ec3a8ed94d0ea8"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteGenre(gomock.Any(), "6669b3b4e9ec3a8ed94d0ea8", false).Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name:   "cascade",
+					Query:  "?cascade=true",
+					Params: httprouter.Params{{Key: "id", Value: "6669b3b4e9ec3a8ed94d0ea8"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteGenre(gomock.Any(), "6669b3b4e9ec3a8ed94d0ea8", true).Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+				{
+					Name:           "wrong cascade",
+					Query:          "?cascade=yes",
+					Params:         httprouter.Params{{Key: "id", Value: "6669b3b4e9ec3a8ed94d0ea8"}},
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"cascade: must be true or false"}, `strconv.ParseBool: parsing "yes": invalid syntax`),
+					ExceptedBody:   `{"messages":["cascade: must be true or false"],"dev_message":"strconv.ParseBool: parsing \"yes\": invalid syntax","code":"IE-0003"}`,
+				},
+				{
+					Name:           "no id",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"id: parameter is required"}, "id path is not present"),
+					ExceptedBody:   `{"messages":["id: parameter is required"],"dev_message":"id path is not present","code":"IE-0003"}`,
+				},
+			},
+		},
+	}
 	for _, tt := range testTable {
 		for _, testCase := range tt.Options {
 			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
@@ -88,9 +255,12 @@ func TestHandlers(t *testing.T) {
 				w := httptest.NewRecorder()
 				var r *http.Request
 				if testCase.InputJson != nil && testCase.InputJson() != nil {
-					r = httptest.NewRequest(tt.Method, "http://test", bytes.NewBuffer(*testCase.InputJson()))
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, bytes.NewBuffer(*testCase.InputJson()))
 				} else {
-					r = httptest.NewRequest(tt.Method, "http://test", nil)
+					r = httptest.NewRequest(tt.Method, "http://test"+testCase.Query, nil)
+				}
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
 				}
 				err := errormiddleware.Middleware(tt.Handler)(w, r)
 				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
@@ -50,32 +50,76 @@ func (mr *MockServiceMockRecorder) AddGenre(ctx, genre interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGenre", reflect.TypeOf((*MockService)(nil).AddGenre), ctx, genre)
 }
 
+// DeleteGenre mocks base method.
+func (m *MockService) DeleteGenre(ctx context.Context, id string, cascade bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGenre", ctx, id, cascade)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGenre indicates an expected call of DeleteGenre.
+func (mr *MockServiceMockRecorder) DeleteGenre(ctx, id, cascade interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGenre", reflect.TypeOf((*MockService)(nil).DeleteGenre), ctx, id, cascade)
+}
+
 // GetAllGenres mocks base method.
-func (m *MockService) GetAllGenres(ctx context.Context) ([]*client.Genre, error) {
+func (m *MockService) GetAllGenres(ctx context.Context, cursor string, limit int, counts bool) (*client.GenrePage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllGenres", ctx)
-	ret0, _ := ret[0].([]*client.Genre)
+	ret := m.ctrl.Call(m, "GetAllGenres", ctx, cursor, limit, counts)
+	ret0, _ := ret[0].(*client.GenrePage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllGenres indicates an expected call of GetAllGenres.
-func (mr *MockServiceMockRecorder) GetAllGenres(ctx interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetAllGenres(ctx, cursor, limit, counts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGenres", reflect.TypeOf((*MockService)(nil).GetAllGenres), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllGenres", reflect.TypeOf((*MockService)(nil).GetAllGenres), ctx, cursor, limit, counts)
 }
 
 // GetGenre mocks base method.
-func (m *MockService) GetGenre(ctx context.Context, id string) ([]*client.Genre, error) {
+func (m *MockService) GetGenre(ctx context.Context, id string, counts bool) ([]*client.Genre, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetGenre", ctx, id)
+	ret := m.ctrl.Call(m, "GetGenre", ctx, id, counts)
 	ret0, _ := ret[0].([]*client.Genre)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetGenre indicates an expected call of GetGenre.
-func (mr *MockServiceMockRecorder) GetGenre(ctx, id interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetGenre(ctx, id, counts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenre", reflect.TypeOf((*MockService)(nil).GetGenre), ctx, id, counts)
+}
+
+// GetGenreTree mocks base method.
+func (m *MockService) GetGenreTree(ctx context.Context, counts bool) (*client.GenrePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGenreTree", ctx, counts)
+	ret0, _ := ret[0].(*client.GenrePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGenreTree indicates an expected call of GetGenreTree.
+func (mr *MockServiceMockRecorder) GetGenreTree(ctx, counts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenreTree", reflect.TypeOf((*MockService)(nil).GetGenreTree), ctx, counts)
+}
+
+// UpdateGenre mocks base method.
+func (m *MockService) UpdateGenre(ctx context.Context, id string, query *client.UpdateGenreQuery) (*client.Genre, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGenre", ctx, id, query)
+	ret0, _ := ret[0].(*client.Genre)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateGenre indicates an expected call of UpdateGenre.
+func (mr *MockServiceMockRecorder) UpdateGenre(ctx, id, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGenre", reflect.TypeOf((*MockService)(nil).GetGenre), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGenre", reflect.TypeOf((*MockService)(nil).UpdateGenre), ctx, id, query)
 }
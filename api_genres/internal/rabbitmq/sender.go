@@ -0,0 +1,54 @@
+package rabbitmq
+
+import (
+	"context"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
+)
+
+type Sender struct {
+	connection *amqp.Connection
+	logger     *logging.Logger
+}
+
+func NewSender(connection *amqp.Connection, logger *logging.Logger) *Sender {
+	return &Sender{connection: connection, logger: logger}
+}
+func (s *Sender) Close() error {
+	return nil
+}
+func (s *Sender) SendGenreChangedMessage(ctx context.Context, genreId string) error {
+	return s.publish(ctx, "GenreChangedExchange", genreId)
+}
+func (s *Sender) SendGenreDeletedMessage(ctx context.Context, genreId string) error {
+	return s.publish(ctx, "GenreDeletedExchange", genreId)
+}
+
+// consumers bind their own queues to the exchange, so nothing is kept when nobody listens
+func (s *Sender) publish(ctx context.Context, exchange, genreId string) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare(exchange, "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err = ch.PublishWithContext(cntx, exchange, "#", false, false, amqp.Publishing{
+		ContentType: "text/plain",
+		Body:        []byte(genreId),
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending %s message: %v", exchange, err)
+		return err
+	}
+	s.logger.Infof("Sended genre (%s) message to %s", genreId, exchange)
+	return nil
+}
@@ -0,0 +1,90 @@
+package cursor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type item struct {
+	Id   primitive.ObjectID
+	Name string
+}
+
+func key(i *item) (interface{}, primitive.ObjectID) {
+	return i.Name, i.Id
+}
+func TestEncodeDecode(t *testing.T) {
+	c := &Cursor{Field: "name", Value: "Book", Id: primitive.NewObjectID(), Backward: true}
+
+	decoded, err := Decode(c.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, c, decoded)
+
+	_, err = Decode("not a cursor")
+	assert.Error(t, err)
+	_, err = Decode((&Cursor{Field: "name"}).Encode())
+	assert.Error(t, err)
+}
+func TestFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+
+	c := &Cursor{Field: "year", Value: 2000, Id: id}
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"year": bson.M{"$gt": 2000}},
+		bson.M{"year": 2000, "_id": bson.M{"$gt": id}},
+	}}, c.Filter(true))
+	assert.Equal(t, 1, c.Direction(true))
+
+	c.Backward = true
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"year": bson.M{"$lt": 2000}},
+		bson.M{"year": 2000, "_id": bson.M{"$lt": id}},
+	}}, c.Filter(true))
+	assert.Equal(t, -1, c.Direction(true))
+
+	var empty *Cursor
+	assert.Equal(t, -1, empty.Direction(false))
+}
+func TestPage(t *testing.T) {
+	items := []*item{
+		{Id: primitive.NewObjectID(), Name: "a"},
+		{Id: primitive.NewObjectID(), Name: "b"},
+		{Id: primitive.NewObjectID(), Name: "c"},
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		page, next, prev := Page(append([]*item{}, items...), 2, "name", nil, false, key)
+		assert.Equal(t, items[:2], page)
+		assert.Empty(t, prev)
+
+		c, err := Decode(next)
+		assert.NoError(t, err)
+		assert.Equal(t, &Cursor{Field: "name", Value: "b", Id: items[1].Id}, c)
+	})
+	t.Run("last page", func(t *testing.T) {
+		page, next, prev := Page(append([]*item{}, items[2:]...), 2, "name", &Cursor{Field: "name", Value: "b", Id: items[1].Id}, false, key)
+		assert.Equal(t, items[2:], page)
+		assert.Empty(t, next)
+
+		c, err := Decode(prev)
+		assert.NoError(t, err)
+		assert.Equal(t, &Cursor{Field: "name", Value: "c", Id: items[2].Id, Backward: true}, c)
+	})
+	t.Run("backward page", func(t *testing.T) {
+		// backward pages are fetched in reversed order
+		fetched := []*item{items[1], items[0]}
+		page, next, prev := Page(fetched, 2, "name", &Cursor{Field: "name", Value: "c", Id: items[2].Id, Backward: true}, false, key)
+		assert.Equal(t, items[:2], page)
+		assert.Empty(t, prev)
+		assert.NotEmpty(t, next)
+	})
+	t.Run("empty page", func(t *testing.T) {
+		page, next, prev := Page([]*item{}, 2, "name", nil, true, key)
+		assert.Empty(t, page)
+		assert.Empty(t, next)
+		assert.Empty(t, prev)
+	})
+}
@@ -34,6 +34,7 @@ var errorsCases = []struct {
 	{"Unauthorized error test", UnauthorizedError([]string{""}, ""), "IE-0005"},
 	{"Not unique error test", NotUniqueError([]string{""}, ""), "IE-0006"},
 	{"Forbidden error test", ForbiddenError([]string{""}, ""), "IE-0007"},
+	{"Too large error test", TooLargeError([]string{""}, ""), "IE-0008"},
 }
 
 func TestErrorCodes(t *testing.T) {
@@ -30,6 +30,8 @@ func Middleware(h Handler) Handler {
 					w.WriteHeader(http.StatusConflict)
 				case ForbiddenErrorCode:
 					w.WriteHeader(http.StatusForbidden)
+				case TooLargeErrorCode:
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
 				default:
 					w.WriteHeader(http.StatusBadRequest)
 				}
@@ -0,0 +1,213 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_genres/pkg/logging"
+)
+
+const (
+	HeaderService   = "X-Service"
+	HeaderUser      = "User"
+	HeaderRoles     = "X-User-Roles"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderSignature = "X-Signature"
+	HeaderNonce     = "X-Nonce"
+	HeaderDigest    = "X-Content-SHA256"
+
+	max_clock_skew = 30 * time.Second
+)
+
+var (
+	ErrNotSigned        = errors.New("request is not signed")
+	ErrExpiredSignature = errors.New("request signature is expired")
+	ErrWrongSignature   = errors.New("request signature is wrong")
+	ErrReplayedRequest  = errors.New("request signature was already used")
+)
+
+type key string
+
+const callerKey key = "caller"
+
+// Caller is the service that sent the request and the user it was sent on behalf of.
+// User is empty for requests that services send on their own
+type Caller struct {
+	Service string
+	UserId  string
+	Roles   []string
+}
+
+// Internal requests are signed with HMAC of shared secret. Signature covers method, uri, body,
+// time of signing, random nonce and caller's identity, so request can't be changed.
+// Signed request is valid for max_clock_skew, Middleware accepts every signature only once in that time
+type Signer struct {
+	Service string
+	Secret  string
+}
+
+func (s *Signer) Sign(r *http.Request, userId string, roles []string) error {
+	digest, err := bodyDigest(r)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	r.Header.Set(HeaderService, s.Service)
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, hex.EncodeToString(nonce))
+	r.Header.Set(HeaderDigest, digest)
+	r.Header.Del(HeaderUser)
+	r.Header.Del(HeaderRoles)
+	if len(userId) > 0 {
+		r.Header.Set(HeaderUser, userId)
+		r.Header.Set(HeaderRoles, strings.Join(roles, ","))
+	}
+	r.Header.Set(HeaderSignature, sign(s.Secret, r.Method, r.URL.RequestURI(), timestamp, r.Header.Get(HeaderNonce), digest, s.Service, userId, strings.Join(roles, ",")))
+	return nil
+}
+
+// Verifies request's signature. Body is read only after headers are authenticated, no more than maxBody bytes,
+// to check it's digest and replaced with a copy
+func Verify(r *http.Request, secret string, maxBody int64) (*Caller, error) {
+	signature := r.Header.Get(HeaderSignature)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	if len(signature) == 0 || len(timestamp) == 0 || len(nonce) == 0 {
+		return nil, ErrNotSigned
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrWrongSignature
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > max_clock_skew || skew < -max_clock_skew {
+		return nil, ErrExpiredSignature
+	}
+	digest := r.Header.Get(HeaderDigest)
+	caller := &Caller{Service: r.Header.Get(HeaderService), UserId: r.Header.Get(HeaderUser)}
+	roles := r.Header.Get(HeaderRoles)
+	expected := sign(secret, r.Method, r.URL.RequestURI(), timestamp, nonce, digest, caller.Service, caller.UserId, roles)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrWrongSignature
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(nil, r.Body, maxBody)
+	}
+	actual, err := bodyDigest(r)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(digest), []byte(actual)) {
+		return nil, ErrWrongSignature
+	}
+	if len(roles) > 0 {
+		caller.Roles = strings.Split(roles, ",")
+	}
+	return caller, nil
+}
+func sign(secret string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Body is read fully and replaced, so it can be read again by client or handler
+func bodyDigest(r *http.Request) (string, error) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Remembers used signatures until they expire
+type replayGuard struct {
+	sync.Mutex
+	used    map[string]time.Time
+	cleaned time.Time
+}
+
+func newReplayGuard() *replayGuard {
+	return &replayGuard{used: make(map[string]time.Time)}
+}
+
+// Returns false if signature was already used
+func (g *replayGuard) use(signature string) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	now := time.Now()
+	if now.Sub(g.cleaned) > max_clock_skew {
+		for used, expires := range g.used {
+			if now.After(expires) {
+				delete(g.used, used)
+			}
+		}
+		g.cleaned = now
+	}
+	if _, ok := g.used[signature]; ok {
+		return false
+	}
+	// timestamp can be ahead of local time by skew, so signature is kept for both sides of it
+	g.used[signature] = now.Add(2 * max_clock_skew)
+	return true
+}
+
+// Rejects requests that are not signed by other services, caller is added to request context.
+// Bodies larger than maxBody bytes are rejected
+func Middleware(h http.Handler, secret string, maxBody int64, logger *logging.Logger) http.Handler {
+	guard := newReplayGuard()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, err := Verify(r, secret, maxBody)
+		if err == nil && !guard.use(r.Header.Get(HeaderSignature)) {
+			err = ErrReplayedRequest
+		}
+		if err != nil {
+			logger.Warnf("rejected request %s %s from %s: %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				w.Write(errormiddleware.TooLargeError([]string{fmt.Sprintf("request can't be larger than %d bytes", tooLarge.Limit)}, err.Error()).Marshall())
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write(errormiddleware.UnauthorizedError([]string{"request is not authenticated"}, err.Error()).Marshall())
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), caller)))
+	})
+}
+func NewContext(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey, caller)
+}
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, ok := ctx.Value(callerKey).(*Caller)
+	if !ok {
+		return &Caller{}
+	}
+	return caller
+}
@@ -0,0 +1,57 @@
+package slug
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	format = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+	// Cyrillic letters are transliterated, so russian genre names get readable slugs
+	cyrillic = map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+		'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+		'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+		'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+		'я': "ya",
+	}
+)
+
+// Builds URL-safe slug from the text. Every character that is not a latin letter or digit
+// separates words, apostrophes are dropped. Result is empty if text has no usable characters
+func Make(text string) string {
+	var result strings.Builder
+	separate := false
+	for _, r := range strings.ToLower(text) {
+		var part string
+		switch {
+		case r == '\'' || r == '’':
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		default:
+			transliterated, ok := cyrillic[r]
+			if !ok {
+				separate = true
+				continue
+			}
+			part = transliterated
+		}
+		if len(part) == 0 {
+			continue
+		}
+		if separate && result.Len() > 0 {
+			result.WriteByte('-')
+		}
+		separate = false
+		result.WriteString(part)
+	}
+	return result.String()
+}
+
+// Slug must contain only lowercase latin letters and digits, separated by single dashes
+func Valid(slug string) bool {
+	return format.MatchString(slug)
+}
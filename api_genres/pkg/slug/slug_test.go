@@ -0,0 +1,41 @@
+package slug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	var table = []struct {
+		Text     string
+		Excepted string
+	}{
+		{"Fairy tales", "fairy-tales"},
+		{"Children's literature", "childrens-literature"},
+		{"  Sci-Fi & Fantasy!  ", "sci-fi-fantasy"},
+		{"Детские книги", "detskie-knigi"},
+		{"Бизнес-менеджмент", "biznes-menedzhment"},
+		{"Объявления", "obyavleniya"},
+		{"XX век", "xx-vek"},
+		{"!!!", ""},
+	}
+	for _, tt := range table {
+		t.Run(tt.Text, func(t *testing.T) {
+			slug := Make(tt.Text)
+			assert.Equal(t, tt.Excepted, slug)
+			if len(slug) > 0 {
+				assert.True(t, Valid(slug))
+			}
+		})
+	}
+}
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("fairy-tales"))
+	assert.True(t, Valid("genre2"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("Fairy-tales"))
+	assert.False(t, Valid("fairy--tales"))
+	assert.False(t, Valid("-fairy"))
+	assert.False(t, Valid("сказки"))
+}
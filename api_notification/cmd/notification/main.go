@@ -16,12 +16,14 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client/db"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/config"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/handlers/notification"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/rabbitmq/receivers"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/cache/freecache"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/mongo"
 	rabbitClient "github.com/reversersed/go-web-services/tree/main/api_notification/pkg/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/shutdown"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/signature"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/validator"
 )
 
@@ -47,15 +49,17 @@ func main() {
 	logger.Info("validator initializing...")
 	validator := validator.New()
 
-	logger.Info("services initializing...")
-	storage := db.NewStorage(db_client, config.Database.Db_Base, logger)
-	service := client.NewService(storage, logger, cache, validator, config.Urls)
-
 	logger.Info("rabbitmq initializing...")
 	rabbit, err := rabbitClient.New(config.Rabbit, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	rabbitSender := rabbitmq.NewSender(rabbit.Connection, logger)
+
+	logger.Info("services initializing...")
+	storage := db.NewStorage(db_client, config.Database.Db_Base, config.Database.Db_Retention, logger)
+	service := client.NewService(storage, rabbitSender, logger, cache, validator, config.Urls, &signature.Signer{Service: config.Signature.Service, Secret: config.Signature.Secret})
+
 	notifReceiver := receivers.NewNotificationReceiver(rabbit.Connection, validator, logger, service)
 	notifReceiver.Start()
 
@@ -65,14 +69,20 @@ func main() {
 	userLoginChangedReceiver := receivers.NewUserLoginChangedReceiver(rabbit.Connection, validator, logger, service)
 	userLoginChangedReceiver.Start()
 
+	userPasswordChangedReceiver := receivers.NewUserPasswordChangedReceiver(rabbit.Connection, validator, logger, service)
+	userPasswordChangedReceiver.Start()
+
+	userEmailChangedReceiver := receivers.NewUserEmailChangedReceiver(rabbit.Connection, validator, logger, service)
+	userEmailChangedReceiver.Start()
+
 	logger.Info("handlers registration...")
 	handler := notification.Handler{Service: service, Logger: logger, Validator: validator}
 	handler.Register(router)
 
 	logger.Info("starting application...")
-	start(router, logger, config.Server, rabbit, notifReceiver, userDeletedReceiver, userLoginChangedReceiver)
+	start(signature.Middleware(router, config.Signature.Secret, config.Signature.MaxBodySize, logger), logger, config.Server, rabbit, rabbitSender, notifReceiver, userDeletedReceiver, userLoginChangedReceiver, userPasswordChangedReceiver, userEmailChangedReceiver)
 }
-func start(router *httprouter.Router, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
+func start(router http.Handler, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
 	var server *http.Server
 	var listener net.Listener
 
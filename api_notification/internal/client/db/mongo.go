@@ -2,51 +2,172 @@ package db
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type db struct {
-	sync.RWMutex
-	collection *mongo.Collection
-	logger     *logging.Logger
+	collection    *mongo.Collection
+	notifications *mongo.Collection
+	retention     time.Duration
+	logger        *logging.Logger
 }
 
-func NewStorage(storage *mongo.Database, collection string, logger *logging.Logger) client.Storage {
+func NewStorage(storage *mongo.Database, collection string, retention time.Duration, logger *logging.Logger) client.Storage {
 	db := &db{
-		collection: storage.Collection(collection),
-		logger:     logger,
+		collection:    storage.Collection(collection),
+		notifications: storage.Collection("notifications"),
+		retention:     retention,
+		logger:        logger,
 	}
+	db.createUserIndexes()
+	db.createNotificationIndexes()
+	db.migrateEmbeddedNotifications()
 	return db
 }
+func (d *db) createUserIndexes() {
+	model := mongo.IndexModel{Keys: bson.D{{Key: "id", Value: 1}}, Options: options.Index().SetName("id").SetUnique(true)}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	name, err := d.collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		d.logger.Errorf("can't create user index: %v", err)
+		return
+	}
+	d.logger.Infof("created user index %s", name)
+}
+
+// Notifications are always requested by user from newest to oldest.
+// Expiration time is stored in every document, so changed retention is applied to new notifications only
+func (d *db) createNotificationIndexes() {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "sended", Value: -1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("userid_sended")},
+		{Keys: bson.D{{Key: "userid", Value: 1}, {Key: "read", Value: 1}}, Options: options.Index().SetName("userid_read")},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(0)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	names, err := d.notifications.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		d.logger.Errorf("can't create notification indexes: %v", err)
+		return
+	}
+	d.logger.Infof("created notification indexes %v", names)
+}
+
+// Moves notifications embedded in user documents to notifications collection.
+// Array is removed only after its notifications are inserted, so interrupted migration continues on next start.
+// Every instance runs migration on start, so notifications get the same ids everywhere and are inserted only once
+func (d *db) migrateEmbeddedNotifications() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := d.collection.Find(ctx, bson.M{"notifications": bson.M{"$exists": true}})
+	if err != nil {
+		d.logger.Errorf("can't find users to migrate notifications: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	type embedded struct {
+		Id            primitive.ObjectID     `bson:"id"`
+		Notifications []*client.Notification `bson:"notifications"`
+	}
+	var migrated, users int
+	for cursor.Next(ctx) {
+		var user embedded
+		if err := cursor.Decode(&user); err != nil {
+			d.logger.Errorf("can't decode user while migrating notifications: %v", err)
+			continue
+		}
+		documents := make([]interface{}, 0, len(user.Notifications))
+		for i, notif := range user.Notifications {
+			if notif.Id.IsZero() {
+				notif.Id = embeddedNotificationId(user.Id, i, notif.Sended)
+			}
+			notif.UserId = user.Id
+			notif.ExpiresAt = time.Unix(int64(notif.Sended.T), 0).UTC().Add(d.retention)
+			documents = append(documents, notif)
+		}
+		if len(documents) > 0 {
+			_, err := d.notifications.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+			if err != nil && !isOnlyDuplicateKeyError(err) {
+				d.logger.Errorf("can't migrate notifications of user %s: %v", user.Id.Hex(), err)
+				continue
+			}
+		}
+		if _, err := d.collection.UpdateOne(ctx, bson.M{"id": user.Id}, bson.M{"$unset": bson.M{"notifications": ""}}); err != nil {
+			d.logger.Errorf("can't remove migrated notifications of user %s: %v", user.Id.Hex(), err)
+			continue
+		}
+		migrated += len(documents)
+		users++
+	}
+	if err := cursor.Err(); err != nil {
+		d.logger.Errorf("notifications migration stopped: %v", err)
+	}
+	if users > 0 {
+		d.logger.Infof("migrated %d notifications of %d users", migrated, users)
+	}
+}
+
+// Id of embedded notification without one is made from user id and its position in array.
+// It starts with sending time like any object id, so notifications are still ordered by it
+func embeddedNotificationId(user primitive.ObjectID, index int, sended primitive.Timestamp) primitive.ObjectID {
+	var position [4]byte
+	binary.BigEndian.PutUint32(position[:], uint32(index))
+	hash := sha256.Sum256(append(user[:], position[:]...))
+
+	var id primitive.ObjectID
+	binary.BigEndian.PutUint32(id[:4], sended.T)
+	copy(id[4:], hash[:8])
+	return id
+}
+
+// Notifications inserted by another instance are skipped, any other write error fails the insert
+func isOnlyDuplicateKeyError(err error) bool {
+	var bulk mongo.BulkWriteException
+	if !errors.As(err, &bulk) || bulk.WriteConcernError != nil || len(bulk.WriteErrors) == 0 {
+		return false
+	}
+	for _, e := range bulk.WriteErrors {
+		if e.Code != 11000 {
+			return false
+		}
+	}
+	return true
+}
+
+// User can be created concurrently by several notifications, existing user is not an error
 func (d *db) CreateUser(ctx context.Context, user_id, login string) error {
-	d.Lock()
-	defer d.Unlock()
 	id, err := primitive.ObjectIDFromHex(user_id)
 	if err != nil {
 		return err
 	}
 	user := &client.User{
-		Id:            id,
-		Login:         login,
-		Notifications: []*client.Notification{},
+		Id:    id,
+		Login: login,
 	}
 	_, err = d.collection.InsertOne(ctx, user)
-	if err != nil {
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
 		return err
 	}
 	return nil
 }
 func (d *db) IsUserExists(ctx context.Context, user_id string) (bool, error) {
-	d.RLock()
-	defer d.RUnlock()
 	id, err := primitive.ObjectIDFromHex(user_id)
 	if err != nil {
 		return false, err
@@ -54,44 +175,32 @@ func (d *db) IsUserExists(ctx context.Context, user_id string) (bool, error) {
 	filter := bson.M{"id": id}
 	result := d.collection.FindOne(ctx, filter)
 	if err = result.Err(); err != nil {
-		return false, nil
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
 	}
 	return true, nil
 }
 func (d *db) SendNotification(ctx context.Context, notif *client.Notification, user_id string) error {
-	d.Lock()
-	defer d.Unlock()
 	id, err := primitive.ObjectIDFromHex(user_id)
 	if err != nil {
 		return err
 	}
-	filter := bson.M{"id": id}
-	result := d.collection.FindOne(ctx, filter)
-	var u client.User
-	if err = result.Err(); err != nil {
-		return fmt.Errorf("user does not exist: %v", err)
-	}
-	err = result.Decode(u)
-	if err != nil {
-		return err
-	}
-	notif.Sended = primitive.Timestamp{T: uint32(time.Now().UTC().Unix()), I: 0}
+	now := time.Now().UTC()
 
-	u.Notifications = append([]*client.Notification{notif}, u.Notifications...)
+	notif.Id = primitive.NewObjectID()
+	notif.UserId = id
+	notif.Sended = primitive.Timestamp{T: uint32(now.Unix()), I: 0}
+	notif.ExpiresAt = now.Add(d.retention)
 
-	upd_result, err := d.collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"notifications": u.Notifications}})
+	_, err = d.notifications.InsertOne(ctx, notif)
 	if err != nil {
 		return err
 	}
-	if upd_result.MatchedCount == 0 || upd_result.ModifiedCount == 0 {
-		return fmt.Errorf("user has not been updated")
-	}
 	return nil
 }
 func (d *db) DeleteUser(ctx context.Context, user_id string) error {
-	d.Lock()
-	defer d.Unlock()
-
 	id, err := primitive.ObjectIDFromHex(user_id)
 	if err != nil {
 		return err
@@ -101,13 +210,14 @@ func (d *db) DeleteUser(ctx context.Context, user_id string) error {
 	if err != nil {
 		return err
 	}
-	d.logger.Warnf("deleted %d users with id %s", result.DeletedCount, user_id)
+	notifications, err := d.notifications.DeleteMany(ctx, bson.M{"userid": id})
+	if err != nil {
+		return err
+	}
+	d.logger.Warnf("deleted %d users with id %s and %d their notifications", result.DeletedCount, user_id, notifications.DeletedCount)
 	return nil
 }
 func (d *db) ChangeUserLogin(ctx context.Context, user_id string, newLogin string) error {
-	d.Lock()
-	defer d.Unlock()
-
 	id, err := primitive.ObjectIDFromHex(user_id)
 	if err != nil {
 		return err
@@ -121,3 +231,101 @@ func (d *db) ChangeUserLogin(ctx context.Context, user_id string, newLogin strin
 	d.logger.Infof("user's login changed to %s (%s)", newLogin, user_id)
 	return nil
 }
+func (d *db) GetNotifications(ctx context.Context, user_id string, query *client.GetNotificationsQuery) (*client.NotificationPage, error) {
+	id, err := primitive.ObjectIDFromHex(user_id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"userid": id}
+	if len(query.Type) > 0 {
+		filter["type"] = query.Type
+	}
+	if query.Unread {
+		filter["read"] = false
+	}
+	if len(query.After) > 0 {
+		after, err := primitive.ObjectIDFromHex(query.After)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	total, err := d.notifications.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := d.notifications.CountDocuments(ctx, bson.M{"userid": id, "read": false})
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sended", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit))
+	cursor, err := d.notifications.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &client.NotificationPage{Items: []*client.Notification{}, Total: total, Unread: unread}
+	if err = cursor.All(ctx, &page.Items); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+func (d *db) CountUnread(ctx context.Context, user_id string) (int64, error) {
+	id, err := primitive.ObjectIDFromHex(user_id)
+	if err != nil {
+		return 0, err
+	}
+	return d.notifications.CountDocuments(ctx, bson.M{"userid": id, "read": false})
+}
+func (d *db) MarkRead(ctx context.Context, user_id, notif_id string) error {
+	id, err := primitive.ObjectIDFromHex(user_id)
+	if err != nil {
+		return err
+	}
+	nid, err := primitive.ObjectIDFromHex(notif_id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": nid, "userid": id}
+	result, err := d.notifications.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"notification with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) MarkAllRead(ctx context.Context, user_id string) error {
+	id, err := primitive.ObjectIDFromHex(user_id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"userid": id, "read": false}
+	_, err = d.notifications.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"read": true}})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (d *db) DeleteNotification(ctx context.Context, user_id, notif_id string) error {
+	id, err := primitive.ObjectIDFromHex(user_id)
+	if err != nil {
+		return err
+	}
+	nid, err := primitive.ObjectIDFromHex(notif_id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": nid, "userid": id}
+	result, err := d.notifications.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"notification with provided id not found"}, fmt.Sprintf("deleted count was == %d", result.DeletedCount))
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/errormiddleware"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+	mongoclient "github.com/reversersed/go-web-services/tree/main/api_notification/pkg/mongo"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var dbCfg *config.DbConfig
+var logger *logging.Logger
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	nullLogger, _ := test.NewNullLogger()
+	logger = &logging.Logger{Entry: logrus.NewEntry(nullLogger)}
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+
+	req := testcontainers.ContainerRequest{
+		Image:        "mongo:7.0",
+		ExposedPorts: []string{"27017/tcp"},
+		WaitingFor:   wait.ForLog("Waiting for connections"),
+	}
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	port, err := container.MappedPort(ctx, "27017")
+	if err != nil {
+		log.Fatal(err)
+	}
+	host, err := container.Host(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dbCfg = &config.DbConfig{Db_Host: host, Db_Base: "notifications"}
+	dbCfg.Db_Port, _ = strconv.Atoi(port.Port())
+
+	code := m.Run()
+	if err := container.Terminate(ctx); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(code)
+}
+
+// Every test gets it's own database, so documents of other tests are not counted
+func newDatabase(t *testing.T) *mongo.Database {
+	if testing.Short() {
+		t.Skip("integration tests are not run in short mode")
+	}
+	cfg := *dbCfg
+	cfg.Db_Base = "test_" + primitive.NewObjectID().Hex()
+	database, err := mongoclient.NewClient(context.Background(), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Drop(context.Background())
+		database.Client().Disconnect(context.Background())
+	})
+	return database
+}
+func sendNotifications(t *testing.T, storage client.Storage, userId string, count int) []*client.Notification {
+	notifications := make([]*client.Notification, 0, count)
+	for i := 0; i < count; i++ {
+		notif := &client.Notification{Content: "notification " + strconv.Itoa(i), Type: client.Info}
+		if !assert.NoError(t, storage.SendNotification(context.Background(), notif, userId)) {
+			t.FailNow()
+		}
+		notifications = append(notifications, notif)
+	}
+	return notifications
+}
+
+func TestSendNotification(t *testing.T) {
+	database := newDatabase(t)
+	storage := NewStorage(database, "users", time.Hour, logger)
+	userId := primitive.NewObjectID()
+
+	notif := &client.Notification{Content: "hello world", Type: client.Warning}
+	err := storage.SendNotification(context.Background(), notif, userId.Hex())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, notif.Id.IsZero())
+
+	var stored client.Notification
+	err = database.Collection("notifications").FindOne(context.Background(), bson.M{"_id": notif.Id}).Decode(&stored)
+	if assert.NoError(t, err) {
+		assert.Equal(t, userId, stored.UserId)
+		assert.Equal(t, "hello world", stored.Content)
+		assert.Equal(t, client.Warning, stored.Type)
+		assert.False(t, stored.Read)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+	}
+
+	err = storage.SendNotification(context.Background(), &client.Notification{}, "wrong id")
+	assert.Error(t, err)
+}
+func TestGetNotifications(t *testing.T) {
+	storage := NewStorage(newDatabase(t), "users", time.Hour, logger)
+	userId := primitive.NewObjectID().Hex()
+	sended := sendNotifications(t, storage, userId, 5)
+	sendNotifications(t, storage, primitive.NewObjectID().Hex(), 2)
+
+	page, err := storage.GetNotifications(context.Background(), userId, &client.GetNotificationsQuery{Limit: 3})
+	if assert.NoError(t, err) && assert.Len(t, page.Items, 3) {
+		assert.EqualValues(t, 5, page.Total)
+		assert.EqualValues(t, 5, page.Unread)
+		// newest notifications are first
+		assert.Equal(t, sended[4].Id, page.Items[0].Id)
+		assert.Equal(t, sended[2].Id, page.Items[2].Id)
+	}
+
+	page, err = storage.GetNotifications(context.Background(), userId, &client.GetNotificationsQuery{Offset: 3, Limit: 3})
+	if assert.NoError(t, err) && assert.Len(t, page.Items, 2) {
+		assert.Equal(t, sended[1].Id, page.Items[0].Id)
+		assert.Equal(t, sended[0].Id, page.Items[1].Id)
+	}
+
+	page, err = storage.GetNotifications(context.Background(), userId, &client.GetNotificationsQuery{After: sended[2].Id.Hex(), Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, page.Items, 2) {
+		assert.EqualValues(t, 2, page.Total)
+		assert.Equal(t, sended[4].Id, page.Items[0].Id)
+		assert.Equal(t, sended[3].Id, page.Items[1].Id)
+	}
+
+	page, err = storage.GetNotifications(context.Background(), userId, &client.GetNotificationsQuery{Type: client.Security, Limit: 10})
+	if assert.NoError(t, err) {
+		assert.Empty(t, page.Items)
+		assert.EqualValues(t, 0, page.Total)
+	}
+}
+func TestMarkRead(t *testing.T) {
+	storage := NewStorage(newDatabase(t), "users", time.Hour, logger)
+	userId := primitive.NewObjectID().Hex()
+	sended := sendNotifications(t, storage, userId, 3)
+
+	err := storage.MarkRead(context.Background(), userId, sended[0].Id.Hex())
+	if !assert.NoError(t, err) {
+		return
+	}
+	count, err := storage.CountUnread(context.Background(), userId)
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, 2, count)
+	}
+	page, err := storage.GetNotifications(context.Background(), userId, &client.GetNotificationsQuery{Unread: true, Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, page.Items, 2) {
+		assert.NotEqual(t, sended[0].Id, page.Items[0].Id)
+		assert.NotEqual(t, sended[0].Id, page.Items[1].Id)
+	}
+
+	// notification of another user can't be read
+	err = storage.MarkRead(context.Background(), primitive.NewObjectID().Hex(), sended[1].Id.Hex())
+	assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+
+	err = storage.MarkAllRead(context.Background(), userId)
+	if !assert.NoError(t, err) {
+		return
+	}
+	count, err = storage.CountUnread(context.Background(), userId)
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, 0, count)
+	}
+}
+func TestDeleteNotification(t *testing.T) {
+	storage := NewStorage(newDatabase(t), "users", time.Hour, logger)
+	userId := primitive.NewObjectID().Hex()
+	sended := sendNotifications(t, storage, userId, 2)
+
+	err := storage.DeleteNotification(context.Background(), primitive.NewObjectID().Hex(), sended[0].Id.Hex())
+	assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+
+	err = storage.DeleteNotification(context.Background(), userId, sended[0].Id.Hex())
+	if !assert.NoError(t, err) {
+		return
+	}
+	page, err := storage.GetNotifications(context.Background(), userId, &client.GetNotificationsQuery{Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, page.Items, 1) {
+		assert.Equal(t, sended[1].Id, page.Items[0].Id)
+	}
+
+	err = storage.DeleteNotification(context.Background(), userId, sended[0].Id.Hex())
+	assert.Equal(t, errormiddleware.NotFoundErrorCode, err.(*errormiddleware.Error).Code)
+}
+func TestMigrateEmbeddedNotifications(t *testing.T) {
+	database := newDatabase(t)
+	userId := primitive.NewObjectID()
+	withId := primitive.NewObjectID()
+	sended := primitive.Timestamp{T: uint32(time.Now().Unix())}
+	_, err := database.Collection("users").InsertOne(context.Background(), bson.M{
+		"id":    userId,
+		"login": "user",
+		"notifications": bson.A{
+			bson.M{"_id": withId, "sended": sended, "content": "first", "type": client.Info, "read": true},
+			bson.M{"sended": sended, "content": "second", "type": client.Warning, "read": false},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// migration that was interrupted by another instance has inserted one of notifications already
+	second := embeddedNotificationId(userId, 1, sended)
+	_, err = database.Collection("notifications").InsertOne(context.Background(), bson.M{"_id": second, "userid": userId, "sended": sended, "content": "second", "type": client.Warning})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	storage := NewStorage(database, "users", time.Hour, logger)
+	// every instance runs migration on start
+	NewStorage(database, "users", time.Hour, logger)
+
+	page, err := storage.GetNotifications(context.Background(), userId.Hex(), &client.GetNotificationsQuery{Limit: 10})
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, 2, page.Total)
+		assert.EqualValues(t, 1, page.Unread)
+	}
+	count, err := database.Collection("users").CountDocuments(context.Background(), bson.M{"notifications": bson.M{"$exists": true}})
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, 0, count)
+	}
+	exists, err := storage.IsUserExists(context.Background(), userId.Hex())
+	if assert.NoError(t, err) {
+		assert.True(t, exists)
+	}
+}
+func TestEmbeddedNotificationId(t *testing.T) {
+	user := primitive.NewObjectID()
+	sended := primitive.Timestamp{T: uint32(time.Now().Unix())}
+
+	id := embeddedNotificationId(user, 0, sended)
+	assert.Equal(t, id, embeddedNotificationId(user, 0, sended))
+	assert.NotEqual(t, id, embeddedNotificationId(user, 1, sended))
+	assert.NotEqual(t, id, embeddedNotificationId(primitive.NewObjectID(), 0, sended))
+	assert.Equal(t, int64(sended.T), id.Timestamp().Unix())
+}
+func TestIsOnlyDuplicateKeyError(t *testing.T) {
+	var table = []struct {
+		Name     string
+		Err      error
+		Excepted bool
+	}{
+		{"duplicates", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 11000}}, {WriteError: mongo.WriteError{Code: 11000}}}}, true},
+		{"duplicate and other error", mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 11000}}, {WriteError: mongo.WriteError{Code: 121}}}}, false},
+		{"write concern error", mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{Code: 64}, WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 11000}}}}, false},
+		{"no write errors", mongo.BulkWriteException{}, false},
+		{"not bulk error", errors.New("connection refused"), false},
+	}
+	for _, tt := range table {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Excepted, isOnlyDuplicateKeyError(tt.Err))
+		})
+	}
+}
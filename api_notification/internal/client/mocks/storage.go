@@ -49,6 +49,21 @@ func (mr *MockStorageMockRecorder) ChangeUserLogin(ctx, user_id, newLogin interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUserLogin", reflect.TypeOf((*MockStorage)(nil).ChangeUserLogin), ctx, user_id, newLogin)
 }
 
+// CountUnread mocks base method.
+func (m *MockStorage) CountUnread(ctx context.Context, user_id string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", ctx, user_id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread.
+func (mr *MockStorageMockRecorder) CountUnread(ctx, user_id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockStorage)(nil).CountUnread), ctx, user_id)
+}
+
 // CreateUser mocks base method.
 func (m *MockStorage) CreateUser(ctx context.Context, user_id, login string) error {
 	m.ctrl.T.Helper()
@@ -63,6 +78,20 @@ func (mr *MockStorageMockRecorder) CreateUser(ctx, user_id, login interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), ctx, user_id, login)
 }
 
+// DeleteNotification mocks base method.
+func (m *MockStorage) DeleteNotification(ctx context.Context, user_id, notif_id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotification", ctx, user_id, notif_id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotification indicates an expected call of DeleteNotification.
+func (mr *MockStorageMockRecorder) DeleteNotification(ctx, user_id, notif_id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotification", reflect.TypeOf((*MockStorage)(nil).DeleteNotification), ctx, user_id, notif_id)
+}
+
 // DeleteUser mocks base method.
 func (m *MockStorage) DeleteUser(ctx context.Context, user_id string) error {
 	m.ctrl.T.Helper()
@@ -77,6 +106,21 @@ func (mr *MockStorageMockRecorder) DeleteUser(ctx, user_id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStorage)(nil).DeleteUser), ctx, user_id)
 }
 
+// GetNotifications mocks base method.
+func (m *MockStorage) GetNotifications(ctx context.Context, user_id string, query *client.GetNotificationsQuery) (*client.NotificationPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", ctx, user_id, query)
+	ret0, _ := ret[0].(*client.NotificationPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockStorageMockRecorder) GetNotifications(ctx, user_id, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockStorage)(nil).GetNotifications), ctx, user_id, query)
+}
+
 // IsUserExists mocks base method.
 func (m *MockStorage) IsUserExists(ctx context.Context, user_id string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -92,6 +136,34 @@ func (mr *MockStorageMockRecorder) IsUserExists(ctx, user_id interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserExists", reflect.TypeOf((*MockStorage)(nil).IsUserExists), ctx, user_id)
 }
 
+// MarkAllRead mocks base method.
+func (m *MockStorage) MarkAllRead(ctx context.Context, user_id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllRead", ctx, user_id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAllRead indicates an expected call of MarkAllRead.
+func (mr *MockStorageMockRecorder) MarkAllRead(ctx, user_id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllRead", reflect.TypeOf((*MockStorage)(nil).MarkAllRead), ctx, user_id)
+}
+
+// MarkRead mocks base method.
+func (m *MockStorage) MarkRead(ctx context.Context, user_id, notif_id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, user_id, notif_id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockStorageMockRecorder) MarkRead(ctx, user_id, notif_id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockStorage)(nil).MarkRead), ctx, user_id, notif_id)
+}
+
 // SendNotification mocks base method.
 func (m *MockStorage) SendNotification(ctx context.Context, notif *client.Notification, user_id string) error {
 	m.ctrl.T.Helper()
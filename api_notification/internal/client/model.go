@@ -1,6 +1,10 @@
 package client
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type NotificationType string
 
@@ -10,15 +14,34 @@ const (
 	Security NotificationType = "security"
 )
 
+// Every notification is a separate document, it's removed after expiresAt
 type Notification struct {
-	Sended  primitive.Timestamp `json:"sended,omitempty" bson:"sended"`
-	Content string              `json:"content" bson:"content"`
-	Type    NotificationType    `json:"type" bson:"type"`
+	Id        primitive.ObjectID  `json:"id" bson:"_id"`
+	UserId    primitive.ObjectID  `json:"-" bson:"userid"`
+	Sended    primitive.Timestamp `json:"sended,omitempty" bson:"sended"`
+	Content   string              `json:"content" bson:"content"`
+	Type      NotificationType    `json:"type" bson:"type"`
+	Read      bool                `json:"read" bson:"read"`
+	ExpiresAt time.Time           `json:"-" bson:"expiresAt"`
 }
 type User struct {
-	Id            primitive.ObjectID `json:"id" bson:"id"`
-	Login         string             `json:"login" bson:"login"`
-	Notifications []*Notification    `json:"notifications" bson:"notifications"`
+	Id    primitive.ObjectID `json:"id" bson:"id"`
+	Login string             `json:"login" bson:"login"`
+}
+
+type NotificationPage struct {
+	Items  []*Notification `json:"items" bson:"items"`
+	Total  int64           `json:"total" bson:"total"`
+	Unread int64           `json:"unread" bson:"unread"`
+}
+
+// After is used to get notifications that user missed, it's id of the last received one
+type GetNotificationsQuery struct {
+	After  string           `json:"after" validate:"omitempty,primitiveid"`
+	Type   NotificationType `json:"type" validate:"omitempty,oneof=info warn security"`
+	Unread bool             `json:"unread"`
+	Offset int              `json:"offset" validate:"gte=0"`
+	Limit  int              `json:"limit" validate:"gt=0,lte=100"`
 }
 
 type SendNotificationMessage struct {
@@ -30,3 +53,8 @@ type UserLoginChangedMessage struct {
 	UserId   string `json:"userid" validate:"required,primitiveid"`
 	NewLogin string `json:"newlogin" validate:"required"`
 }
+type UserEmailChangedMessage struct {
+	UserId   string `json:"userid" validate:"required,primitiveid"`
+	OldEmail string `json:"oldemail" validate:"required"`
+	NewEmail string `json:"newemail" validate:"required"`
+}
@@ -0,0 +1,10 @@
+package client
+
+import "context"
+
+//go:generate mockgen -source=publisher.go -destination=mocks/publisher.go
+
+// Stored notifications are published to connected users, gateway instances deliver them
+type Publisher interface {
+	PublishNotification(ctx context.Context, user_id string, notif *Notification) error
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -12,24 +13,28 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/rest"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/signature"
 	valid "github.com/reversersed/go-web-services/tree/main/api_notification/pkg/validator"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type service struct {
 	storage    Storage
+	publisher  Publisher
 	logger     *logging.Logger
 	cache      cache.Cache
 	validator  *valid.Validator
 	restClient *rest.RestClient
 }
 
-func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, validator *valid.Validator, cfg *config.UrlConfig) *service {
-	return &service{storage: storage, logger: logger, cache: cache, validator: validator, restClient: &rest.RestClient{
+func NewService(storage Storage, publisher Publisher, logger *logging.Logger, cache cache.Cache, validator *valid.Validator, cfg *config.UrlConfig, signer *signature.Signer) *service {
+	return &service{storage: storage, publisher: publisher, logger: logger, cache: cache, validator: validator, restClient: &rest.RestClient{
 		BaseURL: cfg.Url_User_Service,
 		HttpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 		Logger: logger,
+		Signer: signer,
 	},
 	}
 }
@@ -95,11 +100,16 @@ func (s *service) SendNotification(ctx context.Context, query *SendNotificationM
 			return
 		}
 	}
-	err = s.storage.SendNotification(cntx, &Notification{Content: query.Content, Type: query.Type}, query.UserId)
+	notif := &Notification{Content: query.Content, Type: query.Type}
+	err = s.storage.SendNotification(cntx, notif, query.UserId)
 	if err != nil {
 		s.logger.Errorf("Error sending notification: %v", err)
 		return
 	}
+	// notification is already stored, user gets it with the next request if it wasn't delivered
+	if err = s.publisher.PublishNotification(cntx, query.UserId, notif); err != nil {
+		s.logger.Errorf("Error publishing notification: %v", err)
+	}
 	s.cache.Set([]byte(query.UserId), []byte(""), int(time.Hour))
 	s.logger.Infof("Notification %s sended to user %s (Content: %s)", query.Type, query.UserId, query.Content)
 }
@@ -128,3 +138,64 @@ func (s *service) OnUserLoginChanged(ctx context.Context, query *UserLoginChange
 		return
 	}
 }
+func (s *service) OnUserPasswordChanged(ctx context.Context, userid string) {
+	s.SendNotification(ctx, &SendNotificationMessage{
+		UserId:  userid,
+		Content: "Your password has been changed. If it wasn't you, reset your password and check your active sessions",
+		Type:    Security,
+	})
+}
+func (s *service) OnUserEmailChanged(ctx context.Context, query *UserEmailChangedMessage) {
+	if err := s.validator.Struct(query); err != nil {
+		s.logger.Errorf("received wrong user email changed query: %v", errormiddleware.ValidationError(err.(validator.ValidationErrors), "").Error())
+		return
+	}
+	s.SendNotification(ctx, &SendNotificationMessage{
+		UserId:  query.UserId,
+		Content: fmt.Sprintf("Email change from %s to %s has been requested. If it wasn't you, change your password and check your active sessions", query.OldEmail, query.NewEmail),
+		Type:    Security,
+	})
+}
+func (s *service) GetNotifications(ctx context.Context, userId string, query *GetNotificationsQuery) (*NotificationPage, error) {
+	if err := s.validator.Struct(query); err != nil {
+		return nil, errormiddleware.ValidationError(err, "wrong notifications query")
+	}
+	if _, err := primitive.ObjectIDFromHex(userId); err != nil {
+		return nil, errormiddleware.BadRequestError([]string{"wrong user id"}, err.Error())
+	}
+	page, err := s.storage.GetNotifications(ctx, userId, query)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+func (s *service) CountUnread(ctx context.Context, userId string) (int64, error) {
+	if _, err := primitive.ObjectIDFromHex(userId); err != nil {
+		return 0, errormiddleware.BadRequestError([]string{"wrong user id"}, err.Error())
+	}
+	return s.storage.CountUnread(ctx, userId)
+}
+func (s *service) MarkRead(ctx context.Context, userId, id string) error {
+	if _, err := primitive.ObjectIDFromHex(userId); err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong user id"}, err.Error())
+	}
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong notification id"}, err.Error())
+	}
+	return s.storage.MarkRead(ctx, userId, id)
+}
+func (s *service) MarkAllRead(ctx context.Context, userId string) error {
+	if _, err := primitive.ObjectIDFromHex(userId); err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong user id"}, err.Error())
+	}
+	return s.storage.MarkAllRead(ctx, userId)
+}
+func (s *service) DeleteNotification(ctx context.Context, userId, id string) error {
+	if _, err := primitive.ObjectIDFromHex(userId); err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong user id"}, err.Error())
+	}
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return errormiddleware.BadRequestError([]string{"wrong notification id"}, err.Error())
+	}
+	return s.storage.DeleteNotification(ctx, userId, id)
+}
@@ -29,9 +29,10 @@ func TestSendNotification(t *testing.T) {
 	log, hook := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
 	cache := cache_mock.NewMockCache(ctrl)
-	service := client.NewService(storage, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: server.URL})
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: server.URL}, nil)
 
 	caseTable := []struct {
 		Name           string
@@ -153,6 +154,23 @@ func TestSendNotification(t *testing.T) {
 			MockBehaviour: func(s *mock.MockStorage, c *cache_mock.MockCache) {
 				c.EXPECT().Get([]byte("57bf425a34ce5ee85891b914")).Return([]byte(""), nil)
 				s.EXPECT().SendNotification(gomock.Any(), gomock.Any(), "57bf425a34ce5ee85891b914").Return(nil)
+				publisher.EXPECT().PublishNotification(gomock.Any(), "57bf425a34ce5ee85891b914", gomock.Any()).Return(nil)
+				c.EXPECT().Set([]byte("57bf425a34ce5ee85891b914"), []byte(""), int(time.Hour))
+			},
+			Model: &client.SendNotificationMessage{
+				UserId:  "57bf425a34ce5ee85891b914",
+				Content: "content notification",
+				Type:    client.Info,
+			},
+		},
+		{
+			Name:           "Publish error",
+			Handler:        nil,
+			ExceptedOutput: "Notification info sended to user 57bf425a34ce5ee85891b914 (Content: content notification)",
+			MockBehaviour: func(s *mock.MockStorage, c *cache_mock.MockCache) {
+				c.EXPECT().Get([]byte("57bf425a34ce5ee85891b914")).Return([]byte(""), nil)
+				s.EXPECT().SendNotification(gomock.Any(), gomock.Any(), "57bf425a34ce5ee85891b914").Return(nil)
+				publisher.EXPECT().PublishNotification(gomock.Any(), "57bf425a34ce5ee85891b914", gomock.Any()).Return(errors.New("connection closed"))
 				c.EXPECT().Set([]byte("57bf425a34ce5ee85891b914"), []byte(""), int(time.Hour))
 			},
 			Model: &client.SendNotificationMessage{
@@ -179,8 +197,9 @@ func TestUserDeleted(t *testing.T) {
 	log, hook := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
 	cache := cache_mock.NewMockCache(ctrl)
-	service := client.NewService(storage, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""})
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""}, nil)
 
 	storage.EXPECT().DeleteUser(gomock.Any(), "userid").Return(nil)
 	service.OnUserDeleted(context.Background(), "userid")
@@ -199,8 +218,9 @@ func TestUserLoginChanged(t *testing.T) {
 	log, hook := test.NewNullLogger()
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
 	cache := cache_mock.NewMockCache(ctrl)
-	service := client.NewService(storage, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""})
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""}, nil)
 
 	storage.EXPECT().ChangeUserLogin(gomock.Any(), "57bf425a34ce5ee85891b914", "user").Return(nil)
 	service.OnUserLoginChanged(context.Background(), &client.UserLoginChangedMessage{
@@ -223,3 +243,104 @@ func TestUserLoginChanged(t *testing.T) {
 		assert.Equal(t, "error", hook.LastEntry().Message)
 	}
 }
+func TestUserPasswordChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
+	cache := cache_mock.NewMockCache(ctrl)
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""}, nil)
+
+	cache.EXPECT().Get([]byte("57bf425a34ce5ee85891b914")).Return([]byte(""), nil)
+	storage.EXPECT().SendNotification(gomock.Any(), gomock.Any(), "57bf425a34ce5ee85891b914").Do(func(ctx context.Context, notif *client.Notification, userId string) {
+		assert.Equal(t, client.Security, notif.Type)
+	}).Return(nil)
+	publisher.EXPECT().PublishNotification(gomock.Any(), "57bf425a34ce5ee85891b914", gomock.Any()).Return(nil)
+	cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	service.OnUserPasswordChanged(context.Background(), "57bf425a34ce5ee85891b914")
+}
+func TestUserEmailChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, hook := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
+	cache := cache_mock.NewMockCache(ctrl)
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""}, nil)
+
+	cache.EXPECT().Get([]byte("57bf425a34ce5ee85891b914")).Return([]byte(""), nil)
+	storage.EXPECT().SendNotification(gomock.Any(), gomock.Any(), "57bf425a34ce5ee85891b914").Do(func(ctx context.Context, notif *client.Notification, userId string) {
+		assert.Equal(t, client.Security, notif.Type)
+		assert.Contains(t, notif.Content, "new@example.com")
+	}).Return(nil)
+	publisher.EXPECT().PublishNotification(gomock.Any(), "57bf425a34ce5ee85891b914", gomock.Any()).Return(nil)
+	cache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	service.OnUserEmailChanged(context.Background(), &client.UserEmailChangedMessage{
+		UserId:   "57bf425a34ce5ee85891b914",
+		OldEmail: "old@example.com",
+		NewEmail: "new@example.com",
+	})
+
+	service.OnUserEmailChanged(context.Background(), &client.UserEmailChangedMessage{})
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, "received wrong user email changed query: Error code: IE-0004, Error: userid: field is required, oldemail: field is required, newemail: field is required, Dev message: ", hook.LastEntry().Message)
+	}
+}
+func TestGetNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
+	cache := cache_mock.NewMockCache(ctrl)
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""}, nil)
+
+	_, err := service.GetNotifications(context.Background(), "57bf425a34ce5ee85891b914", &client.GetNotificationsQuery{Type: "unknown", Limit: 0})
+	assert.EqualError(t, err, "Error code: IE-0004, Error: type: field can only be: info warn security, limit must be greater than 0, Dev message: wrong notifications query")
+
+	_, err = service.GetNotifications(context.Background(), "userid", &client.GetNotificationsQuery{Limit: 10})
+	assert.Error(t, err)
+
+	query := &client.GetNotificationsQuery{Type: client.Info, Limit: 10}
+	page := &client.NotificationPage{Items: []*client.Notification{{Content: "content", Type: client.Info}}, Total: 1, Unread: 1}
+	storage.EXPECT().GetNotifications(gomock.Any(), "57bf425a34ce5ee85891b914", query).Return(page, nil)
+	response, err := service.GetNotifications(context.Background(), "57bf425a34ce5ee85891b914", query)
+	assert.NoError(t, err)
+	assert.Equal(t, page, response)
+}
+func TestChangeNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log, _ := test.NewNullLogger()
+	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
+	storage := mock.NewMockStorage(ctrl)
+	publisher := mock.NewMockPublisher(ctrl)
+	cache := cache_mock.NewMockCache(ctrl)
+	service := client.NewService(storage, publisher, logger, cache, validator.New(), &config.UrlConfig{Url_User_Service: ""}, nil)
+
+	const userId, notifId = "57bf425a34ce5ee85891b914", "57bf425a34ce5ee85891b915"
+
+	storage.EXPECT().CountUnread(gomock.Any(), userId).Return(int64(2), nil)
+	count, err := service.CountUnread(context.Background(), userId)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	assert.Error(t, service.MarkRead(context.Background(), userId, "notifid"))
+	storage.EXPECT().MarkRead(gomock.Any(), userId, notifId).Return(nil)
+	assert.NoError(t, service.MarkRead(context.Background(), userId, notifId))
+
+	storage.EXPECT().MarkAllRead(gomock.Any(), userId).Return(errors.New("error"))
+	assert.EqualError(t, service.MarkAllRead(context.Background(), userId), "error")
+
+	assert.Error(t, service.DeleteNotification(context.Background(), "userid", notifId))
+	storage.EXPECT().DeleteNotification(gomock.Any(), userId, notifId).Return(nil)
+	assert.NoError(t, service.DeleteNotification(context.Background(), userId, notifId))
+}
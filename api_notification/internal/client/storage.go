@@ -10,4 +10,9 @@ type Storage interface {
 	CreateUser(ctx context.Context, user_id, login string) error
 	DeleteUser(ctx context.Context, user_id string) error
 	ChangeUserLogin(ctx context.Context, user_id string, newLogin string) error
+	GetNotifications(ctx context.Context, user_id string, query *GetNotificationsQuery) (*NotificationPage, error)
+	CountUnread(ctx context.Context, user_id string) (int64, error)
+	MarkRead(ctx context.Context, user_id, notif_id string) error
+	MarkAllRead(ctx context.Context, user_id string) error
+	DeleteNotification(ctx context.Context, user_id, notif_id string) error
 }
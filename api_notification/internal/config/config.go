@@ -2,6 +2,7 @@ package config
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
@@ -12,6 +13,8 @@ type ServerConfig struct {
 	ListenPort    int    `env:"PORT" env-required:"true"`
 	Environment   string `env:"ENVIRONMENT"`
 }
+
+// Notifications older than retention are removed by database
 type DbConfig struct {
 	Db_Host string `env:"DB_HOST" env-required:"true"`
 	Db_Base string `env:"DB_BASE" env-required:"true"`
@@ -19,6 +22,8 @@ type DbConfig struct {
 	Db_Name string `env:"DB_NAME"`
 	Db_Pass string `env:"DB_PASS"`
 	Db_Auth string `env:"DB_AUTHDB"`
+
+	Db_Retention time.Duration `env:"DB_NOTIFICATION_RETENTION" env-default:"2160h"`
 }
 type UrlConfig struct {
 	Url_User_Service string `env:"SRV_URL_USER" env-required:"true"`
@@ -29,11 +34,20 @@ type RabbitConfig struct {
 	Rabbit_User string `env:"RABBITMQ_USER" env-required:"true"`
 	Rabbit_Pass string `env:"RABBITMQ_PASS" env-required:"true"`
 }
+
+// Secret is shared by all services, internal requests are signed with it.
+// Signed requests with body larger than MaxBodySize bytes are rejected
+type SignatureConfig struct {
+	Service     string `env:"SERVICE_NAME" env-default:"api_notification"`
+	Secret      string `env:"SERVICE_SECRET" env-required:"true"`
+	MaxBodySize int64  `env:"SERVICE_MAX_BODY_SIZE" env-default:"1048576"`
+}
 type Config struct {
-	Server   *ServerConfig
-	Database *DbConfig
-	Urls     *UrlConfig
-	Rabbit   *RabbitConfig
+	Server    *ServerConfig
+	Database  *DbConfig
+	Urls      *UrlConfig
+	Rabbit    *RabbitConfig
+	Signature *SignatureConfig
 }
 
 var cfg *Config
@@ -44,6 +58,7 @@ func GetConfig() *Config {
 		logger := logging.GetLogger()
 		logger.Info("reading api config...")
 		srvCfg := &ServerConfig{}
+		signatureCfg := &SignatureConfig{}
 		dbCfg := &DbConfig{}
 		urlCfg := &UrlConfig{}
 		rabbitCfg := &RabbitConfig{}
@@ -61,6 +76,10 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		// notification with zero retention would be removed by database right after it's sent
+		if dbCfg.Db_Retention <= 0 {
+			logger.Fatalf("notification retention must be positive, got %s", dbCfg.Db_Retention)
+		}
 		if err := cleanenv.ReadConfig("config/.env", urlCfg); err != nil {
 			desc, _ := cleanenv.GetDescription(cfg, nil)
 			logger.Error(desc)
@@ -71,11 +90,17 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		if err := cleanenv.ReadConfig("config/.env", signatureCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
 		cfg = &Config{
-			Server:   srvCfg,
-			Database: dbCfg,
-			Urls:     urlCfg,
-			Rabbit:   rabbitCfg,
+			Server:    srvCfg,
+			Database:  dbCfg,
+			Urls:      urlCfg,
+			Rabbit:    rabbitCfg,
+			Signature: signatureCfg,
 		}
 	})
 	return cfg
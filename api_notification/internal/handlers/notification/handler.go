@@ -1,16 +1,35 @@
 package notification
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
 	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/signature"
 	valid "github.com/reversersed/go-web-services/tree/main/api_notification/pkg/validator"
 )
 
-const ()
+const (
+	url_notifications        = "/notifications"
+	url_notifications_unread = "/notifications/unread"
+	url_notifications_read   = "/notifications/read"
+	url_notification_read    = "/notifications/read/:id"
+	url_notification         = "/notifications/:id"
+)
 
 //go:generate mockgen -source=handler.go -destination=mocks/service.go
 
 type Service interface {
+	GetNotifications(ctx context.Context, userId string, query *client.GetNotificationsQuery) (*client.NotificationPage, error)
+	CountUnread(ctx context.Context, userId string) (int64, error)
+	MarkRead(ctx context.Context, userId, id string) error
+	MarkAllRead(ctx context.Context, userId string) error
+	DeleteNotification(ctx context.Context, userId, id string) error
 }
 
 type Handler struct {
@@ -20,5 +39,95 @@ type Handler struct {
 }
 
 func (h *Handler) Register(route *httprouter.Router) {
+	route.HandlerFunc(http.MethodGet, url_notifications, h.Logger.Middleware(errormiddleware.Middleware(h.GetNotifications)))
+	route.HandlerFunc(http.MethodGet, url_notifications_unread, h.Logger.Middleware(errormiddleware.Middleware(h.CountUnread)))
+	route.HandlerFunc(http.MethodPatch, url_notifications_read, h.Logger.Middleware(errormiddleware.Middleware(h.MarkAllRead)))
+	route.HandlerFunc(http.MethodPatch, url_notification_read, h.Logger.Middleware(errormiddleware.Middleware(h.MarkRead)))
+	route.HandlerFunc(http.MethodDelete, url_notification, h.Logger.Middleware(errormiddleware.Middleware(h.DeleteNotification)))
+}
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	query := &client.GetNotificationsQuery{
+		After:  r.URL.Query().Get("after"),
+		Type:   client.NotificationType(r.URL.Query().Get("type")),
+		Unread: r.URL.Query().Get("unread") == "true",
+	}
+	var err error
+	if r.URL.Query().Has("offset") {
+		query.Offset, err = strconv.Atoi(r.URL.Query().Get("offset"))
+		if err != nil {
+			return errormiddleware.BadRequestError([]string{"bad query request", "offset must be a number"}, err.Error())
+		}
+	}
+	query.Limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		return errormiddleware.BadRequestError([]string{"bad query request", "limit must be present"}, err.Error())
+	}
 
+	page, err := h.Service.GetNotifications(r.Context(), userId, query)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
+func (h *Handler) CountUnread(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	count, err := h.Service.CountUnread(r.Context(), userId)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(map[string]int64{"unread": count})
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(bytes)
+	return nil
+}
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if err := h.Service.MarkRead(r.Context(), userId, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	if err := h.Service.MarkAllRead(r.Context(), userId); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func (h *Handler) DeleteNotification(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	id := httprouter.ParamsFromContext(r.Context()).ByName("id")
+	if err := h.Service.DeleteNotification(r.Context(), userId, id); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
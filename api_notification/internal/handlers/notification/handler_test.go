@@ -2,6 +2,7 @@ package notification
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,9 +11,11 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
 	mock "github.com/reversersed/go-web-services/tree/main/api_notification/internal/handlers/notification/mocks"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/signature"
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/validator"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
@@ -33,7 +36,13 @@ func TestRegister(t *testing.T) {
 		Name   string
 		Path   string
 		Method string
-	}{}
+	}{
+		{"get notifications", url_notifications, http.MethodGet},
+		{"count unread", url_notifications_unread, http.MethodGet},
+		{"mark all read", url_notifications_read, http.MethodPatch},
+		{"mark read", url_notification_read, http.MethodPatch},
+		{"delete notification", url_notification, http.MethodDelete},
+	}
 
 	router := httprouter.New()
 	h.Register(router)
@@ -49,6 +58,9 @@ func TestHandlers(t *testing.T) {
 	type handlerOptions struct {
 		Name           string
 		MockBehaviour  func(s *mock.MockService)
+		UserId         string
+		Query          string
+		Params         httprouter.Params
 		InputJson      func() *[]byte
 		ExceptedStatus int
 		ExceptedError  error
@@ -59,7 +71,166 @@ func TestHandlers(t *testing.T) {
 		Handler     func(w http.ResponseWriter, r *http.Request) error
 		Method      string
 		Options     []handlerOptions
-	}{}
+	}{
+		{
+			HandlerName: "GetNotifications",
+			Handler:     h.GetNotifications,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name:           "unauthorized",
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty"),
+					ExceptedBody:   string(errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty").Marshall()),
+				},
+				{
+					Name:           "missing limit",
+					UserId:         "userid",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad query request", "limit must be present"}, "strconv.Atoi: parsing \"\": invalid syntax"),
+					ExceptedBody:   string(errormiddleware.BadRequestError([]string{"bad query request", "limit must be present"}, "strconv.Atoi: parsing \"\": invalid syntax").Marshall()),
+				},
+				{
+					Name:           "wrong offset",
+					UserId:         "userid",
+					Query:          "offset=a&limit=10",
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"bad query request", "offset must be a number"}, "strconv.Atoi: parsing \"a\": invalid syntax"),
+					ExceptedBody:   string(errormiddleware.BadRequestError([]string{"bad query request", "offset must be a number"}, "strconv.Atoi: parsing \"a\": invalid syntax").Marshall()),
+				},
+				{
+					Name:   "service error",
+					UserId: "userid",
+					Query:  "limit=10",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetNotifications(gomock.Any(), "userid", &client.GetNotificationsQuery{Limit: 10}).Return(nil, errormiddleware.NotFoundError([]string{"not found"}, ""))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"not found"}, ""),
+					ExceptedBody:   string(errormiddleware.NotFoundError([]string{"not found"}, "").Marshall()),
+				},
+				{
+					Name:   "successful",
+					UserId: "userid",
+					Query:  "after=57bf425a34ce5ee85891b914&type=security&unread=true&offset=5&limit=10",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().GetNotifications(gomock.Any(), "userid", &client.GetNotificationsQuery{After: "57bf425a34ce5ee85891b914", Type: client.Security, Unread: true, Offset: 5, Limit: 10}).Return(&client.NotificationPage{Items: []*client.Notification{}, Total: 5, Unread: 5}, nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"items":[],"total":5,"unread":5}`,
+				},
+			},
+		},
+		{
+			HandlerName: "CountUnread",
+			Handler:     h.CountUnread,
+			Method:      http.MethodGet,
+			Options: []handlerOptions{
+				{
+					Name:           "unauthorized",
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty"),
+					ExceptedBody:   string(errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty").Marshall()),
+				},
+				{
+					Name:   "successful",
+					UserId: "userid",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().CountUnread(gomock.Any(), "userid").Return(int64(3), nil)
+					},
+					ExceptedStatus: http.StatusOK,
+					ExceptedBody:   `{"unread":3}`,
+				},
+			},
+		},
+		{
+			HandlerName: "MarkRead",
+			Handler:     h.MarkRead,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				{
+					Name:           "unauthorized",
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty"),
+					ExceptedBody:   string(errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty").Marshall()),
+				},
+				{
+					Name:   "not found",
+					UserId: "userid",
+					Params: httprouter.Params{{Key: "id", Value: "notifid"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().MarkRead(gomock.Any(), "userid", "notifid").Return(errormiddleware.NotFoundError([]string{"notification with provided id not found"}, ""))
+					},
+					ExceptedStatus: http.StatusNotFound,
+					ExceptedError:  errormiddleware.NotFoundError([]string{"notification with provided id not found"}, ""),
+					ExceptedBody:   string(errormiddleware.NotFoundError([]string{"notification with provided id not found"}, "").Marshall()),
+				},
+				{
+					Name:   "successful",
+					UserId: "userid",
+					Params: httprouter.Params{{Key: "id", Value: "notifid"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().MarkRead(gomock.Any(), "userid", "notifid").Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+			},
+		},
+		{
+			HandlerName: "MarkAllRead",
+			Handler:     h.MarkAllRead,
+			Method:      http.MethodPatch,
+			Options: []handlerOptions{
+				{
+					Name:           "unauthorized",
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty"),
+					ExceptedBody:   string(errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty").Marshall()),
+				},
+				{
+					Name:   "successful",
+					UserId: "userid",
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().MarkAllRead(gomock.Any(), "userid").Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+			},
+		},
+		{
+			HandlerName: "DeleteNotification",
+			Handler:     h.DeleteNotification,
+			Method:      http.MethodDelete,
+			Options: []handlerOptions{
+				{
+					Name:           "unauthorized",
+					ExceptedStatus: http.StatusUnauthorized,
+					ExceptedError:  errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty"),
+					ExceptedBody:   string(errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty").Marshall()),
+				},
+				{
+					Name:   "wrong id",
+					UserId: "userid",
+					Params: httprouter.Params{{Key: "id", Value: "notifid"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteNotification(gomock.Any(), "userid", "notifid").Return(errormiddleware.BadRequestError([]string{"wrong notification id"}, ""))
+					},
+					ExceptedStatus: http.StatusBadRequest,
+					ExceptedError:  errormiddleware.BadRequestError([]string{"wrong notification id"}, ""),
+					ExceptedBody:   string(errormiddleware.BadRequestError([]string{"wrong notification id"}, "").Marshall()),
+				},
+				{
+					Name:   "successful",
+					UserId: "userid",
+					Params: httprouter.Params{{Key: "id", Value: "notifid"}},
+					MockBehaviour: func(s *mock.MockService) {
+						s.EXPECT().DeleteNotification(gomock.Any(), "userid", "notifid").Return(nil)
+					},
+					ExceptedStatus: http.StatusNoContent,
+				},
+			},
+		},
+	}
 	for _, tt := range testTable {
 		for _, testCase := range tt.Options {
 			t.Run(fmt.Sprintf("%s %s", tt.HandlerName, testCase.Name), func(t *testing.T) {
@@ -73,9 +244,15 @@ func TestHandlers(t *testing.T) {
 				w := httptest.NewRecorder()
 				var r *http.Request
 				if testCase.InputJson != nil && testCase.InputJson() != nil {
-					r = httptest.NewRequest(tt.Method, "http://test", bytes.NewBuffer(*testCase.InputJson()))
+					r = httptest.NewRequest(tt.Method, "http://test?"+testCase.Query, bytes.NewBuffer(*testCase.InputJson()))
 				} else {
-					r = httptest.NewRequest(tt.Method, "http://test", nil)
+					r = httptest.NewRequest(tt.Method, "http://test?"+testCase.Query, nil)
+				}
+				if len(testCase.UserId) > 0 {
+					r = r.WithContext(signature.NewContext(r.Context(), &signature.Caller{UserId: testCase.UserId}))
+				}
+				if testCase.Params != nil {
+					r = r.WithContext(context.WithValue(r.Context(), httprouter.ParamsKey, testCase.Params))
 				}
 				err := errormiddleware.Middleware(tt.Handler)(w, r)
 				assert.Equal(t, testCase.ExceptedStatus, w.Result().StatusCode)
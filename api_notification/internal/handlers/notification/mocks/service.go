@@ -5,7 +5,11 @@
 package mock_notification
 
 import (
+	context "context"
+	reflect "reflect"
+
 	gomock "github.com/golang/mock/gomock"
+	client "github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
 )
 
 // MockService is a mock of Service interface.
@@ -30,3 +34,75 @@ func NewMockService(ctrl *gomock.Controller) *MockService {
 func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
+
+// CountUnread mocks base method.
+func (m *MockService) CountUnread(ctx context.Context, userId string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnread", ctx, userId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnread indicates an expected call of CountUnread.
+func (mr *MockServiceMockRecorder) CountUnread(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnread", reflect.TypeOf((*MockService)(nil).CountUnread), ctx, userId)
+}
+
+// DeleteNotification mocks base method.
+func (m *MockService) DeleteNotification(ctx context.Context, userId, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotification", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteNotification indicates an expected call of DeleteNotification.
+func (mr *MockServiceMockRecorder) DeleteNotification(ctx, userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotification", reflect.TypeOf((*MockService)(nil).DeleteNotification), ctx, userId, id)
+}
+
+// GetNotifications mocks base method.
+func (m *MockService) GetNotifications(ctx context.Context, userId string, query *client.GetNotificationsQuery) (*client.NotificationPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", ctx, userId, query)
+	ret0, _ := ret[0].(*client.NotificationPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockServiceMockRecorder) GetNotifications(ctx, userId, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockService)(nil).GetNotifications), ctx, userId, query)
+}
+
+// MarkAllRead mocks base method.
+func (m *MockService) MarkAllRead(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkAllRead", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkAllRead indicates an expected call of MarkAllRead.
+func (mr *MockServiceMockRecorder) MarkAllRead(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllRead", reflect.TypeOf((*MockService)(nil).MarkAllRead), ctx, userId)
+}
+
+// MarkRead mocks base method.
+func (m *MockService) MarkRead(ctx context.Context, userId, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockServiceMockRecorder) MarkRead(ctx, userId, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockService)(nil).MarkRead), ctx, userId, id)
+}
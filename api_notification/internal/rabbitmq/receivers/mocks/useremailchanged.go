@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: useremailchanged.go
+
+// Package mock_receivers is a generated GoMock package.
+package mock_receivers
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	client "github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
+)
+
+// Mockuser_email_changed_service is a mock of user_email_changed_service interface.
+type Mockuser_email_changed_service struct {
+	ctrl     *gomock.Controller
+	recorder *Mockuser_email_changed_serviceMockRecorder
+}
+
+// Mockuser_email_changed_serviceMockRecorder is the mock recorder for Mockuser_email_changed_service.
+type Mockuser_email_changed_serviceMockRecorder struct {
+	mock *Mockuser_email_changed_service
+}
+
+// NewMockuser_email_changed_service creates a new mock instance.
+func NewMockuser_email_changed_service(ctrl *gomock.Controller) *Mockuser_email_changed_service {
+	mock := &Mockuser_email_changed_service{ctrl: ctrl}
+	mock.recorder = &Mockuser_email_changed_serviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockuser_email_changed_service) EXPECT() *Mockuser_email_changed_serviceMockRecorder {
+	return m.recorder
+}
+
+// OnUserEmailChanged mocks base method.
+func (m *Mockuser_email_changed_service) OnUserEmailChanged(ctx context.Context, query *client.UserEmailChangedMessage) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnUserEmailChanged", ctx, query)
+}
+
+// OnUserEmailChanged indicates an expected call of OnUserEmailChanged.
+func (mr *Mockuser_email_changed_serviceMockRecorder) OnUserEmailChanged(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnUserEmailChanged", reflect.TypeOf((*Mockuser_email_changed_service)(nil).OnUserEmailChanged), ctx, query)
+}
@@ -0,0 +1,47 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: userpasswordchanged.go
+
+// Package mock_receivers is a generated GoMock package.
+package mock_receivers
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// Mockuser_password_changed_service is a mock of user_password_changed_service interface.
+type Mockuser_password_changed_service struct {
+	ctrl     *gomock.Controller
+	recorder *Mockuser_password_changed_serviceMockRecorder
+}
+
+// Mockuser_password_changed_serviceMockRecorder is the mock recorder for Mockuser_password_changed_service.
+type Mockuser_password_changed_serviceMockRecorder struct {
+	mock *Mockuser_password_changed_service
+}
+
+// NewMockuser_password_changed_service creates a new mock instance.
+func NewMockuser_password_changed_service(ctrl *gomock.Controller) *Mockuser_password_changed_service {
+	mock := &Mockuser_password_changed_service{ctrl: ctrl}
+	mock.recorder = &Mockuser_password_changed_serviceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockuser_password_changed_service) EXPECT() *Mockuser_password_changed_serviceMockRecorder {
+	return m.recorder
+}
+
+// OnUserPasswordChanged mocks base method.
+func (m *Mockuser_password_changed_service) OnUserPasswordChanged(ctx context.Context, userid string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnUserPasswordChanged", ctx, userid)
+}
+
+// OnUserPasswordChanged indicates an expected call of OnUserPasswordChanged.
+func (mr *Mockuser_password_changed_serviceMockRecorder) OnUserPasswordChanged(ctx, userid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnUserPasswordChanged", reflect.TypeOf((*Mockuser_password_changed_service)(nil).OnUserPasswordChanged), ctx, userid)
+}
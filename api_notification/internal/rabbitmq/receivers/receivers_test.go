@@ -180,3 +180,75 @@ func TestUserLoginChanged(t *testing.T) {
 		assert.Equal(t, sendQuery, notification)
 	}
 }
+
+func TestUserPasswordChanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration tests are not run in short mode")
+	}
+	ctrl := gomock.NewController(t)
+	service := mock.NewMockuser_password_changed_service(ctrl)
+	receiver := NewUserPasswordChangedReceiver(conn.Connection, validator.New(), logger, service)
+
+	Query := make(chan string, 1)
+	service.EXPECT().OnUserPasswordChanged(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, userId string) {
+		Query <- userId
+	})
+
+	receiver.Start()
+	defer receiver.Close()
+
+	ch, err := conn.Channel()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = ch.PublishWithContext(context.Background(), "UserPasswordChangedExchange", "#", false, false, amqp.Publishing{
+		ContentType: "text/plain",
+		Body:        []byte("userId"),
+	})
+	if assert.NoError(t, err) {
+		userid := <-Query
+		assert.Equal(t, "userId", userid)
+	}
+}
+
+func TestUserEmailChanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("integration tests are not run in short mode")
+	}
+	ctrl := gomock.NewController(t)
+	service := mock.NewMockuser_email_changed_service(ctrl)
+	receiver := NewUserEmailChangedReceiver(conn.Connection, validator.New(), logger, service)
+
+	Query := make(chan *client.UserEmailChangedMessage, 1)
+	service.EXPECT().OnUserEmailChanged(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, query *client.UserEmailChangedMessage) {
+		Query <- query
+	})
+
+	receiver.Start()
+	defer receiver.Close()
+
+	sendQuery := &client.UserEmailChangedMessage{
+		UserId:   "userId",
+		OldEmail: "old@example.com",
+		NewEmail: "new@example.com",
+	}
+
+	ch, err := conn.Channel()
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, err := json.Marshal(sendQuery)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = ch.PublishWithContext(context.Background(), "UserEmailChangedExchange", "#", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+	})
+	if assert.NoError(t, err) {
+		notification := <-Query
+		assert.Equal(t, sendQuery, notification)
+	}
+}
@@ -0,0 +1,80 @@
+package receivers
+
+import (
+	"context"
+	"encoding/json"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/rabbitmq"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+	valid "github.com/reversersed/go-web-services/tree/main/api_notification/pkg/validator"
+)
+
+//go:generate mockgen -source=useremailchanged.go -destination=mocks/useremailchanged.go
+
+type user_email_changed_service interface {
+	OnUserEmailChanged(ctx context.Context, query *client.UserEmailChangedMessage)
+}
+type UserEmailChangedReceiver struct {
+	connection *amqp.Connection
+	validator  *valid.Validator
+	logger     *logging.Logger
+	channel    *amqp.Channel
+	service    user_email_changed_service
+}
+
+func NewUserEmailChangedReceiver(connection *amqp.Connection, validator *valid.Validator, logger *logging.Logger, service user_email_changed_service) rabbitmq.Receiver {
+	return &UserEmailChangedReceiver{
+		connection: connection,
+		validator:  validator,
+		logger:     logger,
+		service:    service,
+	}
+}
+func (r *UserEmailChangedReceiver) Start() {
+	ch, err := r.connection.Channel()
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	r.channel = ch
+
+	queue, err := r.channel.QueueDeclare("UserEmailChangedQueue", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	err = ch.ExchangeDeclare("UserEmailChangedExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	err = r.channel.QueueBind(queue.Name, "#", "UserEmailChangedExchange", false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	messages, err := r.channel.Consume(queue.Name, "NotificationAPI", true, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	go func() {
+		for message := range messages {
+			if r.channel.IsClosed() || r.connection.IsClosed() {
+				return
+			}
+			r.logger.Info("Received user email changed message")
+			var msg client.UserEmailChangedMessage
+			err := json.Unmarshal(message.Body, &msg)
+			if err != nil {
+				r.logger.Errorf("Unable to unmarshal message: %v", string(message.Body))
+			} else {
+				r.service.OnUserEmailChanged(context.Background(), &msg)
+			}
+		}
+	}()
+	r.logger.Infof("Waiting for users that changed email...")
+}
+
+func (r *UserEmailChangedReceiver) Close() error {
+	return r.channel.Close()
+}
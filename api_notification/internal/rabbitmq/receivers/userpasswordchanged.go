@@ -0,0 +1,72 @@
+package receivers
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/rabbitmq"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+	valid "github.com/reversersed/go-web-services/tree/main/api_notification/pkg/validator"
+)
+
+//go:generate mockgen -source=userpasswordchanged.go -destination=mocks/userpasswordchanged.go
+
+type user_password_changed_service interface {
+	OnUserPasswordChanged(ctx context.Context, userid string)
+}
+type UserPasswordChangedReceiver struct {
+	connection *amqp.Connection
+	validator  *valid.Validator
+	logger     *logging.Logger
+	channel    *amqp.Channel
+	service    user_password_changed_service
+}
+
+func NewUserPasswordChangedReceiver(connection *amqp.Connection, validator *valid.Validator, logger *logging.Logger, service user_password_changed_service) rabbitmq.Receiver {
+	return &UserPasswordChangedReceiver{
+		connection: connection,
+		validator:  validator,
+		logger:     logger,
+		service:    service,
+	}
+}
+func (r *UserPasswordChangedReceiver) Start() {
+	ch, err := r.connection.Channel()
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	r.channel = ch
+
+	queue, err := r.channel.QueueDeclare("UserPasswordChangedQueue", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	err = ch.ExchangeDeclare("UserPasswordChangedExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	err = r.channel.QueueBind(queue.Name, "#", "UserPasswordChangedExchange", false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+
+	messages, err := r.channel.Consume(queue.Name, "NotificationAPI", true, false, false, false, nil)
+	if err != nil {
+		r.logger.Fatal(err)
+	}
+	go func() {
+		for message := range messages {
+			if r.channel.IsClosed() || r.connection.IsClosed() {
+				return
+			}
+			r.logger.Info("Received user password changed message")
+			r.service.OnUserPasswordChanged(context.Background(), string(message.Body))
+		}
+	}()
+	r.logger.Infof("Waiting for users that changed password...")
+}
+
+func (r *UserPasswordChangedReceiver) Close() error {
+	return r.channel.Close()
+}
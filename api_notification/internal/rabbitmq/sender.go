@@ -0,0 +1,60 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/internal/client"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+)
+
+type Sender struct {
+	connection *amqp.Connection
+	logger     *logging.Logger
+}
+
+func NewSender(connection *amqp.Connection, logger *logging.Logger) *Sender {
+	return &Sender{connection: connection, logger: logger}
+}
+func (s *Sender) Close() error {
+	return nil
+}
+
+// Every gateway instance has it's own queue bound to the exchange, so notification reaches user wherever they are connected
+func (s *Sender) PublishNotification(ctx context.Context, userId string, notif *client.Notification) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare("NotificationSentExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	type query struct {
+		UserId       string               `json:"userid"`
+		Notification *client.Notification `json:"notification"`
+	}
+
+	body, err := json.Marshal(&query{UserId: userId, Notification: notif})
+	if err != nil {
+		return err
+	}
+	err = ch.PublishWithContext(cntx, "NotificationSentExchange", "#", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Body:        body,
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending notification sent message: %v", err)
+		return err
+	}
+	s.logger.Infof("Published notification %s of user %s", notif.Id.Hex(), userId)
+	return nil
+}
@@ -18,6 +18,7 @@ const (
 	UnauthorizedErrorCode Code = "IE-0005"
 	NotUniqueErrorCode    Code = "IE-0006"
 	ForbiddenErrorCode    Code = "IE-0007"
+	TooLargeErrorCode     Code = "IE-0008"
 )
 
 type Error struct {
@@ -110,3 +111,6 @@ func NotUniqueError(message []string, dev_message string) *Error {
 func ForbiddenError(message []string, dev_message string) *Error {
 	return NewError(message, ForbiddenErrorCode, dev_message)
 }
+func TooLargeError(message []string, dev_message string) *Error {
+	return NewError(message, TooLargeErrorCode, dev_message)
+}
@@ -10,18 +10,14 @@ import (
 	"strings"
 
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
-)
-
-type key string
-
-const (
-	UserIdKey key = "user_id"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/signature"
 )
 
 type RestClient struct {
 	BaseURL    string
 	HttpClient *http.Client
 	Logger     *logging.Logger
+	Signer     *signature.Signer
 }
 
 func (c *RestClient) SendRequest(r *http.Request) (*CustomResponse, error) {
@@ -32,10 +28,12 @@ func (c *RestClient) SendRequest(r *http.Request) (*CustomResponse, error) {
 	r.Header.Set("Content-Type", "application/json; charset=utf-8")
 
 	c.Logger.Infof("sending request to %s", r.URL)
-	//reading userid from context and adding it to header
-	userId, valid := r.Context().Value(UserIdKey).(string)
-	if valid && len(userId) > 0 {
-		r.Header.Add("User", userId)
+	//requests are sent on behalf of the user who called this service
+	if c.Signer != nil {
+		caller := signature.CallerFromContext(r.Context())
+		if err := c.Signer.Sign(r, caller.UserId, caller.Roles); err != nil {
+			return nil, err
+		}
 	}
 
 	response, err := c.HttpClient.Do(r)
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_notification/pkg/signature"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
@@ -228,7 +229,9 @@ func TestUserHeader(t *testing.T) {
 	logger := &logging.Logger{Entry: logrus.NewEntry(log)}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		body, _ := json.Marshal(struct{ User string }{User: r.Header.Get("User")})
+		caller, err := signature.Verify(r, "secret", 1<<20)
+		assert.NoError(t, err)
+		body, _ := json.Marshal(struct{ User string }{User: caller.UserId})
 		w.Write(body)
 	}))
 
@@ -236,8 +239,9 @@ func TestUserHeader(t *testing.T) {
 		BaseURL:    server.URL,
 		Logger:     logger,
 		HttpClient: &http.Client{Timeout: 5 * time.Second},
+		Signer:     &signature.Signer{Service: "service", Secret: "secret"},
 	}
-	ctx := context.WithValue(context.Background(), UserIdKey, "userKeyId")
+	ctx := signature.NewContext(context.Background(), &signature.Caller{UserId: "userKeyId"})
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
 	assert.NoError(t, err)
 	response, err := client.SendRequest(request)
@@ -16,12 +16,15 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/client/db"
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/handlers/user"
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/lockout"
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/password"
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/cache/freecache"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/logging"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/mongo"
 	RabbitClient "github.com/reversersed/go-web-services/tree/main/api_user/pkg/rabbitmq"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/shutdown"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/signature"
 )
 
 func main() {
@@ -52,16 +55,20 @@ func main() {
 
 	logger.Info("services initializing...")
 	user_storage := db.NewStorage(db_client, config.Database.Db_Base, logger)
-	user_service := client.NewService(user_storage, logger, cache, rabbitSender)
+	hasher, err := password.NewHasher(config.Password)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	user_service := client.NewService(user_storage, logger, cache, rabbitSender, hasher, password.NewPolicy(config.Password), lockout.NewGuard(cache, config.Lockout))
 
 	logger.Info("handlers registration...")
 	userHandler := user.Handler{Logger: logger, UserService: user_service}
 	userHandler.Register(router)
 
 	logger.Info("starting application...")
-	start(router, logger, config.Server, rabbit, rabbitSender)
+	start(signature.Middleware(router, config.Signature.Secret, config.Signature.MaxBodySize, logger), logger, config.Server, rabbit, rabbitSender)
 }
-func start(router *httprouter.Router, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
+func start(router http.Handler, logger *logging.Logger, cfg *config.ServerConfig, closers ...io.Closer) {
 	var server *http.Server
 	var listener net.Listener
 
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -12,23 +13,61 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type db struct {
 	sync.RWMutex
 	collection *mongo.Collection
+	resets     *mongo.Collection
 	logger     *logging.Logger
 }
 
 func NewStorage(storage *mongo.Database, collection string, logger *logging.Logger) client.Storage {
 	db := &db{
 		collection: storage.Collection(collection),
+		resets:     storage.Collection("password_resets"),
 		logger:     logger,
 	}
+	db.createResetIndexes()
+	db.createIdentityIndexes()
 	defer db.seedAdminAccount()
 	return db
 }
+func (d *db) createResetIndexes() {
+	models := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetName("expiresAt").SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetName("token").SetUnique(true)},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	names, err := d.resets.Indexes().CreateMany(ctx, models)
+	if err != nil {
+		d.logger.Errorf("can't create password reset indexes: %v", err)
+		return
+	}
+	d.logger.Infof("password reset indexes created: %v", names)
+}
+
+// Index is partial, so users without linked identities don't collide with each other
+func (d *db) createIdentityIndexes() {
+	model := mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().SetName("identities").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	name, err := d.collection.Indexes().CreateOne(ctx, model)
+	if err != nil {
+		d.logger.Errorf("can't create identity index: %v", err)
+		return
+	}
+	d.logger.Infof("identity index created: %s", name)
+}
 func (d *db) seedAdminAccount() {
 	d.Lock()
 	defer d.Unlock()
@@ -71,7 +110,12 @@ func (d *db) ApproveUserEmail(ctx context.Context, id string) error {
 	if err != nil {
 		return err
 	}
-	result, err := d.collection.UpdateByID(ctx, obj_id, bson.M{"$set": bson.M{"emailconfirmed": true}})
+	// pending email is moved to email in the same update, so approval is atomic
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"emailconfirmed": true, "email": bson.M{"$ifNull": bson.A{"$pendingemail", "$email"}}}}},
+		{{Key: "$unset", Value: "pendingemail"}},
+	}
+	result, err := d.collection.UpdateByID(ctx, obj_id, update)
 	if err != nil {
 		return err
 	}
@@ -123,6 +167,25 @@ func (d *db) FindByEmail(ctx context.Context, email string) (*client.User, error
 	}
 	return &u, nil
 }
+func (d *db) FindByIdentity(ctx context.Context, provider, subject string) (*client.User, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result := d.collection.FindOne(ctx, filter)
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	var u client.User
+	if err := result.Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
 func (d *db) AddUser(ctx context.Context, user *client.User) (string, error) {
 	d.Lock()
 	defer d.Unlock()
@@ -206,3 +269,178 @@ func (d *db) ChangeUserLogin(ctx context.Context, userId, newLogin string) error
 	}
 	return nil
 }
+func (d *db) ChangeUserPassword(ctx context.Context, userId string, password []byte) error {
+	d.Lock()
+	defer d.Unlock()
+
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	result, err := d.collection.UpdateByID(ctx, primitive_id, bson.M{"$set": bson.M{"password": password}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) AddUserRole(ctx context.Context, userId, role string) error {
+	return d.updateUserRoles(ctx, userId, bson.M{"$addToSet": bson.M{"roles": role}})
+}
+func (d *db) RemoveUserRole(ctx context.Context, userId, role string) error {
+	return d.updateUserRoles(ctx, userId, bson.M{"$pull": bson.M{"roles": role}})
+}
+func (d *db) updateUserRoles(ctx context.Context, userId string, update bson.M) error {
+	d.Lock()
+	defer d.Unlock()
+
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	result, err := d.collection.UpdateByID(ctx, primitive_id, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) CreatePasswordReset(ctx context.Context, reset *client.PasswordReset) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := d.resets.ReplaceOne(ctx, bson.M{"_id": reset.UserId}, reset, options.Replace().SetUpsert(true))
+	return err
+}
+func (d *db) TakePasswordReset(ctx context.Context, tokenHash string) (*client.PasswordReset, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// ttl monitor removes documents once a minute, so expiration is checked here too
+	filter := bson.M{"token": tokenHash, "expiresAt": bson.M{"$gt": time.Now()}}
+	var reset client.PasswordReset
+	if err := d.resets.FindOneAndDelete(ctx, filter).Decode(&reset); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errormiddleware.NotFoundError([]string{"reset token is invalid or expired"}, "no password reset found by token hash")
+		}
+		return nil, err
+	}
+	return &reset, nil
+}
+func (d *db) ChangeUserEmail(ctx context.Context, userId, email string) error {
+	d.Lock()
+	defer d.Unlock()
+
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	result, err := d.collection.UpdateByID(ctx, primitive_id, bson.M{"$set": bson.M{"pendingemail": email, "emailconfirmed": false}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) SetTotpSecret(ctx context.Context, userId, secret string) error {
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": primitive_id, "twofactor": bson.M{"$ne": true}}
+	result, err := d.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totpsecret": secret}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.BadRequestError([]string{"two-factor authentication is already enabled"}, fmt.Sprintf("user %s not found or has 2FA enabled", userId))
+	}
+	return nil
+}
+func (d *db) EnableTwoFactor(ctx context.Context, userId string, step int64, recoveryCodes []string) error {
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"twofactor": true, "totpstep": step, "recoverycodes": recoveryCodes}}
+	result, err := d.collection.UpdateByID(ctx, primitive_id, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) DisableTwoFactor(ctx context.Context, userId string) error {
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"twofactor": false}, "$unset": bson.M{"totpsecret": "", "totpstep": "", "recoverycodes": ""}}
+	result, err := d.collection.UpdateByID(ctx, primitive_id, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
+func (d *db) UseTotpStep(ctx context.Context, userId string, step int64) error {
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	// step is compared in the filter, so two concurrent requests can't use the same code
+	filter := bson.M{"_id": primitive_id, "$or": bson.A{bson.M{"totpstep": bson.M{"$lt": step}}, bson.M{"totpstep": bson.M{"$exists": false}}}}
+	result, err := d.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totpstep": step}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.UnauthorizedError([]string{"code has already been used"}, fmt.Sprintf("step %d of user %s was already used", step, userId))
+	}
+	return nil
+}
+func (d *db) UseRecoveryCode(ctx context.Context, userId, codeHash string) error {
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": primitive_id, "recoverycodes": codeHash}
+	result, err := d.collection.UpdateOne(ctx, filter, bson.M{"$pull": bson.M{"recoverycodes": codeHash}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.UnauthorizedError([]string{"wrong recovery code"}, fmt.Sprintf("user %s has no such recovery code", userId))
+	}
+	return nil
+}
+func (d *db) AddIdentity(ctx context.Context, userId string, identity client.Identity) error {
+	d.Lock()
+	defer d.Unlock()
+
+	primitive_id, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return err
+	}
+	result, err := d.collection.UpdateByID(ctx, primitive_id, bson.M{"$addToSet": bson.M{"identities": identity}})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errormiddleware.NotUniqueError([]string{"identity is already linked to other user"}, fmt.Sprintf("%s identity %s is linked to other user", identity.Provider, identity.Subject))
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, fmt.Sprintf("matched count was == %d", result.MatchedCount))
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package client
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type User struct {
 	Id             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
@@ -9,7 +13,19 @@ type User struct {
 	Roles          []string           `json:"roles" bson:"roles"`
 	Email          string             `json:"email" bson:"email"`
 	EmailConfirmed bool               `json:"emailconfirmed" bson:"emailconfirmed"`
+	PendingEmail   string             `json:"pendingemail,omitempty" bson:"pendingemail,omitempty"` // new email waiting for confirmation, current one is used until then
 	LoginCooldown  uint64             `json:"-" bson:"logincooldown"`
+	TwoFactor      bool               `json:"twofactor" bson:"twofactor"`
+	TotpSecret     string             `json:"-" bson:"totpsecret,omitempty"`    // stored on enrollment, 2FA is enabled after the first verified code
+	TotpStep       int64              `json:"-" bson:"totpstep,omitempty"`      // time step of the last accepted code, so code can't be used twice
+	RecoveryCodes  []string           `json:"-" bson:"recoverycodes,omitempty"` // hashes of unused recovery codes
+	Identities     []Identity         `json:"-" bson:"identities,omitempty"`    // accounts of external identity providers linked to the user
+}
+
+// Account of OpenID provider, subject is unique only within its provider
+type Identity struct {
+	Provider string `bson:"provider"`
+	Subject  string `bson:"subject"`
 }
 
 type AuthUserByLoginAndPassword struct {
@@ -27,3 +43,54 @@ type DeleteUserQuery struct {
 type ChangeUserLoginQuery struct {
 	Login string `json:"newlogin"`
 }
+type ChangeUserPasswordQuery struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"newpassword"`
+}
+type ChangeUserEmailQuery struct {
+	Email string `json:"newemail"`
+}
+type ForgotPasswordQuery struct {
+	Email string `json:"email"`
+}
+type ResetPasswordQuery struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+type TwoFactorCodeQuery struct {
+	Code string `json:"code"`
+}
+type DisableTwoFactorQuery struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+type VerifyTwoFactorQuery struct {
+	UserId string `json:"userid"`
+	Code   string `json:"code"`
+}
+type ChangeUserRoleQuery struct {
+	UserId string `json:"userid"`
+	Role   string `json:"role"`
+}
+type IdentitySignInQuery struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailverified"`
+	Login         string `json:"login"`
+}
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+type RecoveryCodes struct {
+	Codes []string `json:"recoverycodes"`
+}
+
+// User can have only one active reset, so user's id is used as document id.
+// Only hash of the token is stored, token itself is sent to user's email
+type PasswordReset struct {
+	UserId    string    `bson:"_id"`
+	TokenHash string    `bson:"token"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
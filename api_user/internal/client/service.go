@@ -2,27 +2,57 @@ package client
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/email"
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/lockout"
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/password"
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/rabbitmq"
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/totp"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/cache"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/signature"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	reset_token_ttl      = 30 * time.Minute
+	reset_email_cooldown = time.Minute
+	totp_issuer          = "Example"
+	recovery_codes_count = 10
+	// logins of users created by identity providers follow the same rules as registered ones: 4-16 latin letters
+	identity_login_min   = 4
+	identity_login_max   = 16
+	identity_login_tries = 5
+)
+
+// Roles are mapped to permissions by gateway, user service only keeps the list of known ones
+var known_roles = []string{"user", "editor", "admin"}
+
+// Roles that have permission to grant and revoke roles, same as roles:write in gateway
+var role_managers = []string{"admin"}
+
 type service struct {
 	storage      Storage
 	logger       *logging.Logger
 	cache        cache.Cache
 	rabbitSender *rabbitmq.Sender
+	hasher       *password.Hasher
+	policy       *password.Policy
+	guard        *lockout.Guard
 }
 
-func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, rabbitSender *rabbitmq.Sender) *service {
-	return &service{storage: storage, logger: logger, cache: cache, rabbitSender: rabbitSender}
+func NewService(storage Storage, logger *logging.Logger, cache cache.Cache, rabbitSender *rabbitmq.Sender, hasher *password.Hasher, policy *password.Policy, guard *lockout.Guard) *service {
+	return &service{storage: storage, logger: logger, cache: cache, rabbitSender: rabbitSender, hasher: hasher, policy: policy, guard: guard}
 }
 func (s *service) SendEmailConfirmation(ctx context.Context, userId string) error {
 	u, err := s.storage.FindById(ctx, userId)
@@ -35,8 +65,12 @@ func (s *service) SendEmailConfirmation(ctx context.Context, userId string) erro
 	if _, err := s.cache.Get([]byte(fmt.Sprintf("cd%s", userId))); err == nil {
 		return errormiddleware.ForbiddenError([]string{"message resending cooldown still not expired"}, "can't send message now because of cooldown")
 	}
+	receiver := u.Email
+	if len(u.PendingEmail) > 0 {
+		receiver = u.PendingEmail
+	}
 	code := primitive.NewObjectID().Hex()
-	ok := email.SendEmailConfirmationMessage(u.Email, u.Login, code)
+	ok := email.SendEmailConfirmationMessage(receiver, u.Login, code)
 	if !ok {
 		return fmt.Errorf("can't send email message")
 	}
@@ -60,6 +94,16 @@ func (s *service) ValidateEmailConfirmationCode(ctx context.Context, userId stri
 	if string(cached_code) != code {
 		return errormiddleware.NotFoundError([]string{"code is incorrect"}, "code has found in cache, but provided code is incorrect. maybe the wrong link")
 	}
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return errormiddleware.NotFoundError([]string{"user with provided id not found"}, err.Error())
+	}
+	if len(u.PendingEmail) > 0 {
+		// email could be taken by someone else while it was waiting for confirmation
+		if owner, err := s.storage.FindByEmail(ctx, u.PendingEmail); err == nil && owner.Id != u.Id {
+			return errormiddleware.NotUniqueError([]string{"email already taken"}, fmt.Sprintf("pending email of user %s was taken by %s", userId, owner.Id.Hex()))
+		}
+	}
 	s.cache.Delete([]byte(fmt.Sprintf("cd%s", userId)))
 	s.cache.Delete([]byte(fmt.Sprintf("code%s", userId)))
 
@@ -69,7 +113,12 @@ func (s *service) ValidateEmailConfirmationCode(ctx context.Context, userId stri
 	}
 	return nil
 }
-func (s *service) SignInUser(ctx context.Context, model *AuthUserByLoginAndPassword) (*User, error) {
+func (s *service) SignInUser(ctx context.Context, model *AuthUserByLoginAndPassword, ip string) (*User, error) {
+	// attempt is counted for ip here, so unknown logins are counted too
+	if wait := s.guard.Check("", ip); wait > 0 {
+		s.logger.Warnf("sign in from %s was rejected because of too many failed attempts", ip)
+		return nil, lockedError(wait)
+	}
 	u, err := s.storage.FindByLogin(ctx, model.Login)
 	if err != nil {
 		u, err = s.storage.FindByEmail(ctx, model.Login)
@@ -77,15 +126,36 @@ func (s *service) SignInUser(ctx context.Context, model *AuthUserByLoginAndPassw
 			return nil, err
 		}
 	}
+	userId := u.Id.Hex()
+	// locked account is not signed in even with the right password, so the password can't be guessed during lockout
+	if wait := s.guard.Check(userId, ""); wait > 0 {
+		s.logger.Warnf("sign in of user %s (%s) from %s was rejected because of too many failed attempts", u.Login, userId, ip)
+		return nil, lockedError(wait)
+	}
 
-	if err = bcrypt.CompareHashAndPassword(u.Password, []byte(model.Password)); err != nil {
+	if err = s.hasher.Compare(u.Password, model.Password); err != nil {
+		s.failSignIn(ctx, u, ip)
 		return nil, err
 	}
+	s.guard.Success(userId, ip)
+	if s.hasher.NeedsRehash(u.Password) {
+		// password is known only here, so it's the only place to upgrade old hashes
+		if pass, err := s.hasher.Hash(model.Password); err != nil {
+			s.logger.Errorf("can't rehash password of user %s: %v", u.Login, err)
+		} else if err := s.storage.ChangeUserPassword(ctx, u.Id.Hex(), pass); err != nil {
+			s.logger.Errorf("can't update password hash of user %s: %v", u.Login, err)
+		} else {
+			s.logger.Infof("password hash of user %s has been upgraded", u.Login)
+		}
+	}
 
 	return u, nil
 }
 func (s *service) RegisterUser(ctx context.Context, model *RegisterUserQuery) (*User, error) {
-	pass, err := bcrypt.GenerateFromPassword([]byte(model.Password), bcrypt.MinCost)
+	if err := s.policy.Check(model.Password); err != nil {
+		return nil, err
+	}
+	pass, err := s.hasher.Hash(model.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +188,85 @@ func (s *service) RegisterUser(ctx context.Context, model *RegisterUserQuery) (*
 	}
 	return &user, nil
 }
+
+// Signs in user by identity verified by OpenID provider. Identity is linked to the user with the same email
+// only if both sides have confirmed it, otherwise anyone could take an account by registering its email at some provider
+func (s *service) SignInWithIdentity(ctx context.Context, query *IdentitySignInQuery) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if u, err := s.storage.FindByIdentity(ctx, query.Provider, query.Subject); err == nil {
+		return u, nil
+	}
+	if len(query.Email) == 0 {
+		return nil, errormiddleware.BadRequestError([]string{"identity provider didn't share user's email"}, fmt.Sprintf("%s identity %s has no email", query.Provider, query.Subject))
+	}
+	identity := Identity{Provider: query.Provider, Subject: query.Subject}
+	if u, err := s.storage.FindByEmail(ctx, query.Email); err == nil {
+		if !query.EmailVerified || !u.EmailConfirmed {
+			s.logger.Warnf("%s identity %s wasn't linked to user %s because email is not confirmed", query.Provider, query.Subject, u.Login)
+			return nil, errormiddleware.NotUniqueError([]string{"email already taken", "sign in with password and confirm email to link the account"}, "email of identity is not confirmed on one of sides")
+		}
+		if err := s.storage.AddIdentity(ctx, u.Id.Hex(), identity); err != nil {
+			return nil, err
+		}
+		s.logger.Warnf("%s identity %s has been linked to user %s (%s)", query.Provider, query.Subject, u.Login, u.Id.Hex())
+		content := fmt.Sprintf("Your %s account has been linked, now it can be used to sign in. If it wasn't you, change your password immediately", query.Provider)
+		if err := s.rabbitSender.SendNotificationMessage(ctx, u.Id.Hex(), content, "security"); err != nil {
+			s.logger.Errorf("can't send identity linked notification: %v", err)
+		}
+		return u, nil
+	}
+
+	login, err := s.identityLogin(ctx, query.Login, query.Email)
+	if err != nil {
+		return nil, err
+	}
+	// user has no password, it can be set later with password reset
+	user := User{
+		Login:          login,
+		Roles:          []string{"user"},
+		Email:          query.Email,
+		EmailConfirmed: query.EmailVerified,
+		Identities:     []Identity{identity},
+	}
+	result, err := s.storage.AddUser(ctx, &user)
+	if err != nil {
+		return nil, err
+	}
+	user.Id, err = primitive.ObjectIDFromHex(result)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Infof("user %s (%s) has been registered by %s identity", user.Login, result, query.Provider)
+	return &user, nil
+}
+
+// Makes a free login of provider's username or email, random suffix is added when it's taken
+func (s *service) identityLogin(ctx context.Context, username, email string) (string, error) {
+	base := onlyLatin(username)
+	if len(base) < identity_login_min {
+		base = onlyLatin(strings.Split(email, "@")[0])
+	}
+	if len(base) < identity_login_min {
+		base = "user"
+	}
+	base = base[:min(len(base), identity_login_max)]
+	if _, err := s.storage.FindByLogin(ctx, base); err != nil {
+		return base, nil
+	}
+	for i := 0; i < identity_login_tries; i++ {
+		suffix, err := randomLetters(6)
+		if err != nil {
+			return "", err
+		}
+		login := base[:min(len(base), identity_login_max-len(suffix))] + suffix
+		if _, err := s.storage.FindByLogin(ctx, login); err != nil {
+			return login, nil
+		}
+	}
+	return "", errormiddleware.NotUniqueError([]string{"can't find free login for the user"}, fmt.Sprintf("all generated logins of %s are taken", base))
+}
 func (s *service) GetUserById(ctx context.Context, userId string) (*User, error) {
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -147,7 +296,7 @@ func (s *service) DeleteUser(ctx context.Context, userId, password string) error
 		return errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
 	}
 
-	if err = bcrypt.CompareHashAndPassword(u.Password, []byte(password)); err != nil {
+	if err = s.hasher.Compare(u.Password, password); err != nil {
 		s.logger.Warnf("user %s (%s) tried to delete account with a wrong password", u.Login, userId)
 		return errormiddleware.BadRequestError([]string{"wrong password"}, err.Error())
 	}
@@ -191,3 +340,358 @@ func (s *service) UpdateUserLogin(ctx context.Context, userId, newLogin string)
 	}
 	return u, nil
 }
+func (s *service) UpdateUserPassword(ctx context.Context, userId, password, newPassword string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return nil, errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if err = s.hasher.Compare(u.Password, password); err != nil {
+		s.logger.Warnf("user %s (%s) tried to change password with a wrong password", u.Login, userId)
+		return nil, errormiddleware.BadRequestError([]string{"wrong password"}, err.Error())
+	}
+	if err := s.policy.Check(newPassword); err != nil {
+		return nil, err
+	}
+	pass, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.ChangeUserPassword(ctx, userId, pass); err != nil {
+		return nil, err
+	}
+	s.logger.Warnf("user %s (%s) has changed password", u.Login, userId)
+	if err := s.rabbitSender.SendUserPasswordChangedMessage(ctx, userId); err != nil {
+		s.logger.Errorf("can't send password changed message: %v", err)
+	}
+	return u, nil
+}
+
+func (s *service) GrantRole(ctx context.Context, callerId, userId, role string) (*User, error) {
+	return s.changeRole(ctx, callerId, userId, role, true)
+}
+func (s *service) RevokeRole(ctx context.Context, callerId, userId, role string) (*User, error) {
+	return s.changeRole(ctx, callerId, userId, role, false)
+}
+
+// Tokens keep roles until they are refreshed, so gateway is notified to update user's sessions.
+// Nobody can change own roles, so the last admin can't lock everyone out by mistake
+func (s *service) changeRole(ctx context.Context, callerId, userId, role string, grant bool) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// request may come from any signed service, so caller's roles are checked here too
+	if !slices.ContainsFunc(signature.CallerFromContext(ctx).Roles, func(r string) bool { return slices.Contains(role_managers, r) }) {
+		return nil, errormiddleware.ForbiddenError([]string{"you don't have permission to manage roles"}, fmt.Sprintf("user %s tried to change roles without permission", callerId))
+	}
+	if !slices.Contains(known_roles, role) {
+		return nil, errormiddleware.BadRequestError([]string{fmt.Sprintf("unknown role %s", role), fmt.Sprintf("known roles are %s", strings.Join(known_roles, ", "))}, "received unknown role")
+	}
+	if callerId == userId {
+		return nil, errormiddleware.ForbiddenError([]string{"you can't change your own roles"}, fmt.Sprintf("user %s tried to change own roles", userId))
+	}
+	if !grant && role == "user" {
+		return nil, errormiddleware.BadRequestError([]string{"user role can't be revoked"}, "tried to revoke base role")
+	}
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return nil, errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if slices.Contains(u.Roles, role) == grant {
+		return u, nil
+	}
+	action := "revoked from"
+	if grant {
+		err = s.storage.AddUserRole(ctx, userId, role)
+		action = "granted to"
+	} else {
+		err = s.storage.RemoveUserRole(ctx, userId, role)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u, err = s.storage.FindById(ctx, userId); err != nil {
+		return nil, err
+	}
+	s.logger.Warnf("role %s has been %s user %s (%s) by %s", role, action, u.Login, userId, callerId)
+	if err := s.rabbitSender.SendUserRolesChangedMessage(ctx, userId, u.Roles); err != nil {
+		s.logger.Errorf("can't send roles changed message: %v", err)
+	}
+	content := fmt.Sprintf("Role %s has been %s your account", role, action)
+	if err := s.rabbitSender.SendNotificationMessage(ctx, userId, content, "security"); err != nil {
+		s.logger.Errorf("can't send roles changed notification: %v", err)
+	}
+	return u, nil
+}
+
+// New email is kept as pending until it's confirmed, so user can still sign in with the current one
+func (s *service) UpdateUserEmail(ctx context.Context, userId, newEmail string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := s.storage.FindByEmail(ctx, newEmail); err == nil {
+		return nil, errormiddleware.NotUniqueError([]string{"email already taken"}, fmt.Sprintf("email %s already taken", newEmail))
+	}
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return nil, errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if err := s.storage.ChangeUserEmail(ctx, userId, newEmail); err != nil {
+		return nil, err
+	}
+	s.logger.Infof("user %s (%s) has requested email change to %s", u.Login, userId, newEmail)
+
+	// code sent to the previous pending email must not confirm the new one
+	s.cache.Delete([]byte(fmt.Sprintf("cd%s", userId)))
+	s.cache.Delete([]byte(fmt.Sprintf("code%s", userId)))
+	if err := s.SendEmailConfirmation(ctx, userId); err != nil {
+		s.logger.Warnf("can't send confirmation to new email of user %s: %v", userId, err)
+	}
+	if err := s.rabbitSender.SendUserEmailChangedMessage(ctx, userId, u.Email, newEmail); err != nil {
+		s.logger.Errorf("can't send email changed message: %v", err)
+	}
+	return s.storage.FindById(ctx, userId)
+}
+
+// Response doesn't depend on whether user with the email exists, so emails can't be enumerated
+func (s *service) SendPasswordReset(ctx context.Context, mail string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	u, err := s.storage.FindByEmail(ctx, mail)
+	if err != nil {
+		s.logger.Infof("password reset was requested for unknown email %s", mail)
+		return nil
+	}
+	userId := u.Id.Hex()
+	if _, err := s.cache.Get([]byte(fmt.Sprintf("rcd%s", userId))); err == nil {
+		s.logger.Infof("password reset for user %s (%s) was requested during cooldown", u.Login, userId)
+		return nil
+	}
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	reset := &PasswordReset{UserId: userId, TokenHash: hashToken(token), ExpiresAt: time.Now().Add(reset_token_ttl)}
+	if err := s.storage.CreatePasswordReset(ctx, reset); err != nil {
+		return err
+	}
+	if ok := email.SendPasswordResetMessage(u.Email, u.Login, token); !ok {
+		return fmt.Errorf("can't send email message")
+	}
+	if err := s.cache.Set([]byte(fmt.Sprintf("rcd%s", userId)), []byte("cooldown"), int(reset_email_cooldown/time.Second)); err != nil {
+		return err
+	}
+	s.logger.Infof("password reset link was sent to user %s (%s)", u.Login, userId)
+	return nil
+}
+func (s *service) ResetPassword(ctx context.Context, token, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// token is checked after the policy, so it's not spent on a weak password
+	if err := s.policy.Check(password); err != nil {
+		return err
+	}
+	reset, err := s.storage.TakePasswordReset(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	pass, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.ChangeUserPassword(ctx, reset.UserId, pass); err != nil {
+		return err
+	}
+	s.logger.Warnf("user %s has reset password", reset.UserId)
+	if err := s.rabbitSender.SendUserPasswordChangedMessage(ctx, reset.UserId); err != nil {
+		s.logger.Errorf("can't send password changed message: %v", err)
+	}
+	return nil
+}
+
+// Secret can be enrolled again until 2FA is enabled, e.g. if user lost the QR code
+func (s *service) EnrollTwoFactor(ctx context.Context, userId string) (*TwoFactorEnrollment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return nil, errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if u.TwoFactor {
+		return nil, errormiddleware.BadRequestError([]string{"two-factor authentication is already enabled"}, fmt.Sprintf("user %s has 2FA enabled", userId))
+	}
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.SetTotpSecret(ctx, userId, secret); err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollment{Secret: secret, URI: totp.URI(totp_issuer, u.Login, secret)}, nil
+}
+
+// Enables 2FA after the code from authenticator app is verified. Recovery codes are returned only once
+func (s *service) EnableTwoFactor(ctx context.Context, userId, code string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return nil, errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if u.TwoFactor {
+		return nil, errormiddleware.BadRequestError([]string{"two-factor authentication is already enabled"}, fmt.Sprintf("user %s has 2FA enabled", userId))
+	}
+	if len(u.TotpSecret) == 0 {
+		return nil, errormiddleware.BadRequestError([]string{"two-factor authentication is not enrolled"}, fmt.Sprintf("user %s has no totp secret", userId))
+	}
+	step, ok := totp.Validate(u.TotpSecret, code, time.Now())
+	if !ok {
+		return nil, errormiddleware.BadRequestError([]string{"wrong code"}, "totp code was not validated")
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storage.EnableTwoFactor(ctx, userId, step, hashes); err != nil {
+		return nil, err
+	}
+	s.logger.Warnf("user %s (%s) has enabled two-factor authentication", u.Login, userId)
+	if err := s.rabbitSender.SendNotificationMessage(ctx, userId, "Two-factor authentication has been enabled for your account", "security"); err != nil {
+		s.logger.Errorf("can't send 2FA enabled notification: %v", err)
+	}
+	return codes, nil
+}
+func (s *service) DisableTwoFactor(ctx context.Context, userId, password, code string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if !u.TwoFactor {
+		return errormiddleware.BadRequestError([]string{"two-factor authentication is not enabled"}, fmt.Sprintf("user %s has no 2FA", userId))
+	}
+	if err = s.hasher.Compare(u.Password, password); err != nil {
+		s.logger.Warnf("user %s (%s) tried to disable 2FA with a wrong password", u.Login, userId)
+		return errormiddleware.BadRequestError([]string{"wrong password"}, err.Error())
+	}
+	if err := s.verifyTwoFactorCode(ctx, u, code); err != nil {
+		return err
+	}
+	if err := s.storage.DisableTwoFactor(ctx, userId); err != nil {
+		return err
+	}
+	s.logger.Warnf("user %s (%s) has disabled two-factor authentication", u.Login, userId)
+	if err := s.rabbitSender.SendNotificationMessage(ctx, userId, "Two-factor authentication has been disabled for your account. If it wasn't you, change your password immediately", "security"); err != nil {
+		s.logger.Errorf("can't send 2FA disabled notification: %v", err)
+	}
+	return nil
+}
+
+// Second step of sign in, code can be taken from authenticator app or be one of recovery codes.
+// Failed codes are counted the same way as failed passwords
+func (s *service) VerifyTwoFactor(ctx context.Context, userId, code, ip string) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if wait := s.guard.Check(userId, ip); wait > 0 {
+		s.logger.Warnf("2FA of user %s from %s was rejected because of too many failed attempts", userId, ip)
+		return nil, lockedError(wait)
+	}
+	u, err := s.storage.FindById(ctx, userId)
+	if err != nil {
+		return nil, errormiddleware.NotFoundError([]string{"user with provided id does not exists"}, err.Error())
+	}
+	if !u.TwoFactor {
+		return nil, errormiddleware.BadRequestError([]string{"two-factor authentication is not enabled"}, fmt.Sprintf("user %s has no 2FA", userId))
+	}
+	if err := s.verifyTwoFactorCode(ctx, u, code); err != nil {
+		s.failSignIn(ctx, u, ip)
+		return nil, err
+	}
+	s.guard.Success(userId, ip)
+	return u, nil
+}
+func (s *service) verifyTwoFactorCode(ctx context.Context, u *User, code string) error {
+	if step, ok := totp.Validate(u.TotpSecret, code, time.Now()); ok {
+		return s.storage.UseTotpStep(ctx, u.Id.Hex(), step)
+	}
+	recovery := normalizeRecoveryCode(code)
+	if len(recovery) == 0 {
+		return errormiddleware.UnauthorizedError([]string{"wrong code"}, "empty code received")
+	}
+	if err := s.storage.UseRecoveryCode(ctx, u.Id.Hex(), hashToken(recovery)); err != nil {
+		return err
+	}
+	s.logger.Warnf("user %s (%s) has used recovery code", u.Login, u.Id.Hex())
+	return nil
+}
+func (s *service) failSignIn(ctx context.Context, u *User, ip string) {
+	if !s.guard.Fail(u.Id.Hex()) {
+		return
+	}
+	s.logger.Warnf("user %s (%s) has been locked after failed sign in from %s", u.Login, u.Id.Hex(), ip)
+	content := fmt.Sprintf("There were too many failed attempts to sign in to your account, so signing in is locked for %d minutes. If it wasn't you, we recommend changing your password", int(s.guard.Duration().Minutes()))
+	if err := s.rabbitSender.SendNotificationMessage(ctx, u.Id.Hex(), content, "security"); err != nil {
+		s.logger.Errorf("can't send account locked notification: %v", err)
+	}
+}
+func lockedError(wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	return errormiddleware.LockedError([]string{fmt.Sprintf("too many failed sign in attempts, try again in %d seconds", seconds)}, "sign in is temporarily locked")
+}
+func newResetToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Codes are shown as xxxxx-xxxxx, only hashes are stored
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recovery_codes_count)
+	hashes := make([]string, 0, recovery_codes_count)
+	for i := 0; i < recovery_codes_count; i++ {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(bytes)
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashToken(code))
+	}
+	return codes, hashes, nil
+}
+func onlyLatin(value string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, value)
+}
+func randomLetters(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	for i := range bytes {
+		bytes[i] = 'a' + bytes[i]%26
+	}
+	return string(bytes), nil
+}
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
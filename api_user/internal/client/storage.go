@@ -6,8 +6,30 @@ type Storage interface {
 	FindByLogin(ctx context.Context, login string) (*User, error)
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	FindById(ctx context.Context, id string) (*User, error)
+	FindByIdentity(ctx context.Context, provider, subject string) (*User, error)
+	// Links external identity to the user, identity can belong only to one user
+	AddIdentity(ctx context.Context, userId string, identity Identity) error
+	// Confirms user's email, pending email replaces the current one if there is one
 	ApproveUserEmail(ctx context.Context, id string) error
 	AddUser(ctx context.Context, user *User) (string, error)
 	DeleteUser(ctx context.Context, userId string) error
 	ChangeUserLogin(ctx context.Context, userId, newLogin string) error
+	ChangeUserPassword(ctx context.Context, userId string, password []byte) error
+	AddUserRole(ctx context.Context, userId, role string) error
+	RemoveUserRole(ctx context.Context, userId, role string) error
+	// Sets new email as pending and resets confirmation, current email is replaced on approval
+	ChangeUserEmail(ctx context.Context, userId, email string) error
+	// Replaces user's previous reset if there was one
+	CreatePasswordReset(ctx context.Context, reset *PasswordReset) error
+	// Finds not expired reset by token hash and deletes it, so token can be used only once
+	TakePasswordReset(ctx context.Context, tokenHash string) (*PasswordReset, error)
+	// Stores secret of 2FA that is not enabled yet
+	SetTotpSecret(ctx context.Context, userId, secret string) error
+	EnableTwoFactor(ctx context.Context, userId string, step int64, recoveryCodes []string) error
+	// Removes secret and recovery codes
+	DisableTwoFactor(ctx context.Context, userId string) error
+	// Accepts only steps newer than the last used one
+	UseTotpStep(ctx context.Context, userId string, step int64) error
+	// Removes recovery code hash, so every code can be used only once
+	UseRecoveryCode(ctx context.Context, userId, codeHash string) error
 }
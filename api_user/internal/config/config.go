@@ -2,6 +2,7 @@ package config
 
 import (
 	"sync"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/logging"
@@ -26,6 +27,8 @@ type SmtpConfig struct {
 	SmtpPort       int    `env:"SMTP_PORT"`
 	SmtpLogin      string `env:"SMTP_LOGIN"`
 	SmtpPassword   string `env:"SMTP_PASS"`
+	// Page where user sets a new password, reset token is added to it as query parameter
+	PasswordResetURL string `env:"PASSWORD_RESET_URL"`
 }
 type RabbitConfig struct {
 	Rabbit_Host string `env:"RABBITMQ_HOST" env-required:"true"`
@@ -33,11 +36,50 @@ type RabbitConfig struct {
 	Rabbit_User string `env:"RABBITMQ_USER" env-required:"true"`
 	Rabbit_Pass string `env:"RABBITMQ_PASS" env-required:"true"`
 }
+
+// Policy is checked when password is set. Hashes made by other algorithm or
+// with weaker parameters are upgraded on the next successful sign in
+type PasswordConfig struct {
+	MinLength        int    `env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MaxLength        int    `env:"PASSWORD_MAX_LENGTH" env-default:"64"`
+	RequireLowercase bool   `env:"PASSWORD_REQUIRE_LOWERCASE" env-default:"true"`
+	RequireUppercase bool   `env:"PASSWORD_REQUIRE_UPPERCASE" env-default:"true"`
+	RequireDigit     bool   `env:"PASSWORD_REQUIRE_DIGIT" env-default:"true"`
+	RequireSpecial   bool   `env:"PASSWORD_REQUIRE_SPECIAL" env-default:"true"`
+	Algorithm        string `env:"PASSWORD_HASH" env-default:"argon2id"` // bcrypt or argon2id
+	BcryptCost       int    `env:"PASSWORD_BCRYPT_COST" env-default:"12"`
+	Argon2Memory     uint32 `env:"PASSWORD_ARGON2_MEMORY" env-default:"65536"` // in KiB
+	Argon2Time       uint32 `env:"PASSWORD_ARGON2_TIME" env-default:"3"`
+	Argon2Threads    uint8  `env:"PASSWORD_ARGON2_THREADS" env-default:"2"`
+}
+
+// Failed sign in attempts are counted per account and per ip. After free attempts
+// every next one is delayed twice as long, after max attempts sign in is locked for lockout duration
+type LockoutConfig struct {
+	FreeAttempts   int           `env:"SIGNIN_FREE_ATTEMPTS" env-default:"3"`
+	MaxAttempts    int           `env:"SIGNIN_MAX_ATTEMPTS" env-default:"10"`
+	IpFreeAttempts int           `env:"SIGNIN_IP_FREE_ATTEMPTS" env-default:"10"`
+	IpMaxAttempts  int           `env:"SIGNIN_IP_MAX_ATTEMPTS" env-default:"50"`
+	BaseDelay      time.Duration `env:"SIGNIN_BASE_DELAY" env-default:"1s"`
+	MaxDelay       time.Duration `env:"SIGNIN_MAX_DELAY" env-default:"5m"`
+	Duration       time.Duration `env:"SIGNIN_LOCKOUT_DURATION" env-default:"15m"`
+}
+
+// Secret is shared by all services, internal requests are signed with it.
+// Signed requests with body larger than MaxBodySize bytes are rejected
+type SignatureConfig struct {
+	Service     string `env:"SERVICE_NAME" env-default:"api_user"`
+	Secret      string `env:"SERVICE_SECRET" env-required:"true"`
+	MaxBodySize int64  `env:"SERVICE_MAX_BODY_SIZE" env-default:"1048576"`
+}
 type Config struct {
-	Server   *ServerConfig
-	Database *DatabaseConfig
-	SMTP     *SmtpConfig
-	Rabbit   *RabbitConfig
+	Server    *ServerConfig
+	Database  *DatabaseConfig
+	SMTP      *SmtpConfig
+	Rabbit    *RabbitConfig
+	Signature *SignatureConfig
+	Password  *PasswordConfig
+	Lockout   *LockoutConfig
 }
 
 var cfg *Config
@@ -48,6 +90,9 @@ func GetConfig() *Config {
 		logger := logging.GetLogger()
 		logger.Info("reading api config...")
 		srvCfg := &ServerConfig{}
+		signatureCfg := &SignatureConfig{}
+		passwordCfg := &PasswordConfig{}
+		lockoutCfg := &LockoutConfig{}
 		dbCfg := &DatabaseConfig{}
 		smtpCfg := &SmtpConfig{}
 		rabbitCfg := &RabbitConfig{}
@@ -75,11 +120,29 @@ func GetConfig() *Config {
 			logger.Error(desc)
 			logger.Fatal(err)
 		}
+		if err := cleanenv.ReadConfig("config/.env", signatureCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", passwordCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
+		if err := cleanenv.ReadConfig("config/.env", lockoutCfg); err != nil {
+			desc, _ := cleanenv.GetDescription(cfg, nil)
+			logger.Error(desc)
+			logger.Fatal(err)
+		}
 		cfg = &Config{
-			Server:   srvCfg,
-			Database: dbCfg,
-			SMTP:     smtpCfg,
-			Rabbit:   rabbitCfg,
+			Server:    srvCfg,
+			Database:  dbCfg,
+			SMTP:      smtpCfg,
+			Rabbit:    rabbitCfg,
+			Signature: signatureCfg,
+			Password:  passwordCfg,
+			Lockout:   lockoutCfg,
 		}
 	})
 	return cfg
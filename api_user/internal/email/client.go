@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"net/url"
 
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/logging"
@@ -16,6 +17,7 @@ type smtpInfo struct {
 	user     string
 	password string
 	gateway  string
+	reset    string
 	active   bool
 }
 
@@ -30,12 +32,16 @@ func init() {
 		port:     cfg.SmtpPort,
 		user:     cfg.SmtpLogin,
 		gateway:  cfg.GatewayAddress,
+		reset:    cfg.PasswordResetURL,
 		active:   true,
 	}
 
 	if len(smtpConfig.host) == 0 {
 		smtpConfig.active = false
 	}
+	if len(smtpConfig.reset) == 0 {
+		smtpConfig.reset = fmt.Sprintf("%s/password/reset", smtpConfig.gateway)
+	}
 	logger = logging.GetLogger()
 }
 func SendEmailConfirmationMessage(receiver string, userlogin string, code string) bool {
@@ -43,9 +49,43 @@ func SendEmailConfirmationMessage(receiver string, userlogin string, code string
 		logger.Warnf("SMTP hosting is not provided. Confirmation email wasn't sent")
 		return false
 	}
+	ok := send(receiver, "Подтверждение почты", "templates/email.confirmation.html", struct {
+		ProjectName     string
+		Login           string
+		ConfirmationURL string
+	}{
+		ProjectName:     "Example",
+		Login:           userlogin,
+		ConfirmationURL: fmt.Sprintf("%s/api/v1/users/email?code=%s", smtpConfig.gateway, code),
+	})
+	if ok {
+		logger.Infof("sent email confirmation message to %s from %s", receiver, smtpConfig.user)
+	}
+	return ok
+}
+func SendPasswordResetMessage(receiver string, userlogin string, token string) bool {
+	if !smtpConfig.active {
+		logger.Warnf("SMTP hosting is not provided. Password reset email wasn't sent")
+		return false
+	}
+	ok := send(receiver, "Восстановление пароля", "templates/password.reset.html", struct {
+		ProjectName string
+		Login       string
+		ResetURL    string
+	}{
+		ProjectName: "Example",
+		Login:       userlogin,
+		ResetURL:    fmt.Sprintf("%s?token=%s", smtpConfig.reset, url.QueryEscape(token)),
+	})
+	if ok {
+		logger.Infof("sent password reset message to %s from %s", receiver, smtpConfig.user)
+	}
+	return ok
+}
+func send(receiver string, subject string, templateFile string, data any) bool {
 	auth := smtp.PlainAuth("", smtpConfig.user, smtpConfig.password, smtpConfig.host)
 
-	t, err := template.ParseFiles("templates/email.confirmation.html")
+	t, err := template.ParseFiles(templateFile)
 	if err != nil {
 		logger.Errorf("can't find or parse html template: %s", err)
 		return false
@@ -53,21 +93,13 @@ func SendEmailConfirmationMessage(receiver string, userlogin string, code string
 	var body bytes.Buffer
 
 	mimeHeaders := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	_, err = body.Write([]byte(fmt.Sprintf("From: %s \r\nTo: %s \r\nSubject: Подтверждение почты \n%s\n\n", smtpConfig.user, receiver, mimeHeaders)))
+	_, err = body.Write([]byte(fmt.Sprintf("From: %s \r\nTo: %s \r\nSubject: %s \n%s\n\n", smtpConfig.user, receiver, subject, mimeHeaders)))
 	if err != nil {
 		logger.Errorf("can't create email header: %s", err)
 		return false
 	}
 
-	err = t.Execute(&body, struct {
-		ProjectName     string
-		Login           string
-		ConfirmationURL string
-	}{
-		ProjectName:     "Example",
-		Login:           userlogin,
-		ConfirmationURL: fmt.Sprintf("%s/api/v1/users/email?code=%s", smtpConfig.gateway, code),
-	})
+	err = t.Execute(&body, data)
 	if err != nil {
 		logger.Errorf("can't create email body: %s", err)
 		return false
@@ -78,6 +110,5 @@ func SendEmailConfirmationMessage(receiver string, userlogin string, code string
 		logger.Errorf("can't send email: %s", err)
 		return false
 	}
-	logger.Infof("sent email confirmation message to %s from %s", receiver, smtpConfig.user)
 	return true
 }
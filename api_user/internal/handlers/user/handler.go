@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_user/internal/client"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/errormiddleware"
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/logging"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/signature"
 )
 
 const (
@@ -19,10 +21,20 @@ const (
 	url_user_find         = "/users"
 	url_user_delete       = "/users/delete"
 	url_user_changelogin  = "/users/changename"
+	url_user_password     = "/users/password"
+	url_user_email        = "/users/email"
+	url_password_forgot   = "/users/password/forgot"
+	url_password_reset    = "/users/password/reset"
+	url_twofactor_enroll  = "/users/2fa/enroll"
+	url_twofactor_enable  = "/users/2fa/enable"
+	url_twofactor_disable = "/users/2fa/disable"
+	url_twofactor_verify  = "/users/2fa/verify"
+	url_identity          = "/users/oidc"
+	url_user_roles        = "/users/roles"
 )
 
 type Service interface {
-	SignInUser(ctx context.Context, query *client.AuthUserByLoginAndPassword) (*client.User, error)
+	SignInUser(ctx context.Context, query *client.AuthUserByLoginAndPassword, ip string) (*client.User, error)
 	RegisterUser(ctx context.Context, query *client.RegisterUserQuery) (*client.User, error)
 	SendEmailConfirmation(ctx context.Context, userId string) error
 	ValidateEmailConfirmationCode(ctx context.Context, userId string, code string) error
@@ -30,6 +42,17 @@ type Service interface {
 	GetUserByLogin(ctx context.Context, login string) (*client.User, error)
 	DeleteUser(ctx context.Context, userId, password string) error
 	UpdateUserLogin(ctx context.Context, userId, newLogin string) (*client.User, error)
+	UpdateUserPassword(ctx context.Context, userId, password, newPassword string) (*client.User, error)
+	UpdateUserEmail(ctx context.Context, userId, newEmail string) (*client.User, error)
+	SendPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+	EnrollTwoFactor(ctx context.Context, userId string) (*client.TwoFactorEnrollment, error)
+	EnableTwoFactor(ctx context.Context, userId, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userId, password, code string) error
+	VerifyTwoFactor(ctx context.Context, userId, code, ip string) (*client.User, error)
+	SignInWithIdentity(ctx context.Context, query *client.IdentitySignInQuery) (*client.User, error)
+	GrantRole(ctx context.Context, callerId, userId, role string) (*client.User, error)
+	RevokeRole(ctx context.Context, callerId, userId, role string) (*client.User, error)
 }
 type Handler struct {
 	Logger      *logging.Logger
@@ -43,9 +66,20 @@ func (h *Handler) Register(route *httprouter.Router) {
 	route.HandlerFunc(http.MethodGet, url_user_find, h.Logger.Middleware(errormiddleware.Middleware(h.FindUser)))
 	route.HandlerFunc(http.MethodDelete, url_user_delete, h.Logger.Middleware(errormiddleware.Middleware(h.DeleteUser)))
 	route.HandlerFunc(http.MethodPatch, url_user_changelogin, h.Logger.Middleware(errormiddleware.Middleware(h.ChangeUserLogin)))
+	route.HandlerFunc(http.MethodPatch, url_user_password, h.Logger.Middleware(errormiddleware.Middleware(h.ChangeUserPassword)))
+	route.HandlerFunc(http.MethodPatch, url_user_email, h.Logger.Middleware(errormiddleware.Middleware(h.ChangeUserEmail)))
+	route.HandlerFunc(http.MethodPost, url_password_forgot, h.Logger.Middleware(errormiddleware.Middleware(h.ForgotPassword)))
+	route.HandlerFunc(http.MethodPost, url_password_reset, h.Logger.Middleware(errormiddleware.Middleware(h.ResetPassword)))
+	route.HandlerFunc(http.MethodPost, url_twofactor_enroll, h.Logger.Middleware(errormiddleware.Middleware(h.EnrollTwoFactor)))
+	route.HandlerFunc(http.MethodPost, url_twofactor_enable, h.Logger.Middleware(errormiddleware.Middleware(h.EnableTwoFactor)))
+	route.HandlerFunc(http.MethodPost, url_twofactor_disable, h.Logger.Middleware(errormiddleware.Middleware(h.DisableTwoFactor)))
+	route.HandlerFunc(http.MethodPost, url_twofactor_verify, h.Logger.Middleware(errormiddleware.Middleware(h.VerifyTwoFactor)))
+	route.HandlerFunc(http.MethodPost, url_identity, h.Logger.Middleware(errormiddleware.Middleware(h.SignInWithIdentity)))
+	route.HandlerFunc(http.MethodPost, url_user_roles, h.Logger.Middleware(errormiddleware.Middleware(h.GrantRole)))
+	route.HandlerFunc(http.MethodDelete, url_user_roles, h.Logger.Middleware(errormiddleware.Middleware(h.RevokeRole)))
 }
 func (h *Handler) ChangeUserLogin(w http.ResponseWriter, r *http.Request) error {
-	userId := r.Header.Get("User")
+	userId := signature.CallerFromContext(r.Context()).UserId
 	if len(userId) <= 0 {
 		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
 	}
@@ -71,7 +105,7 @@ func (h *Handler) ChangeUserLogin(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) error {
-	userId := r.Header.Get("User")
+	userId := signature.CallerFromContext(r.Context()).UserId
 	if len(userId) <= 0 {
 		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
 	}
@@ -128,7 +162,7 @@ func (h *Handler) FindUser(w http.ResponseWriter, r *http.Request) error {
 	return errormiddleware.BadRequestError([]string{"query has to have one of parameters", "login: user login", "id: user id"}, "bad request provided")
 }
 func (h *Handler) ConfirmEmail(w http.ResponseWriter, r *http.Request) error {
-	userId := r.Header.Get("User")
+	userId := signature.CallerFromContext(r.Context()).UserId
 	if len(userId) <= 0 {
 		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
 	}
@@ -156,8 +190,13 @@ func (h *Handler) AuthUser(w http.ResponseWriter, r *http.Request) error {
 		h.Logger.Warn("error occured while decoding request body: %w", err)
 		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
 	}
-	u, err := h.UserService.SignInUser(r.Context(), &query)
+	//gateway forwards address of the client, so failed attempts can be counted per ip
+	u, err := h.UserService.SignInUser(r.Context(), &query, r.Header.Get("X-Real-IP"))
 	if err != nil {
+		var locked *errormiddleware.Error
+		if errors.As(err, &locked) && locked.Code == errormiddleware.LockedErrorCode {
+			return locked
+		}
 		return errormiddleware.NotFoundError([]string{"user with provided login and password not found"}, err.Error())
 	}
 
@@ -193,3 +232,224 @@ func (h *Handler) RegUser(w http.ResponseWriter, r *http.Request) error {
 	h.Logger.Infof("user %s has been registered", query.Login)
 	return nil
 }
+func (h *Handler) ChangeUserPassword(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	var query client.ChangeUserPasswordQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	u, err := h.UserService.UpdateUserPassword(ctx, userId, query.Password, query.NewPassword)
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
+func (h *Handler) ChangeUserEmail(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	var query client.ChangeUserEmailQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	u, err := h.UserService.UpdateUserEmail(ctx, userId, query.Email)
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+	var query client.ForgotPasswordQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	if len(query.Email) == 0 {
+		return errormiddleware.BadRequestError([]string{"email must be provided"}, "received empty email")
+	}
+	if err := h.UserService.SendPasswordReset(r.Context(), query.Email); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) error {
+	defer r.Body.Close()
+	var query client.ResetPasswordQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	if len(query.Token) == 0 || len(query.Password) == 0 {
+		return errormiddleware.BadRequestError([]string{"token and password must be provided"}, "received empty token or password")
+	}
+	if err := h.UserService.ResetPassword(r.Context(), query.Token, query.Password); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	enrollment, err := h.UserService.EnrollTwoFactor(r.Context(), userId)
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(enrollment)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
+func (h *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	var query client.TwoFactorCodeQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	codes, err := h.UserService.EnableTwoFactor(r.Context(), userId, query.Code)
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(&client.RecoveryCodes{Codes: codes})
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
+func (h *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	userId := signature.CallerFromContext(r.Context()).UserId
+	if len(userId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	var query client.DisableTwoFactorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	if err := h.UserService.DisableTwoFactor(r.Context(), userId, query.Password, query.Code); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// User is not authorized yet, gateway sends user's id from verified pre-auth token
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	var query client.VerifyTwoFactorQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	if len(query.UserId) == 0 || len(query.Code) == 0 {
+		return errormiddleware.BadRequestError([]string{"user id and code must be provided"}, "received empty user id or code")
+	}
+	u, err := h.UserService.VerifyTwoFactor(r.Context(), query.UserId, query.Code, r.Header.Get("X-Real-IP"))
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
+
+// Identity is verified by gateway, this endpoint only finds, links or creates the user
+func (h *Handler) SignInWithIdentity(w http.ResponseWriter, r *http.Request) error {
+	var query client.IdentitySignInQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	if len(query.Provider) == 0 || len(query.Subject) == 0 {
+		return errormiddleware.BadRequestError([]string{"provider and subject must be provided"}, "received empty provider or subject")
+	}
+	u, err := h.UserService.SignInWithIdentity(r.Context(), &query)
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
+
+// Permission to manage roles is checked by gateway and by service with caller's roles
+func (h *Handler) GrantRole(w http.ResponseWriter, r *http.Request) error {
+	return h.changeRole(w, r, h.UserService.GrantRole)
+}
+func (h *Handler) RevokeRole(w http.ResponseWriter, r *http.Request) error {
+	return h.changeRole(w, r, h.UserService.RevokeRole)
+}
+func (h *Handler) changeRole(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, callerId, userId, role string) (*client.User, error)) error {
+	callerId := signature.CallerFromContext(r.Context()).UserId
+	if len(callerId) <= 0 {
+		return errormiddleware.UnauthorizedError([]string{"can't get user authorized id"}, "context id was empty")
+	}
+	var query client.ChangeUserRoleQuery
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		return errormiddleware.BadRequestError([]string{"invalid json scheme"}, err.Error())
+	}
+	if len(query.UserId) == 0 || len(query.Role) == 0 {
+		return errormiddleware.BadRequestError([]string{"user id and role must be provided"}, "received empty user id or role")
+	}
+	u, err := change(r.Context(), callerId, query.UserId, query.Role)
+	if err != nil {
+		return err
+	}
+	object, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(object)
+	return nil
+}
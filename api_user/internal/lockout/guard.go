@@ -0,0 +1,134 @@
+package lockout
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/cache"
+)
+
+type attempts struct {
+	Failures int   `json:"failures"`
+	Last     int64 `json:"last"` // unix nano of the last attempt
+	Locked   bool  `json:"locked"`
+}
+
+// Guard counts failed sign in attempts per account and per ip address.
+// Attempt is counted by Check before credentials are verified, so parallel attempts can't pass the backoff
+// together, and it's given back on success. Counters are forgotten after lockout duration without attempts
+type Guard struct {
+	cache cache.Cache
+	cfg   *config.LockoutConfig
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+func NewGuard(cache cache.Cache, cfg *config.LockoutConfig) *Guard {
+	return &Guard{cache: cache, cfg: cfg, now: time.Now}
+}
+
+// Returns how long the caller has to wait before the next attempt, attempt is counted if there is no need to wait.
+// Empty account or ip are not checked
+func (g *Guard) Check(account, ip string) time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var accountState, ipState *attempts
+	var wait time.Duration
+	if len(account) > 0 {
+		accountState = g.get(accountKey(account))
+		wait = g.wait(accountState, g.cfg.FreeAttempts, g.cfg.MaxAttempts)
+	}
+	if len(ip) > 0 {
+		ipState = g.get(ipKey(ip))
+		wait = max(wait, g.wait(ipState, g.cfg.IpFreeAttempts, g.cfg.IpMaxAttempts))
+	}
+	if wait > 0 {
+		return wait
+	}
+	now := g.now().UnixNano()
+	if accountState != nil {
+		accountState.Failures++
+		accountState.Last = now
+		g.set(accountKey(account), accountState)
+	}
+	if ipState != nil {
+		ipState.Failures++
+		ipState.Last = now
+		g.set(ipKey(ip), ipState)
+	}
+	return 0
+}
+
+// Attempt counted by Check has failed. Returns true only once, when the account gets locked
+func (g *Guard) Fail(account string) bool {
+	if len(account) == 0 {
+		return false
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	state := g.get(accountKey(account))
+	if state.Locked || state.Failures < g.cfg.MaxAttempts {
+		return false
+	}
+	state.Locked = true
+	g.set(accountKey(account), state)
+	return true
+}
+
+// Forgets account's failed attempts after successful sign in. Only the successful attempt is given back to ip,
+// so one known account can't be used to reset it's counter
+func (g *Guard) Success(account, ip string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if len(account) > 0 {
+		g.cache.Delete(accountKey(account))
+	}
+	if len(ip) > 0 {
+		state := g.get(ipKey(ip))
+		if state.Failures <= 1 {
+			g.cache.Delete(ipKey(ip))
+			return
+		}
+		state.Failures--
+		g.set(ipKey(ip), state)
+	}
+}
+func (g *Guard) Duration() time.Duration {
+	return g.cfg.Duration
+}
+func (g *Guard) wait(state *attempts, free, limit int) time.Duration {
+	if state.Failures <= free {
+		return 0
+	}
+	delay := g.cfg.Duration
+	if state.Failures < limit {
+		delay = g.cfg.BaseDelay << (state.Failures - free - 1)
+		if delay <= 0 || delay > g.cfg.MaxDelay {
+			delay = g.cfg.MaxDelay
+		}
+	}
+	return max(time.Unix(0, state.Last).Add(delay).Sub(g.now()), 0)
+}
+func (g *Guard) get(key []byte) *attempts {
+	state := &attempts{}
+	if value, err := g.cache.Get(key); err == nil {
+		json.Unmarshal(value, state)
+	}
+	return state
+}
+func (g *Guard) set(key []byte, state *attempts) {
+	value, _ := json.Marshal(state)
+	g.cache.Set(key, value, int(g.cfg.Duration/time.Second))
+}
+func accountKey(account string) []byte {
+	return []byte(fmt.Sprintf("la%s", account))
+}
+func ipKey(ip string) []byte {
+	return []byte(fmt.Sprintf("li%s", ip))
+}
@@ -0,0 +1,96 @@
+package lockout
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/cache/freecache"
+	"github.com/stretchr/testify/assert"
+)
+
+var cfg = &config.LockoutConfig{
+	FreeAttempts:   2,
+	MaxAttempts:    6,
+	IpFreeAttempts: 3,
+	IpMaxAttempts:  6,
+	BaseDelay:      time.Second,
+	MaxDelay:       3 * time.Second,
+	Duration:       time.Minute,
+}
+
+func newGuard() (*Guard, *time.Time) {
+	now := time.Now()
+	guard := NewGuard(freecache.NewCache(1024*1024), cfg)
+	guard.now = func() time.Time { return now }
+	return guard, &now
+}
+
+// Waits for the backoff and makes the attempt
+func attempt(guard *Guard, now *time.Time, account, ip string) {
+	*now = now.Add(guard.Check(account, ip))
+	guard.Check(account, ip)
+}
+func TestBackoff(t *testing.T) {
+	guard, now := newGuard()
+
+	excepted := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 3 * time.Second}
+	for i, wait := range excepted {
+		assert.Equal(t, wait, guard.Check("user", ""), "wait after %d failed attempts", i)
+		if wait > 0 {
+			*now = now.Add(wait)
+			assert.Zero(t, guard.Check("user", ""), "attempt must be allowed after waiting")
+		}
+		locked := guard.Fail("user")
+		assert.Equal(t, i == len(excepted)-1, locked, "account must be locked only once, at max attempts")
+	}
+	assert.Equal(t, time.Minute, guard.Check("user", ""))
+	assert.False(t, guard.Fail("user"), "account must be locked only once, at max attempts")
+	assert.Zero(t, guard.Check("other", ""))
+
+	guard.Success("user", "")
+	assert.Zero(t, guard.Check("user", ""))
+}
+func TestWaitExpires(t *testing.T) {
+	guard, now := newGuard()
+	for i := 0; i < cfg.MaxAttempts; i++ {
+		attempt(guard, now, "user", "")
+	}
+	*now = now.Add(50 * time.Second)
+	assert.Equal(t, 10*time.Second, guard.Check("user", ""))
+	*now = now.Add(10 * time.Second)
+	assert.Zero(t, guard.Check("user", ""))
+}
+func TestIpCounter(t *testing.T) {
+	guard, _ := newGuard()
+	for i := 0; i < cfg.IpFreeAttempts; i++ {
+		assert.Zero(t, guard.Check("user"+string(rune('a'+i)), "127.0.0.1"))
+	}
+	assert.Zero(t, guard.Check("new", "127.0.0.1"))
+	assert.Equal(t, time.Second, guard.Check("new", "127.0.0.1"), "ip must be delayed even for accounts without failures")
+	assert.Zero(t, guard.Check("new", "127.0.0.2"))
+
+	guard.Success("usera", "127.0.0.1")
+	assert.Zero(t, guard.Check("", "127.0.0.1"), "successful attempt must be given back to ip")
+	guard.Success("usera", "")
+	assert.Equal(t, time.Second, guard.Check("", "127.0.0.1"), "successful sign in must not reset ip counter")
+}
+func TestParallelAttempts(t *testing.T) {
+	guard, _ := newGuard()
+
+	var passed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if guard.Check("user", "") == 0 {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, cfg.FreeAttempts+1, passed.Load(), "parallel attempts must not pass the backoff together")
+}
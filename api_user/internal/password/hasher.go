@@ -0,0 +1,139 @@
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+
+	argon2_salt_length = 16
+	argon2_key_length  = 32
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match the hash")
+	ErrUnknownFormat = errors.New("password hash has unknown format")
+)
+
+// Hasher makes hashes with configured algorithm and verifies hashes of any supported one.
+// Argon2id hashes are stored in PHC string format: $argon2id$v=19$m=65536,t=3,p=2$salt$key
+type Hasher struct {
+	algorithm string
+	cost      int
+	memory    uint32
+	time      uint32
+	threads   uint8
+}
+
+func NewHasher(cfg *config.PasswordConfig) (*Hasher, error) {
+	h := &Hasher{
+		algorithm: cfg.Algorithm,
+		cost:      cfg.BcryptCost,
+		memory:    cfg.Argon2Memory,
+		time:      cfg.Argon2Time,
+		threads:   cfg.Argon2Threads,
+	}
+	switch h.algorithm {
+	case Bcrypt:
+		if h.cost < bcrypt.MinCost || h.cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case Argon2id:
+		if h.memory == 0 || h.time == 0 || h.threads == 0 {
+			return nil, fmt.Errorf("argon2id parameters must be greater than zero")
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %s", h.algorithm)
+	}
+	return h, nil
+}
+func (h *Hasher) Hash(password string) ([]byte, error) {
+	if h.algorithm == Bcrypt {
+		return bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	}
+	salt := make([]byte, argon2_salt_length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.threads, argon2_key_length)
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))), nil
+}
+func (h *Hasher) Compare(hash []byte, password string) error {
+	if bytes.HasPrefix(hash, []byte("$argon2id$")) {
+		params, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return err
+	}
+	return nil
+}
+
+// Hash needs to be updated if it was made by other algorithm or with other parameters
+func (h *Hasher) NeedsRehash(hash []byte) bool {
+	if bytes.HasPrefix(hash, []byte("$argon2id$")) {
+		if h.algorithm != Argon2id {
+			return true
+		}
+		params, _, _, err := decodeArgon2(hash)
+		return err != nil || params.memory != h.memory || params.time != h.time || params.threads != h.threads
+	}
+	if h.algorithm != Bcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost < h.cost
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func decodeArgon2(hash []byte) (*argon2Params, []byte, []byte, error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, ErrUnknownFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrUnknownFormat
+	}
+	params := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return nil, nil, nil, ErrUnknownFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrUnknownFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrUnknownFormat
+	}
+	return params, salt, key, nil
+}
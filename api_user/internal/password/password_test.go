@@ -0,0 +1,119 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/errormiddleware"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var bcryptConfig = &config.PasswordConfig{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost + 1}
+var argon2Config = &config.PasswordConfig{Algorithm: Argon2id, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1}
+
+func TestHasher(t *testing.T) {
+	for _, cfg := range []*config.PasswordConfig{bcryptConfig, argon2Config} {
+		t.Run(cfg.Algorithm, func(t *testing.T) {
+			hasher, err := NewHasher(cfg)
+			if !assert.NoError(t, err) {
+				return
+			}
+			hash, err := hasher.Hash("User!1password")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, hasher.Compare(hash, "User!1password"))
+			assert.Equal(t, ErrMismatch, hasher.Compare(hash, "User!1passworD"))
+			assert.False(t, hasher.NeedsRehash(hash))
+		})
+	}
+}
+func TestRehash(t *testing.T) {
+	bcryptHasher, _ := NewHasher(bcryptConfig)
+	argon2Hasher, _ := NewHasher(argon2Config)
+	weakHash, _ := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.MinCost)
+
+	assert.True(t, bcryptHasher.NeedsRehash(weakHash), "hash with lower cost must be upgraded")
+	assert.True(t, argon2Hasher.NeedsRehash(weakHash), "bcrypt hash must be upgraded to argon2id")
+	assert.NoError(t, argon2Hasher.Compare(weakHash, "admin"), "old hashes must stay valid")
+
+	argon2Hash, _ := argon2Hasher.Hash("admin")
+	assert.True(t, bcryptHasher.NeedsRehash(argon2Hash))
+	stronger, _ := NewHasher(&config.PasswordConfig{Algorithm: Argon2id, Argon2Memory: 2048, Argon2Time: 1, Argon2Threads: 1})
+	assert.True(t, stronger.NeedsRehash(argon2Hash))
+	assert.NoError(t, stronger.Compare(argon2Hash, "admin"))
+}
+func TestWrongConfig(t *testing.T) {
+	_, err := NewHasher(&config.PasswordConfig{Algorithm: "md5"})
+	assert.Error(t, err)
+	_, err = NewHasher(&config.PasswordConfig{Algorithm: Bcrypt, BcryptCost: 100})
+	assert.Error(t, err)
+	_, err = NewHasher(&config.PasswordConfig{Algorithm: Argon2id})
+	assert.Error(t, err)
+}
+func TestPolicy(t *testing.T) {
+	policy := NewPolicy(&config.PasswordConfig{Algorithm: Bcrypt, MinLength: 8, MaxLength: 64, RequireLowercase: true, RequireUppercase: true, RequireDigit: true, RequireSpecial: true})
+	cases := []struct {
+		Name     string
+		Password string
+		Messages []string
+	}{
+		{"valid", "User!1password", nil},
+		{"empty", "", []string{"password: field is required"}},
+		{"short", "Us!1", []string{"password must be more than 8 characters length"}},
+		{"long", "User!1" + strings.Repeat("a", 64), []string{"password can't be more than 64 characters length"}},
+		{"multibyte", "User!1" + strings.Repeat("пароль", 5), nil},
+		{"too many bytes", "User!1" + strings.Repeat("пароль", 9), []string{"password can't be more than 72 bytes length"}},
+		{"no uppercase", "user!1password", []string{"password must contain at least one uppercase character"}},
+		{"no special", "User1password", []string{"password must contain at least one special symbol"}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := policy.Check(tt.Password)
+			if tt.Messages == nil {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.IsType(t, &errormiddleware.Error{}, err) {
+				assert.Equal(t, errormiddleware.ValidationErrorCode, err.(*errormiddleware.Error).Code)
+				assert.Equal(t, tt.Messages, err.(*errormiddleware.Error).Message)
+			}
+		})
+	}
+
+	relaxed := NewPolicy(&config.PasswordConfig{MinLength: 4, MaxLength: 64})
+	assert.NoError(t, relaxed.Check("pass"))
+}
+func TestPolicyFitsBcrypt(t *testing.T) {
+	policy := NewPolicy(&config.PasswordConfig{Algorithm: Bcrypt, MinLength: 8, MaxLength: 64})
+	hasher, err := NewHasher(&config.PasswordConfig{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// 64 characters are accepted by length, but take 128 bytes
+	long := strings.Repeat("п", 64)
+	if assert.Error(t, policy.Check(long)) {
+		_, err = hasher.Hash(long)
+		assert.Error(t, err)
+	}
+	fits := strings.Repeat("п", 36)
+	if assert.NoError(t, policy.Check(fits)) {
+		_, err = hasher.Hash(fits)
+		assert.NoError(t, err)
+	}
+}
+func TestPolicyArgon2id(t *testing.T) {
+	policy := NewPolicy(&config.PasswordConfig{Algorithm: Argon2id, MinLength: 8, MaxLength: 64})
+	hasher, err := NewHasher(argon2Config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// argon2id has no length limit, so long passphrase is limited only by characters
+	long := strings.Repeat("п", 64)
+	if assert.NoError(t, policy.Check(long)) {
+		_, err = hasher.Hash(long)
+		assert.NoError(t, err)
+	}
+}
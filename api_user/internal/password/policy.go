@@ -0,0 +1,57 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/reversersed/go-web-services/tree/main/api_user/internal/config"
+	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/errormiddleware"
+	valid "github.com/reversersed/go-web-services/tree/main/api_user/pkg/validator"
+)
+
+// bcrypt rejects longer passwords. Non-latin characters take several bytes,
+// so the limit is checked apart from max length in characters when passwords are hashed with bcrypt
+const max_bytes = 72
+
+type field struct {
+	Password string `json:"password"`
+}
+
+// Policy builds validation rules from config, so the rules can be changed without code changes
+type Policy struct {
+	validator *valid.Validator
+}
+
+func NewPolicy(cfg *config.PasswordConfig) *Policy {
+	rules := []string{"required", fmt.Sprintf("min=%d", cfg.MinLength), fmt.Sprintf("max=%d", cfg.MaxLength)}
+	if cfg.Algorithm == Bcrypt {
+		rules = append(rules, fmt.Sprintf("maxbytes=%d", max_bytes))
+	}
+	if cfg.RequireLowercase {
+		rules = append(rules, "lowercase")
+	}
+	if cfg.RequireUppercase {
+		rules = append(rules, "uppercase")
+	}
+	if cfg.RequireDigit {
+		rules = append(rules, "digitrequired")
+	}
+	if cfg.RequireSpecial {
+		rules = append(rules, "specialsymbol")
+	}
+	v := valid.New()
+	v.RegisterStructValidationMapRules(map[string]string{"Password": strings.Join(rules, ",")}, field{})
+	return &Policy{validator: v}
+}
+
+// Returns validation error with every broken rule
+func (p *Policy) Check(password string) error {
+	if err := p.validator.Struct(field{Password: password}); err != nil {
+		if errs, ok := err.(validator.ValidationErrors); ok {
+			return errormiddleware.ValidationError(errs, "password does not match the policy")
+		}
+		return err
+	}
+	return nil
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/reversersed/go-web-services/tree/main/api_user/pkg/logging"
 )
 
+// Gateway revokes sessions created before the change. Message timestamp has only seconds,
+// so exact time of the change in milliseconds is sent in this header
+const HeaderChangedAt = "changedAt"
+
 type Sender struct {
 	connection *amqp.Connection
 	logger     *logging.Logger
@@ -51,8 +55,11 @@ func (s *Sender) SendUserLoginChangedMessage(ctx context.Context, userId string,
 	if err != nil {
 		return err
 	}
+	changed := time.Now()
 	err = ch.PublishWithContext(cntx, "UserLoginChangedExchange", "#", false, false, amqp.Publishing{
 		ContentType: "application/json",
+		Timestamp:   changed,
+		Headers:     amqp.Table{HeaderChangedAt: changed.UnixMilli()},
 		Body:        body,
 	})
 	if err != nil {
@@ -62,6 +69,42 @@ func (s *Sender) SendUserLoginChangedMessage(ctx context.Context, userId string,
 	s.logger.Warnf("Sended user (%s) login changed to %s message", userId, newLogin)
 	return nil
 }
+func (s *Sender) SendUserRolesChangedMessage(ctx context.Context, userId string, roles []string) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	// every gateway binds it's own queue, so message is only published to exchange
+	err = ch.ExchangeDeclare("UserRolesChangedExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	type query struct {
+		UserId string   `json:"userid"`
+		Roles  []string `json:"roles"`
+	}
+
+	body, err := json.Marshal(&query{UserId: userId, Roles: roles})
+	if err != nil {
+		return err
+	}
+	err = ch.PublishWithContext(cntx, "UserRolesChangedExchange", "#", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Body:        body,
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending user roles changed message: %v", err)
+		return err
+	}
+	s.logger.Warnf("Sended user (%s) roles changed to %v message", userId, roles)
+	return nil
+}
 func (s *Sender) SendUserDeletedMessage(ctx context.Context, userId string) error {
 	ch, err := s.connection.Channel()
 	if err != nil {
@@ -84,8 +127,11 @@ func (s *Sender) SendUserDeletedMessage(ctx context.Context, userId string) erro
 	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	changed := time.Now()
 	err = ch.PublishWithContext(cntx, "UserDeletedExchange", "#", false, false, amqp.Publishing{
 		ContentType: "text/plain",
+		Timestamp:   changed,
+		Headers:     amqp.Table{HeaderChangedAt: changed.UnixMilli()},
 		Body:        []byte(userId),
 	})
 	if err != nil {
@@ -95,3 +141,127 @@ func (s *Sender) SendUserDeletedMessage(ctx context.Context, userId string) erro
 	s.logger.Warnf("Sended user (%s) deleted meesage", userId)
 	return nil
 }
+func (s *Sender) SendUserPasswordChangedMessage(ctx context.Context, userId string) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	queue, err := ch.QueueDeclare("UserPasswordChangedQueue", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.ExchangeDeclare("UserPasswordChangedExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.QueueBind(queue.Name, "#", "UserPasswordChangedExchange", false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	changed := time.Now()
+	err = ch.PublishWithContext(cntx, "UserPasswordChangedExchange", "#", false, false, amqp.Publishing{
+		ContentType: "text/plain",
+		Timestamp:   changed,
+		Headers:     amqp.Table{HeaderChangedAt: changed.UnixMilli()},
+		Body:        []byte(userId),
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending user password changed message: %v", err)
+		return err
+	}
+	s.logger.Warnf("Sended user (%s) password changed message", userId)
+	return nil
+}
+func (s *Sender) SendUserEmailChangedMessage(ctx context.Context, userId string, oldEmail string, newEmail string) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	queue, err := ch.QueueDeclare("UserEmailChangedQueue", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.ExchangeDeclare("UserEmailChangedExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.QueueBind(queue.Name, "#", "UserEmailChangedExchange", false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	type query struct {
+		UserId   string `json:"userid"`
+		OldEmail string `json:"oldemail"`
+		NewEmail string `json:"newemail"`
+	}
+
+	body, err := json.Marshal(&query{UserId: userId, OldEmail: oldEmail, NewEmail: newEmail})
+	if err != nil {
+		return err
+	}
+	err = ch.PublishWithContext(cntx, "UserEmailChangedExchange", "#", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Body:        body,
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending user email changed message: %v", err)
+		return err
+	}
+	s.logger.Warnf("Sended user (%s) email changed message", userId)
+	return nil
+}
+func (s *Sender) SendNotificationMessage(ctx context.Context, userId string, content string, notificationType string) error {
+	ch, err := s.connection.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	queue, err := ch.QueueDeclare("NotificationReceiverQueue", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.ExchangeDeclare("NotificationExchange", "fanout", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	err = ch.QueueBind(queue.Name, "#", "NotificationExchange", false, nil)
+	if err != nil {
+		return err
+	}
+	cntx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	type query struct {
+		UserId  string `json:"userid"`
+		Content string `json:"content"`
+		Type    string `json:"type"`
+	}
+
+	body, err := json.Marshal(&query{UserId: userId, Content: content, Type: notificationType})
+	if err != nil {
+		return err
+	}
+	err = ch.PublishWithContext(cntx, "NotificationExchange", "#", false, false, amqp.Publishing{
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Body:        body,
+	})
+	if err != nil {
+		s.logger.Errorf("Error sending notification message: %v", err)
+		return err
+	}
+	s.logger.Infof("Sended %s notification to user (%s)", notificationType, userId)
+	return nil
+}
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// secret and codes are taken from RFC 6238 test vectors (last 6 digits of SHA1 codes)
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	cases := []struct {
+		Time int64
+		Code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range cases {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.Time, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.Code, code)
+	}
+}
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step, ok := Validate(rfcSecret, "050471", now)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(rfcSecret, "050471", now.Add(period*time.Second))
+	assert.True(t, ok, "code of previous step must be accepted")
+	_, ok = Validate(rfcSecret, "050471", now.Add(3*period*time.Second))
+	assert.False(t, ok, "old code must be refused")
+	_, ok = Validate(rfcSecret, "050472", now)
+	assert.False(t, ok)
+	_, ok = Validate(rfcSecret, "50471", now)
+	assert.False(t, ok)
+	_, ok = Validate("not base32!", "050471", now)
+	assert.False(t, ok)
+}
+func TestSecretAndURI(t *testing.T) {
+	secret, err := NewSecret()
+	if !assert.NoError(t, err) {
+		return
+	}
+	other, _ := NewSecret()
+	assert.NotEqual(t, secret, other)
+
+	uri, err := url.Parse(URI("Example", "user", secret))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "otpauth", uri.Scheme)
+		assert.Equal(t, "totp", uri.Host)
+		assert.Equal(t, "/Example:user", uri.Path)
+		assert.Equal(t, secret, uri.Query().Get("secret"))
+		assert.Equal(t, "Example", uri.Query().Get("issuer"))
+	}
+}
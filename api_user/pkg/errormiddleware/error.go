@@ -18,6 +18,8 @@ const (
 	UnauthorizedErrorCode Code = "IE-0005"
 	NotUniqueErrorCode    Code = "IE-0006"
 	ForbiddenErrorCode    Code = "IE-0007"
+	TooLargeErrorCode     Code = "IE-0008"
+	LockedErrorCode       Code = "IE-0010"
 )
 
 type Error struct {
@@ -71,6 +73,8 @@ func ValidationError(errors error, dev_message string) *Error {
 			errs = append(errs, fmt.Sprintf("%s must be more than %s characters length", err.Field(), err.Param()))
 		case "max":
 			errs = append(errs, fmt.Sprintf("%s can't be more than %s characters length", err.Field(), err.Param()))
+		case "maxbytes":
+			errs = append(errs, fmt.Sprintf("%s can't be more than %s bytes length", err.Field(), err.Param()))
 		case "lte":
 			errs = append(errs, fmt.Sprintf("%s must be less or equal than %s", err.Field(), err.Param()))
 		case "gte":
@@ -110,3 +114,9 @@ func NotUniqueError(message []string, dev_message string) *Error {
 func ForbiddenError(message []string, dev_message string) *Error {
 	return NewError(message, ForbiddenErrorCode, dev_message)
 }
+func TooLargeError(message []string, dev_message string) *Error {
+	return NewError(message, TooLargeErrorCode, dev_message)
+}
+func LockedError(message []string, dev_message string) *Error {
+	return NewError(message, LockedErrorCode, dev_message)
+}
@@ -30,6 +30,10 @@ func Middleware(h Handler) Handler {
 					w.WriteHeader(http.StatusConflict)
 				case ForbiddenErrorCode:
 					w.WriteHeader(http.StatusForbidden)
+				case TooLargeErrorCode:
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				case LockedErrorCode:
+					w.WriteHeader(http.StatusLocked)
 				default:
 					w.WriteHeader(http.StatusBadRequest)
 				}
@@ -25,6 +25,8 @@ var errorCodeCases = []struct {
 	{"Unauthorized custom error", UnauthorizedError([]string{""}, ""), http.StatusUnauthorized},
 	{"NotUnique custom error", NotUniqueError([]string{""}, ""), http.StatusConflict},
 	{"Forbidden custom error", ForbiddenError([]string{""}, ""), http.StatusForbidden},
+	{"Too large custom error", TooLargeError([]string{""}, ""), http.StatusRequestEntityTooLarge},
+	{"Locked custom error", LockedError([]string{""}, ""), http.StatusLocked},
 	{"Unknowed custom error code", NewError([]string{""}, "0000", ""), http.StatusBadRequest},
 	{"Successful response", nil, http.StatusOK},
 }
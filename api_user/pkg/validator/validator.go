@@ -3,6 +3,7 @@ package validator
 import (
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -31,6 +32,7 @@ func New() *Validator {
 	v.RegisterValidation("digitrequired", validate_AtLeastOneDigit)
 	v.RegisterValidation("specialsymbol", validate_SpecialSymbol)
 	v.RegisterValidation("onlyenglish", validate_OnlyEnglish)
+	v.RegisterValidation("maxbytes", validate_MaxBytes)
 	return &Validator{v}
 }
 func validate_PrimitiveId(field validator.FieldLevel) bool {
@@ -39,6 +41,14 @@ func validate_PrimitiveId(field validator.FieldLevel) bool {
 	_, err := primitive.ObjectIDFromHex(field.Field().String())
 	return (err == nil) || (field.Field().Kind() == reflect.TypeOf(obj).Kind())
 }
+func validate_MaxBytes(field validator.FieldLevel) bool {
+	// max counts characters, this one counts bytes of string
+	max, err := strconv.Atoi(field.Param())
+	if err != nil {
+		return false
+	}
+	return len(field.Field().String()) <= max
+}
 func validate_OnlyEnglish(field validator.FieldLevel) bool {
 	mathed, err := regexp.MatchString(`^[a-zA-Z]+$`, field.Field().String())
 	if err != nil {
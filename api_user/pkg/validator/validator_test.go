@@ -28,6 +28,8 @@ var cases = []struct {
 	{"digit required tag fail testing", "there is not number", "digitrequired", true},
 	{"specials required tag testing", "there is special !", "specialsymbol", false},
 	{"specials required tag fail testing", "there is not specials", "specialsymbol", true},
+	{"max bytes tag testing", "пароль", "maxbytes=12", false},
+	{"max bytes tag fail testing on multibyte letters", "пароль", "max=6,maxbytes=11", true},
 }
 
 func TestValidator(t *testing.T) {